@@ -0,0 +1,248 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// TransitStreamDefaultMountPoint is the default mount point of the
+	// transit secrets engine.
+	TransitStreamDefaultMountPoint = "transit"
+
+	// TransitStreamDefaultChunkSize is the default size of the plaintext
+	// chunks sent to Vault when encrypting a stream.
+	TransitStreamDefaultChunkSize = 1024 * 1024
+
+	// transitStreamMaxFrameSize bounds the size of a single header or chunk
+	// read back when decrypting, so that a corrupt stream cannot cause an
+	// arbitrarily large allocation.
+	transitStreamMaxFrameSize = 64 * 1024 * 1024
+)
+
+// TransitStream is used to encrypt and decrypt payloads of arbitrary size
+// with a transit key. Payloads are read from an io.Reader and sent to Vault
+// one chunk at a time, so that they are never held in memory as a whole.
+//
+// The encrypted stream consists of the stream header returned by Vault,
+// followed by the encrypted chunks, each prefixed with its length as a
+// big-endian uint32.
+type TransitStream struct {
+	c          *Client
+	MountPoint string
+	Name       string
+
+	// Context is the base64-decoded key derivation context, required if the
+	// key has derivation enabled.
+	Context []byte
+
+	// ChunkSize is the size of the plaintext chunks. Defaults to
+	// TransitStreamDefaultChunkSize.
+	ChunkSize int
+}
+
+// TransitStream returns a client to encrypt and decrypt streams with the
+// named key of the transit secrets engine at its default mount point.
+func (c *Client) TransitStream(name string) *TransitStream {
+	return c.TransitStreamWithMountPoint(TransitStreamDefaultMountPoint, name)
+}
+
+// TransitStreamWithMountPoint returns a client to encrypt and decrypt streams
+// with the named key of the transit secrets engine at the given mount point.
+func (c *Client) TransitStreamWithMountPoint(mountPoint, name string) *TransitStream {
+	return &TransitStream{
+		c:          c,
+		MountPoint: mountPoint,
+		Name:       name,
+		ChunkSize:  TransitStreamDefaultChunkSize,
+	}
+}
+
+// Encrypt wraps EncryptWithContext using context.Background.
+func (t *TransitStream) Encrypt(dst io.Writer, src io.Reader) error {
+	return t.EncryptWithContext(context.Background(), dst, src)
+}
+
+// EncryptWithContext reads src until EOF and writes the encrypted stream to
+// dst.
+func (t *TransitStream) EncryptWithContext(ctx context.Context, dst io.Writer, src io.Reader) error {
+	chunkSize := t.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = TransitStreamDefaultChunkSize
+	}
+
+	reader := bufio.NewReader(src)
+	buf := make([]byte, chunkSize)
+	var header string
+	for index := 0; ; index++ {
+		n, err := io.ReadFull(reader, buf)
+		final := false
+		switch err {
+		case nil:
+			// A full chunk is the last one if nothing follows it
+			if _, err := reader.Peek(1); err == io.EOF {
+				final = true
+			} else if err != nil {
+				return err
+			}
+		case io.EOF, io.ErrUnexpectedEOF:
+			final = true
+		default:
+			return err
+		}
+
+		data := map[string]interface{}{
+			"stream":        true,
+			"stream_header": header,
+			"chunk_index":   index,
+			"final_chunk":   final,
+			"plaintext":     base64.StdEncoding.EncodeToString(buf[:n]),
+		}
+		if len(t.Context) != 0 {
+			data["context"] = base64.StdEncoding.EncodeToString(t.Context)
+		}
+
+		secret, err := t.c.Logical().WriteWithContext(ctx, fmt.Sprintf("%s/encrypt/%s", t.MountPoint, t.Name), data)
+		if err != nil {
+			return err
+		}
+		if secret == nil || secret.Data == nil {
+			return errors.New("empty response encrypting stream chunk")
+		}
+
+		if header == "" {
+			header, _ = secret.Data["stream_header"].(string)
+			if header == "" {
+				return errors.New("no stream header returned by Vault")
+			}
+			if err := writeTransitStreamFrame(dst, []byte(header)); err != nil {
+				return err
+			}
+		}
+
+		encoded, _ := secret.Data["ciphertext"].(string)
+		ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return fmt.Errorf("error decoding stream chunk: %w", err)
+		}
+		if err := writeTransitStreamFrame(dst, ciphertext); err != nil {
+			return err
+		}
+
+		if final {
+			return nil
+		}
+	}
+}
+
+// Decrypt wraps DecryptWithContext using context.Background.
+func (t *TransitStream) Decrypt(dst io.Writer, src io.Reader) error {
+	return t.DecryptWithContext(context.Background(), dst, src)
+}
+
+// DecryptWithContext reads an encrypted stream from src and writes the
+// plaintext to dst. As chunks are written out as they are decrypted, dst may
+// have received part of the plaintext when an error is returned for a
+// corrupted or truncated stream.
+func (t *TransitStream) DecryptWithContext(ctx context.Context, dst io.Writer, src io.Reader) error {
+	reader := bufio.NewReader(src)
+
+	header, err := readTransitStreamFrame(reader)
+	if err != nil {
+		if err == io.EOF {
+			return errors.New("missing stream header")
+		}
+		return err
+	}
+
+	chunk, err := readTransitStreamFrame(reader)
+	if err != nil {
+		if err == io.EOF {
+			return errors.New("stream contains no chunks")
+		}
+		return err
+	}
+
+	for index := 0; ; index++ {
+		// The chunk is the final one if nothing follows it
+		next, err := readTransitStreamFrame(reader)
+		final := err == io.EOF
+		if err != nil && !final {
+			return err
+		}
+
+		data := map[string]interface{}{
+			"stream":        true,
+			"stream_header": string(header),
+			"chunk_index":   index,
+			"final_chunk":   final,
+			"ciphertext":    base64.StdEncoding.EncodeToString(chunk),
+		}
+		if len(t.Context) != 0 {
+			data["context"] = base64.StdEncoding.EncodeToString(t.Context)
+		}
+
+		secret, err := t.c.Logical().WriteWithContext(ctx, fmt.Sprintf("%s/decrypt/%s", t.MountPoint, t.Name), data)
+		if err != nil {
+			return err
+		}
+		if secret == nil || secret.Data == nil {
+			return errors.New("empty response decrypting stream chunk")
+		}
+
+		encoded, _ := secret.Data["plaintext"].(string)
+		plaintext, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return fmt.Errorf("error decoding stream chunk: %w", err)
+		}
+		if _, err := dst.Write(plaintext); err != nil {
+			return err
+		}
+
+		if final {
+			return nil
+		}
+		chunk = next
+	}
+}
+
+func writeTransitStreamFrame(w io.Writer, frame []byte) error {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(frame)))
+	if _, err := w.Write(length[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(frame)
+	return err
+}
+
+// readTransitStreamFrame reads a length-prefixed frame, returning io.EOF only
+// if the stream ends cleanly before the frame.
+func readTransitStreamFrame(r io.Reader) ([]byte, error) {
+	var length [4]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, errors.New("truncated stream")
+		}
+		return nil, err
+	}
+
+	size := binary.BigEndian.Uint32(length[:])
+	if size > transitStreamMaxFrameSize {
+		return nil, fmt.Errorf("stream frame of %d bytes exceeds the maximum of %d", size, transitStreamMaxFrameSize)
+	}
+
+	frame := make([]byte, size)
+	if _, err := io.ReadFull(r, frame); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, errors.New("truncated stream")
+		}
+		return nil, err
+	}
+	return frame, nil
+}
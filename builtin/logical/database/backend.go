@@ -71,12 +71,19 @@ func Backend(conf *logical.BackendConfig) *databaseBackend {
 		Help: strings.TrimSpace(backendHelp),
 
 		PathsSpecial: &logical.Paths{
+			Root: []string{
+				"restore-root/*",
+			},
 			LocalStorage: []string{
 				framework.WALPrefix,
 			},
 			SealWrapStorage: []string{
 				"config/*",
+				// Dynamic roles may hold the CA private key of client
+				// certificate credentials
+				"role/*",
 				"static-role/*",
+				rootCredentialHistoryPath + "*",
 			},
 		},
 		Paths: framework.PathAppend(
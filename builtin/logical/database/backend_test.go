@@ -230,8 +230,10 @@ func TestBackend_config_connection(t *testing.T) {
 		expected := map[string]interface{}{
 			"plugin_name": "postgresql-database-plugin",
 			"connection_details": map[string]interface{}{
-				"connection_url": "sample_connection_url",
-				"someotherdata":  "testing",
+				"connection_url":                  "sample_connection_url",
+				"someotherdata":                   "testing",
+				"supported_credential_types":      []interface{}{"password", "client_certificate"},
+				"self_managed_password_supported": true,
 			},
 			"allowed_roles":                      []string{"*"},
 			"root_credentials_rotate_statements": []string{},
@@ -283,8 +285,10 @@ func TestBackend_config_connection(t *testing.T) {
 		expected := map[string]interface{}{
 			"plugin_name": "postgresql-database-plugin",
 			"connection_details": map[string]interface{}{
-				"connection_url": "sample_convection_url",
-				"someotherdata":  "testing",
+				"connection_url":                  "sample_convection_url",
+				"someotherdata":                   "testing",
+				"supported_credential_types":      []interface{}{"password", "client_certificate"},
+				"self_managed_password_supported": true,
 			},
 			"allowed_roles":                      []string{"*"},
 			"root_credentials_rotate_statements": []string{},
@@ -325,8 +329,10 @@ func TestBackend_config_connection(t *testing.T) {
 		expected := map[string]interface{}{
 			"plugin_name": "postgresql-database-plugin",
 			"connection_details": map[string]interface{}{
-				"connection_url": "sample_convection_url",
-				"someotherdata":  "testing",
+				"connection_url":                  "sample_convection_url",
+				"someotherdata":                   "testing",
+				"supported_credential_types":      []interface{}{"password", "client_certificate"},
+				"self_managed_password_supported": true,
 			},
 			"allowed_roles":                      []string{"flu", "barre"},
 			"root_credentials_rotate_statements": []string{},
@@ -722,8 +728,10 @@ func TestBackend_connectionCrud(t *testing.T) {
 	expected := map[string]interface{}{
 		"plugin_name": "postgresql-database-plugin",
 		"connection_details": map[string]interface{}{
-			"username":       "postgres",
-			"connection_url": connURL,
+			"username":                        "postgres",
+			"connection_url":                  connURL,
+			"supported_credential_types":      []interface{}{"password", "client_certificate"},
+			"self_managed_password_supported": true,
 		},
 		"allowed_roles":                      []string{"plugin-role-test"},
 		"root_credentials_rotate_statements": []string(nil),
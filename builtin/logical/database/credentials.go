@@ -5,12 +5,18 @@ import (
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/vault/helper/random"
+	v5 "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/helper/template"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -167,3 +173,173 @@ func (kg rsaKeyGenerator) configMap() (map[string]interface{}, error) {
 	}
 	return config, nil
 }
+
+// defaultCommonNameTemplate renders the common name of client certificates as
+// the username, which certificate authentication in PostgreSQL expects.
+const defaultCommonNameTemplate = "{{.Username}}"
+
+// clientCertificateGenerator generates client certificate credentials signed
+// by the configured CA.
+type clientCertificateGenerator struct {
+	// CommonNameTemplate is the template of the common name of the generated
+	// certificates. It is rendered with the username of the user and its
+	// username metadata. Defaults to the username.
+	CommonNameTemplate string `mapstructure:"common_name_template,omitempty"`
+
+	// CACert is the PEM-encoded CA certificate signing the generated
+	// certificates.
+	CACert string `mapstructure:"ca_cert,omitempty"`
+
+	// CAPrivateKey is the PEM-encoded private key of the CA certificate.
+	CAPrivateKey string `mapstructure:"ca_private_key,omitempty"`
+
+	// KeyType is the type of the private key to generate.
+	// Options include: 'rsa' (default), 'ec', and 'ed25519'
+	KeyType string `mapstructure:"key_type,omitempty"`
+
+	// KeyBits is the bit size of the private key to generate. Defaults to
+	// 2048 for RSA keys and 256 for EC keys.
+	KeyBits int `mapstructure:"key_bits,omitempty"`
+
+	// SignatureBits is the bit size of the hash used to sign the certificate.
+	// Options include: 256 (default), 384, and 512
+	SignatureBits int `mapstructure:"signature_bits,omitempty"`
+}
+
+// newClientCertificateGenerator returns a new clientCertificateGenerator
+// using the given config. Default values will be set on the returned
+// clientCertificateGenerator if not provided in the given config.
+func newClientCertificateGenerator(config map[string]interface{}) (clientCertificateGenerator, error) {
+	var cg clientCertificateGenerator
+	if err := mapstructure.WeakDecode(config, &cg); err != nil {
+		return cg, err
+	}
+
+	if cg.CommonNameTemplate == "" {
+		cg.CommonNameTemplate = defaultCommonNameTemplate
+	}
+	if _, err := template.NewTemplate(template.Template(cg.CommonNameTemplate)); err != nil {
+		return cg, fmt.Errorf("invalid common_name_template: %w", err)
+	}
+
+	if cg.CACert == "" || cg.CAPrivateKey == "" {
+		return cg, errors.New("missing ca_cert or ca_private_key")
+	}
+	if _, err := cg.signingBundle(); err != nil {
+		return cg, err
+	}
+
+	cg.KeyType = strings.ToLower(cg.KeyType)
+	switch cg.KeyType {
+	case "":
+		cg.KeyType = "rsa"
+	case "rsa", "ec", "ed25519":
+	default:
+		return cg, fmt.Errorf("invalid key_type: %v", cg.KeyType)
+	}
+
+	var err error
+	cg.KeyBits, cg.SignatureBits, err = certutil.ValidateDefaultOrValueKeyTypeSignatureLength(cg.KeyType, cg.KeyBits, cg.SignatureBits)
+	if err != nil {
+		return cg, err
+	}
+
+	return cg, nil
+}
+
+// signingBundle parses the CA certificate and private key of the generator.
+func (cg *clientCertificateGenerator) signingBundle() (*certutil.CAInfoBundle, error) {
+	parsed, err := certutil.ParsePEMBundle(strings.TrimSpace(cg.CACert) + "\n" + strings.TrimSpace(cg.CAPrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CA certificate and private key: %w", err)
+	}
+	if parsed.Certificate == nil || parsed.PrivateKey == nil {
+		return nil, errors.New("ca_cert must contain a certificate and ca_private_key a private key")
+	}
+	if !parsed.Certificate.IsCA {
+		return nil, errors.New("ca_cert is not a CA certificate")
+	}
+	if err := parsed.Verify(); err != nil {
+		return nil, fmt.Errorf("invalid CA certificate and private key: %w", err)
+	}
+
+	return &certutil.CAInfoBundle{
+		ParsedCertBundle: *parsed,
+		URLs:             &certutil.URLEntries{},
+	}, nil
+}
+
+// commonNameMetadata is the data the common name template is rendered with.
+type commonNameMetadata struct {
+	v5.UsernameMetadata
+	Username string
+}
+
+// Generate generates a private key and a client certificate for it, with the
+// common name rendered from the given username and username metadata, valid
+// until the given expiration or the expiration of the CA certificate,
+// whichever comes first. Returns the PEM-encoded certificate bundle and the
+// subject of the certificate (in that order) or an error.
+func (cg *clientCertificateGenerator) generate(r io.Reader, expiration time.Time, username string, userMeta v5.UsernameMetadata) (*certutil.CertBundle, string, error) {
+	reader := rand.Reader
+	if r != nil {
+		reader = r
+	}
+
+	caBundle, err := cg.signingBundle()
+	if err != nil {
+		return nil, "", err
+	}
+
+	cnTemplate, err := template.NewTemplate(template.Template(cg.CommonNameTemplate))
+	if err != nil {
+		return nil, "", err
+	}
+	commonName, err := cnTemplate.Generate(commonNameMetadata{
+		UsernameMetadata: userMeta,
+		Username:         username,
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to render common_name_template: %w", err)
+	}
+
+	if caNotAfter := caBundle.Certificate.NotAfter; expiration.After(caNotAfter) {
+		expiration = caNotAfter
+	}
+
+	parsed, err := certutil.CreateCertificateWithRandomSource(&certutil.CreationBundle{
+		Params: &certutil.CreationParameters{
+			Subject: pkix.Name{
+				CommonName: commonName,
+			},
+			KeyType:           cg.KeyType,
+			KeyBits:           cg.KeyBits,
+			SignatureBits:     cg.SignatureBits,
+			NotAfter:          expiration,
+			KeyUsage:          x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageKeyAgreement,
+			ExtKeyUsage:       certutil.ClientAuthExtKeyUsage,
+			NotBeforeDuration: 30 * time.Second,
+			URLs:              &certutil.URLEntries{},
+		},
+		SigningBundle: caBundle,
+	}, reader)
+	if err != nil {
+		return nil, "", err
+	}
+
+	bundle, err := parsed.ToCertBundle()
+	if err != nil {
+		return nil, "", err
+	}
+	return bundle, parsed.Certificate.Subject.String(), nil
+}
+
+// configMap returns the configuration of the clientCertificateGenerator
+// as a map from string to string.
+func (cg clientCertificateGenerator) configMap() (map[string]interface{}, error) {
+	config := make(map[string]interface{})
+	if err := mapstructure.WeakDecode(cg, &config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
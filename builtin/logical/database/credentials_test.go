@@ -5,10 +5,14 @@ import (
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/pem"
 	"testing"
+	"time"
 
+	v5 "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
 	"github.com/hashicorp/vault/sdk/helper/base62"
+	"github.com/hashicorp/vault/sdk/helper/certutil"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -541,3 +545,277 @@ func Test_rsaKeyGenerator_configMap(t *testing.T) {
 		})
 	}
 }
+
+func Test_newClientCertificateGenerator(t *testing.T) {
+	caCert, caKey := testCACertificate(t, time.Now().Add(time.Hour))
+	_, otherKey := testCACertificate(t, time.Now().Add(time.Hour))
+
+	type args struct {
+		config map[string]interface{}
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    clientCertificateGenerator
+		wantErr bool
+	}{
+		{
+			name: "newClientCertificateGenerator with nil config",
+			args: args{
+				config: nil,
+			},
+			wantErr: true,
+		},
+		{
+			name: "newClientCertificateGenerator without common_name_template",
+			args: args{
+				config: map[string]interface{}{
+					"ca_cert":        caCert,
+					"ca_private_key": caKey,
+				},
+			},
+			want: clientCertificateGenerator{
+				CommonNameTemplate: "{{.Username}}",
+				CACert:             caCert,
+				CAPrivateKey:       caKey,
+				KeyType:            "rsa",
+				KeyBits:            2048,
+				SignatureBits:      256,
+			},
+		},
+		{
+			name: "newClientCertificateGenerator with invalid common_name_template",
+			args: args{
+				config: map[string]interface{}{
+					"common_name_template": "{{.DisplayName",
+					"ca_cert":              caCert,
+					"ca_private_key":       caKey,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "newClientCertificateGenerator without CA",
+			args: args{
+				config: map[string]interface{}{
+					"common_name_template": "{{.DisplayName}}",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "newClientCertificateGenerator with mismatched CA private key",
+			args: args{
+				config: map[string]interface{}{
+					"common_name_template": "{{.DisplayName}}",
+					"ca_cert":              caCert,
+					"ca_private_key":       otherKey,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "newClientCertificateGenerator with invalid key_type",
+			args: args{
+				config: map[string]interface{}{
+					"common_name_template": "{{.DisplayName}}",
+					"ca_cert":              caCert,
+					"ca_private_key":       caKey,
+					"key_type":             "dsa",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "newClientCertificateGenerator with invalid key_bits",
+			args: args{
+				config: map[string]interface{}{
+					"common_name_template": "{{.DisplayName}}",
+					"ca_cert":              caCert,
+					"ca_private_key":       caKey,
+					"key_type":             "ec",
+					"key_bits":             "2048",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "newClientCertificateGenerator with default key configuration",
+			args: args{
+				config: map[string]interface{}{
+					"common_name_template": "{{.DisplayName}}",
+					"ca_cert":              caCert,
+					"ca_private_key":       caKey,
+				},
+			},
+			want: clientCertificateGenerator{
+				CommonNameTemplate: "{{.DisplayName}}",
+				CACert:             caCert,
+				CAPrivateKey:       caKey,
+				KeyType:            "rsa",
+				KeyBits:            2048,
+				SignatureBits:      256,
+			},
+		},
+		{
+			name: "newClientCertificateGenerator with ec key_type",
+			args: args{
+				config: map[string]interface{}{
+					"common_name_template": "{{.DisplayName}}",
+					"ca_cert":              caCert,
+					"ca_private_key":       caKey,
+					"key_type":             "ec",
+					"signature_bits":       "384",
+				},
+			},
+			want: clientCertificateGenerator{
+				CommonNameTemplate: "{{.DisplayName}}",
+				CACert:             caCert,
+				CAPrivateKey:       caKey,
+				KeyType:            "ec",
+				KeyBits:            256,
+				SignatureBits:      384,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newClientCertificateGenerator(tt.args.config)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_clientCertificateGenerator_generate(t *testing.T) {
+	caNotAfter := time.Now().Add(2 * time.Hour).Truncate(time.Second)
+	caCert, caKey := testCACertificate(t, caNotAfter)
+	caBundle, err := certutil.ParsePEMBundle(caCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(caBundle.Certificate)
+
+	type args struct {
+		config     map[string]interface{}
+		expiration time.Time
+	}
+	tests := []struct {
+		name             string
+		args             args
+		wantKeyType      certutil.PrivateKeyType
+		wantNotAfter     time.Time
+		wantSubject      string
+		wantSignatureAlg x509.SignatureAlgorithm
+	}{
+		{
+			name: "generate client certificate with default key configuration",
+			args: args{
+				config: map[string]interface{}{
+					"common_name_template": "v-{{.RoleName}}-{{.DisplayName}}",
+					"ca_cert":              caCert,
+					"ca_private_key":       caKey,
+				},
+				expiration: time.Now().Add(time.Hour).Truncate(time.Second),
+			},
+			wantKeyType:      certutil.RSAPrivateKey,
+			wantNotAfter:     time.Now().Add(time.Hour).Truncate(time.Second),
+			wantSubject:      "CN=v-my-role-token",
+			wantSignatureAlg: x509.ECDSAWithSHA256,
+		},
+		{
+			name: "generate client certificate with the username as common name",
+			args: args{
+				config: map[string]interface{}{
+					"ca_cert":        caCert,
+					"ca_private_key": caKey,
+					"key_type":       "ec",
+				},
+				expiration: time.Now().Add(time.Hour).Truncate(time.Second),
+			},
+			wantKeyType:      certutil.ECPrivateKey,
+			wantNotAfter:     time.Now().Add(time.Hour).Truncate(time.Second),
+			wantSubject:      "CN=v-token-my-role",
+			wantSignatureAlg: x509.ECDSAWithSHA256,
+		},
+		{
+			name: "generate client certificate with ed25519 key outliving the CA",
+			args: args{
+				config: map[string]interface{}{
+					"common_name_template": "{{.DisplayName}}",
+					"ca_cert":              caCert,
+					"ca_private_key":       caKey,
+					"key_type":             "ed25519",
+				},
+				expiration: caNotAfter.Add(time.Hour),
+			},
+			wantKeyType:      certutil.Ed25519PrivateKey,
+			wantNotAfter:     caNotAfter,
+			wantSubject:      "CN=token",
+			wantSignatureAlg: x509.ECDSAWithSHA256,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cg, err := newClientCertificateGenerator(tt.args.config)
+			assert.NoError(t, err)
+
+			bundle, subject, err := cg.generate(rand.Reader, tt.args.expiration, "v-token-my-role", v5.UsernameMetadata{
+				DisplayName: "token",
+				RoleName:    "my-role",
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantSubject, subject)
+			assert.Equal(t, tt.wantKeyType, bundle.PrivateKeyType)
+
+			parsed, err := certutil.ParsePEMBundle(bundle.Certificate + "\n" + bundle.PrivateKey)
+			assert.NoError(t, err)
+			assert.NoError(t, parsed.Verify())
+
+			cert := parsed.Certificate
+			assert.Equal(t, tt.wantSubject, cert.Subject.String())
+			assert.Equal(t, tt.wantNotAfter.UTC(), cert.NotAfter.UTC())
+			assert.Equal(t, tt.wantSignatureAlg, cert.SignatureAlgorithm)
+			assert.Equal(t, []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}, cert.ExtKeyUsage)
+			assert.False(t, cert.IsCA)
+
+			// Assert that the certificate is trusted for client authentication
+			_, err = cert.Verify(x509.VerifyOptions{
+				Roots:     roots,
+				KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+			})
+			assert.NoError(t, err)
+		})
+	}
+}
+
+// testCACertificate returns the PEM-encoded certificate and private key of a
+// new self-signed CA expiring at notAfter.
+func testCACertificate(t *testing.T, notAfter time.Time) (string, string) {
+	t.Helper()
+	parsed, err := certutil.CreateCertificate(&certutil.CreationBundle{
+		Params: &certutil.CreationParameters{
+			Subject: pkix.Name{
+				CommonName: "Database CA",
+			},
+			KeyType:  "ec",
+			KeyBits:  256,
+			NotAfter: notAfter,
+			KeyUsage: x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+			URLs:     &certutil.URLEntries{},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle, err := parsed.ToCertBundle()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bundle.Certificate, bundle.PrivateKey
+}
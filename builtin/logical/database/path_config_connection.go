@@ -49,6 +49,11 @@ func (c *DatabaseConfig) SupportsCredentialType(credentialType v5.CredentialType
 	return false
 }
 
+func (c *DatabaseConfig) SupportsSelfManagedPassword() bool {
+	supported, _ := c.ConnectionDetails[v5.SelfManagedPasswordSupportedKey].(bool)
+	return supported
+}
+
 // pathResetConnection configures a path to reset a plugin.
 func pathResetConnection(b *databaseBackend) *framework.Path {
 	return &framework.Path{
@@ -240,6 +245,11 @@ func (b *databaseBackend) connectionDeleteHandler() framework.OperationFunc {
 			return nil, fmt.Errorf("failed to delete connection configuration: %w", err)
 		}
 
+		err = req.Storage.Delete(ctx, rootCredentialHistoryPath+name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete root credential history: %w", err)
+		}
+
 		if err := b.ClearConnection(name); err != nil {
 			return nil, err
 		}
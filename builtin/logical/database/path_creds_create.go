@@ -2,12 +2,15 @@ package database
 
 import (
 	"context"
+	"crypto/x509/pkix"
 	"fmt"
 	"time"
 
 	"github.com/hashicorp/go-secure-stdlib/strutil"
+	uuid "github.com/hashicorp/go-uuid"
 	v5 "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
 	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/certutil"
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
@@ -96,11 +99,25 @@ func (b *databaseBackend) pathCredsCreateRead() framework.OperationFunc {
 		// to ensure the database credential does not expire before the lease
 		expiration = expiration.Add(5 * time.Second)
 
+		// Make the identity of the requester available to username templates
+		usernameConfig := v5.UsernameMetadata{
+			DisplayName:   req.DisplayName,
+			RoleName:      name,
+			EntityID:      req.EntityID,
+			MountAccessor: req.MountAccessor,
+		}
+		if req.EntityID != "" {
+			entity, err := b.System().EntityInfo(req.EntityID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to look up entity: %w", err)
+			}
+			if entity != nil {
+				usernameConfig.EntityMetadata = entity.Metadata
+			}
+		}
+
 		newUserReq := v5.NewUserRequest{
-			UsernameConfig: v5.UsernameMetadata{
-				DisplayName: req.DisplayName,
-				RoleName:    name,
-			},
+			UsernameConfig: usernameConfig,
 			Statements: v5.Statements{
 				Commands: role.Statements.Creation,
 			},
@@ -111,6 +128,7 @@ func (b *databaseBackend) pathCredsCreateRead() framework.OperationFunc {
 		}
 
 		respData := make(map[string]interface{})
+		var certGenerator clientCertificateGenerator
 
 		// Generate the credential based on the role's credential type
 		switch role.CredentialType {
@@ -154,6 +172,26 @@ func (b *databaseBackend) pathCredsCreateRead() framework.OperationFunc {
 
 			// Set output credential
 			respData["rsa_private_key"] = string(private)
+
+		case v5.CredentialTypeClientCertificate:
+			certGenerator, err = newClientCertificateGenerator(role.CredentialConfig)
+			if err != nil {
+				return nil, fmt.Errorf("failed to construct credential generator: %s", err)
+			}
+
+			// The common name of the certificate may be rendered from the
+			// username, which the plugin only generates when creating the
+			// user. Create the user with a placeholder subject which no
+			// certificate carries; the certificate is generated once the
+			// username is known.
+			placeholder, err := uuid.GenerateUUID()
+			if err != nil {
+				return nil, err
+			}
+
+			// Set input credential
+			newUserReq.CredentialType = v5.CredentialTypeClientCertificate
+			newUserReq.Subject = pkix.Name{CommonName: placeholder}.String()
 		}
 
 		// Overwriting the password in the event this is a legacy database
@@ -165,6 +203,28 @@ func (b *databaseBackend) pathCredsCreateRead() framework.OperationFunc {
 		}
 		respData["username"] = newUserResp.Username
 
+		if role.CredentialType == v5.CredentialTypeClientCertificate {
+			bundle, err := b.setClientCertificate(ctx, dbi, role, certGenerator, newUserResp.Username, expiration, usernameConfig)
+			if err != nil {
+				// Don't leave behind a user without a usable credential
+				_, delErr := dbi.database.DeleteUser(ctx, v5.DeleteUserRequest{
+					Username: newUserResp.Username,
+					Statements: v5.Statements{
+						Commands: role.Statements.Revocation,
+					},
+				})
+				if delErr != nil {
+					b.Logger().Error("failed to delete user without a client certificate", "username", newUserResp.Username, "error", delErr)
+				}
+				return nil, err
+			}
+
+			// Set output credential
+			respData["client_certificate"] = bundle.Certificate
+			respData["private_key"] = bundle.PrivateKey
+			respData["private_key_type"] = string(bundle.PrivateKeyType)
+		}
+
 		// Database plugins using the v4 interface generate and return the password.
 		// Set the password response to what is returned by the NewUser request.
 		if role.CredentialType == v5.CredentialTypePassword {
@@ -184,6 +244,33 @@ func (b *databaseBackend) pathCredsCreateRead() framework.OperationFunc {
 	}
 }
 
+// setClientCertificate generates the client certificate of a user created
+// for the dynamic role, and has the plugin run the role's rotation statements
+// to set its subject in place of the placeholder the user was created with.
+func (b *databaseBackend) setClientCertificate(ctx context.Context, dbi *dbPluginInstance, role *roleEntry, generator clientCertificateGenerator, username string, expiration time.Time, usernameConfig v5.UsernameMetadata) (*certutil.CertBundle, error) {
+	bundle, subject, err := generator.generate(b.GetRandomReader(), expiration, username, usernameConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate client certificate: %s", err)
+	}
+
+	_, err = dbi.database.UpdateUser(ctx, v5.UpdateUserRequest{
+		Username:       username,
+		CredentialType: v5.CredentialTypeClientCertificate,
+		Subject: &v5.ChangeSubject{
+			NewSubject: subject,
+			Statements: v5.Statements{
+				Commands: role.Statements.Rotation,
+			},
+		},
+	}, false)
+	if err != nil {
+		b.CloseIfShutdown(dbi, err)
+		return nil, fmt.Errorf("failed to set client certificate subject: %w", err)
+	}
+
+	return bundle, nil
+}
+
 func (b *databaseBackend) pathStaticCredsRead() framework.OperationFunc {
 	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 		name := data.Get("name").(string)
@@ -210,8 +297,16 @@ func (b *databaseBackend) pathStaticCredsRead() framework.OperationFunc {
 		respData := map[string]interface{}{
 			"username":            role.StaticAccount.Username,
 			"ttl":                 role.StaticAccount.CredentialTTL().Seconds(),
-			"rotation_period":     role.StaticAccount.RotationPeriod.Seconds(),
 			"last_vault_rotation": role.StaticAccount.LastVaultRotation,
+			"next_vault_rotation": role.StaticAccount.NextRotationTime(),
+		}
+		if role.StaticAccount.RotationSchedule != "" {
+			respData["rotation_schedule"] = role.StaticAccount.RotationSchedule
+			if role.StaticAccount.RotationWindow != 0 {
+				respData["rotation_window"] = role.StaticAccount.RotationWindow.Seconds()
+			}
+		} else {
+			respData["rotation_period"] = role.StaticAccount.RotationPeriod.Seconds()
 		}
 
 		switch role.CredentialType {
@@ -219,6 +314,12 @@ func (b *databaseBackend) pathStaticCredsRead() framework.OperationFunc {
 			respData["password"] = role.StaticAccount.Password
 		case v5.CredentialTypeRSAPrivateKey:
 			respData["rsa_private_key"] = string(role.StaticAccount.PrivateKey)
+		case v5.CredentialTypeClientCertificate:
+			respData["client_certificate"] = string(role.StaticAccount.ClientCertificate)
+			respData["private_key"] = string(role.StaticAccount.PrivateKey)
+			if parsed, err := certutil.ParsePEMBundle(string(role.StaticAccount.PrivateKey)); err == nil {
+				respData["private_key_type"] = string(parsed.PrivateKeyType)
+			}
 		}
 
 		return &logical.Response{
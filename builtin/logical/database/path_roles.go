@@ -2,10 +2,12 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	"github.com/hashicorp/cronexpr"
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/go-secure-stdlib/strutil"
 	v4 "github.com/hashicorp/vault/sdk/database/dbplugin"
@@ -92,7 +94,7 @@ func fieldsForType(roleType string) map[string]*framework.FieldSchema {
 		"credential_type": {
 			Type: framework.TypeString,
 			Description: "The type of credential to manage. Options include: " +
-				"'password', 'rsa_private_key'. Defaults to 'password'.",
+				"'password', 'rsa_private_key', 'client_certificate'. Defaults to 'password'.",
 			Default: "password",
 		},
 		"credential_config": {
@@ -156,6 +158,14 @@ func dynamicFields() map[string]*framework.FieldSchema {
 	type will support this functionality. See the plugin's API page for
 	more information on support and formatting for this parameter.`,
 		},
+		"rotation_statements": {
+			Type: framework.TypeStringSlice,
+			Description: `Specifies the database statements to be executed
+	to set the subject of a user's client certificate, once it has been
+	generated. Only used with the "client_certificate" credential_type. See
+	the plugin's API page for more information on support and formatting for
+	this parameter.`,
+		},
 	}
 	return fields
 }
@@ -167,13 +177,34 @@ func staticFields() map[string]*framework.FieldSchema {
 		"username": {
 			Type: framework.TypeString,
 			Description: `Name of the static user account for Vault to manage.
-	Requires "rotation_period" to be specified`,
+	Requires "rotation_period" or "rotation_schedule" to be specified`,
+		},
+		"self_managed_password": {
+			Type: framework.TypeString,
+			Description: `The current password of the static user account. If
+	set, Vault rotates the password by authenticating to the database as the
+	user, rather than with the credentials of the connection. Only valid with
+	the "password" credential_type.`,
 		},
 		"rotation_period": {
 			Type: framework.TypeDurationSecond,
 			Description: `Period for automatic
 	credential rotation of the given username. Not valid unless used with
-	"username".`,
+	"username". Mutually exclusive with "rotation_schedule".`,
+		},
+		"rotation_schedule": {
+			Type: framework.TypeString,
+			Description: `Cron-style schedule, evaluated in UTC, for
+	automatic credential rotation of the given username. Not valid unless used
+	with "username". Mutually exclusive with "rotation_period".`,
+		},
+		"rotation_window": {
+			Type: framework.TypeDurationSecond,
+			Description: `The amount of time, in seconds, after each
+	scheduled rotation time in which the rotation is allowed to occur. If the
+	rotation could not happen within the window, it is delayed until the next
+	scheduled time. Defaults to no window, in which case a missed rotation
+	happens as soon as possible. Only valid with "rotation_schedule".`,
 		},
 		"rotation_statements": {
 			Type: framework.TypeStringSlice,
@@ -270,14 +301,25 @@ func (b *databaseBackend) pathStaticRoleRead(ctx context.Context, req *logical.R
 	if role.StaticAccount != nil {
 		data["username"] = role.StaticAccount.Username
 		data["rotation_statements"] = role.Statements.Rotation
-		data["rotation_period"] = role.StaticAccount.RotationPeriod.Seconds()
+		if role.StaticAccount.RotationSchedule != "" {
+			data["rotation_schedule"] = role.StaticAccount.RotationSchedule
+			if role.StaticAccount.RotationWindow != 0 {
+				data["rotation_window"] = role.StaticAccount.RotationWindow.Seconds()
+			}
+		} else {
+			data["rotation_period"] = role.StaticAccount.RotationPeriod.Seconds()
+		}
+		if role.StaticAccount.SelfManaged {
+			data["self_managed"] = true
+		}
 		if !role.StaticAccount.LastVaultRotation.IsZero() {
 			data["last_vault_rotation"] = role.StaticAccount.LastVaultRotation
+			data["next_vault_rotation"] = role.StaticAccount.NextRotationTime()
 		}
 	}
 
 	if len(role.CredentialConfig) > 0 {
-		data["credential_config"] = role.CredentialConfig
+		data["credential_config"] = role.credentialConfigResponse()
 	}
 	if len(role.Statements.Rotation) == 0 {
 		data["rotation_statements"] = []string{}
@@ -303,12 +345,13 @@ func (b *databaseBackend) pathRoleRead(ctx context.Context, req *logical.Request
 		"revocation_statements": role.Statements.Revocation,
 		"rollback_statements":   role.Statements.Rollback,
 		"renew_statements":      role.Statements.Renewal,
+		"rotation_statements":   role.Statements.Rotation,
 		"default_ttl":           role.DefaultTTL.Seconds(),
 		"max_ttl":               role.MaxTTL.Seconds(),
 		"credential_type":       role.CredentialType.String(),
 	}
 	if len(role.CredentialConfig) > 0 {
-		data["credential_config"] = role.CredentialConfig
+		data["credential_config"] = role.credentialConfigResponse()
 	}
 	if len(role.Statements.Creation) == 0 {
 		data["creation_statements"] = []string{}
@@ -322,6 +365,9 @@ func (b *databaseBackend) pathRoleRead(ctx context.Context, req *logical.Request
 	if len(role.Statements.Renewal) == 0 {
 		data["renew_statements"] = []string{}
 	}
+	if len(role.Statements.Rotation) == 0 {
+		data["rotation_statements"] = []string{}
+	}
 
 	return &logical.Response{
 		Data: data,
@@ -420,6 +466,12 @@ func (b *databaseBackend) pathRoleCreateUpdate(ctx context.Context, req *logical
 			role.Statements.Renewal = data.Get("renew_statements").([]string)
 		}
 
+		if rotationStmtsRaw, ok := data.GetOk("rotation_statements"); ok {
+			role.Statements.Rotation = rotationStmtsRaw.([]string)
+		} else if createOperation {
+			role.Statements.Rotation = data.Get("rotation_statements").([]string)
+		}
+
 		// Do not persist deprecated statements that are populated on role read
 		role.Statements.CreationStatements = ""
 		role.Statements.RevocationStatements = ""
@@ -513,12 +565,21 @@ func (b *databaseBackend) pathStaticRoleCreateUpdate(ctx context.Context, req *l
 	}
 	role.StaticAccount.Username = username
 
-	// If it's a Create operation, both username and rotation_period must be included
-	rotationPeriodSecondsRaw, ok := data.GetOk("rotation_period")
-	if !ok && createRole {
-		return logical.ErrorResponse("rotation_period is required to create static accounts"), nil
+	// If it's a Create operation, both username and one of rotation_period or
+	// rotation_schedule must be included
+	rotationPeriodSecondsRaw, periodOk := data.GetOk("rotation_period")
+	rotationScheduleRaw, scheduleOk := data.GetOk("rotation_schedule")
+	rotationWindowSecondsRaw, windowOk := data.GetOk("rotation_window")
+	if periodOk && scheduleOk {
+		return logical.ErrorResponse("mutually exclusive fields rotation_period and rotation_schedule were both specified; only one of them can be provided"), nil
+	}
+	if !periodOk && !scheduleOk && createRole {
+		return logical.ErrorResponse("one of rotation_period or rotation_schedule is required to create static accounts"), nil
 	}
-	if ok {
+	if periodOk {
+		if windowOk {
+			return logical.ErrorResponse("rotation_window is invalid with use of rotation_period"), nil
+		}
 		rotationPeriodSeconds := rotationPeriodSecondsRaw.(int)
 		if rotationPeriodSeconds < defaultQueueTickSeconds {
 			// If rotation frequency is specified, and this is an update, the value
@@ -527,6 +588,29 @@ func (b *databaseBackend) pathStaticRoleCreateUpdate(ctx context.Context, req *l
 			return logical.ErrorResponse(fmt.Sprintf("rotation_period must be %d seconds or more", defaultQueueTickSeconds)), nil
 		}
 		role.StaticAccount.RotationPeriod = time.Duration(rotationPeriodSeconds) * time.Second
+		// Switching to a rotation period clears any rotation schedule
+		role.StaticAccount.RotationSchedule = ""
+		role.StaticAccount.RotationWindow = 0
+	}
+	if scheduleOk {
+		rotationSchedule := rotationScheduleRaw.(string)
+		if _, err := parseRotationSchedule(rotationSchedule); err != nil {
+			return logical.ErrorResponse("could not parse rotation_schedule: %s", err), nil
+		}
+		role.StaticAccount.RotationSchedule = rotationSchedule
+		role.StaticAccount.RotationPeriod = 0
+	}
+	if windowOk {
+		if role.StaticAccount.RotationSchedule == "" {
+			return logical.ErrorResponse("rotation_window is invalid without use of rotation_schedule"), nil
+		}
+		rotationWindowSeconds := rotationWindowSecondsRaw.(int)
+		if rotationWindowSeconds != 0 && rotationWindowSeconds < minRotationWindowSeconds {
+			// The window must leave room for the rotation queue to pick the
+			// role up and for retries in case the rotation fails
+			return logical.ErrorResponse(fmt.Sprintf("rotation_window must be %d seconds or more", minRotationWindowSeconds)), nil
+		}
+		role.StaticAccount.RotationWindow = time.Duration(rotationWindowSeconds) * time.Second
 	}
 
 	if rotationStmtsRaw, ok := data.GetOk("rotation_statements"); ok {
@@ -552,6 +636,30 @@ func (b *databaseBackend) pathStaticRoleCreateUpdate(ctx context.Context, req *l
 		return logical.ErrorResponse("credential_config validation failed: %s", err), nil
 	}
 
+	if selfManagedPasswordRaw, ok := data.GetOk("self_managed_password"); ok {
+		selfManagedPassword := selfManagedPasswordRaw.(string)
+		if selfManagedPassword == "" {
+			return logical.ErrorResponse("self_managed_password cannot be empty"), nil
+		}
+		if !createRole && !role.StaticAccount.SelfManaged {
+			return logical.ErrorResponse("cannot set self_managed_password on a static role which is not self-managed"), nil
+		}
+		dbConfig, err := b.DatabaseConfig(ctx, req.Storage, role.DBName)
+		if err != nil {
+			return nil, err
+		}
+		if !dbConfig.SupportsSelfManagedPassword() {
+			return logical.ErrorResponse("the database plugin of %q does not support self_managed_password", role.DBName), nil
+		}
+		// The given password is the current password of the user, which Vault
+		// authenticates with on the next rotation
+		role.StaticAccount.SelfManaged = true
+		role.StaticAccount.Password = selfManagedPassword
+	}
+	if role.StaticAccount.SelfManaged && role.CredentialType != v5.CredentialTypePassword {
+		return logical.ErrorResponse("self-managed static roles only support the %q credential_type", v5.CredentialTypePassword.String()), nil
+	}
+
 	// lvr represents the roles' LastVaultRotation
 	lvr := role.StaticAccount.LastVaultRotation
 
@@ -600,7 +708,7 @@ func (b *databaseBackend) pathStaticRoleCreateUpdate(ctx context.Context, req *l
 		}
 	}
 
-	item.Priority = lvr.Add(role.StaticAccount.RotationPeriod).Unix()
+	item.Priority = role.StaticAccount.NextRotationTimeFromInput(lvr).Unix()
 
 	// Add their rotation to the queue
 	if err := b.pushItem(item); err != nil {
@@ -628,6 +736,8 @@ func (r *roleEntry) setCredentialType(credentialType string) error {
 		r.CredentialType = v5.CredentialTypePassword
 	case v5.CredentialTypeRSAPrivateKey.String():
 		r.CredentialType = v5.CredentialTypeRSAPrivateKey
+	case v5.CredentialTypeClientCertificate.String():
+		r.CredentialType = v5.CredentialTypeClientCertificate
 	default:
 		return fmt.Errorf("invalid credential_type %q", credentialType)
 	}
@@ -669,11 +779,42 @@ func (r *roleEntry) setCredentialConfig(config map[string]string) error {
 		if len(cm) > 0 {
 			r.CredentialConfig = cm
 		}
+	case v5.CredentialTypeClientCertificate:
+		// The configuration has no usable defaults, so keep the existing one
+		// when none is given
+		if len(c) == 0 {
+			c = r.CredentialConfig
+		}
+		generator, err := newClientCertificateGenerator(c)
+		if err != nil {
+			return err
+		}
+		cm, err := generator.configMap()
+		if err != nil {
+			return err
+		}
+		if len(cm) > 0 {
+			r.CredentialConfig = cm
+		}
 	}
 
 	return nil
 }
 
+// credentialConfigResponse returns the credential configuration of the role
+// to be returned on reads, without the private key of the CA signing client
+// certificates.
+func (r *roleEntry) credentialConfigResponse() map[string]interface{} {
+	config := make(map[string]interface{}, len(r.CredentialConfig))
+	for k, v := range r.CredentialConfig {
+		if k == "ca_private_key" {
+			continue
+		}
+		config[k] = v
+	}
+	return config
+}
+
 type staticAccount struct {
 	// Username to create or assume management for static accounts
 	Username string `json:"username"`
@@ -690,6 +831,11 @@ type staticAccount struct {
 	// CredentialTypeRSAPrivateKey.
 	PrivateKey []byte `json:"private_key"`
 
+	// ClientCertificate is the current client certificate credential for static
+	// accounts, issued for PrivateKey. Returned on credential request if the
+	// role's credential type is CredentialTypeClientCertificate.
+	ClientCertificate []byte `json:"client_certificate"`
+
 	// LastVaultRotation represents the last time Vault rotated the password
 	LastVaultRotation time.Time `json:"last_vault_rotation"`
 
@@ -698,15 +844,87 @@ type staticAccount struct {
 	// determine if a password needs to be rotated
 	RotationPeriod time.Duration `json:"rotation_period"`
 
+	// RotationSchedule is a cron-style schedule, evaluated in UTC, of the
+	// rotations. It is used instead of RotationPeriod when set.
+	RotationSchedule string `json:"rotation_schedule"`
+
+	// RotationWindow is the amount of time after each scheduled rotation time
+	// in which the rotation is allowed to happen. Zero means the rotation can
+	// happen at any time after the scheduled time.
+	RotationWindow time.Duration `json:"rotation_window"`
+
+	// SelfManaged indicates that the user manages its own password. Rotations
+	// authenticate to the database as the user with the current Password,
+	// rather than with the credentials of the connection.
+	SelfManaged bool `json:"self_managed"`
+
 	// RevokeUser is a boolean flag to indicate if Vault should revoke the
 	// database user when the role is deleted
 	RevokeUserOnDelete bool `json:"revoke_user_on_delete"`
 }
 
-// NextRotationTime calculates the next rotation by adding the Rotation Period
-// to the last known vault rotation
+// NextRotationTime calculates the next rotation from the last known vault
+// rotation. If the rotation is overdue but its rotation window has passed, the
+// next scheduled rotation time is returned instead.
 func (s *staticAccount) NextRotationTime() time.Time {
-	return s.LastVaultRotation.Add(s.RotationPeriod)
+	next := s.NextRotationTimeFromInput(s.LastVaultRotation)
+	if now := time.Now(); next.Before(now) && !s.IsInsideRotationWindow(now) {
+		return s.NextRotationTimeFromInput(now)
+	}
+	return next
+}
+
+// NextRotationTimeFromInput calculates the rotation following the given time,
+// either by adding the Rotation Period or from the Rotation Schedule
+func (s *staticAccount) NextRotationTimeFromInput(input time.Time) time.Time {
+	schedule := s.schedule()
+	if schedule == nil {
+		return input.Add(s.RotationPeriod)
+	}
+	return schedule.Next(input.UTC())
+}
+
+// IsInsideRotationWindow returns whether the given time falls in the rotation
+// window following a scheduled rotation time. It is always true for accounts
+// without a rotation window.
+func (s *staticAccount) IsInsideRotationWindow(t time.Time) bool {
+	schedule := s.schedule()
+	if schedule == nil || s.RotationWindow == 0 {
+		return true
+	}
+	// The first scheduled time after the start of the window ending at t is
+	// not later than t only if t is inside the window of that scheduled time
+	return !schedule.Next(t.UTC().Add(-s.RotationWindow)).After(t)
+}
+
+// schedule returns the parsed rotation schedule of the account, or nil if it
+// rotates based on its rotation period. Schedules are validated when the role
+// is written, so an invalid schedule is treated as no schedule.
+func (s *staticAccount) schedule() *cronexpr.Expression {
+	if s.RotationSchedule == "" {
+		return nil
+	}
+	schedule, err := parseRotationSchedule(s.RotationSchedule)
+	if err != nil {
+		return nil
+	}
+	return schedule
+}
+
+// parseRotationSchedule parses a cron-style rotation schedule with five
+// fields: minute, hour, day of month, month and day of week.
+func parseRotationSchedule(rotationSchedule string) (*cronexpr.Expression, error) {
+	if fields := strings.Fields(rotationSchedule); len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields, got %d", len(fields))
+	}
+	schedule, err := cronexpr.Parse(rotationSchedule)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.Next(time.Now().UTC()).IsZero() {
+		return nil, errors.New("schedule never matches")
+	}
+	return schedule, nil
 }
 
 // CredentialTTL calculates the approximate time remaining until the credential is
@@ -778,7 +996,19 @@ rollback a change if needed.
 const pathStaticRoleHelpDesc = `
 This path lets you manage the static roles that can be created with this
 backend. Static Roles are associated with a single database user, and manage the
-credential based on a rotation period, automatically rotating the credential.
+credential based on a rotation period or schedule, automatically rotating the
+credential.
+
+The "rotation_schedule" parameter sets a cron-style schedule, evaluated in UTC,
+instead of a "rotation_period", e.g. "0 2 * * SAT" to rotate every Saturday at
+02:00. The optional "rotation_window" parameter restricts the rotations to the
+given duration after each scheduled time, so that a rotation which could not
+happen in time is delayed until the next scheduled time.
+
+The "self_managed_password" parameter sets the current password of a user which
+manages its own credentials. Vault rotates the password of such a user by
+authenticating to the database as the user, so the connection does not require
+credentials allowed to change the passwords of other users.
 
 The "db_name" parameter is required and configures the name of the database
 connection to use.
@@ -797,6 +1027,9 @@ and "}}" to be replaced.
   * "public_key" - The public key generated for the DB user. Populated if the
   static role's credential_type is 'rsa_private_key'.
 
+  * "subject" - The subject of the client certificate generated for the DB user.
+  Populated if the static role's credential_type is 'client_certificate'.
+
 Example of a decent creation_statements for a postgresql database plugin:
 
         CREATE ROLE "{{name}}" WITH
@@ -12,12 +12,15 @@ import (
 	"github.com/hashicorp/vault/helper/namespace"
 	postgreshelper "github.com/hashicorp/vault/helper/testhelpers/postgresql"
 	v5 "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/hashicorp/vault/sdk/helper/certutil"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
 func TestBackend_Roles_CredentialTypes(t *testing.T) {
+	caCert, caKey := testCACertificate(t, time.Now().Add(time.Hour))
+
 	config := logical.TestBackendConfig()
 	config.System = logical.TestSystemView()
 	config.StorageView = &logical.InmemStorage{}
@@ -149,6 +152,34 @@ func TestBackend_Roles_CredentialTypes(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "role with client_certificate credential type and configuration",
+			args: args{
+				credentialType: v5.CredentialTypeClientCertificate,
+				credentialConfig: map[string]string{
+					"common_name_template": "{{.DisplayName}}",
+					"ca_cert":              caCert,
+					"ca_private_key":       caKey,
+					"key_type":             "ec",
+				},
+			},
+			expectedResp: map[string]interface{}{
+				"credential_type": v5.CredentialTypeClientCertificate.String(),
+				"credential_config": map[string]interface{}{
+					"common_name_template": "{{.DisplayName}}",
+					"ca_cert":              caCert,
+					"key_type":             "ec",
+					"key_bits":             json.Number("256"),
+				},
+			},
+		},
+		{
+			name: "role with client_certificate credential type and default configuration",
+			args: args{
+				credentialType: v5.CredentialTypeClientCertificate,
+			},
+			wantErr: true,
+		},
 		{
 			name: "role with rsa_private_key credential type invalid format configuration",
 			args: args{
@@ -268,7 +299,7 @@ func TestBackend_StaticRole_Config(t *testing.T) {
 				"username": dbUser,
 			},
 			path: "plugin-role-test",
-			err:  errors.New("rotation_period is required to create static accounts"),
+			err:  errors.New("one of rotation_period or rotation_schedule is required to create static accounts"),
 		},
 		"disallowed role config": {
 			account: map[string]interface{}{
@@ -825,6 +856,371 @@ func TestWALsDeletedOnRoleDeletion(t *testing.T) {
 	requireWALs(t, storage, 1)
 }
 
+func TestBackend_StaticRole_RotationSchedule(t *testing.T) {
+	ctx := context.Background()
+	b, storage, mockDB := getBackend(t)
+	defer b.Cleanup(ctx)
+	configureDBMount(t, storage)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(ctx, &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+		})
+	}
+
+	invalidCases := map[string]map[string]interface{}{
+		"period and schedule": {
+			"rotation_period":   "86400s",
+			"rotation_schedule": "0 2 * * *",
+		},
+		"window with period": {
+			"rotation_period": "86400s",
+			"rotation_window": "3600s",
+		},
+		"invalid schedule": {
+			"rotation_schedule": "0 2 * *",
+		},
+		"short window": {
+			"rotation_schedule": "0 2 * * *",
+			"rotation_window":   "60s",
+		},
+	}
+	for name, data := range invalidCases {
+		t.Run(name, func(t *testing.T) {
+			data["username"] = "hashicorp"
+			data["db_name"] = "mockv5"
+			resp, err := request(logical.CreateOperation, "static-roles/hashicorp", data)
+			if err != nil || resp == nil || !resp.IsError() {
+				t.Fatalf("expected error response, got resp: %#v, err: %v", resp, err)
+			}
+		})
+	}
+
+	mockDB.On("UpdateUser", mock.Anything, mock.Anything).
+		Return(v5.UpdateUserResponse{}, nil).
+		Once()
+	resp, err := request(logical.CreateOperation, "static-roles/hashicorp", map[string]interface{}{
+		"username":          "hashicorp",
+		"db_name":           "mockv5",
+		"rotation_schedule": "0 2 * * SAT",
+		"rotation_window":   "7200s",
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatal(resp, err)
+	}
+
+	resp, err = request(logical.ReadOperation, "static-roles/hashicorp", nil)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatal(resp, err)
+	}
+	if resp.Data["rotation_schedule"] != "0 2 * * SAT" || resp.Data["rotation_window"] != float64(7200) {
+		t.Fatalf("bad rotation schedule: %#v", resp.Data)
+	}
+	if _, ok := resp.Data["rotation_period"]; ok {
+		t.Fatalf("unexpected rotation_period: %#v", resp.Data)
+	}
+
+	resp, err = request(logical.ReadOperation, "static-creds/hashicorp", nil)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatal(resp, err)
+	}
+	next := resp.Data["next_vault_rotation"].(time.Time)
+	if next.Weekday() != time.Saturday || next.Hour() != 2 || next.Minute() != 0 || !next.After(time.Now()) {
+		t.Fatalf("bad next rotation: %s", next)
+	}
+	item, err := b.popFromRotationQueueByKey("hashicorp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Priority != next.Unix() {
+		t.Fatalf("expected queue priority %d, got %d", next.Unix(), item.Priority)
+	}
+	if err := b.pushItem(item); err != nil {
+		t.Fatal(err)
+	}
+
+	// Switching back to a rotation period clears the schedule and window
+	resp, err = request(logical.UpdateOperation, "static-roles/hashicorp", map[string]interface{}{
+		"username":        "hashicorp",
+		"rotation_period": "86400s",
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatal(resp, err)
+	}
+	resp, err = request(logical.ReadOperation, "static-roles/hashicorp", nil)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatal(resp, err)
+	}
+	if resp.Data["rotation_period"] != float64(86400) || resp.Data["rotation_schedule"] != nil || resp.Data["rotation_window"] != nil {
+		t.Fatalf("bad rotation period: %#v", resp.Data)
+	}
+
+	resp, err = request(logical.UpdateOperation, "static-roles/hashicorp", map[string]interface{}{
+		"username":        "hashicorp",
+		"rotation_window": "3600s",
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected error setting a window without a schedule, got resp: %#v, err: %v", resp, err)
+	}
+}
+
+func TestStaticAccount_RotationWindow(t *testing.T) {
+	account := &staticAccount{
+		RotationSchedule: "0 2 * * *",
+		RotationWindow:   time.Hour,
+	}
+	day := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := map[string]struct {
+		now      time.Time
+		inWindow bool
+		next     time.Time
+	}{
+		"before window": {
+			now:      day.Add(time.Hour),
+			inWindow: false,
+			next:     day.Add(2 * time.Hour),
+		},
+		"start of window": {
+			now:      day.Add(2 * time.Hour),
+			inWindow: true,
+			next:     day.Add(26 * time.Hour),
+		},
+		"inside window": {
+			now:      day.Add(2*time.Hour + 30*time.Minute),
+			inWindow: true,
+			next:     day.Add(26 * time.Hour),
+		},
+		"after window": {
+			now:      day.Add(3*time.Hour + time.Minute),
+			inWindow: false,
+			next:     day.Add(26 * time.Hour),
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if inWindow := account.IsInsideRotationWindow(tc.now); inWindow != tc.inWindow {
+				t.Fatalf("expected inside window to be %t, got %t", tc.inWindow, inWindow)
+			}
+			if next := account.NextRotationTimeFromInput(tc.now); !next.Equal(tc.next) {
+				t.Fatalf("expected next rotation %s, got %s", tc.next, next)
+			}
+		})
+	}
+
+	// Accounts without a window can always rotate
+	account.RotationWindow = 0
+	if !account.IsInsideRotationWindow(day.Add(12 * time.Hour)) {
+		t.Fatal("expected account without a window to always be inside the window")
+	}
+	account = &staticAccount{RotationPeriod: time.Hour}
+	if !account.IsInsideRotationWindow(day) || !account.NextRotationTimeFromInput(day).Equal(day.Add(time.Hour)) {
+		t.Fatal("bad rotation of account with a rotation period")
+	}
+}
+
+func TestBackend_ClientCertificateCredentials(t *testing.T) {
+	ctx := context.Background()
+	b, storage, mockDB := getBackend(t)
+	defer b.Cleanup(ctx)
+
+	entry, err := logical.StorageEntryJSON("config/mockv5", &DatabaseConfig{
+		AllowedRoles: []string{"*"},
+		ConnectionDetails: map[string]interface{}{
+			v5.SupportedCredentialTypesKey: []interface{}{
+				v5.CredentialTypePassword.String(),
+				v5.CredentialTypeClientCertificate.String(),
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Put(ctx, entry); err != nil {
+		t.Fatal(err)
+	}
+
+	caCert, caKey := testCACertificate(t, time.Now().Add(24*time.Hour))
+	credentialConfig := map[string]string{
+		"common_name_template": "{{.RoleName}}-{{.DisplayName}}",
+		"ca_cert":              caCert,
+		"ca_private_key":       caKey,
+		"key_type":             "ec",
+	}
+	request := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(ctx, &logical.Request{
+			Operation:   op,
+			Path:        path,
+			Storage:     storage,
+			Data:        data,
+			DisplayName: "token",
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatal(resp, err)
+		}
+		return resp
+	}
+	requireCertificate := func(resp *logical.Response, subject string) {
+		t.Helper()
+		parsed, err := certutil.ParsePEMBundle(resp.Data["client_certificate"].(string) + "\n" + resp.Data["private_key"].(string))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := parsed.Verify(); err != nil {
+			t.Fatal(err)
+		}
+		if parsed.Certificate.Subject.String() != subject || resp.Data["private_key_type"] != "ec" {
+			t.Fatalf("bad client certificate for %s: %#v", subject, resp.Data)
+		}
+	}
+
+	// Dynamic roles create the user with a placeholder subject, then set the
+	// subject of the certificate, whose common name defaults to the username
+	request(logical.CreateOperation, "roles/dynamic", map[string]interface{}{
+		"db_name":         "mockv5",
+		"credential_type": v5.CredentialTypeClientCertificate.String(),
+		"credential_config": map[string]string{
+			"ca_cert":        caCert,
+			"ca_private_key": caKey,
+			"key_type":       "ec",
+		},
+		"rotation_statements": []string{"ALTER USER {{name}} REQUIRE SUBJECT '{{subject}}'"},
+		"default_ttl":         "1h",
+	})
+	mockDB.On("NewUser", mock.Anything, mock.MatchedBy(func(req v5.NewUserRequest) bool {
+		return req.CredentialType == v5.CredentialTypeClientCertificate &&
+			strings.HasPrefix(req.Subject, "CN=") && req.Subject != "CN=v-dynamic"
+	})).Return(v5.NewUserResponse{Username: "v-dynamic"}, nil).Once()
+	mockDB.On("UpdateUser", mock.Anything, mock.MatchedBy(func(req v5.UpdateUserRequest) bool {
+		return req.Username == "v-dynamic" && req.CredentialType == v5.CredentialTypeClientCertificate &&
+			req.Subject != nil && req.Subject.NewSubject == "CN=v-dynamic" &&
+			len(req.Subject.Statements.Commands) == 1
+	})).Return(v5.UpdateUserResponse{}, nil).Once()
+	resp := request(logical.ReadOperation, "creds/dynamic", nil)
+	if resp.Data["username"] != "v-dynamic" {
+		t.Fatalf("bad username: %#v", resp.Data)
+	}
+	requireCertificate(resp, "CN=v-dynamic")
+
+	// A user whose subject can't be set is deleted
+	mockDB.On("NewUser", mock.Anything, mock.Anything).Return(v5.NewUserResponse{Username: "v-failed"}, nil).Once()
+	mockDB.On("UpdateUser", mock.Anything, mock.MatchedBy(func(req v5.UpdateUserRequest) bool {
+		return req.Username == "v-failed"
+	})).Return(v5.UpdateUserResponse{}, errors.New("failed to alter user")).Once()
+	mockDB.On("DeleteUser", mock.Anything, mock.MatchedBy(func(req v5.DeleteUserRequest) bool {
+		return req.Username == "v-failed"
+	})).Return(v5.DeleteUserResponse{}, nil).Once()
+	_, err = b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "creds/dynamic",
+		Storage:   storage,
+	})
+	if err == nil {
+		t.Fatal("expected an error when the subject can't be set")
+	}
+	mockDB.AssertCalled(t, "DeleteUser", mock.Anything, mock.Anything)
+
+	// Updating the role without a credential configuration keeps the CA
+	request(logical.UpdateOperation, "roles/dynamic", map[string]interface{}{
+		"default_ttl": "2h",
+	})
+	resp = request(logical.ReadOperation, "roles/dynamic", nil)
+	config := resp.Data["credential_config"].(map[string]interface{})
+	if config["ca_cert"] != caCert || config["ca_private_key"] != nil {
+		t.Fatalf("bad credential config: %#v", config)
+	}
+
+	// Static roles update the subject of the user on each rotation
+	mockDB.On("UpdateUser", mock.Anything, mock.MatchedBy(func(req v5.UpdateUserRequest) bool {
+		return req.CredentialType == v5.CredentialTypeClientCertificate &&
+			req.Subject != nil && req.Subject.NewSubject == "CN=static-hashicorp"
+	})).Return(v5.UpdateUserResponse{}, nil).Twice()
+	request(logical.CreateOperation, "static-roles/static", map[string]interface{}{
+		"db_name":           "mockv5",
+		"username":          "hashicorp",
+		"rotation_period":   "3h",
+		"credential_type":   v5.CredentialTypeClientCertificate.String(),
+		"credential_config": credentialConfig,
+	})
+	resp = request(logical.ReadOperation, "static-creds/static", nil)
+	requireCertificate(resp, "CN=static-hashicorp")
+	firstCertificate := resp.Data["client_certificate"]
+
+	// The certificate expires a grace period after the next rotation
+	parsed, err := certutil.ParsePEMBundle(firstCertificate.(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantNotAfter := time.Now().Add(3*time.Hour + clientCertificateGracePeriod)
+	if diff := parsed.Certificate.NotAfter.Sub(wantNotAfter); diff > time.Minute || diff < -time.Minute {
+		t.Fatalf("bad client certificate expiration: %v, want %v", parsed.Certificate.NotAfter, wantNotAfter)
+	}
+
+	request(logical.UpdateOperation, "rotate-role/static", nil)
+	resp = request(logical.ReadOperation, "static-creds/static", nil)
+	requireCertificate(resp, "CN=static-hashicorp")
+	if resp.Data["client_certificate"] == firstCertificate {
+		t.Fatal("expected a new client certificate after rotation")
+	}
+	mockDB.AssertNumberOfCalls(t, "NewUser", 2)
+	mockDB.AssertNumberOfCalls(t, "UpdateUser", 4)
+}
+
+func TestBackend_CredsCreate_UsernameMetadata(t *testing.T) {
+	ctx := context.Background()
+	b, storage, mockDB := getBackend(t)
+	defer b.Cleanup(ctx)
+	configureDBMount(t, storage)
+
+	b.System().(*logical.StaticSystemView).EntityVal = &logical.Entity{
+		ID: "entity-id",
+		Metadata: map[string]string{
+			"team": "audit",
+		},
+	}
+
+	resp, err := b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      "roles/dynamic",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"db_name":     "mockv5",
+			"default_ttl": "1h",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatal(resp, err)
+	}
+
+	mockDB.On("NewUser", mock.Anything, mock.MatchedBy(func(req v5.NewUserRequest) bool {
+		return deep.Equal(req.UsernameConfig, v5.UsernameMetadata{
+			DisplayName: "token",
+			RoleName:    "dynamic",
+			EntityID:    "entity-id",
+			EntityMetadata: map[string]string{
+				"team": "audit",
+			},
+			MountAccessor: "database_1234",
+		}) == nil
+	})).Return(v5.NewUserResponse{Username: "v-token-dynamic"}, nil).Once()
+	resp, err = b.HandleRequest(ctx, &logical.Request{
+		Operation:     logical.ReadOperation,
+		Path:          "creds/dynamic",
+		Storage:       storage,
+		DisplayName:   "token",
+		EntityID:      "entity-id",
+		MountAccessor: "database_1234",
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatal(resp, err)
+	}
+	mockDB.AssertNumberOfCalls(t, "NewUser", 1)
+}
+
 func createRole(t *testing.T, b *databaseBackend, storage logical.Storage, mockDB *mockNewDatabase, roleName string) {
 	t.Helper()
 	mockDB.On("UpdateUser", mock.Anything, mock.Anything).
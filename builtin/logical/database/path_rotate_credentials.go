@@ -33,6 +33,44 @@ func pathRotateRootCredentials(b *databaseBackend) []*framework.Path {
 			HelpSynopsis:    pathRotateCredentialsUpdateHelpSyn,
 			HelpDescription: pathRotateCredentialsUpdateHelpDesc,
 		},
+		{
+			Pattern: "rotate-root/" + framework.GenericNameRegex("name") + "/history",
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of this database connection",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.pathRotateRootCredentialsHistoryRead(),
+				},
+			},
+
+			HelpSynopsis:    pathRotateCredentialsHistoryHelpSyn,
+			HelpDescription: pathRotateCredentialsHistoryHelpDesc,
+		},
+		{
+			Pattern: "restore-root/" + framework.GenericNameRegex("name"),
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of this database connection",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback:                    b.pathRestoreRootCredentialsUpdate(),
+					ForwardPerformanceSecondary: true,
+					ForwardPerformanceStandby:   true,
+				},
+			},
+
+			HelpSynopsis:    pathRestoreCredentialsUpdateHelpSyn,
+			HelpDescription: pathRestoreCredentialsUpdateHelpDesc,
+		},
 		{
 			Pattern: "rotate-role/" + framework.GenericNameRegex("name"),
 			Fields: map[string]*framework.FieldSchema{
@@ -122,6 +160,14 @@ func (b *databaseBackend) pathRotateRootCredentialsUpdate() framework.OperationF
 			return nil, err
 		}
 
+		// Keep the credential being replaced before changing it in the
+		// database, so that it can be restored even if the rotation only
+		// partially succeeds
+		err = b.recordRootCredential(ctx, req.Storage, name, rootUsername, oldPassword)
+		if err != nil {
+			return nil, err
+		}
+
 		updateReq := v5.UpdateUserRequest{
 			Username:       rootUsername,
 			CredentialType: v5.CredentialTypePassword,
@@ -134,6 +180,11 @@ func (b *databaseBackend) pathRotateRootCredentialsUpdate() framework.OperationF
 		}
 		newConfigDetails, err := dbi.database.UpdateUser(ctx, updateReq, true)
 		if err != nil {
+			// The credential was not replaced, so it doesn't belong in the
+			// history
+			if err := b.forgetRootCredential(ctx, req.Storage, name, oldPassword); err != nil {
+				b.Logger().Warn("unable to update root credential history", "error", err, "connection", name)
+			}
 			return nil, fmt.Errorf("failed to update user: %w", err)
 		}
 		if newConfigDetails != nil {
@@ -153,6 +204,158 @@ func (b *databaseBackend) pathRotateRootCredentialsUpdate() framework.OperationF
 	}
 }
 
+func (b *databaseBackend) pathRotateRootCredentialsHistoryRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+		name := data.Get("name").(string)
+		if name == "" {
+			return logical.ErrorResponse(respErrEmptyName), nil
+		}
+
+		if _, err := b.DatabaseConfig(ctx, req.Storage, name); err != nil {
+			return nil, err
+		}
+
+		history, err := b.rootCredentialHistory(ctx, req.Storage, name)
+		if err != nil {
+			return nil, err
+		}
+
+		// Only return when the credentials were rotated, never the credentials
+		entries := make([]map[string]interface{}, 0, len(history.Entries))
+		for _, entry := range history.Entries {
+			entries = append(entries, map[string]interface{}{
+				"username":      entry.Username,
+				"rotation_time": entry.RotationTime,
+			})
+		}
+
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"history": entries,
+			},
+		}, nil
+	}
+}
+
+func (b *databaseBackend) pathRestoreRootCredentialsUpdate() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+		name := data.Get("name").(string)
+		if name == "" {
+			return logical.ErrorResponse(respErrEmptyName), nil
+		}
+
+		config, err := b.DatabaseConfig(ctx, req.Storage, name)
+		if err != nil {
+			return nil, err
+		}
+
+		rootUsername, ok := config.ConnectionDetails["username"].(string)
+		if !ok || rootUsername == "" {
+			return nil, fmt.Errorf("unable to restore root credentials: no username in configuration")
+		}
+
+		history, err := b.rootCredentialHistory(ctx, req.Storage, name)
+		if err != nil {
+			return nil, err
+		}
+		if len(history.Entries) == 0 {
+			return logical.ErrorResponse("no previous root credentials to restore"), nil
+		}
+		restore := history.Entries[0]
+		if restore.Username != rootUsername {
+			return logical.ErrorResponse("previous root credentials are for username %q, but the connection uses %q", restore.Username, rootUsername), nil
+		}
+
+		currentPassword, _ := config.ConnectionDetails["password"].(string)
+
+		// inDatabase is set if the database already accepts the previous
+		// credentials, in which case only the configuration is restored
+		var inDatabase bool
+		dbi, err := b.GetConnection(ctx, req.Storage, name)
+		if err != nil {
+			// A partially failed rotation can leave the current credentials
+			// unusable, so try the previous credentials instead
+			b.Logger().Warn("unable to connect with the current root credentials, trying the previous root credentials", "connection", name, "error", err)
+			config.ConnectionDetails["password"] = restore.Password
+			dbi, err = b.GetConnectionWithConfig(ctx, name, config)
+			if err != nil {
+				return nil, fmt.Errorf("unable to connect with the current or previous root credentials: %w", err)
+			}
+			inDatabase = true
+		}
+
+		// Take out the backend lock since we are swapping out the connection
+		b.Lock()
+		defer b.Unlock()
+
+		// Take the write lock on the instance
+		dbi.Lock()
+		defer dbi.Unlock()
+
+		defer func() {
+			// Close the plugin
+			dbi.closed = true
+			if err := dbi.database.Close(); err != nil {
+				b.Logger().Error("error closing the database plugin connection", "err", err)
+			}
+			// Even on error, still remove the connection
+			delete(b.connections, name)
+		}()
+
+		var walID string
+		if !inDatabase && currentPassword != restore.Password {
+			// Write a WAL entry so that the current credentials are rolled
+			// back if the restored credentials fail to be stored
+			walID, err = framework.PutWAL(ctx, req.Storage, rotateRootWALKey, &rotateRootCredentialsWAL{
+				ConnectionName: name,
+				UserName:       rootUsername,
+				OldPassword:    currentPassword,
+				NewPassword:    restore.Password,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			updateReq := v5.UpdateUserRequest{
+				Username:       rootUsername,
+				CredentialType: v5.CredentialTypePassword,
+				Password: &v5.ChangePassword{
+					NewPassword: restore.Password,
+					Statements: v5.Statements{
+						Commands: config.RootCredentialsRotateStatements,
+					},
+				},
+			}
+
+			// It actually is the root user here, but we only want to use SetCredentials since
+			// RotateRootCredentials doesn't give any control over what password is used
+			if _, err := dbi.database.UpdateUser(ctx, updateReq, false); err != nil {
+				return nil, fmt.Errorf("failed to update user: %w", err)
+			}
+			config.ConnectionDetails["password"] = restore.Password
+		}
+
+		err = storeConfig(ctx, req.Storage, name, config)
+		if err != nil {
+			return nil, err
+		}
+
+		history.Entries = history.Entries[1:]
+		err = storeRootCredentialHistory(ctx, req.Storage, name, history)
+		if err != nil {
+			b.Logger().Warn("unable to update root credential history", "error", err, "connection", name)
+		}
+
+		if walID != "" {
+			err = framework.DeleteWAL(ctx, req.Storage, walID)
+			if err != nil {
+				b.Logger().Warn("unable to delete WAL", "error", err, "WAL ID", walID)
+			}
+		}
+		return nil, nil
+	}
+}
+
 func (b *databaseBackend) pathRotateRoleCredentialsUpdate() framework.OperationFunc {
 	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 		name := data.Get("name").(string)
@@ -199,7 +402,7 @@ func (b *databaseBackend) pathRotateRoleCredentialsUpdate() framework.OperationF
 				item.Value = resp.WALID
 			}
 		} else {
-			item.Priority = resp.RotationTime.Add(role.StaticAccount.RotationPeriod).Unix()
+			item.Priority = role.StaticAccount.NextRotationTimeFromInput(resp.RotationTime).Unix()
 			// Clear any stored WAL ID as we must have successfully deleted our WAL to get here.
 			item.Value = ""
 		}
@@ -227,6 +430,29 @@ const pathRotateCredentialsUpdateHelpDesc = `
 This path attempts to rotate the root credentials for the given database. 
 `
 
+const pathRotateCredentialsHistoryHelpSyn = `
+Read the history of root credential rotations for a certain database connection.
+`
+
+const pathRotateCredentialsHistoryHelpDesc = `
+This path returns the username and rotation time of the previous root
+credentials of the given database, most recent first. Vault keeps the last 5
+root credentials replaced by "rotate-root". The credentials themselves are never
+returned.
+`
+
+const pathRestoreCredentialsUpdateHelpSyn = `
+Request to restore the previous root credentials for a certain database connection.
+`
+
+const pathRestoreCredentialsUpdateHelpDesc = `
+This path restores the most recent previous root credentials of the given
+database, as listed by "rotate-root/<name>/history". Vault changes the password
+of the root user back to the previous password, or, if the database no longer
+accepts the current root credentials, uses the previous credentials if the
+database accepts them. This path requires sudo capability.
+`
+
 const pathRotateRoleCredentialsUpdateHelpSyn = `
 Request to rotate the credentials for a static user account.
 `
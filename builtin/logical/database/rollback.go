@@ -3,6 +3,8 @@ package database
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/hashicorp/vault/sdk/database/dbplugin"
 	v5 "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
@@ -12,8 +14,21 @@ import (
 	"google.golang.org/grpc/status"
 )
 
-// WAL storage key used for the rollback of root database credentials
-const rotateRootWALKey = "rotateRootWALKey"
+const (
+	// WAL storage key used for the rollback of root database credentials
+	rotateRootWALKey = "rotateRootWALKey"
+
+	// rootCredentialHistoryPath is the storage prefix of the previous root
+	// credentials of each connection. WAL entries are local to the cluster
+	// and deleted once a rotation completes or is rolled back, so the
+	// history is kept beside the connection configuration, and replicated
+	// with it, rather than in the WAL.
+	rootCredentialHistoryPath = "root-credential-history/"
+
+	// maxRootCredentialHistory is the number of previous root credentials
+	// kept for each connection
+	maxRootCredentialHistory = 5
+)
 
 // WAL entry used for the rollback of root database credentials
 type rotateRootCredentialsWAL struct {
@@ -111,3 +126,90 @@ func (b *databaseBackend) rollbackDatabaseCredentials(ctx context.Context, confi
 	}
 	return err
 }
+
+// rootCredentialHistory is the list of previous root credentials of a
+// connection, most recent first. It is kept in seal wrapped storage since it
+// contains credentials.
+type rootCredentialHistory struct {
+	Entries []rootCredentialHistoryEntry `json:"entries"`
+}
+
+// rootCredentialHistoryEntry is a root credential which was replaced by a
+// rotation of the root credentials
+type rootCredentialHistoryEntry struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+
+	// RotationTime is the time the credential was replaced
+	RotationTime time.Time `json:"rotation_time"`
+}
+
+// rootCredentialHistory returns the previous root credentials of the given
+// connection. The history is empty if the root credentials were never
+// rotated.
+func (b *databaseBackend) rootCredentialHistory(ctx context.Context, s logical.Storage, name string) (*rootCredentialHistory, error) {
+	entry, err := s.Get(ctx, rootCredentialHistoryPath+name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read root credential history: %w", err)
+	}
+
+	history := &rootCredentialHistory{}
+	if entry == nil {
+		return history, nil
+	}
+	if err := entry.DecodeJSON(history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// storeRootCredentialHistory stores the given history of the root credentials
+// of the given connection, keeping at most maxRootCredentialHistory entries.
+func storeRootCredentialHistory(ctx context.Context, s logical.Storage, name string, history *rootCredentialHistory) error {
+	if len(history.Entries) > maxRootCredentialHistory {
+		history.Entries = history.Entries[:maxRootCredentialHistory]
+	}
+
+	entry, err := logical.StorageEntryJSON(rootCredentialHistoryPath+name, history)
+	if err != nil {
+		return fmt.Errorf("unable to marshal object to JSON: %w", err)
+	}
+	if err := s.Put(ctx, entry); err != nil {
+		return fmt.Errorf("failed to save root credential history: %w", err)
+	}
+	return nil
+}
+
+// recordRootCredential adds the given root credential, about to be replaced,
+// to the history of the given connection. It is recorded before the rotation
+// changes the credential in the database, alongside the WAL entry, so that a
+// partially failed rotation never loses it.
+func (b *databaseBackend) recordRootCredential(ctx context.Context, s logical.Storage, name, username, password string) error {
+	history, err := b.rootCredentialHistory(ctx, s, name)
+	if err != nil {
+		return err
+	}
+
+	history.Entries = append([]rootCredentialHistoryEntry{{
+		Username:     username,
+		Password:     password,
+		RotationTime: time.Now(),
+	}}, history.Entries...)
+	return storeRootCredentialHistory(ctx, s, name, history)
+}
+
+// forgetRootCredential removes the most recent entry of the history of the
+// given connection if it holds the given password, after a rotation failed to
+// replace it.
+func (b *databaseBackend) forgetRootCredential(ctx context.Context, s logical.Storage, name, password string) error {
+	history, err := b.rootCredentialHistory(ctx, s, name)
+	if err != nil {
+		return err
+	}
+	if len(history.Entries) == 0 || history.Entries[0].Password != password {
+		return nil
+	}
+
+	history.Entries = history.Entries[1:]
+	return storeRootCredentialHistory(ctx, s, name, history)
+}
@@ -2,15 +2,19 @@ package database
 
 import (
 	"context"
+	"errors"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/hashicorp/go-secure-stdlib/strutil"
 	"github.com/hashicorp/vault/helper/namespace"
 	postgreshelper "github.com/hashicorp/vault/helper/testhelpers/postgresql"
 	v5 "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/mock"
 )
 
 const (
@@ -420,3 +424,197 @@ func TestBackend_RotateRootCredentials_WAL_no_rollback_2(t *testing.T) {
 		t.Fatalf("err:%s resp:%v\n", err, credResp)
 	}
 }
+
+func TestBackend_RotateRootCredentials_History(t *testing.T) {
+	ctx := context.Background()
+	b, storage, mockDB := getBackend(t)
+	defer b.Cleanup(ctx)
+
+	entry, err := logical.StorageEntryJSON("config/mockv5", &DatabaseConfig{
+		AllowedRoles: []string{"*"},
+		ConnectionDetails: map[string]interface{}{
+			"username": databaseUser,
+			"password": defaultPassword,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Put(ctx, entry); err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(op logical.Operation, path string) (*logical.Response, error) {
+		return b.HandleRequest(ctx, &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   storage,
+		})
+	}
+	currentPassword := func() string {
+		t.Helper()
+		config, err := b.DatabaseConfig(ctx, storage, "mockv5")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return config.ConnectionDetails["password"].(string)
+	}
+	requireHistory := func(expected int) []map[string]interface{} {
+		t.Helper()
+		resp, err := request(logical.ReadOperation, "rotate-root/mockv5/history")
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatal(resp, err)
+		}
+		history := resp.Data["history"].([]map[string]interface{})
+		if len(history) != expected {
+			t.Fatalf("expected %d history entries, got %d", expected, len(history))
+		}
+		for _, entry := range history {
+			if entry["username"] != databaseUser || entry["password"] != nil {
+				t.Fatalf("bad history entry: %#v", entry)
+			}
+		}
+		return history
+	}
+
+	// Nothing to restore before the first rotation
+	requireHistory(0)
+	resp, err := request(logical.UpdateOperation, "restore-root/mockv5")
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatal("expected error restoring without history", resp, err)
+	}
+
+	// Each rotation records the replaced credential, up to the maximum
+	var passwords []string
+	for i := 0; i < maxRootCredentialHistory+1; i++ {
+		passwords = append(passwords, currentPassword())
+		mockDB.On("UpdateUser", mock.Anything, mock.Anything).
+			Return(v5.UpdateUserResponse{}, nil).
+			Once()
+		resp, err := request(logical.UpdateOperation, "rotate-root/mockv5")
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatal(resp, err)
+		}
+		mockDB = setupMockDB(b)
+	}
+	history := requireHistory(maxRootCredentialHistory)
+	if history[0]["rotation_time"].(time.Time).Before(history[1]["rotation_time"].(time.Time)) {
+		t.Fatal("expected the most recent rotation first")
+	}
+
+	// Restoring changes the password back to the previous one
+	rotated := currentPassword()
+	previous := passwords[len(passwords)-1]
+	mockDB.On("UpdateUser", mock.Anything, mock.MatchedBy(func(req v5.UpdateUserRequest) bool {
+		return req.Username == databaseUser && req.Password.NewPassword == previous
+	})).Return(v5.UpdateUserResponse{}, nil).Once()
+	resp, err = request(logical.UpdateOperation, "restore-root/mockv5")
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatal(resp, err)
+	}
+	mockDB.AssertNumberOfCalls(t, "UpdateUser", 1)
+	if password := currentPassword(); password != previous || password == rotated {
+		t.Fatalf("expected password to be restored to %q, got %q", previous, password)
+	}
+	requireHistory(maxRootCredentialHistory - 1)
+	requireWALs(t, storage, 0)
+
+	// Deleting the connection deletes its history
+	if _, err := request(logical.DeleteOperation, "config/mockv5"); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := b.rootCredentialHistory(ctx, storage, "mockv5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored.Entries) != 0 {
+		t.Fatal("expected history to be deleted with the connection")
+	}
+}
+
+func TestBackend_RestoreRootCredentials_RequiresSudo(t *testing.T) {
+	b, _, _ := getBackend(t)
+	defer b.Cleanup(context.Background())
+
+	if !strutil.StrListContains(b.SpecialPaths().Root, "restore-root/*") {
+		t.Fatal("expected restore-root to require sudo capability")
+	}
+}
+
+// failingConfigStorage fails writes of connection configurations.
+type failingConfigStorage struct {
+	logical.Storage
+}
+
+func (s failingConfigStorage) Put(ctx context.Context, entry *logical.StorageEntry) error {
+	if strings.HasPrefix(entry.Key, "config/") {
+		return errors.New("failing configuration write")
+	}
+	return s.Storage.Put(ctx, entry)
+}
+
+func TestBackend_RotateRootCredentials_HistoryPartialFailure(t *testing.T) {
+	ctx := context.Background()
+	b, storage, mockDB := getBackend(t)
+	defer b.Cleanup(ctx)
+
+	entry, err := logical.StorageEntryJSON("config/mockv5", &DatabaseConfig{
+		AllowedRoles: []string{"*"},
+		ConnectionDetails: map[string]interface{}{
+			"username": databaseUser,
+			"password": defaultPassword,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Put(ctx, entry); err != nil {
+		t.Fatal(err)
+	}
+
+	rotate := func(s logical.Storage) error {
+		_, err := b.HandleRequest(ctx, &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "rotate-root/mockv5",
+			Storage:   s,
+		})
+		return err
+	}
+	requireHistory := func(expected ...string) {
+		t.Helper()
+		history, err := b.rootCredentialHistory(ctx, storage, "mockv5")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var passwords []string
+		for _, entry := range history.Entries {
+			passwords = append(passwords, entry.Password)
+		}
+		if !reflect.DeepEqual(passwords, expected) {
+			t.Fatalf("expected history %v, got %v", expected, passwords)
+		}
+	}
+
+	// A rotation which fails to change the password in the database doesn't
+	// add it to the history
+	mockDB.On("UpdateUser", mock.Anything, mock.Anything).
+		Return(v5.UpdateUserResponse{}, errors.New("failed to alter user")).
+		Once()
+	if err := rotate(storage); err == nil {
+		t.Fatal("expected the rotation to fail")
+	}
+	requireHistory()
+	requireWALs(t, storage, 1)
+
+	// The replaced password is in the history, as in the WAL entry, before the
+	// database is changed, so it is kept when the new password can't be stored
+	mockDB = setupMockDB(b)
+	mockDB.On("UpdateUser", mock.Anything, mock.Anything).
+		Return(v5.UpdateUserResponse{}, nil).
+		Once()
+	if err := rotate(failingConfigStorage{storage}); err == nil {
+		t.Fatal("expected the rotation to fail")
+	}
+	requireHistory(defaultPassword)
+	requireWALs(t, storage, 2)
+}
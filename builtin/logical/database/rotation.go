@@ -20,6 +20,14 @@ const (
 	// Default interval to check the queue for items needing rotation
 	defaultQueueTickSeconds = 5
 
+	// Minimum rotation window of static roles with a rotation schedule
+	minRotationWindowSeconds = 3600
+
+	// Time a static role's client certificate stays valid after its next
+	// rotation is due, so that it does not expire while the rotation is
+	// retried
+	clientCertificateGracePeriod = time.Hour
+
 	// Config key to set an alternate interval
 	queueTickIntervalKey = "rotation_queue_tick_interval"
 
@@ -211,7 +219,8 @@ func (b *databaseBackend) rotateCredential(ctx context.Context, s logical.Storag
 
 	// If "now" is less than the Item priority, then this item does not need to
 	// be rotated
-	if time.Now().Unix() < item.Priority {
+	now := time.Now()
+	if now.Unix() < item.Priority {
 		if err := b.pushItem(item); err != nil {
 			b.logger.Error("unable to push item on to queue", "error", err)
 		}
@@ -219,6 +228,19 @@ func (b *databaseBackend) rotateCredential(ctx context.Context, s logical.Storag
 		return false
 	}
 
+	// If the rotation window of the role has passed, delay the rotation until
+	// the next scheduled time. Rotations interrupted by a failure, for which a
+	// WAL entry exists, are always completed.
+	walID, hasWAL := item.Value.(string)
+	if (!hasWAL || walID == "") && !role.StaticAccount.IsInsideRotationWindow(now) {
+		item.Priority = role.StaticAccount.NextRotationTimeFromInput(now).Unix()
+		b.logger.Debug("rotation window passed, delaying rotation", "role", item.Key, "next rotation", time.Unix(item.Priority, 0))
+		if err := b.pushItem(item); err != nil {
+			b.logger.Error("unable to push item on to queue", "error", err)
+		}
+		return true
+	}
+
 	input := &setStaticAccountInput{
 		RoleName: item.Key,
 		Role:     role,
@@ -257,7 +279,7 @@ func (b *databaseBackend) rotateCredential(ctx context.Context, s logical.Storag
 	}
 
 	// Update priority and push updated Item to the queue
-	nextRotation := lvr.Add(role.StaticAccount.RotationPeriod)
+	nextRotation := role.StaticAccount.NextRotationTimeFromInput(lvr)
 	item.Priority = nextRotation.Unix()
 	if err := b.pushItem(item); err != nil {
 		b.logger.Warn("unable to push item on to queue", "error", err)
@@ -348,6 +370,12 @@ func (b *databaseBackend) setStaticAccount(ctx context.Context, s logical.Storag
 			input.Role.CredentialType.String())
 	}
 
+	// The connection may have been reconfigured with a plugin which can't
+	// authenticate as self-managed users since the role was created
+	if input.Role.StaticAccount.SelfManaged && !dbConfig.SupportsSelfManagedPassword() {
+		return output, fmt.Errorf("self-managed static roles are not supported by the database plugin of %q", input.Role.DBName)
+	}
+
 	// Get the Database object
 	dbi, err := b.GetConnection(ctx, s, input.Role.DBName)
 	if err != nil {
@@ -364,6 +392,16 @@ func (b *databaseBackend) setStaticAccount(ctx context.Context, s logical.Storag
 		Commands: input.Role.Statements.Rotation,
 	}
 
+	// Self-managed users change their own password, so the database
+	// authenticates as the user with its current password
+	var selfManagedPassword string
+	if input.Role.StaticAccount.SelfManaged {
+		selfManagedPassword = input.Role.StaticAccount.Password
+	}
+
+	// rollForward is set if the credential of an existing WAL entry is used
+	var rollForward bool
+
 	// Use credential from input if available. This happens if we're restoring from
 	// a WAL item or processing the rotation queue with an item that has a WAL
 	// associated with it
@@ -388,6 +426,7 @@ func (b *databaseBackend) setStaticAccount(ctx context.Context, s logical.Storag
 			output.WALID = ""
 		case wal.CredentialType == v5.CredentialTypePassword:
 			// Roll forward by using the credential in the existing WAL entry
+			rollForward = true
 			updateReq.CredentialType = v5.CredentialTypePassword
 			updateReq.Password = &v5.ChangePassword{
 				NewPassword: wal.NewPassword,
@@ -464,6 +503,40 @@ func (b *databaseBackend) setStaticAccount(ctx context.Context, s logical.Storag
 
 			// Set new credential in static account
 			input.Role.StaticAccount.PrivateKey = private
+		case v5.CredentialTypeClientCertificate:
+			generator, err := newClientCertificateGenerator(input.Role.CredentialConfig)
+			if err != nil {
+				return output, fmt.Errorf("failed to construct credential generator: %s", err)
+			}
+
+			// The certificate stays valid for a grace period after the next
+			// rotation is due, or until the end of the rotation window, which
+			// bounds how long it remains usable once it has been replaced
+			grace := clientCertificateGracePeriod
+			if window := input.Role.StaticAccount.RotationWindow; window > grace {
+				grace = window
+			}
+			expiration := input.Role.StaticAccount.NextRotationTimeFromInput(time.Now()).Add(grace)
+
+			// Generate the client certificate
+			bundle, subject, err := generator.generate(b.GetRandomReader(), expiration, input.Role.StaticAccount.Username, v5.UsernameMetadata{
+				DisplayName: input.Role.StaticAccount.Username,
+				RoleName:    input.RoleName,
+			})
+			if err != nil {
+				return output, fmt.Errorf("failed to generate client certificate: %s", err)
+			}
+
+			// Set new credential in update user request
+			updateReq.CredentialType = v5.CredentialTypeClientCertificate
+			updateReq.Subject = &v5.ChangeSubject{
+				NewSubject: subject,
+				Statements: statements,
+			}
+
+			// Set new credential in static account
+			input.Role.StaticAccount.ClientCertificate = []byte(bundle.Certificate)
+			input.Role.StaticAccount.PrivateKey = []byte(bundle.PrivateKey)
 		}
 
 		output.WALID, err = framework.PutWAL(ctx, s, staticWALKey, walEntry)
@@ -473,7 +546,19 @@ func (b *databaseBackend) setStaticAccount(ctx context.Context, s logical.Storag
 		b.Logger().Debug("writing WAL", "role", input.RoleName, "WAL ID", output.WALID)
 	}
 
+	if selfManagedPassword != "" && updateReq.Password != nil {
+		updateReq.SelfManagedPassword = selfManagedPassword
+	}
+
 	_, err = dbi.database.UpdateUser(ctx, updateReq, false)
+	if err != nil && rollForward && updateReq.SelfManagedPassword != "" {
+		// The previous attempt may have changed the password in the database
+		// before failing to store it, in which case the user authenticates
+		// with the password of the WAL entry
+		b.Logger().Debug("retrying self-managed rotation with the password of the WAL", "role", input.RoleName, "WAL ID", output.WALID)
+		updateReq.SelfManagedPassword = updateReq.Password.NewPassword
+		_, err = dbi.database.UpdateUser(ctx, updateReq, false)
+	}
 	if err != nil {
 		b.CloseIfShutdown(dbi, err)
 		return output, fmt.Errorf("error setting credentials: %w", err)
@@ -1313,6 +1313,247 @@ func TestDeletesOlderWALsOnLoad(t *testing.T) {
 	requireWALs(t, storage, 1)
 }
 
+func TestBackend_StaticRole_SelfManaged(t *testing.T) {
+	ctx := context.Background()
+	b, storage, mockDB := getBackend(t)
+	defer b.Cleanup(ctx)
+	configureDBMount(t, storage)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(ctx, &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+		})
+	}
+	expectSelfManagedUpdate := func(currentPassword string) {
+		mockDB.On("UpdateUser", mock.Anything, mock.MatchedBy(func(req v5.UpdateUserRequest) bool {
+			return req.SelfManagedPassword == currentPassword && req.Password != nil &&
+				req.Password.NewPassword != "" && req.Password.NewPassword != currentPassword
+		})).Return(v5.UpdateUserResponse{}, nil).Once()
+	}
+	requirePassword := func(expected string) string {
+		t.Helper()
+		role, err := b.StaticRole(ctx, storage, "hashicorp")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !role.StaticAccount.SelfManaged || (expected != "" && role.StaticAccount.Password != expected) {
+			t.Fatalf("bad static account: %#v", role.StaticAccount)
+		}
+		return role.StaticAccount.Password
+	}
+
+	// Self-managed roles require support from the database plugin
+	resp, err := request(logical.CreateOperation, "static-roles/hashicorp", map[string]interface{}{
+		"username":              "hashicorp",
+		"db_name":               "mockv5",
+		"rotation_period":       "1h",
+		"self_managed_password": "current",
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatal("expected error for self-managed role of a database plugin without support", resp, err)
+	}
+	configureSelfManagedDBMount(t, storage)
+
+	// Self-managed roles only support passwords
+	resp, err = request(logical.CreateOperation, "static-roles/hashicorp", map[string]interface{}{
+		"username":              "hashicorp",
+		"db_name":               "mockv5",
+		"rotation_period":       "1h",
+		"self_managed_password": "current",
+		"credential_type":       v5.CredentialTypeRSAPrivateKey.String(),
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatal("expected error for self-managed role with rsa_private_key credentials", resp, err)
+	}
+
+	// The initial rotation authenticates with the given password
+	expectSelfManagedUpdate("current")
+	resp, err = request(logical.CreateOperation, "static-roles/hashicorp", map[string]interface{}{
+		"username":              "hashicorp",
+		"db_name":               "mockv5",
+		"rotation_period":       "1h",
+		"self_managed_password": "current",
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatal(resp, err)
+	}
+	password := requirePassword("")
+
+	resp, err = request(logical.ReadOperation, "static-roles/hashicorp", nil)
+	if err != nil || resp == nil || resp.Data["self_managed"] != true || resp.Data["password"] != nil {
+		t.Fatal("expected self-managed role", resp, err)
+	}
+
+	// Rotations authenticate with the password of the previous rotation
+	expectSelfManagedUpdate(password)
+	resp, err = request(logical.UpdateOperation, "rotate-role/hashicorp", nil)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatal(resp, err)
+	}
+	newPassword := requirePassword("")
+	if newPassword == password {
+		t.Fatal("expected password to be rotated")
+	}
+
+	// Updating the password of the role replaces the current password
+	resp, err = request(logical.UpdateOperation, "static-roles/hashicorp", map[string]interface{}{
+		"username":              "hashicorp",
+		"self_managed_password": "changed",
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatal(resp, err)
+	}
+	requirePassword("changed")
+	expectSelfManagedUpdate("changed")
+	resp, err = request(logical.UpdateOperation, "rotate-role/hashicorp", nil)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatal(resp, err)
+	}
+	mockDB.AssertNumberOfCalls(t, "UpdateUser", 3)
+
+	// Roles which are not self-managed cannot become self-managed
+	createRole(t, b, storage, mockDB, "managed")
+	resp, err = request(logical.UpdateOperation, "static-roles/managed", map[string]interface{}{
+		"username":              "managed",
+		"self_managed_password": "current",
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatal("expected error for setting self_managed_password on an existing role", resp, err)
+	}
+
+	// Rotations fail once the plugin no longer supports self-managed roles
+	configureDBMount(t, storage)
+	resp, err = request(logical.UpdateOperation, "rotate-role/hashicorp", nil)
+	if err == nil && (resp == nil || !resp.IsError()) {
+		t.Fatal("expected error for rotating a self-managed role of a database plugin without support", resp)
+	}
+	mockDB.AssertNumberOfCalls(t, "UpdateUser", 4)
+}
+
+func TestRollsSelfManagedPasswordForwardsUsingWAL(t *testing.T) {
+	ctx := context.Background()
+	b, storage, mockDB := getBackend(t)
+	defer b.Cleanup(ctx)
+	configureSelfManagedDBMount(t, storage)
+
+	mockDB.On("UpdateUser", mock.Anything, mock.Anything).
+		Return(v5.UpdateUserResponse{}, nil).
+		Once()
+	resp, err := b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      "static-roles/hashicorp",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"username":              "hashicorp",
+			"db_name":               "mockv5",
+			"rotation_period":       "1h",
+			"self_managed_password": "current",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatal(resp, err)
+	}
+	role, err := b.StaticRole(ctx, storage, "hashicorp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldPassword := role.StaticAccount.Password
+
+	generateWALFromFailedRotation(t, b, storage, mockDB, "hashicorp")
+	walIDs := requireWALs(t, storage, 1)
+	wal, err := b.findStaticWAL(ctx, storage, walIDs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The database already changed the password in the failed rotation, so
+	// authenticating with the old password fails and the password of the WAL
+	// is used instead
+	mockDB.On("UpdateUser", mock.Anything, mock.MatchedBy(func(req v5.UpdateUserRequest) bool {
+		return req.SelfManagedPassword == oldPassword
+	})).Return(v5.UpdateUserResponse{}, errors.New("authentication failed")).Once()
+	mockDB.On("UpdateUser", mock.Anything, mock.MatchedBy(func(req v5.UpdateUserRequest) bool {
+		return req.SelfManagedPassword == wal.NewPassword && req.Password.NewPassword == wal.NewPassword
+	})).Return(v5.UpdateUserResponse{}, nil).Once()
+	resp, err = b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "rotate-role/hashicorp",
+		Storage:   storage,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatal(resp, err)
+	}
+
+	role, err = b.StaticRole(ctx, storage, "hashicorp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if role.StaticAccount.Password != wal.NewPassword {
+		t.Fatal("role password", role.StaticAccount.Password, "WAL new password", wal.NewPassword)
+	}
+	requireWALs(t, storage, 0)
+	mockDB.AssertNumberOfCalls(t, "UpdateUser", 4)
+}
+
+func TestBackend_StaticRole_RotationWindowMissed(t *testing.T) {
+	ctx := context.Background()
+	b, storage, mockDB := getBackend(t)
+	defer b.Cleanup(ctx)
+	configureDBMount(t, storage)
+
+	// Schedule the rotations half a day away from now so that the rotation
+	// window has passed
+	hour := (time.Now().UTC().Hour() + 12) % 24
+	mockDB.On("UpdateUser", mock.Anything, mock.Anything).
+		Return(v5.UpdateUserResponse{}, nil).
+		Once()
+	resp, err := b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      "static-roles/hashicorp",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"username":          "hashicorp",
+			"db_name":           "mockv5",
+			"rotation_schedule": fmt.Sprintf("0 %d * * *", hour),
+			"rotation_window":   "3600s",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatal(resp, err)
+	}
+	role, err := b.StaticRole(ctx, storage, "hashicorp")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Make the rotation overdue; the missed rotation must be delayed to the
+	// next scheduled time without rotating the credentials
+	item, err := b.popFromRotationQueueByKey("hashicorp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	item.Priority = time.Now().Add(-time.Hour).Unix()
+	if err := b.pushItem(item); err != nil {
+		t.Fatal(err)
+	}
+	if !b.rotateCredential(ctx, storage) {
+		t.Fatal("expected the queue to be processed")
+	}
+	mockDB.AssertNumberOfCalls(t, "UpdateUser", 1)
+
+	item, err = b.popFromRotationQueueByKey("hashicorp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := role.StaticAccount.NextRotationTimeFromInput(time.Now())
+	if item.Priority != next.Unix() || next.UTC().Hour() != hour {
+		t.Fatalf("expected rotation to be delayed to %s, got %s", next, time.Unix(item.Priority, 0))
+	}
+}
+
 func generateWALFromFailedRotation(t *testing.T, b *databaseBackend, storage logical.Storage, mockDB *mockNewDatabase, roleName string) {
 	t.Helper()
 	mockDB.On("UpdateUser", mock.Anything, mock.Anything).
@@ -1410,6 +1651,26 @@ func configureDBMount(t *testing.T, storage logical.Storage) {
 	}
 }
 
+// configureSelfManagedDBMount configures the mock database plugin as
+// supporting self-managed static roles.
+func configureSelfManagedDBMount(t *testing.T, storage logical.Storage) {
+	t.Helper()
+	entry, err := logical.StorageEntryJSON("config/mockv5", &DatabaseConfig{
+		AllowedRoles: []string{"*"},
+		ConnectionDetails: map[string]interface{}{
+			v5.SelfManagedPasswordSupportedKey: true,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = storage.Put(context.Background(), entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 // capturePasswords captures the current passwords at the time of calling, and
 // returns a map of username / passwords building off of the input map
 func capturePasswords(t *testing.T, b logical.Backend, config *logical.BackendConfig, testCases []string, pws map[string][]string) map[string][]string {
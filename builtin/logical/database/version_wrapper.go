@@ -126,6 +126,9 @@ func (d databaseVersionWrapper) UpdateUser(ctx context.Context, req v5.UpdateUse
 		// errors which I'm punting on since we don't need it for now
 		return nil, fmt.Errorf("cannot specify both password and expiration change at the same time")
 	}
+	if req.SelfManagedPassword != "" {
+		return nil, fmt.Errorf("self-managed passwords are not supported by this database plugin")
+	}
 
 	// Change password
 	if req.Password != nil {
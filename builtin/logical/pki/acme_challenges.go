@@ -0,0 +1,254 @@
+package pki
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	acmeChallengeHTTP01 = "http-01"
+	acmeChallengeDNS01  = "dns-01"
+
+	acmeChallengeTimeout = 30 * time.Second
+
+	// The largest http-01 response body we're willing to read; a key
+	// authorization is well under this size.
+	acmeHTTP01MaxBodySize = 1024
+)
+
+// acmeChallengeValidator performs the network side of challenge validation.
+// The http-01 port is only configurable so that tests can point the
+// validator at a local stand-in responder.
+type acmeChallengeValidator struct {
+	httpPort int
+}
+
+func newAcmeChallengeValidator() *acmeChallengeValidator {
+	return &acmeChallengeValidator{
+		httpPort: 80,
+	}
+}
+
+// defaultAcmeHTTP01DeniedCIDRs are the addresses http-01 validation refuses
+// to connect to unless configured otherwise: loopback, link-local (including
+// cloud metadata services), unspecified and multicast addresses.
+var defaultAcmeHTTP01DeniedCIDRs = []string{
+	"0.0.0.0/8",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"224.0.0.0/4",
+	"::/128",
+	"::1/128",
+	"fe80::/10",
+	"ff00::/8",
+}
+
+// acmeHTTP01Policy decides which addresses http-01 validation may connect
+// to. When allowed networks are configured, only addresses within them are
+// permitted; otherwise, any address outside the denied networks is.
+type acmeHTTP01Policy struct {
+	allowed []*net.IPNet
+	denied  []*net.IPNet
+}
+
+func newAcmeHTTP01Policy(config *acmeConfigEntry) (*acmeHTTP01Policy, error) {
+	allowed, err := parseCIDRs(config.HTTP01AllowedCIDRs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid http01_allowed_cidrs: %w", err)
+	}
+	denied, err := parseCIDRs(config.HTTP01DeniedCIDRs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid http01_denied_cidrs: %w", err)
+	}
+	return &acmeHTTP01Policy{allowed: allowed, denied: denied}, nil
+}
+
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+func (p *acmeHTTP01Policy) check(ip net.IP) error {
+	if len(p.allowed) > 0 {
+		for _, ipNet := range p.allowed {
+			if ipNet.Contains(ip) {
+				return nil
+			}
+		}
+		return fmt.Errorf("address %v is not within the allowed networks", ip)
+	}
+
+	for _, ipNet := range p.denied {
+		if ipNet.Contains(ip) {
+			return fmt.Errorf("address %v is within the denied network %v", ip, ipNet)
+		}
+	}
+	return nil
+}
+
+// httpClient returns a client for http-01 validation which checks the
+// address of every connection it makes against the policy, after name
+// resolution, so that neither redirects nor DNS rebinding can get around
+// it. Proxies are not used, as the proxy's address would be checked
+// instead of the responder's.
+func (v *acmeChallengeValidator) httpClient(policy *acmeHTTP01Policy) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: acmeChallengeTimeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil {
+				return fmt.Errorf("unable to parse address %v", host)
+			}
+			return policy.check(ip)
+		},
+	}
+
+	return &http.Client{
+		Timeout: acmeChallengeTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: acmeChallengeTimeout,
+			DisableKeepAlives:   true,
+		},
+		CheckRedirect: checkAcmeRedirect,
+	}
+}
+
+// checkAcmeRedirect restricts which redirects the http-01 validator will
+// follow. Per RFC 8555 Section 8.3, redirects may be followed, but only
+// to http or https on the standard ports; we also bound how many we follow
+// to avoid loops. The addresses redirected to are checked when connecting.
+func checkAcmeRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return fmt.Errorf("stopped after 10 redirects")
+	}
+
+	switch req.URL.Scheme {
+	case "http", "https":
+	default:
+		return fmt.Errorf("refusing to follow redirect to scheme %q", req.URL.Scheme)
+	}
+
+	switch req.URL.Port() {
+	case "", "80", "443":
+	default:
+		return fmt.Errorf("refusing to follow redirect to port %v", req.URL.Port())
+	}
+
+	return nil
+}
+
+// acmeKeyAuthorization computes the key authorization for the given token
+// and account key thumbprint; see RFC 8555 Section 8.1.
+func acmeKeyAuthorization(token string, thumbprint string) string {
+	return token + "." + thumbprint
+}
+
+// validate dispatches on the challenge type, returning an ACME error when
+// the challenge response could not be verified.
+func (v *acmeChallengeValidator) validate(ctx context.Context, config *acmeConfigEntry, challenge *acmeChallenge, identifier acmeIdentifier, thumbprint string) error {
+	keyAuthz := acmeKeyAuthorization(challenge.Token, thumbprint)
+
+	switch challenge.Type {
+	case acmeChallengeHTTP01:
+		return v.validateHTTP01(ctx, config, identifier, challenge.Token, keyAuthz)
+	case acmeChallengeDNS01:
+		return v.validateDNS01(ctx, config, identifier, keyAuthz)
+	default:
+		return newAcmeError(acmeProblemMalformed, "unknown challenge type: %v", challenge.Type)
+	}
+}
+
+func (v *acmeChallengeValidator) validateHTTP01(ctx context.Context, config *acmeConfigEntry, identifier acmeIdentifier, token string, keyAuthz string) error {
+	policy, err := newAcmeHTTP01Policy(config)
+	if err != nil {
+		return err
+	}
+
+	host := identifier.Value
+	if identifier.Type == "ip" && strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if v.httpPort != 80 {
+		host = host + ":" + strconv.Itoa(v.httpPort)
+	}
+
+	url := "http://" + host + "/.well-known/acme-challenge/" + token
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return newAcmeError(acmeProblemMalformed, "unable to build http-01 request: %v", err)
+	}
+
+	resp, err := v.httpClient(policy).Do(httpReq)
+	if err != nil {
+		return newAcmeError(acmeProblemConnection, "unable to fetch http-01 challenge response from %v: %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newAcmeError(acmeProblemIncorrectResponse, "http-01 challenge response from %v had status %v", url, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, acmeHTTP01MaxBodySize))
+	if err != nil {
+		return newAcmeError(acmeProblemConnection, "unable to read http-01 challenge response from %v: %v", url, err)
+	}
+
+	// Trailing whitespace is permitted by RFC 8555 Section 8.3.
+	if strings.TrimRight(string(body), " \t\r\n") != keyAuthz {
+		return newAcmeError(acmeProblemIncorrectResponse, "http-01 challenge response from %v did not match the expected key authorization", url)
+	}
+
+	return nil
+}
+
+func (v *acmeChallengeValidator) validateDNS01(ctx context.Context, config *acmeConfigEntry, identifier acmeIdentifier, keyAuthz string) error {
+	resolver := net.DefaultResolver
+	if len(config.DNSResolver) > 0 {
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, config.DNSResolver)
+			},
+		}
+	}
+
+	digest := sha256.Sum256([]byte(keyAuthz))
+	expected := base64.RawURLEncoding.EncodeToString(digest[:])
+
+	// Use the fully-qualified name so resolv.conf search domains don't apply.
+	name := "_acme-challenge." + strings.TrimSuffix(identifier.Value, ".") + "."
+	records, err := resolver.LookupTXT(ctx, name)
+	if err != nil {
+		return newAcmeError(acmeProblemDNS, "unable to look up TXT records for %v: %v", name, err)
+	}
+
+	for _, record := range records {
+		if record == expected {
+			return nil
+		}
+	}
+
+	return newAcmeError(acmeProblemIncorrectResponse, "no TXT record for %v matched the expected key authorization digest", name)
+}
@@ -0,0 +1,90 @@
+package pki
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// ACME problem types; see RFC 8555 Section 6.7.
+const (
+	acmeErrorPrefix = "urn:ietf:params:acme:error:"
+
+	acmeProblemAccountDoesNotExist   = "accountDoesNotExist"
+	acmeProblemBadCSR                = "badCSR"
+	acmeProblemBadNonce              = "badNonce"
+	acmeProblemBadSignatureAlgorithm = "badSignatureAlgorithm"
+	acmeProblemConnection            = "connection"
+	acmeProblemDNS                   = "dns"
+	acmeProblemIncorrectResponse     = "incorrectResponse"
+	acmeProblemMalformed             = "malformed"
+	acmeProblemOrderNotReady         = "orderNotReady"
+	acmeProblemRejectedIdentifier    = "rejectedIdentifier"
+	acmeProblemServerInternal        = "serverInternal"
+	acmeProblemUnauthorized          = "unauthorized"
+	acmeProblemUnsupportedIdentifier = "unsupportedIdentifier"
+	acmeProblemUserActionRequired    = "userActionRequired"
+
+	acmeProblemContentType = "application/problem+json"
+)
+
+var acmeProblemStatus = map[string]int{
+	acmeProblemAccountDoesNotExist: http.StatusBadRequest,
+	acmeProblemOrderNotReady:       http.StatusForbidden,
+	acmeProblemServerInternal:      http.StatusInternalServerError,
+	acmeProblemUnauthorized:        http.StatusForbidden,
+	acmeProblemUserActionRequired:  http.StatusForbidden,
+}
+
+// acmeError is an error which will be rendered to the ACME client as a
+// problem document.
+type acmeError struct {
+	Problem string
+	Detail  string
+}
+
+func (e *acmeError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Problem, e.Detail)
+}
+
+func (e *acmeError) Status() int {
+	if status, ok := acmeProblemStatus[e.Problem]; ok {
+		return status
+	}
+	return http.StatusBadRequest
+}
+
+func (e *acmeError) toProblem() map[string]interface{} {
+	return map[string]interface{}{
+		"type":   acmeErrorPrefix + e.Problem,
+		"detail": e.Detail,
+	}
+}
+
+func newAcmeError(problem string, format string, args ...interface{}) *acmeError {
+	return &acmeError{
+		Problem: problem,
+		Detail:  fmt.Sprintf(format, args...),
+	}
+}
+
+// acmeErrorResponse renders any error returned by an ACME handler as an
+// RFC 7807 problem document. Errors which aren't ACME errors are treated as
+// internal server errors and their details are only logged.
+func (b *backend) acmeErrorResponse(err error) (*logical.Response, error) {
+	var aErr *acmeError
+	if !errors.As(err, &aErr) {
+		b.Logger().Error("internal error servicing ACME request", "error", err)
+		aErr = newAcmeError(acmeProblemServerInternal, "internal error servicing the request")
+	}
+
+	body, mErr := json.Marshal(aErr.toProblem())
+	if mErr != nil {
+		return nil, fmt.Errorf("failed to marshal ACME problem document: %w", mErr)
+	}
+
+	return b.acmeRawResponse(aErr.Status(), acmeProblemContentType, body, nil), nil
+}
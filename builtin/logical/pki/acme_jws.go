@@ -0,0 +1,147 @@
+package pki
+
+import (
+	"context"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// The signature algorithms we accept on ACME requests; see RFC 8555
+// Section 6.2. MAC-based algorithms are explicitly disallowed.
+var acmeAllowedJWSAlgorithms = map[string]bool{
+	string(jose.RS256): true,
+	string(jose.ES256): true,
+	string(jose.ES384): true,
+	string(jose.ES512): true,
+	string(jose.EdDSA): true,
+}
+
+// acmeJWS holds the verified contents of an ACME request's JWS envelope.
+type acmeJWS struct {
+	// Account is set when the request was signed by an existing account
+	// (via the kid header); otherwise Jwk holds the embedded public key.
+	Account *acmeAccount
+	Jwk     *jose.JSONWebKey
+
+	Payload []byte
+}
+
+// isPostAsGet returns whether the request is a POST-as-GET request, whose
+// payload is the empty string; see RFC 8555 Section 6.3.
+func (j *acmeJWS) isPostAsGet() bool {
+	return len(j.Payload) == 0
+}
+
+func (j *acmeJWS) decodePayload(out interface{}) error {
+	if err := json.Unmarshal(j.Payload, out); err != nil {
+		return newAcmeError(acmeProblemMalformed, "failed to decode request payload: %v", err)
+	}
+	return nil
+}
+
+func acmeJWKThumbprint(jwk *jose.JSONWebKey) (string, error) {
+	thumbprint, err := jwk.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return "", newAcmeError(acmeProblemMalformed, "failed to compute JWK thumbprint: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(thumbprint), nil
+}
+
+// parseAcmeJWS verifies the flattened JWS carried in the request body,
+// checking the nonce, url and signature per RFC 8555 Section 6.2-6.5. When
+// requireAccount is set the request must be signed by an existing, valid
+// account; otherwise it must embed the public key it was signed with.
+func (b *backend) parseAcmeJWS(ctx context.Context, req *logical.Request, data *framework.FieldData, ac *acmeContext, requireAccount bool) (*acmeJWS, error) {
+	// A POST-as-GET request has an empty payload, which some clients omit
+	// entirely.
+	raw := map[string]string{"payload": ""}
+	for _, field := range []string{"protected", "payload", "signature"} {
+		value, ok := data.Raw[field].(string)
+		if !ok && field == "payload" && data.Raw[field] == nil {
+			continue
+		}
+		if !ok {
+			return nil, newAcmeError(acmeProblemMalformed, "missing or invalid %v field in JWS", field)
+		}
+		raw[field] = value
+	}
+
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := jose.ParseSigned(string(encoded))
+	if err != nil {
+		return nil, newAcmeError(acmeProblemMalformed, "failed to parse JWS: %v", err)
+	}
+	if len(parsed.Signatures) != 1 {
+		return nil, newAcmeError(acmeProblemMalformed, "JWS must contain exactly one signature")
+	}
+
+	header := parsed.Signatures[0].Protected
+	if !acmeAllowedJWSAlgorithms[header.Algorithm] {
+		return nil, newAcmeError(acmeProblemBadSignatureAlgorithm, "unsupported JWS signature algorithm: %v", header.Algorithm)
+	}
+
+	if !b.acmeState.redeemNonce(header.Nonce) {
+		return nil, newAcmeError(acmeProblemBadNonce, "invalid or expired nonce")
+	}
+
+	url, _ := header.ExtraHeaders["url"].(string)
+	if url != ac.requestURL(req) {
+		return nil, newAcmeError(acmeProblemUnauthorized, "JWS url header (%v) does not match the request URL", url)
+	}
+
+	result := &acmeJWS{}
+	switch {
+	case header.JSONWebKey != nil && len(header.KeyID) > 0:
+		return nil, newAcmeError(acmeProblemMalformed, "JWS must not contain both jwk and kid headers")
+	case requireAccount && len(header.KeyID) == 0:
+		return nil, newAcmeError(acmeProblemMalformed, "JWS must be signed by an account (kid header)")
+	case !requireAccount && header.JSONWebKey == nil:
+		return nil, newAcmeError(acmeProblemMalformed, "JWS must contain a jwk header")
+	case requireAccount:
+		accountPrefix := ac.baseURL + "account/"
+		if !strings.HasPrefix(header.KeyID, accountPrefix) {
+			return nil, newAcmeError(acmeProblemMalformed, "kid (%v) is not an account of this directory", header.KeyID)
+		}
+
+		account, err := loadAcmeAccount(ctx, req.Storage, strings.TrimPrefix(header.KeyID, accountPrefix))
+		if err != nil {
+			return nil, err
+		}
+		if account == nil {
+			return nil, newAcmeError(acmeProblemAccountDoesNotExist, "account %v does not exist", header.KeyID)
+		}
+		if account.Status != acmeStatusValid {
+			return nil, newAcmeError(acmeProblemUnauthorized, "account is %v", account.Status)
+		}
+
+		var jwk jose.JSONWebKey
+		if err := jwk.UnmarshalJSON(account.Jwk); err != nil {
+			return nil, err
+		}
+
+		result.Account = account
+		result.Jwk = &jwk
+	default:
+		if !header.JSONWebKey.IsPublic() || !header.JSONWebKey.Valid() {
+			return nil, newAcmeError(acmeProblemMalformed, "jwk header must contain a valid public key")
+		}
+		result.Jwk = header.JSONWebKey
+	}
+
+	result.Payload, err = parsed.Verify(result.Jwk)
+	if err != nil {
+		return nil, newAcmeError(acmeProblemMalformed, "failed to verify JWS signature: %v", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,264 @@
+package pki
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	acmeStoragePrefix           = "acme/"
+	acmeAccountPrefix           = acmeStoragePrefix + "accounts/"
+	acmeAccountThumbprintPrefix = acmeStoragePrefix + "account-thumbprints/"
+
+	acmeNonceLifetime         = 15 * time.Minute
+	acmeOrderLifetime         = 24 * time.Hour
+	acmeAuthorizationLifetime = 24 * time.Hour
+)
+
+// Status values for ACME objects; see RFC 8555 Section 7.1.6.
+const (
+	acmeStatusPending     = "pending"
+	acmeStatusReady       = "ready"
+	acmeStatusProcessing  = "processing"
+	acmeStatusValid       = "valid"
+	acmeStatusInvalid     = "invalid"
+	acmeStatusDeactivated = "deactivated"
+	acmeStatusExpired     = "expired"
+)
+
+// acmeState holds the cluster-node-local state of the ACME server: the
+// outstanding anti-replay nonces and the challenge validator.
+type acmeState struct {
+	nonces    sync.Map
+	validator *acmeChallengeValidator
+}
+
+func newAcmeState() *acmeState {
+	return &acmeState{
+		validator: newAcmeChallengeValidator(),
+	}
+}
+
+func (a *acmeState) getNonce() (string, error) {
+	raw := make([]byte, 21)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	nonce := base64.RawURLEncoding.EncodeToString(raw)
+	a.nonces.Store(nonce, time.Now().Add(acmeNonceLifetime))
+	return nonce, nil
+}
+
+// redeemNonce consumes the given nonce, returning whether or not it was
+// outstanding and unexpired.
+func (a *acmeState) redeemNonce(nonce string) bool {
+	rawExpiry, present := a.nonces.LoadAndDelete(nonce)
+	if !present {
+		return false
+	}
+
+	return time.Now().Before(rawExpiry.(time.Time))
+}
+
+// tidyNonces removes expired nonces; it is called from the backend's
+// periodic function.
+func (a *acmeState) tidyNonces() {
+	now := time.Now()
+	a.nonces.Range(func(key, value interface{}) bool {
+		if now.After(value.(time.Time)) {
+			a.nonces.Delete(key)
+		}
+		return true
+	})
+}
+
+type acmeAccount struct {
+	ID                   string    `json:"id"`
+	Status               string    `json:"status"`
+	Contact              []string  `json:"contact"`
+	TermsOfServiceAgreed bool      `json:"terms_of_service_agreed"`
+	Jwk                  []byte    `json:"jwk"`
+	Thumbprint           string    `json:"thumbprint"`
+	CreatedDate          time.Time `json:"created_date"`
+}
+
+type acmeIdentifier struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type acmeChallenge struct {
+	Type      string                 `json:"type"`
+	Token     string                 `json:"token"`
+	Status    string                 `json:"status"`
+	Validated time.Time              `json:"validated"`
+	Error     map[string]interface{} `json:"error,omitempty"`
+}
+
+type acmeAuthorization struct {
+	ID         string           `json:"id"`
+	AccountID  string           `json:"account_id"`
+	Identifier acmeIdentifier   `json:"identifier"`
+	Status     string           `json:"status"`
+	Expires    time.Time        `json:"expires"`
+	Challenges []*acmeChallenge `json:"challenges"`
+	Wildcard   bool             `json:"wildcard"`
+}
+
+type acmeOrder struct {
+	ID                      string           `json:"id"`
+	AccountID               string           `json:"account_id"`
+	Status                  string           `json:"status"`
+	Expires                 time.Time        `json:"expires"`
+	Identifiers             []acmeIdentifier `json:"identifiers"`
+	AuthorizationIDs        []string         `json:"authorization_ids"`
+	RoleName                string           `json:"role_name"`
+	IssuerRef               string           `json:"issuer_ref"`
+	CertificateSerialNumber string           `json:"certificate_serial_number"`
+	CertificateIssuerID     issuerID         `json:"certificate_issuer_id"`
+}
+
+func acmeGetEntry(ctx context.Context, s logical.Storage, path string, out interface{}) (bool, error) {
+	entry, err := s.Get(ctx, path)
+	if err != nil {
+		return false, fmt.Errorf("failed to load ACME entry %v: %w", path, err)
+	}
+	if entry == nil {
+		return false, nil
+	}
+
+	if err := entry.DecodeJSON(out); err != nil {
+		return false, fmt.Errorf("failed to decode ACME entry %v: %w", path, err)
+	}
+
+	return true, nil
+}
+
+func acmePutEntry(ctx context.Context, s logical.Storage, path string, in interface{}) error {
+	entry, err := logical.StorageEntryJSON(path, in)
+	if err != nil {
+		return fmt.Errorf("failed to encode ACME entry %v: %w", path, err)
+	}
+
+	return s.Put(ctx, entry)
+}
+
+func loadAcmeAccount(ctx context.Context, s logical.Storage, id string) (*acmeAccount, error) {
+	var account acmeAccount
+	found, err := acmeGetEntry(ctx, s, acmeAccountPrefix+id, &account)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func loadAcmeAccountByThumbprint(ctx context.Context, s logical.Storage, thumbprint string) (*acmeAccount, error) {
+	var id string
+	found, err := acmeGetEntry(ctx, s, acmeAccountThumbprintPrefix+thumbprint, &id)
+	if err != nil || !found {
+		return nil, err
+	}
+	return loadAcmeAccount(ctx, s, id)
+}
+
+func saveAcmeAccount(ctx context.Context, s logical.Storage, account *acmeAccount) error {
+	if err := acmePutEntry(ctx, s, acmeAccountPrefix+account.ID, account); err != nil {
+		return err
+	}
+	return acmePutEntry(ctx, s, acmeAccountThumbprintPrefix+account.Thumbprint, account.ID)
+}
+
+// Orders and authorizations are stored beneath their owning account, so
+// that lookups made with an account's key can only ever see its own objects.
+func acmeOrderPath(accountId string, orderId string) string {
+	return acmeAccountPrefix + accountId + "/orders/" + orderId
+}
+
+func acmeAuthorizationPath(accountId string, authzId string) string {
+	return acmeAccountPrefix + accountId + "/authorizations/" + authzId
+}
+
+func listAcmeOrders(ctx context.Context, s logical.Storage, accountId string) ([]string, error) {
+	return s.List(ctx, acmeOrderPath(accountId, ""))
+}
+
+func loadAcmeOrder(ctx context.Context, s logical.Storage, accountId string, orderId string) (*acmeOrder, error) {
+	var order acmeOrder
+	found, err := acmeGetEntry(ctx, s, acmeOrderPath(accountId, orderId), &order)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func saveAcmeOrder(ctx context.Context, s logical.Storage, order *acmeOrder) error {
+	return acmePutEntry(ctx, s, acmeOrderPath(order.AccountID, order.ID), order)
+}
+
+func loadAcmeAuthorization(ctx context.Context, s logical.Storage, accountId string, authzId string) (*acmeAuthorization, error) {
+	var authz acmeAuthorization
+	found, err := acmeGetEntry(ctx, s, acmeAuthorizationPath(accountId, authzId), &authz)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &authz, nil
+}
+
+func saveAcmeAuthorization(ctx context.Context, s logical.Storage, authz *acmeAuthorization) error {
+	return acmePutEntry(ctx, s, acmeAuthorizationPath(authz.AccountID, authz.ID), authz)
+}
+
+// refreshStatus updates the status of a pending authorization that has since
+// expired.
+func (a *acmeAuthorization) refreshStatus() {
+	if a.Status == acmeStatusPending && time.Now().After(a.Expires) {
+		a.Status = acmeStatusExpired
+	}
+}
+
+// refreshOrderStatus recomputes the status of a pending order from the state
+// of its authorizations, per the state machine in RFC 8555 Section 7.1.6.
+func refreshOrderStatus(ctx context.Context, s logical.Storage, order *acmeOrder) error {
+	if order.Status != acmeStatusPending && order.Status != acmeStatusReady {
+		return nil
+	}
+
+	if time.Now().After(order.Expires) {
+		order.Status = acmeStatusInvalid
+		return nil
+	}
+
+	allValid := true
+	for _, authzId := range order.AuthorizationIDs {
+		authz, err := loadAcmeAuthorization(ctx, s, order.AccountID, authzId)
+		if err != nil {
+			return err
+		}
+		if authz == nil {
+			return fmt.Errorf("order %v references missing authorization %v", order.ID, authzId)
+		}
+
+		authz.refreshStatus()
+		switch authz.Status {
+		case acmeStatusValid:
+		case acmeStatusPending:
+			allValid = false
+		default:
+			order.Status = acmeStatusInvalid
+			return nil
+		}
+	}
+
+	if allValid {
+		order.Status = acmeStatusReady
+	}
+
+	return nil
+}
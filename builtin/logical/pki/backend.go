@@ -77,20 +77,33 @@ func Backend(conf *logical.BackendConfig) *backend {
 				"ca",
 				"crl/pem",
 				"crl",
+				"crl/delta/pem",
+				"crl/delta",
 				"issuer/+/crl/der",
 				"issuer/+/crl/pem",
 				"issuer/+/crl",
+				"issuer/+/crl/delta/der",
+				"issuer/+/crl/delta/pem",
+				"issuer/+/crl/delta",
 				"issuer/+/pem",
 				"issuer/+/der",
 				"issuer/+/json",
 				"issuers",
+				"ocsp",   // OCSP POST
+				"ocsp/*", // OCSP GET
+				"roles/+/acme/*",
+				"issuer/+/roles/+/acme/*",
 			},
 
 			LocalStorage: []string{
 				"revoked/",
+				deltaWALPath,
 				legacyCRLPath,
 				"crls/",
 				"certs/",
+				certIndexPrefix,
+				tidyHistoryPath,
+				acmeStoragePrefix,
 			},
 
 			Root: []string{
@@ -116,13 +129,16 @@ func Backend(conf *logical.BackendConfig) *backend {
 			pathConfigCA(&b),
 			pathConfigCRL(&b),
 			pathConfigURLs(&b),
+			pathConfigAcme(&b),
 			pathSignVerbatim(&b),
 			pathSign(&b),
 			pathIssue(&b),
 			pathRotateCRL(&b),
+			pathRotateDeltaCRL(&b),
 			pathRevoke(&b),
 			pathTidy(&b),
 			pathTidyStatus(&b),
+			pathConfigAutoTidy(&b),
 
 			// Issuer APIs
 			pathListIssuers(&b),
@@ -138,6 +154,9 @@ func Backend(conf *logical.BackendConfig) *backend {
 			pathRotateRoot(&b),
 			pathIssuerGenerateIntermediate(&b),
 			pathCrossSignIntermediate(&b),
+			pathIssuerCrossSign(&b),
+			pathIssuerReissue(&b),
+			pathIssuersChainGraph(&b),
 			pathConfigIssuers(&b),
 			pathReplaceRoot(&b),
 
@@ -156,6 +175,11 @@ func Backend(conf *logical.BackendConfig) *backend {
 			pathFetchValidRaw(&b),
 			pathFetchValid(&b),
 			pathFetchListCerts(&b),
+			pathSearchCerts(&b),
+
+			// OCSP APIs
+			pathOcspGet(&b),
+			pathOcspPost(&b),
 		},
 
 		Secrets: []*framework.Secret{
@@ -170,13 +194,22 @@ func Backend(conf *logical.BackendConfig) *backend {
 
 	b.crlLifetime = time.Hour * 72
 	b.tidyCASGuard = new(uint32)
+	b.certIndexCASGuard = new(uint32)
 	b.tidyStatus = &tidyStatus{state: tidyStatusInactive}
+	// Avoid running auto-tidy immediately on mount or unseal.
+	b.lastTidy = time.Now()
 	b.storage = conf.StorageView
 	b.backendUUID = conf.BackendUUID
 
 	b.pkiStorageVersion.Store(0)
 
-	b.crlBuilder = &crlBuilder{}
+	// Until the delta CRLs are first built, we can't know whether they
+	// include every revocation in the delta WAL.
+	b.crlBuilder = &crlBuilder{pendingDeltaRevocations: 1}
+
+	b.acmeState = newAcmeState()
+	b.Backend.Paths = append(b.Backend.Paths, pathsAcme(&b)...)
+
 	return &b
 }
 
@@ -188,15 +221,22 @@ type backend struct {
 	crlLifetime       time.Duration
 	revokeStorageLock sync.RWMutex
 	tidyCASGuard      *uint32
+	certIndexCASGuard *uint32
 
 	tidyStatusLock sync.RWMutex
 	tidyStatus     *tidyStatus
+	lastTidy       time.Time
 
 	pkiStorageVersion atomic.Value
 	crlBuilder        *crlBuilder
 
 	// Write lock around issuers and keys.
 	issuersLock sync.RWMutex
+
+	acmeState *acmeState
+	// Lock around the creation and update of ACME accounts, keeping the
+	// account-by-thumbprint index consistent.
+	acmeAccountLock sync.Mutex
 }
 
 type (
@@ -213,18 +253,24 @@ const (
 
 type tidyStatus struct {
 	// Parameters used to initiate the operation
-	safetyBuffer     int
-	tidyCertStore    bool
-	tidyRevokedCerts bool
+	source             string
+	safetyBuffer       int
+	issuerSafetyBuffer int
+	tidyCertStore      bool
+	tidyRevokedCerts   bool
+	tidyRevokedAssocs  bool
+	tidyExpiredIssuers bool
 
 	// Status
-	state                   tidyStatusState
-	err                     error
-	timeStarted             time.Time
-	timeFinished            time.Time
-	message                 string
-	certStoreDeletedCount   uint
-	revokedCertDeletedCount uint
+	state                     tidyStatusState
+	err                       error
+	timeStarted               time.Time
+	timeFinished              time.Time
+	message                   string
+	certStoreDeletedCount     uint
+	revokedCertDeletedCount   uint
+	missingIssuerCertCount    uint
+	expiredIssuerDeletedCount uint
 }
 
 const backendHelp = `
@@ -375,5 +421,24 @@ func (b *backend) invalidate(ctx context.Context, key string) {
 }
 
 func (b *backend) periodicFunc(ctx context.Context, request *logical.Request) error {
-	return b.crlBuilder.rebuildIfForced(ctx, b, request)
+	b.acmeState.tidyNonces()
+
+	crlErr := b.crlBuilder.rebuildIfForced(ctx, b, request)
+	if crlErr == nil {
+		crlErr = b.crlBuilder.rebuildIfScheduled(ctx, b, request)
+	}
+
+	// A failure to rebuild the CRL shouldn't prevent tidy from running.
+	tidyErr := b.periodicAutoTidy(ctx, request)
+
+	indexErr := b.periodicCertIndexBackfill(ctx, request)
+
+	if crlErr != nil {
+		return crlErr
+	}
+	if tidyErr != nil {
+		return tidyErr
+	}
+
+	return indexErr
 }
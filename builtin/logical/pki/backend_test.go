@@ -3429,6 +3429,185 @@ func TestBackend_AllowedURISANsTemplate(t *testing.T) {
 	}
 }
 
+func TestBackend_IdentityTemplatedSANs(t *testing.T) {
+	coreConfig := &vault.CoreConfig{
+		CredentialBackends: map[string]logical.Factory{
+			"userpass": userpass.Factory,
+		},
+		LogicalBackends: map[string]logical.Factory{
+			"pki": Factory,
+		},
+	}
+	cluster := vault.NewTestCluster(t, coreConfig, &vault.TestClusterOptions{
+		HandlerFunc: vaulthttp.Handler,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+	client := cluster.Cores[0].Client
+	rootToken := client.Token()
+
+	err := client.Sys().PutPolicy("test", `
+   path "pki/*" {
+     capabilities = ["update"]
+   }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.Sys().EnableAuth("userpass", "userpass", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Logical().Write("auth/userpass/users/userpassname", map[string]interface{}{
+		"password": "test",
+		"policies": "test",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	secret, err := client.Logical().Write("auth/userpass/login/userpassname", map[string]interface{}{
+		"password": "test",
+	})
+	if err != nil || secret == nil {
+		t.Fatal(err)
+	}
+	userpassToken := secret.Auth.ClientToken
+
+	// Describe the workload in the entity's metadata.
+	if _, err := client.Logical().Write("identity/entity/id/"+secret.Auth.EntityID, map[string]interface{}{
+		"metadata": map[string]string{
+			"service": "billing",
+			"team":    "payments",
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	auths, err := client.Sys().ListAuth()
+	if err != nil {
+		t.Fatal(err)
+	}
+	userpassAccessor := auths["userpass/"].Accessor
+
+	err = client.Sys().Mount("pki", &api.MountInput{
+		Type: "pki",
+		Config: api.MountConfigInput{
+			DefaultLeaseTTL: "16h",
+			MaxLeaseTTL:     "60h",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Logical().Write("pki/root/generate/internal", map[string]interface{}{
+		"ttl":         "40h",
+		"common_name": "myvault.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Invalid templates are rejected when writing the role.
+	_, err = client.Logical().Write("pki/roles/invalid", map[string]interface{}{
+		"identity_alt_names": "{{identity.entity.metadata.service}.svc.example.com",
+	})
+	if err == nil {
+		t.Fatal("expected error writing role with invalid identity template")
+	}
+
+	_, err = client.Logical().Write("pki/roles/workload", map[string]interface{}{
+		"allowed_domains":     "example.com",
+		"allow_subdomains":    true,
+		"require_cn":          false,
+		"allowed_uri_sans":    "spiffe://example.com/*",
+		"allowed_other_sans":  "1.3.6.1.4.1.311.20.2.3;UTF8:*@example.com",
+		"identity_alt_names":  "{{identity.entity.metadata.service}}.svc.example.com",
+		"identity_uri_sans":   "spiffe://example.com/{{identity.entity.aliases." + userpassAccessor + ".name}}/{{identity.entity.metadata.service}}",
+		"identity_other_sans": "1.3.6.1.4.1.311.20.2.3;UTF8:{{identity.entity.metadata.service}}@example.com",
+		"ou":                  "{{identity.entity.metadata.team}},static",
+		"ou_template":         true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.SetToken(userpassToken)
+	resp, err := client.Logical().Write("pki/issue/workload", map[string]interface{}{
+		"alt_names": "other.example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert := parseCert(t, resp.Data["certificate"].(string))
+	if diff := deep.Equal(cert.DNSNames, []string{"billing.svc.example.com", "other.example.com"}); diff != nil {
+		t.Fatal(diff)
+	}
+	if len(cert.URIs) != 1 || cert.URIs[0].String() != "spiffe://example.com/userpassname/billing" {
+		t.Fatalf("unexpected URI SANs: %v", cert.URIs)
+	}
+	ou := cert.Subject.OrganizationalUnit
+	sort.Strings(ou)
+	if diff := deep.Equal(ou, []string{"payments", "static"}); diff != nil {
+		t.Fatal(diff)
+	}
+	others, err := getOtherSANsFromX509Extensions(cert.Extensions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(others) != 1 || others[0].value != "billing@example.com" {
+		t.Fatalf("unexpected other SANs: %v", others)
+	}
+
+	// Requests without an entity can't populate the templates.
+	client.SetToken(rootToken)
+	_, err = client.Logical().Write("pki/issue/workload", map[string]interface{}{})
+	if err == nil {
+		t.Fatal("expected error issuing from templated role without an entity")
+	}
+
+	// Templated names are still subject to the role's domain policy.
+	_, err = client.Logical().Write("pki/roles/outside", map[string]interface{}{
+		"allowed_domains":    "example.com",
+		"allow_subdomains":   true,
+		"require_cn":         false,
+		"identity_alt_names": "{{identity.entity.metadata.service}}.example.org",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Logical().Write("pki/roles/outside-uri", map[string]interface{}{
+		"allowed_domains":   "example.com",
+		"allow_subdomains":  true,
+		"require_cn":        false,
+		"identity_uri_sans": "spiffe://example.org/{{identity.entity.metadata.service}}",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken(userpassToken)
+	for _, role := range []string{"outside", "outside-uri"} {
+		_, err = client.Logical().Write("pki/issue/"+role, map[string]interface{}{})
+		if err == nil || !strings.Contains(err.Error(), "not allowed by this role") {
+			t.Fatalf("expected templated SANs outside the role's policy to be rejected for %s, got: %v", role, err)
+		}
+	}
+	client.SetToken(rootToken)
+
+	// Nor can entities missing the referenced metadata.
+	_, err = client.Logical().Write("pki/roles/workload", map[string]interface{}{
+		"identity_alt_names": "{{identity.entity.metadata.region}}.example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken(userpassToken)
+	_, err = client.Logical().Write("pki/issue/workload", map[string]interface{}{})
+	if err == nil {
+		t.Fatal("expected error issuing from role with missing entity metadata")
+	}
+}
+
 func TestBackend_AllowedDomainsTemplate(t *testing.T) {
 	coreConfig := &vault.CoreConfig{
 		CredentialBackends: map[string]logical.Factory{
@@ -3626,6 +3805,9 @@ func TestReadWriteDeleteRoles(t *testing.T) {
 		"allowed_uri_sans_template":          false,
 		"enforce_hostnames":                  true,
 		"policy_identifiers":                 []interface{}{},
+		"extensions":                         []interface{}{},
+		"extensions_template":                false,
+		"allowed_extensions":                 []interface{}{},
 		"require_cn":                         true,
 		"allowed_domains_template":           false,
 		"allow_token_displayname":            false,
@@ -3644,6 +3826,10 @@ func TestReadWriteDeleteRoles(t *testing.T) {
 		"allow_glob_domains":                 false,
 		"ttl":                                json.Number("0"),
 		"ou":                                 []interface{}{},
+		"ou_template":                        false,
+		"identity_alt_names":                 []interface{}{},
+		"identity_uri_sans":                  []interface{}{},
+		"identity_other_sans":                []interface{}{},
 		"email_protection_flag":              false,
 		"locality":                           []interface{}{},
 		"server_flag":                        true,
@@ -3939,16 +4125,23 @@ func TestBackend_RevokePlusTidy_Intermediate(t *testing.T) {
 			t.Fatal(err)
 		}
 		expectedData := map[string]interface{}{
-			"safety_buffer":              json.Number("1"),
-			"tidy_cert_store":            true,
-			"tidy_revoked_certs":         true,
-			"state":                      "Finished",
-			"error":                      nil,
-			"time_started":               nil,
-			"time_finished":              nil,
-			"message":                    nil,
-			"cert_store_deleted_count":   json.Number("1"),
-			"revoked_cert_deleted_count": json.Number("1"),
+			"source":                                "manual",
+			"safety_buffer":                         json.Number("1"),
+			"issuer_safety_buffer":                  json.Number("31536000"),
+			"tidy_cert_store":                       true,
+			"tidy_revoked_certs":                    true,
+			"tidy_revoked_cert_issuer_associations": false,
+			"tidy_expired_issuers":                  false,
+			"state":                                 "Finished",
+			"error":                                 nil,
+			"time_started":                          nil,
+			"time_finished":                         nil,
+			"message":                               nil,
+			"cert_store_deleted_count":              json.Number("1"),
+			"revoked_cert_deleted_count":            json.Number("1"),
+			"missing_issuer_cert_count":             json.Number("0"),
+			"expired_issuer_deleted_count":          json.Number("0"),
+			"history":                               nil,
 		}
 		// Let's copy the times from the response so that we can use deep.Equal()
 		timeStarted, ok := tidyStatus.Data["time_started"]
@@ -3962,6 +4155,20 @@ func TestBackend_RevokePlusTidy_Intermediate(t *testing.T) {
 		}
 		expectedData["time_finished"] = timeFinished
 
+		// Both tidy operations should be in the history, newest first.
+		history, ok := tidyStatus.Data["history"].([]interface{})
+		if !ok || len(history) != 2 {
+			t.Fatalf("Expected tidy status response to include two history entries; got: %v", tidyStatus.Data["history"])
+		}
+		latest := history[0].(map[string]interface{})
+		if latest["state"] != "Finished" || latest["source"] != "manual" || latest["time_started"] != timeStarted {
+			t.Fatalf("Expected most recent history entry to match the finished tidy; got: %v", latest)
+		}
+		if latest["cert_store_deleted_count"] != json.Number("1") || latest["revoked_cert_deleted_count"] != json.Number("1") {
+			t.Fatalf("Expected most recent history entry to include the deleted counts; got: %v", latest)
+		}
+		expectedData["history"] = tidyStatus.Data["history"]
+
 		if diff := deep.Equal(expectedData, tidyStatus.Data); diff != nil {
 			t.Fatal(diff)
 		}
@@ -20,6 +20,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/hashicorp/go-secure-stdlib/strutil"
 	"github.com/hashicorp/vault/sdk/framework"
@@ -167,7 +169,7 @@ func fetchCertBySerial(ctx context.Context, b *backend, req *logical.Request, pr
 	case strings.HasPrefix(prefix, "revoked/"):
 		legacyPath = "revoked/" + colonSerial
 		path = "revoked/" + hyphenSerial
-	case serial == legacyCRLPath:
+	case serial == legacyCRLPath || serial == deltaCRLPath:
 		if err = b.crlBuilder.rebuildIfForced(ctx, b, req); err != nil {
 			return nil, err
 		}
@@ -175,6 +177,12 @@ func fetchCertBySerial(ctx context.Context, b *backend, req *logical.Request, pr
 		if err != nil {
 			return nil, err
 		}
+		if serial == deltaCRLPath {
+			if path == legacyCRLPath {
+				return nil, errutil.UserError{Err: "delta CRLs are not supported until migration has completed"}
+			}
+			path += deltaCRLPathSuffix
+		}
 	default:
 		legacyPath = "certs/" + colonSerial
 		path = "certs/" + hyphenSerial
@@ -243,6 +251,35 @@ func validateURISAN(b *backend, data *inputBundle, uri string) bool {
 	return valid
 }
 
+// populateRoleIdentityTemplates evaluates the identity templates in a role's
+// values against the requesting entity. Values which aren't templates are
+// returned as-is; the field name is only used in errors.
+func populateRoleIdentityTemplates(b *backend, data *inputBundle, field string, values []string) ([]string, error) {
+	var result []string
+	for _, value := range values {
+		isTemplate, err := framework.ValidateIdentityTemplate(value)
+		if err != nil {
+			return nil, errutil.UserError{Err: fmt.Sprintf("role's %s value %q is not a valid identity template: %v", field, value, err)}
+		}
+		if !isTemplate {
+			result = append(result, value)
+			continue
+		}
+
+		if data.req == nil || data.req.EntityID == "" {
+			return nil, errutil.UserError{Err: fmt.Sprintf("role's %s requires an identity entity, but the request has none", field)}
+		}
+
+		populated, err := framework.PopulateIdentityTemplate(value, data.req.EntityID, b.System())
+		if err != nil {
+			return nil, errutil.UserError{Err: fmt.Sprintf("unable to populate role's %s template %q: %v", field, value, err)}
+		}
+		result = append(result, populated)
+	}
+
+	return result, nil
+}
+
 // Given a set of requested names for a certificate, verifies that all of them
 // match the various toggles set in the role for controlling issuance.
 // If one does not pass, it is returned in the string argument.
@@ -570,6 +607,283 @@ func parseOtherSANs(others []string) (map[string][]string, error) {
 	return result, nil
 }
 
+// reservedExtensionOIDs are the extensions which Vault sets itself, and
+// so can't be set as custom extensions.
+var reservedExtensionOIDs = map[string]string{
+	"2.5.29.14":         "Subject Key Identifier",
+	"2.5.29.15":         "Key Usage",
+	"2.5.29.17":         "Subject Alternative Name",
+	"2.5.29.19":         "Basic Constraints",
+	"2.5.29.30":         "Name Constraints",
+	"2.5.29.31":         "CRL Distribution Points",
+	"2.5.29.32":         "Certificate Policies",
+	"2.5.29.35":         "Authority Key Identifier",
+	"2.5.29.37":         "Extended Key Usage",
+	"2.5.29.46":         "Freshest CRL",
+	"1.3.6.1.5.5.7.1.1": "Authority Information Access",
+}
+
+// splitCustomExtension splits a custom extension of the form
+// <oid>;<type>:<value>, validating the OID.
+func splitCustomExtension(extension string) (string, string, string, error) {
+	splitExtension := strings.SplitN(extension, ";", 2)
+	if len(splitExtension) != 2 {
+		return "", "", "", fmt.Errorf("expected a semicolon in extension %q", extension)
+	}
+	oid := strings.TrimSpace(splitExtension[0])
+	if _, err := certutil.StringToOid(oid); err != nil {
+		return "", "", "", fmt.Errorf("%q is not a valid OID in extension %q", oid, extension)
+	}
+	if name, reserved := reservedExtensionOIDs[oid]; reserved {
+		return "", "", "", fmt.Errorf("the %s extension (%s) is managed by Vault and can't be set as a custom extension", name, oid)
+	}
+
+	splitType := strings.SplitN(splitExtension[1], ":", 2)
+	if len(splitType) != 2 {
+		return "", "", "", fmt.Errorf("expected a colon in extension %q", extension)
+	}
+
+	return oid, strings.ToLower(splitType[0]), splitType[1], nil
+}
+
+// marshalCustomExtensionValue encodes the value of a custom extension into
+// DER. The type is one of utf8, ia5, printable, int, bool, oid, null or der,
+// the last of which takes the base64 encoding of a single DER value.
+func marshalCustomExtensionValue(valueType string, value string) ([]byte, error) {
+	switch valueType {
+	case "utf8", "utf-8":
+		return asn1.MarshalWithParams(value, "utf8")
+	case "ia5":
+		return asn1.MarshalWithParams(value, "ia5")
+	case "printable":
+		return asn1.MarshalWithParams(value, "printable")
+	case "int", "integer":
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid integer: %w", value, err)
+		}
+		return asn1.Marshal(parsed)
+	case "bool", "boolean":
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid boolean: %w", value, err)
+		}
+		return asn1.Marshal(parsed)
+	case "oid":
+		parsed, err := certutil.StringToOid(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid OID: %w", value, err)
+		}
+		return asn1.Marshal(parsed)
+	case "null":
+		if value != "" {
+			return nil, fmt.Errorf("null values must be empty")
+		}
+		return asn1.NullBytes, nil
+	case "der":
+		der, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("der values must be base64 encoded: %w", err)
+		}
+		var raw asn1.RawValue
+		rest, err := asn1.Unmarshal(der, &raw)
+		if err != nil {
+			return nil, fmt.Errorf("der value is not valid ASN.1: %w", err)
+		}
+		if len(rest) > 0 {
+			return nil, fmt.Errorf("der value contains trailing data after its ASN.1 value")
+		}
+		return der, nil
+	default:
+		return nil, fmt.Errorf("unknown extension value type %q; must be one of utf8, ia5, printable, int, bool, oid, null or der", valueType)
+	}
+}
+
+// parseCustomExtensions parses and encodes custom extensions of the form
+// <oid>;<type>:<value>. Custom extensions are never critical.
+func parseCustomExtensions(extensions []string) ([]pkix.Extension, error) {
+	var result []pkix.Extension
+	seen := make(map[string]bool, len(extensions))
+	for _, extension := range extensions {
+		oid, valueType, value, err := splitCustomExtension(extension)
+		if err != nil {
+			return nil, err
+		}
+		if seen[oid] {
+			return nil, fmt.Errorf("extension %s was specified more than once", oid)
+		}
+		seen[oid] = true
+
+		der, err := marshalCustomExtensionValue(valueType, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value in extension %q: %w", extension, err)
+		}
+
+		parsedOid, _ := certutil.StringToOid(oid)
+		result = append(result, pkix.Extension{
+			Id:    parsedOid,
+			Value: der,
+		})
+	}
+
+	return result, nil
+}
+
+// validateRoleExtensions validates a role's fixed custom extensions; when
+// identity templates are allowed, templated values are only validated once
+// populated at issuance.
+func validateRoleExtensions(extensions []string, allowTemplates bool) error {
+	var untemplated []string
+	for _, extension := range extensions {
+		if allowTemplates {
+			isTemplate, err := framework.ValidateIdentityTemplate(extension)
+			if err != nil {
+				return fmt.Errorf("extension %q is not a valid identity template: %w", extension, err)
+			}
+			if isTemplate {
+				if _, _, _, err := splitCustomExtension(extension); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		untemplated = append(untemplated, extension)
+	}
+
+	_, err := parseCustomExtensions(untemplated)
+	return err
+}
+
+// validateAllowedExtensions validates a role's allowed_extensions, which
+// are OIDs or a single "*" to allow any extension not managed by Vault.
+func validateAllowedExtensions(allowed []string) error {
+	if len(allowed) == 1 && allowed[0] == "*" {
+		return nil
+	}
+
+	for _, oid := range allowed {
+		if _, err := certutil.StringToOid(oid); err != nil {
+			return fmt.Errorf("%q is not a valid OID", oid)
+		}
+		if name, reserved := reservedExtensionOIDs[oid]; reserved {
+			return fmt.Errorf("the %s extension (%s) is managed by Vault and can't be allowed as a custom extension", name, oid)
+		}
+	}
+
+	return nil
+}
+
+func isExtensionAllowed(role *roleEntry, oid string) bool {
+	if _, reserved := reservedExtensionOIDs[oid]; reserved {
+		return false
+	}
+
+	for _, allowed := range role.AllowedExtensions {
+		if allowed == "*" || allowed == oid {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getCustomExtensions builds the custom extensions of a certificate: the
+// role's fixed extensions, plus any requested via the API (or the CSR, when
+// using its SANs) which the role allows.
+func getCustomExtensions(b *backend, data *inputBundle, csr *x509.CertificateRequest) ([]pkix.Extension, error) {
+	roleExtensions := data.role.Extensions
+	if data.role.ExtensionsTemplate {
+		var err error
+		roleExtensions, err = populateRoleIdentityTemplates(b, data, "extensions", roleExtensions)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := parseCustomExtensions(roleExtensions)
+	if err != nil {
+		return nil, errutil.UserError{Err: fmt.Sprintf("could not parse role's extensions: %v", err)}
+	}
+
+	setByRole := make(map[string]bool, len(result))
+	for _, extension := range result {
+		setByRole[extension.Id.String()] = true
+	}
+
+	if requestedRaw, ok := data.apiData.GetOk("extensions"); ok {
+		requested, err := parseCustomExtensions(requestedRaw.([]string))
+		if err != nil {
+			return nil, errutil.UserError{Err: fmt.Sprintf("could not parse requested extensions: %v", err)}
+		}
+
+		for _, extension := range requested {
+			oid := extension.Id.String()
+			if setByRole[oid] {
+				return nil, errutil.UserError{Err: fmt.Sprintf("extension %s is set by this role and can't be requested", oid)}
+			}
+			if !isExtensionAllowed(data.role, oid) {
+				return nil, errutil.UserError{Err: fmt.Sprintf("extension %s not allowed by this role", oid)}
+			}
+			result = append(result, extension)
+		}
+	}
+
+	if csr != nil && data.role.UseCSRSANs {
+		for _, extension := range csr.Extensions {
+			oid := extension.Id.String()
+			if extension.Critical || setByRole[oid] || certutil.HasExtension(result, extension.Id) || !isExtensionAllowed(data.role, oid) {
+				continue
+			}
+			result = append(result, pkix.Extension{
+				Id:    extension.Id,
+				Value: extension.Value,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// validatePolicyIdentifiers checks the qualifiers of certificate policies:
+// CPS pointers must be HTTP(S) URIs and user notices are limited to the
+// 200 characters permitted by RFC 5280.
+func validatePolicyIdentifiers(policyIdentifiers []string) error {
+	for _, policyIdentifier := range policyIdentifiers {
+		entry, err := certutil.GetPolicyIdentifierFromString(policyIdentifier)
+		if err != nil {
+			return err
+		}
+		if entry == nil {
+			continue
+		}
+		if _, err := certutil.StringToOid(entry.PolicyIdentifierOid); err != nil {
+			return fmt.Errorf("policy identifier %q is not a valid OID", entry.PolicyIdentifierOid)
+		}
+
+		if entry.CPS != "" {
+			cps, err := url.Parse(entry.CPS)
+			if err != nil || (cps.Scheme != "http" && cps.Scheme != "https") || cps.Host == "" {
+				return fmt.Errorf("CPS %q of policy %s must be an http or https URI", entry.CPS, entry.PolicyIdentifierOid)
+			}
+			for _, r := range entry.CPS {
+				if r > unicode.MaxASCII {
+					return fmt.Errorf("CPS %q of policy %s must only contain ASCII characters", entry.CPS, entry.PolicyIdentifierOid)
+				}
+			}
+		}
+
+		if utf8.RuneCountInString(entry.Notice) > 200 {
+			return fmt.Errorf("user notice of policy %s is longer than 200 characters", entry.PolicyIdentifierOid)
+		}
+	}
+
+	if _, err := certutil.CreatePolicyInformationExtensionFromStorageStrings(policyIdentifiers); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func validateSerialNumber(data *inputBundle, serialNumber string) string {
 	valid := false
 	if len(data.role.AllowedSerialNumbers) > 0 {
@@ -857,9 +1171,10 @@ func signCert(b *backend,
 
 // otherNameRaw describes a name related to a certificate which is not in one
 // of the standard name formats. RFC 5280, 4.2.1.6:
-// OtherName ::= SEQUENCE {
-//      type-id    OBJECT IDENTIFIER,
-//      value      [0] EXPLICIT ANY DEFINED BY type-id }
+//
+//	OtherName ::= SEQUENCE {
+//	     type-id    OBJECT IDENTIFIER,
+//	     value      [0] EXPLICIT ANY DEFINED BY type-id }
 type otherNameRaw struct {
 	TypeID asn1.ObjectIdentifier
 	Value  asn1.RawValue
@@ -1076,6 +1391,42 @@ func generateCreationBundle(b *backend, data *inputBundle, caSign *certutil.CAIn
 			return nil, errutil.UserError{Err: fmt.Sprintf(
 				"email address %s not allowed by this role", badName)}
 		}
+
+		// Names templated from the requester's identity may contain values
+		// the requester controls, such as alias metadata, so they're held
+		// to the same domain policy as requested names.
+		identityNames, err := populateRoleIdentityTemplates(b, data, "identity_alt_names", data.role.IdentityAltNames)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range identityNames {
+			if strings.Contains(v, "@") {
+				if badName := validateNames(b, data, []string{v}); len(badName) != 0 {
+					return nil, errutil.UserError{Err: fmt.Sprintf(
+						"identity templated email address %s not allowed by this role", badName)}
+				}
+				emailAddresses = append(emailAddresses, v)
+				continue
+			}
+
+			p := idna.New(
+				idna.StrictDomainName(true),
+				idna.VerifyDNSLength(true),
+			)
+			converted, err := p.ToASCII(v)
+			if err != nil {
+				return nil, errutil.UserError{Err: err.Error()}
+			}
+			if !hostnameRegex.MatchString(converted) {
+				return nil, errutil.UserError{Err: fmt.Sprintf(
+					"identity templated alternate name %s is not a valid hostname", v)}
+			}
+			if badName := validateNames(b, data, []string{converted}); len(badName) != 0 {
+				return nil, errutil.UserError{Err: fmt.Sprintf(
+					"identity templated alternate name %s not allowed by this role", badName)}
+			}
+			dnsNames = append(dnsNames, converted)
+		}
 	}
 
 	// otherSANsInput has the same format as the other_sans HTTP param in the
@@ -1116,6 +1467,44 @@ func generateCreationBundle(b *backend, data *inputBundle, caSign *certutil.CAIn
 			otherSANs = requested
 		}
 	}
+	customExtensions, err := getCustomExtensions(b, data, csr)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data.role.PolicyIdentifiers) > 0 {
+		if err := validatePolicyIdentifiers(data.role.PolicyIdentifiers); err != nil {
+			return nil, errutil.UserError{Err: fmt.Sprintf("invalid certificate policies: %v", err)}
+		}
+	}
+
+	if len(data.role.IdentityOtherSANs) > 0 {
+		identityOtherSANs, err := populateRoleIdentityTemplates(b, data, "identity_other_sans", data.role.IdentityOtherSANs)
+		if err != nil {
+			return nil, err
+		}
+		templated, err := parseOtherSANs(identityOtherSANs)
+		if err != nil {
+			return nil, errutil.UserError{Err: fmt.Errorf("could not parse identity templated other SAN: %w", err).Error()}
+		}
+		badOID, badName, err := validateOtherSANs(data, templated)
+		switch {
+		case err != nil:
+			return nil, errutil.UserError{Err: err.Error()}
+		case len(badName) > 0:
+			return nil, errutil.UserError{Err: fmt.Sprintf(
+				"identity templated other SAN %s not allowed for OID %s by this role", badName, badOID)}
+		case len(badOID) > 0:
+			return nil, errutil.UserError{Err: fmt.Sprintf(
+				"identity templated other SAN OID %s not allowed by this role", badOID)}
+		}
+		if otherSANs == nil {
+			otherSANs = make(map[string][]string, len(templated))
+		}
+		for oid, names := range templated {
+			otherSANs[oid] = append(otherSANs[oid], names...)
+		}
+	}
 
 	// Get and verify any IP SANs
 	ipAddresses := []net.IP{}
@@ -1202,6 +1591,37 @@ func generateCreationBundle(b *backend, data *inputBundle, caSign *certutil.CAIn
 				}
 			}
 		}
+
+		identityURIs, err := populateRoleIdentityTemplates(b, data, "identity_uri_sans", data.role.IdentityURISANs)
+		if err != nil {
+			return nil, err
+		}
+		for _, uri := range identityURIs {
+			if !validateURISAN(b, data, uri) {
+				return nil, errutil.UserError{
+					Err: fmt.Sprintf(
+						"the identity templated URI Subject Alternative Name '%s' is not allowed by this role", uri),
+				}
+			}
+
+			parsedURI, err := url.Parse(uri)
+			if parsedURI == nil || err != nil {
+				return nil, errutil.UserError{
+					Err: fmt.Sprintf(
+						"the identity templated URI Subject Alternative Name '%s' is not a valid URI", uri),
+				}
+			}
+
+			URIs = append(URIs, parsedURI)
+		}
+	}
+
+	ou := data.role.OU
+	if data.role.OUTemplate {
+		ou, err = populateRoleIdentityTemplates(b, data, "ou", data.role.OU)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Most of these could also be RemoveDuplicateStable, or even
@@ -1211,7 +1631,7 @@ func generateCreationBundle(b *backend, data *inputBundle, caSign *certutil.CAIn
 		SerialNumber:       ridSerialNumber,
 		Country:            strutil.RemoveDuplicatesStable(data.role.Country, false),
 		Organization:       strutil.RemoveDuplicatesStable(data.role.Organization, false),
-		OrganizationalUnit: strutil.RemoveDuplicatesStable(data.role.OU, false),
+		OrganizationalUnit: strutil.RemoveDuplicatesStable(ou, false),
 		Locality:           strutil.RemoveDuplicatesStable(data.role.Locality, false),
 		Province:           strutil.RemoveDuplicatesStable(data.role.Province, false),
 		StreetAddress:      strutil.RemoveDuplicatesStable(data.role.StreetAddress, false),
@@ -1222,7 +1642,6 @@ func generateCreationBundle(b *backend, data *inputBundle, caSign *certutil.CAIn
 	var ttl time.Duration
 	var maxTTL time.Duration
 	var notAfter time.Time
-	var err error
 	{
 		ttl = time.Duration(data.apiData.Get("ttl").(int)) * time.Second
 		notAfterAlt := data.role.NotAfter
@@ -1301,6 +1720,7 @@ func generateCreationBundle(b *backend, data *inputBundle, caSign *certutil.CAIn
 			ExtKeyUsage:                   parseExtKeyUsages(data.role),
 			ExtKeyUsageOIDs:               data.role.ExtKeyUsageOIDs,
 			PolicyIdentifiers:             data.role.PolicyIdentifiers,
+			CustomExtensions:              customExtensions,
 			BasicConstraintsValidForNonCA: data.role.BasicConstraintsValidForNonCA,
 			NotBeforeDuration:             data.role.NotBeforeDuration,
 			ForceAppendCaChain:            caSign != nil,
@@ -6,6 +6,8 @@ import (
 	"crypto/x509"
 	"fmt"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/vault/sdk/logical"
 )
@@ -87,15 +89,13 @@ func rebuildIssuersChains(ctx context.Context, s logical.Storage, referenceCert
 	// We expect each of these maps to be the size of the number of issuers
 	// we have (as we're mapping from issuers to other values).
 	//
-	// The first caches the storage entry for the issuer, the second caches
-	// the parsed *x509.Certificate of the issuer itself, and the third and
-	// fourth maps that certificate back to the other issuers with that
-	// subject (note the keyword _other_: we'll exclude self-loops here) --
-	// either via a parent or child relationship.
+	// The first caches the storage entry for the issuer and the second caches
+	// the parsed *x509.Certificate of the issuer itself. Later, the parent
+	// and children maps relate that certificate back to the other issuers
+	// with that subject (note the keyword _other_: we'll exclude self-loops
+	// here) -- either via a parent or child relationship.
 	issuerIdEntryMap := make(map[issuerID]*issuerEntry, len(issuers))
 	issuerIdCertMap := make(map[issuerID]*x509.Certificate, len(issuers))
-	issuerIdParentsMap := make(map[issuerID][]issuerID, len(issuers))
-	issuerIdChildrenMap := make(map[issuerID][]issuerID, len(issuers))
 
 	// For every known issuer, we map that subject back to the id of issuers
 	// containing that subject. This lets us build our issuerID -> parents
@@ -104,8 +104,8 @@ func rebuildIssuersChains(ctx context.Context, s logical.Storage, referenceCert
 	subjectIssuerIdsMap := make(map[string][]issuerID, len(issuers))
 
 	// First, read every issuer entry from storage. We'll propagate entries
-	// to three of the maps here: all but issuerIdParentsMap and
-	// issuerIdChildrenMap, which we'll do in a second pass.
+	// to these three maps here; issuerIdParentsMap and issuerIdChildrenMap
+	// are built in a second pass.
 	for _, identifier := range issuers {
 		var stored *issuerEntry
 
@@ -151,51 +151,7 @@ func rebuildIssuersChains(ctx context.Context, s logical.Storage, referenceCert
 	// in sorted order, so the resulting map entries (of ids) are also sorted.
 	// Thus, the graph structure is in sorted order and thus the toposort
 	// below will be stable.
-	for _, child := range issuers {
-		// Fetch the certificate as we'll need it later.
-		childCert := issuerIdCertMap[child]
-
-		parentSubject := string(issuerIdCertMap[child].RawIssuer)
-		parentCerts, ok := subjectIssuerIdsMap[parentSubject]
-		if !ok {
-			// When the issuer isn't known to Vault, the lookup by the issuer
-			// will be empty. This most commonly occurs when intermediates are
-			// directly added (via intermediate/set-signed) without providing
-			// the root.
-			continue
-		}
-
-		// Now, iterate over all possible parents and assign the child/parent
-		// relationship.
-		for _, parent := range parentCerts {
-			// Skip self-references to the exact same certificate.
-			if child == parent {
-				continue
-			}
-
-			// While we could use Subject/Authority Key Identifier (SKI/AKI)
-			// as a heuristic for whether or not this relationship is valid,
-			// this is insufficient as otherwise valid CA certificates could
-			// elide this information. That means its best to actually validate
-			// the signature (e.g., call child.CheckSignatureFrom(parent))
-			// instead.
-			parentCert := issuerIdCertMap[parent]
-			if err := childCert.CheckSignatureFrom(parentCert); err != nil {
-				// We cannot return an error here as it could be that this
-				// signature is entirely valid -- but just for a different
-				// key. Instead, skip adding the parent->child and
-				// child->parent link.
-				continue
-			}
-
-			// Otherwise, we can append it to the map, allowing us to walk the
-			// issuer->parent mapping.
-			issuerIdParentsMap[child] = append(issuerIdParentsMap[child], parent)
-
-			// Also cross-add the child relationship step at the same time.
-			issuerIdChildrenMap[parent] = append(issuerIdChildrenMap[parent], child)
-		}
-	}
+	issuerIdParentsMap, issuerIdChildrenMap := computeIssuerParentsAndChildren(issuers, issuerIdCertMap, subjectIssuerIdsMap)
 
 	// Finally, we consult RFC 8446 Section 4.4.2 for creating an algorithm for
 	// building the chain:
@@ -430,6 +386,63 @@ func rebuildIssuersChains(ctx context.Context, s logical.Storage, referenceCert
 	return nil
 }
 
+// computeIssuerParentsAndChildren builds the issuerID -> parents and
+// issuerID -> children mappings between the given issuers, based on
+// verifying each issuer's signature against every issuer with a matching
+// subject.
+func computeIssuerParentsAndChildren(issuers []issuerID, issuerIdCertMap map[issuerID]*x509.Certificate, subjectIssuerIdsMap map[string][]issuerID) (map[issuerID][]issuerID, map[issuerID][]issuerID) {
+	issuerIdParentsMap := make(map[issuerID][]issuerID, len(issuers))
+	issuerIdChildrenMap := make(map[issuerID][]issuerID, len(issuers))
+
+	for _, child := range issuers {
+		// Fetch the certificate as we'll need it later.
+		childCert := issuerIdCertMap[child]
+
+		parentSubject := string(issuerIdCertMap[child].RawIssuer)
+		parentCerts, ok := subjectIssuerIdsMap[parentSubject]
+		if !ok {
+			// When the issuer isn't known to Vault, the lookup by the issuer
+			// will be empty. This most commonly occurs when intermediates are
+			// directly added (via intermediate/set-signed) without providing
+			// the root.
+			continue
+		}
+
+		// Now, iterate over all possible parents and assign the child/parent
+		// relationship.
+		for _, parent := range parentCerts {
+			// Skip self-references to the exact same certificate.
+			if child == parent {
+				continue
+			}
+
+			// While we could use Subject/Authority Key Identifier (SKI/AKI)
+			// as a heuristic for whether or not this relationship is valid,
+			// this is insufficient as otherwise valid CA certificates could
+			// elide this information. That means its best to actually validate
+			// the signature (e.g., call child.CheckSignatureFrom(parent))
+			// instead.
+			parentCert := issuerIdCertMap[parent]
+			if err := childCert.CheckSignatureFrom(parentCert); err != nil {
+				// We cannot return an error here as it could be that this
+				// signature is entirely valid -- but just for a different
+				// key. Instead, skip adding the parent->child and
+				// child->parent link.
+				continue
+			}
+
+			// Otherwise, we can append it to the map, allowing us to walk the
+			// issuer->parent mapping.
+			issuerIdParentsMap[child] = append(issuerIdParentsMap[child], parent)
+
+			// Also cross-add the child relationship step at the same time.
+			issuerIdChildrenMap[parent] = append(issuerIdChildrenMap[parent], child)
+		}
+	}
+
+	return issuerIdParentsMap, issuerIdChildrenMap
+}
+
 func addToChainIfNotExisting(includedParentCerts map[string]bool, entry *issuerEntry, certToAdd string) {
 	included, ok := includedParentCerts[certToAdd]
 	if ok && included {
@@ -1340,3 +1353,143 @@ func addParentChainsToEntry(
 		}
 	}
 }
+
+// issuerChainGraphNode describes an issuer's place in the graph of all
+// known issuers: the issuers which signed it (its parents), the issuers it
+// signed (its children) and the issuers making up its computed CA chain, in
+// order.
+type issuerChainGraphNode struct {
+	Name         string
+	KeyID        keyID
+	SerialNumber string
+	Subject      string
+	NotAfter     time.Time
+	Parents      []issuerID
+	Children     []issuerID
+	CAChain      []issuerID
+}
+
+func fetchIssuerChainGraph(ctx context.Context, s logical.Storage) (map[issuerID]*issuerChainGraphNode, error) {
+	issuers, err := listIssuers(ctx, s)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list issuers to build chain graph: %v", err)
+	}
+
+	sort.SliceStable(issuers, func(i, j int) bool {
+		return issuers[i] > issuers[j]
+	})
+
+	issuerIdEntryMap := make(map[issuerID]*issuerEntry, len(issuers))
+	issuerIdCertMap := make(map[issuerID]*x509.Certificate, len(issuers))
+	subjectIssuerIdsMap := make(map[string][]issuerID, len(issuers))
+	certIssuerIdMap := make(map[string]issuerID, len(issuers))
+	for _, identifier := range issuers {
+		stored, err := fetchIssuerById(ctx, s, identifier)
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch issuer %v to build chain graph: %v", identifier, err)
+		}
+
+		cert, err := stored.GetCertificate()
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse issuer %v to certificate to build chain graph: %v", identifier, err)
+		}
+
+		issuerIdEntryMap[identifier] = stored
+		issuerIdCertMap[identifier] = cert
+		subjectIssuerIdsMap[string(cert.RawSubject)] = append(subjectIssuerIdsMap[string(cert.RawSubject)], identifier)
+		certIssuerIdMap[strings.TrimSpace(stored.Certificate)] = identifier
+	}
+
+	issuerIdParentsMap, issuerIdChildrenMap := computeIssuerParentsAndChildren(issuers, issuerIdCertMap, subjectIssuerIdsMap)
+
+	graph := make(map[issuerID]*issuerChainGraphNode, len(issuers))
+	for _, identifier := range issuers {
+		entry := issuerIdEntryMap[identifier]
+		cert := issuerIdCertMap[identifier]
+
+		node := &issuerChainGraphNode{
+			Name:         entry.Name,
+			KeyID:        entry.KeyID,
+			SerialNumber: entry.SerialNumber,
+			Subject:      cert.Subject.String(),
+			NotAfter:     cert.NotAfter,
+			Parents:      append([]issuerID{}, issuerIdParentsMap[identifier]...),
+			Children:     append([]issuerID{}, issuerIdChildrenMap[identifier]...),
+			CAChain:      []issuerID{},
+		}
+		for _, chainCert := range entry.CAChain {
+			if chainId, ok := certIssuerIdMap[strings.TrimSpace(chainCert)]; ok {
+				node.CAChain = append(node.CAChain, chainId)
+			}
+		}
+
+		graph[identifier] = node
+	}
+
+	return graph, nil
+}
+
+func issuerChainGraphToResponseData(graph map[issuerID]*issuerChainGraphNode) map[string]interface{} {
+	ret := make(map[string]interface{}, len(graph))
+	for identifier, node := range graph {
+		ret[identifier.String()] = map[string]interface{}{
+			"issuer_name":   node.Name,
+			"key_id":        node.KeyID,
+			"serial_number": node.SerialNumber,
+			"subject":       node.Subject,
+			"not_after":     node.NotAfter.Format(time.RFC3339),
+			"parents":       node.Parents,
+			"children":      node.Children,
+			"ca_chain":      node.CAChain,
+		}
+	}
+
+	return ret
+}
+
+// attachCrossSignedIssuer links an issuer and a cross-signed certificate
+// for it, when requested by the operator. Automatic chain building only
+// groups certificates sharing a subject and key when they're self-signed
+// (reissued roots); a cross-signed intermediate has a different issuer, so
+// the two would be kept apart. Instead, set the manual chain of each to its
+// own computed chain followed by that of the other, so that either presents
+// both validation paths. As manual chains are no longer rebuilt, this is
+// opt-in; an issuer whose manual chain is already set is left alone.
+func attachCrossSignedIssuer(ctx context.Context, s logical.Storage, original issuerID, crossSigned issuerID) error {
+	graph, err := fetchIssuerChainGraph(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	for _, pair := range [][2]issuerID{{original, crossSigned}, {crossSigned, original}} {
+		self, other := graph[pair[0]], graph[pair[1]]
+		if self == nil || other == nil {
+			return fmt.Errorf("unable to find issuers %v and %v in chain graph", pair[0], pair[1])
+		}
+
+		candidates := append([]issuerID{}, self.CAChain...)
+		candidates = append(candidates, pair[1])
+		candidates = append(candidates, other.CAChain...)
+
+		manualChain := []issuerID{pair[0]}
+		for _, candidate := range candidates {
+			if !containsIssuer(manualChain, candidate) {
+				manualChain = append(manualChain, candidate)
+			}
+		}
+
+		entry, err := fetchIssuerById(ctx, s, pair[0])
+		if err != nil {
+			return err
+		}
+		if len(entry.ManualChain) > 0 {
+			continue
+		}
+		entry.ManualChain = manualChain
+		if err := writeIssuer(ctx, s, entry); err != nil {
+			return err
+		}
+	}
+
+	return rebuildIssuersChains(ctx, s, nil)
+}
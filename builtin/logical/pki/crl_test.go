@@ -2,6 +2,8 @@ package pki
 
 import (
 	"context"
+	"crypto/x509/pkix"
+	"encoding/asn1"
 	"testing"
 	"time"
 
@@ -210,3 +212,199 @@ func requestCrlFromBackend(t *testing.T, s logical.Storage, b *backend) *logical
 	require.False(t, resp.IsError(), "crl error response: %v", resp)
 	return resp
 }
+
+func TestAutoRebuild(t *testing.T) {
+	ctx := context.Background()
+	b, s := createBackendWithStorage(t)
+
+	_, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "root example.com",
+		"key_type":    "ec",
+		"ttl":         "40h",
+	})
+	require.NoError(t, err)
+	_, err = CBWrite(b, s, "roles/test", map[string]interface{}{
+		"allowed_domains":  "example.com",
+		"allow_subdomains": true,
+		"key_type":         "ec",
+		"ttl":              "1h",
+	})
+	require.NoError(t, err)
+
+	// Deltas can't be enabled without auto-rebuilding.
+	_, err = CBWrite(b, s, "config/crl", map[string]interface{}{
+		"enable_delta": true,
+	})
+	require.Error(t, err, "expected error enabling delta without auto_rebuild")
+
+	// A grace period of most of the CRL's lifetime causes a rebuild on the
+	// first periodic run after ThisUpdate has passed.
+	_, err = CBWrite(b, s, "config/crl", map[string]interface{}{
+		"expiry":                    "1m",
+		"auto_rebuild":              true,
+		"auto_rebuild_grace_period": "59s",
+	})
+	require.NoError(t, err)
+
+	// Rotate so that the CRL picks up the shorter expiry.
+	_, err = CBRead(b, s, "crl/rotate")
+	require.NoError(t, err)
+
+	resp, err := CBRead(b, s, "config/crl")
+	require.NoError(t, err)
+	require.Equal(t, true, resp.Data["auto_rebuild"])
+	require.Equal(t, false, resp.Data["enable_delta"])
+	require.Equal(t, "15m", resp.Data["delta_rebuild_interval"])
+
+	crl1 := parseCrlPemBytes(t, requestCrlFromBackend(t, s, b).Data["http_raw_body"].([]byte))
+
+	resp, err = CBWrite(b, s, "issue/test", map[string]interface{}{
+		"common_name": "leaf.example.com",
+	})
+	require.NoError(t, err)
+	serial := resp.Data["serial_number"].(string)
+	_, err = CBWrite(b, s, "revoke", map[string]interface{}{
+		"serial_number": serial,
+	})
+	require.NoError(t, err)
+
+	// Revocation no longer rebuilds the CRL inline.
+	crl2 := parseCrlPemBytes(t, requestCrlFromBackend(t, s, b).Data["http_raw_body"].([]byte))
+	require.Equal(t, crl1.ThisUpdate, crl2.ThisUpdate)
+	require.Empty(t, crl2.RevokedCertificates)
+
+	// CRL times are truncated to the second, so wait out a full one.
+	for time.Now().Before(crl1.ThisUpdate.Add(2 * time.Second)) {
+		time.Sleep(100 * time.Millisecond)
+	}
+	require.NoError(t, b.periodicFunc(ctx, &logical.Request{Storage: s}))
+
+	crl3 := parseCrlPemBytes(t, requestCrlFromBackend(t, s, b).Data["http_raw_body"].([]byte))
+	require.True(t, crl3.ThisUpdate.After(crl2.ThisUpdate))
+	requireSerialNumberInCRL(t, crl3, serial)
+}
+
+func TestDeltaCRLs(t *testing.T) {
+	ctx := context.Background()
+	b, s := createBackendWithStorage(t)
+
+	_, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "root example.com",
+		"key_type":    "ec",
+		"ttl":         "40h",
+	})
+	require.NoError(t, err)
+	_, err = CBWrite(b, s, "roles/test", map[string]interface{}{
+		"allowed_domains":  "example.com",
+		"allow_subdomains": true,
+		"key_type":         "ec",
+		"ttl":              "1h",
+	})
+	require.NoError(t, err)
+
+	deltaURL := "http://localhost:8200/v1/pki/crl/delta"
+	_, err = CBWrite(b, s, "config/urls", map[string]interface{}{
+		"delta_crl_distribution_points": deltaURL,
+	})
+	require.NoError(t, err)
+	_, err = CBWrite(b, s, "config/crl", map[string]interface{}{
+		"auto_rebuild":           true,
+		"enable_delta":           true,
+		"delta_rebuild_interval": "1s",
+	})
+	require.NoError(t, err)
+
+	fetchCRL := func(path string) pkix.TBSCertificateList {
+		resp, err := CBRead(b, s, path)
+		requireSuccessNonNilResponse(t, resp, err, path)
+		return parseCrlPemBytes(t, resp.Data["http_raw_body"].([]byte))
+	}
+	requireExtension := func(crl pkix.TBSCertificateList, oid asn1.ObjectIdentifier) pkix.Extension {
+		for _, ext := range crl.Extensions {
+			if ext.Id.Equal(oid) {
+				return ext
+			}
+		}
+		t.Fatalf("extension %v not found on CRL", oid)
+		return pkix.Extension{}
+	}
+	crlNumber := func(crl pkix.TBSCertificateList) int64 {
+		var number int64
+		_, err := asn1.Unmarshal(requireExtension(crl, asn1.ObjectIdentifier{2, 5, 29, 20}).Value, &number)
+		require.NoError(t, err)
+		return number
+	}
+	baseCRLNumber := func(crl pkix.TBSCertificateList) int64 {
+		ext := requireExtension(crl, asn1.ObjectIdentifier{2, 5, 29, 27})
+		require.True(t, ext.Critical)
+		var number int64
+		_, err := asn1.Unmarshal(ext.Value, &number)
+		require.NoError(t, err)
+		return number
+	}
+
+	// Enabling deltas rotated the complete CRL, which now points at the
+	// delta CRL, and built an empty delta against it.
+	complete := fetchCRL("crl/pem")
+	freshest := requireExtension(complete, asn1.ObjectIdentifier{2, 5, 29, 46})
+	require.Contains(t, string(freshest.Value), deltaURL)
+	delta := fetchCRL("crl/delta/pem")
+	require.Empty(t, delta.RevokedCertificates)
+	require.Equal(t, crlNumber(complete), baseCRLNumber(delta))
+	require.Greater(t, crlNumber(delta), crlNumber(complete))
+
+	// Issued certificates carry the same pointer.
+	resp, err := CBWrite(b, s, "issue/test", map[string]interface{}{
+		"common_name": "leaf.example.com",
+	})
+	require.NoError(t, err)
+	leaf := parseCert(t, resp.Data["certificate"].(string))
+	var foundFreshest bool
+	for _, ext := range leaf.Extensions {
+		foundFreshest = foundFreshest || ext.Id.Equal(asn1.ObjectIdentifier{2, 5, 29, 46})
+	}
+	require.True(t, foundFreshest, "expected Freshest CRL extension on issued certificate")
+
+	serial := resp.Data["serial_number"].(string)
+	_, err = CBWrite(b, s, "revoke", map[string]interface{}{
+		"serial_number": serial,
+	})
+	require.NoError(t, err)
+
+	// The revocation lands on the next delta CRL, not the complete one.
+	for time.Now().Before(delta.ThisUpdate.Add(2 * time.Second)) {
+		time.Sleep(100 * time.Millisecond)
+	}
+	require.NoError(t, b.periodicFunc(ctx, &logical.Request{Storage: s}))
+
+	require.Empty(t, fetchCRL("crl/pem").RevokedCertificates)
+	delta = fetchCRL("issuer/default/crl/delta/pem")
+	requireSerialNumberInCRL(t, delta, serial)
+	require.Equal(t, crlNumber(complete), baseCRLNumber(delta))
+
+	// Rotating the complete CRL picks the revocation up and clears the
+	// delta CRL again.
+	_, err = CBRead(b, s, "crl/rotate")
+	require.NoError(t, err)
+	complete = fetchCRL("crl/pem")
+	requireSerialNumberInCRL(t, complete, serial)
+	delta = fetchCRL("crl/delta/pem")
+	require.Empty(t, delta.RevokedCertificates)
+	require.Equal(t, crlNumber(complete), baseCRLNumber(delta))
+
+	walEntries, err := s.List(ctx, deltaWALPath)
+	require.NoError(t, err)
+	require.Empty(t, walEntries)
+
+	// Disabling deltas removes the stale delta CRL.
+	_, err = CBWrite(b, s, "config/crl", map[string]interface{}{
+		"enable_delta": false,
+	})
+	require.NoError(t, err)
+	resp, err = CBRead(b, s, "crl/delta")
+	require.NoError(t, err)
+	require.Equal(t, 204, resp.Data[logical.HTTPStatusCode])
+
+	_, err = CBRead(b, s, "crl/rotate-delta")
+	require.Error(t, err, "expected error rotating delta CRLs while disabled")
+}
@@ -20,7 +20,16 @@ import (
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
-const revokedPath = "revoked/"
+const (
+	revokedPath = "revoked/"
+
+	// Revocations not yet on a complete CRL are tracked here, so that delta
+	// CRLs need not list every revoked certificate.
+	deltaWALPath = "delta-wal/"
+
+	deltaCRLPath       = "delta-crl"
+	deltaCRLPathSuffix = "-delta"
+)
 
 type revocationInfo struct {
 	CertificateBytes  []byte    `json:"certificate_bytes"`
@@ -29,6 +38,10 @@ type revocationInfo struct {
 	CertificateIssuer issuerID  `json:"issuer_id"`
 }
 
+type deltaWALInfo struct {
+	Serial string `json:"serial"`
+}
+
 // crlBuilder is gatekeeper for controlling various read/write operations to the storage of the CRL.
 // The extra complexity arises from secondary performance clusters seeing various writes to its storage
 // without the actual API calls. During the storage invalidation process, we do not have the required state
@@ -37,6 +50,10 @@ type revocationInfo struct {
 type crlBuilder struct {
 	m            sync.Mutex
 	forceRebuild uint32
+
+	// Set when a revocation has been written to the delta WAL since the
+	// delta CRLs were last built.
+	pendingDeltaRevocations uint32
 }
 
 const (
@@ -87,6 +104,9 @@ func (cb *crlBuilder) _doRebuild(ctx context.Context, b *backend, request *logic
 		// the CRL, so we missed the update and cleared the flag.)
 		atomic.CompareAndSwapUint32(&cb.forceRebuild, 1, 0)
 
+		// Building the complete CRLs rebuilds the deltas as well.
+		atomic.StoreUint32(&cb.pendingDeltaRevocations, 0)
+
 		// if forceRebuild was requested, that should force a complete rebuild even if requested not too by forceNew
 		myForceNew := forceBuildFlag == 1 || forceNew
 		return buildCRLs(ctx, b, request, myForceNew)
@@ -95,6 +115,81 @@ func (cb *crlBuilder) _doRebuild(ctx context.Context, b *backend, request *logic
 	return nil
 }
 
+// rebuildIfScheduled is to be called by the periodic function; when
+// auto-rebuilding is enabled, it rebuilds the complete CRLs as they near
+// expiry and the delta CRLs once new revocations have waited out the
+// delta rebuild interval.
+func (cb *crlBuilder) rebuildIfScheduled(ctx context.Context, b *backend, request *logical.Request) error {
+	// Like requestRebuildIfActiveNode, only the active node can write the CRL.
+	if b.System().ReplicationState().HasState(consts.ReplicationPerformanceStandby) ||
+		b.System().ReplicationState().HasState(consts.ReplicationDRSecondary) {
+		return nil
+	}
+
+	// Delta CRLs and tracking CRL expiry both require the cluster-local CRL
+	// config, which isn't persisted until the migration has completed; the
+	// legacy CRL is still rebuilt on every revocation.
+	if b.useLegacyBundleCaStorage() {
+		return nil
+	}
+
+	config, err := b.CRL(ctx, request.Storage)
+	if err != nil {
+		return fmt.Errorf("error fetching CRL config information: %w", err)
+	}
+
+	if !config.AutoRebuild || config.Disable {
+		return nil
+	}
+
+	localCRLConfig, err := getLocalCRLConfig(ctx, request.Storage)
+	if err != nil {
+		return fmt.Errorf("error fetching cluster-local CRL configuration: %w", err)
+	}
+
+	gracePeriod, err := time.ParseDuration(config.AutoRebuildGracePeriod)
+	if err != nil {
+		return fmt.Errorf("error parsing CRL auto-rebuild grace period of %s: %w", config.AutoRebuildGracePeriod, err)
+	}
+
+	now := time.Now()
+	for _, crlIdentifier := range localCRLConfig.IssuerIDCRLMap {
+		expiry, ok := localCRLConfig.CRLExpirationMap[crlIdentifier]
+		if !ok || now.After(expiry.Add(-gracePeriod)) {
+			// Rebuilding the complete CRLs also rebuilds the deltas.
+			return cb.rebuild(ctx, b, request, false)
+		}
+	}
+
+	if !config.EnableDelta || atomic.LoadUint32(&cb.pendingDeltaRevocations) == 0 {
+		return nil
+	}
+
+	deltaRebuildInterval, err := time.ParseDuration(config.DeltaRebuildInterval)
+	if err != nil {
+		return fmt.Errorf("error parsing delta CRL rebuild interval of %s: %w", config.DeltaRebuildInterval, err)
+	}
+
+	if now.Before(localCRLConfig.DeltaLastModified.Add(deltaRebuildInterval)) {
+		return nil
+	}
+
+	return cb.rebuildDeltaCRLs(ctx, b, request, false)
+}
+
+// rebuildDeltaCRLs is to be called when only the delta CRLs need updating,
+// leaving the complete CRLs as they are.
+func (cb *crlBuilder) rebuildDeltaCRLs(ctx context.Context, b *backend, request *logical.Request, forceNew bool) error {
+	cb.m.Lock()
+	defer cb.m.Unlock()
+
+	// As with forceRebuild, clear the flag before building so that a
+	// revocation racing with us isn't missed.
+	atomic.StoreUint32(&cb.pendingDeltaRevocations, 0)
+
+	return buildDeltaCRLs(ctx, b, request, forceNew)
+}
+
 // Revokes a cert, and tries to be smart about error recovery
 func revokeCert(ctx context.Context, b *backend, req *logical.Request, serial string, fromLease bool) (*logical.Response, error) {
 	// As this backend is self-contained and this function does not hook into
@@ -223,16 +318,44 @@ func revokeCert(ctx context.Context, b *backend, req *logical.Request, serial st
 		if err != nil {
 			return nil, fmt.Errorf("error saving revoked certificate to new location")
 		}
+
+		err = markCertIndexEntryRevoked(ctx, req.Storage, serial, currTime)
+		if err != nil {
+			return nil, fmt.Errorf("error updating certificate index: %w", err)
+		}
 	}
 
-	crlErr := b.crlBuilder.rebuild(ctx, b, req, false)
-	if crlErr != nil {
-		switch crlErr.(type) {
-		case errutil.UserError:
-			return logical.ErrorResponse(fmt.Sprintf("Error during CRL building: %s", crlErr)), nil
-		default:
-			return nil, fmt.Errorf("error encountered during CRL building: %w", crlErr)
+	config, err := b.CRL(ctx, req.Storage)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching CRL config information: %w", err)
+	}
+
+	// When auto-rebuilding, the legacy CRL is still rebuilt inline; see
+	// rebuildIfScheduled.
+	if !config.AutoRebuild || b.useLegacyBundleCaStorage() {
+		crlErr := b.crlBuilder.rebuild(ctx, b, req, false)
+		if crlErr != nil {
+			switch crlErr.(type) {
+			case errutil.UserError:
+				return logical.ErrorResponse(fmt.Sprintf("Error during CRL building: %s", crlErr)), nil
+			default:
+				return nil, fmt.Errorf("error encountered during CRL building: %w", crlErr)
+			}
+		}
+	} else if config.EnableDelta && !alreadyRevoked {
+		// Record the revocation for the next delta CRL; the entry is
+		// removed once a complete CRL includes it.
+		walEntry, err := logical.StorageEntryJSON(deltaWALPath+normalizeSerial(serial), deltaWALInfo{Serial: serial})
+		if err != nil {
+			return nil, fmt.Errorf("error creating delta CRL WAL entry: %w", err)
 		}
+
+		err = req.Storage.Put(ctx, walEntry)
+		if err != nil {
+			return nil, fmt.Errorf("error saving delta CRL WAL entry: %w", err)
+		}
+
+		atomic.StoreUint32(&b.crlBuilder.pendingDeltaRevocations, 1)
 	}
 
 	resp := &logical.Response{
@@ -247,6 +370,47 @@ func revokeCert(ctx context.Context, b *backend, req *logical.Request, serial st
 }
 
 func buildCRLs(ctx context.Context, b *backend, req *logical.Request, forceNew bool) error {
+	// Any revocation presently in the delta WAL will be on the complete CRLs
+	// we're about to build; list them first, so that revocations landing
+	// while we build are kept around for the next delta CRL.
+	var walSerials []string
+	if !b.useLegacyBundleCaStorage() {
+		var err error
+		walSerials, err = req.Storage.List(ctx, deltaWALPath)
+		if err != nil {
+			return fmt.Errorf("error building CRLs: while listing delta WAL: %v", err)
+		}
+	}
+
+	if err := buildAnyCRLs(ctx, b, req, forceNew, false); err != nil {
+		return err
+	}
+
+	for _, serial := range walSerials {
+		if err := req.Storage.Delete(ctx, deltaWALPath+serial); err != nil {
+			return fmt.Errorf("error building CRLs: unable to clear delta WAL entry for serial %v: %v", serial, err)
+		}
+	}
+
+	// With the WAL cleared, the delta CRLs need rebuilding against the new
+	// complete CRLs.
+	return buildDeltaCRLs(ctx, b, req, forceNew)
+}
+
+func buildDeltaCRLs(ctx context.Context, b *backend, req *logical.Request, forceNew bool) error {
+	crlInfo, err := b.CRL(ctx, req.Storage)
+	if err != nil {
+		return fmt.Errorf("error building delta CRLs: while fetching CRL config: %v", err)
+	}
+
+	if !crlInfo.EnableDelta || crlInfo.Disable || b.useLegacyBundleCaStorage() {
+		return nil
+	}
+
+	return buildAnyCRLs(ctx, b, req, forceNew, true)
+}
+
+func buildAnyCRLs(ctx context.Context, b *backend, req *logical.Request, forceNew bool, isDelta bool) error {
 	// In order to build all CRLs, we need knowledge of all issuers. Any two
 	// issuers with the same keys _and_ subject should have the same CRL since
 	// they're functionally equivalent.
@@ -346,8 +510,9 @@ func buildCRLs(ctx context.Context, b *backend, req *logical.Request, forceNew b
 	// Next, we load and parse all revoked certificates. We need to assign
 	// these certificates to an issuer. Some certificates will not be
 	// assignable (if they were issued by a since-deleted issuer), so we need
-	// a separate pool for those.
-	unassignedCerts, revokedCertsMap, err := getRevokedCertEntries(ctx, req, issuerIDCertMap)
+	// a separate pool for those. Delta CRLs only need those revoked since the
+	// last complete CRL.
+	unassignedCerts, revokedCertsMap, err := getRevokedCertEntries(ctx, req, issuerIDCertMap, isDelta)
 	if err != nil {
 		return fmt.Errorf("error building CRLs: unable to get revoked certificate entries: %v", err)
 	}
@@ -387,6 +552,17 @@ func buildCRLs(ctx context.Context, b *backend, req *logical.Request, forceNew b
 				}
 			}
 
+			// A delta CRL can only be built against an existing complete
+			// CRL; new issuers get theirs on the next complete rebuild.
+			var lastCompleteNumber int64
+			if isDelta {
+				var haveComplete bool
+				lastCompleteNumber, haveComplete = crlConfig.LastCompleteNumberMap[crlIdentifier]
+				if len(crlIdentifier) == 0 || !haveComplete {
+					continue
+				}
+			}
+
 			if len(crlIdentifier) == 0 {
 				// Create a new random UUID for this CRL if none exists.
 				crlIdentifier = genCRLId()
@@ -399,17 +575,37 @@ func buildCRLs(ctx context.Context, b *backend, req *logical.Request, forceNew b
 			}
 
 			// We always update the CRL Number since we never want to
-			// duplicate numbers and missing numbers is fine.
+			// duplicate numbers and missing numbers is fine. Complete and
+			// delta CRLs share this sequence, per RFC 5280 Section 5.2.3.
 			crlNumber := crlConfig.CRLNumberMap[crlIdentifier]
 			crlConfig.CRLNumberMap[crlIdentifier] += 1
 
 			// Lastly, build the CRL.
-			if err := buildCRL(ctx, b, req, forceNew, representative, revokedCerts, crlIdentifier, crlNumber); err != nil {
+			nextUpdate, err := buildCRL(ctx, b, req, forceNew, representative, revokedCerts, crlIdentifier, crlNumber, isDelta, lastCompleteNumber)
+			if err != nil {
 				return fmt.Errorf("error building CRLs: unable to build CRL for issuer (%v): %v", representative, err)
 			}
+
+			if !isDelta {
+				crlConfig.LastCompleteNumberMap[crlIdentifier] = crlNumber
+				crlConfig.CRLExpirationMap[crlIdentifier] = nextUpdate
+			}
 		}
 	}
 
+	if isDelta {
+		crlConfig.DeltaLastModified = time.Now().UTC()
+
+		// Only the complete CRL build cleans up after deleted issuers.
+		if err := setLocalCRLConfig(ctx, req.Storage, crlConfig); err != nil {
+			return fmt.Errorf("error building delta CRLs: unable to persist updated cluster-local CRL config: %v", err)
+		}
+
+		return nil
+	}
+
+	crlConfig.LastModified = time.Now().UTC()
+
 	// Before persisting our updated CRL config, check to see if we have
 	// any dangling references. If we have any issuers that don't exist,
 	// remove them, remembering their CRLs IDs. If we've completely removed
@@ -447,6 +643,11 @@ func buildCRLs(ctx context.Context, b *backend, req *logical.Request, forceNew b
 			if err := req.Storage.Delete(ctx, "crls/"+crlId.String()); err != nil {
 				return fmt.Errorf("error building CRLs: unable to clean up deleted issuers' CRL: %v", err)
 			}
+			if err := req.Storage.Delete(ctx, "crls/"+crlId.String()+deltaCRLPathSuffix); err != nil {
+				return fmt.Errorf("error building CRLs: unable to clean up deleted issuers' delta CRL: %v", err)
+			}
+			delete(crlConfig.LastCompleteNumberMap, crlId)
+			delete(crlConfig.CRLExpirationMap, crlId)
 		}
 	}
 
@@ -462,11 +663,18 @@ func buildCRLs(ctx context.Context, b *backend, req *logical.Request, forceNew b
 	return nil
 }
 
-func getRevokedCertEntries(ctx context.Context, req *logical.Request, issuerIDCertMap map[issuerID]*x509.Certificate) ([]pkix.RevokedCertificate, map[issuerID][]pkix.RevokedCertificate, error) {
+func getRevokedCertEntries(ctx context.Context, req *logical.Request, issuerIDCertMap map[issuerID]*x509.Certificate, isDelta bool) ([]pkix.RevokedCertificate, map[issuerID][]pkix.RevokedCertificate, error) {
 	var unassignedCerts []pkix.RevokedCertificate
 	revokedCertsMap := make(map[issuerID][]pkix.RevokedCertificate)
 
-	revokedSerials, err := req.Storage.List(ctx, revokedPath)
+	// The delta WAL is keyed by the same (normalized) serial as the
+	// revoked certificate entries.
+	listPath := revokedPath
+	if isDelta {
+		listPath = deltaWALPath
+	}
+
+	revokedSerials, err := req.Storage.List(ctx, listPath)
 	if err != nil {
 		return nil, nil, errutil.InternalError{Err: fmt.Sprintf("error fetching list of revoked certs: %s", err)}
 	}
@@ -478,6 +686,11 @@ func getRevokedCertEntries(ctx context.Context, req *logical.Request, issuerIDCe
 			return nil, nil, errutil.InternalError{Err: fmt.Sprintf("unable to fetch revoked cert with serial %s: %s", serial, err)}
 		}
 		if revokedEntry == nil {
+			if isDelta {
+				// Tidied since it was revoked; it has expired, so there's
+				// nothing to add to the delta CRL.
+				continue
+			}
 			return nil, nil, errutil.InternalError{Err: fmt.Sprintf("revoked certificate entry for serial %s is nil", serial)}
 		}
 		if revokedEntry.Value == nil || len(revokedEntry.Value) == 0 {
@@ -523,17 +736,10 @@ func getRevokedCertEntries(ctx context.Context, req *logical.Request, issuerIDCe
 		}
 
 		// Now we need to assign the revoked certificate to an issuer.
-		foundParent := false
-		for issuerId, issuerCert := range issuerIDCertMap {
-			if bytes.Equal(revokedCert.RawIssuer, issuerCert.RawSubject) {
-				if err := revokedCert.CheckSignatureFrom(issuerCert); err == nil {
-					// Valid mapping. Add it to the specified entry.
-					revokedCertsMap[issuerId] = append(revokedCertsMap[issuerId], newRevCert)
-					revInfo.CertificateIssuer = issuerId
-					foundParent = true
-					break
-				}
-			}
+		foundParent := associateRevokedCertWithIssuer(&revInfo, revokedCert, issuerIDCertMap)
+		if foundParent {
+			// Valid mapping. Add it to the specified entry.
+			revokedCertsMap[revInfo.CertificateIssuer] = append(revokedCertsMap[revInfo.CertificateIssuer], newRevCert)
 		}
 
 		if !foundParent {
@@ -558,12 +764,58 @@ func getRevokedCertEntries(ctx context.Context, req *logical.Request, issuerIDCe
 	return unassignedCerts, revokedCertsMap, nil
 }
 
+// associateRevokedCertWithIssuer finds the issuer which signed the revoked
+// certificate, updating the revocation entry's CertificateIssuer. Returns
+// false when no such issuer exists.
+func associateRevokedCertWithIssuer(revInfo *revocationInfo, revokedCert *x509.Certificate, issuerIDCertMap map[issuerID]*x509.Certificate) bool {
+	for issuerId, issuerCert := range issuerIDCertMap {
+		if bytes.Equal(revokedCert.RawIssuer, issuerCert.RawSubject) {
+			if err := revokedCert.CheckSignatureFrom(issuerCert); err == nil {
+				revInfo.CertificateIssuer = issuerId
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// fetchIssuerMapForRevocationChecking maps the ID of every issuer to its
+// parsed certificate, for use with associateRevokedCertWithIssuer.
+func fetchIssuerMapForRevocationChecking(ctx context.Context, s logical.Storage) (map[issuerID]*x509.Certificate, error) {
+	issuers, err := listIssuers(ctx, s)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch issuers list: %w", err)
+	}
+
+	issuerIDCertMap := make(map[issuerID]*x509.Certificate, len(issuers))
+	for _, issuer := range issuers {
+		thisEntry, err := fetchIssuerById(ctx, s, issuer)
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch issuer %v: %w", issuer, err)
+		}
+
+		thisCert, err := thisEntry.GetCertificate()
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse issuer %v's certificate: %w", issuer, err)
+		}
+
+		issuerIDCertMap[issuer] = thisCert
+	}
+
+	return issuerIDCertMap, nil
+}
+
 // Builds a CRL by going through the list of revoked certificates and building
-// a new CRL with the stored revocation times and serial numbers.
-func buildCRL(ctx context.Context, b *backend, req *logical.Request, forceNew bool, thisIssuerId issuerID, revoked []pkix.RevokedCertificate, identifier crlID, crlNumber int64) error {
+// a new CRL with the stored revocation times and serial numbers. Delta CRLs
+// reference the number of the complete CRL they update. Returns the
+// NextUpdate time of the CRL written, if any.
+func buildCRL(ctx context.Context, b *backend, req *logical.Request, forceNew bool, thisIssuerId issuerID, revoked []pkix.RevokedCertificate, identifier crlID, crlNumber int64, isDelta bool, lastCompleteNumber int64) (time.Time, error) {
+	var nextUpdate time.Time
+
 	crlInfo, err := b.CRL(ctx, req.Storage)
 	if err != nil {
-		return errutil.InternalError{Err: fmt.Sprintf("error fetching CRL config information: %s", err)}
+		return nextUpdate, errutil.InternalError{Err: fmt.Sprintf("error fetching CRL config information: %s", err)}
 	}
 
 	crlLifetime := b.crlLifetime
@@ -572,14 +824,14 @@ func buildCRL(ctx context.Context, b *backend, req *logical.Request, forceNew bo
 	if crlInfo.Expiry != "" {
 		crlDur, err := time.ParseDuration(crlInfo.Expiry)
 		if err != nil {
-			return errutil.InternalError{Err: fmt.Sprintf("error parsing CRL duration of %s", crlInfo.Expiry)}
+			return nextUpdate, errutil.InternalError{Err: fmt.Sprintf("error parsing CRL duration of %s", crlInfo.Expiry)}
 		}
 		crlLifetime = crlDur
 	}
 
 	if crlInfo.Disable {
 		if !forceNew {
-			return nil
+			return nextUpdate, nil
 		}
 
 		// NOTE: in this case, the passed argument (revoked) is not added
@@ -598,22 +850,40 @@ WRITE:
 	if caErr != nil {
 		switch caErr.(type) {
 		case errutil.UserError:
-			return errutil.UserError{Err: fmt.Sprintf("could not fetch the CA certificate: %s", caErr)}
+			return nextUpdate, errutil.UserError{Err: fmt.Sprintf("could not fetch the CA certificate: %s", caErr)}
 		default:
-			return errutil.InternalError{Err: fmt.Sprintf("error fetching CA certificate: %s", caErr)}
+			return nextUpdate, errutil.InternalError{Err: fmt.Sprintf("error fetching CA certificate: %s", caErr)}
+		}
+	}
+
+	var extensions []pkix.Extension
+	if isDelta {
+		ext, err := certutil.CreateDeltaCRLIndicatorExt(lastCompleteNumber)
+		if err != nil {
+			return nextUpdate, errutil.InternalError{Err: fmt.Sprintf("could not create delta CRL indicator extension: %s", err)}
+		}
+		extensions = append(extensions, ext)
+	} else if crlInfo.EnableDelta && signingBundle.URLs != nil && len(signingBundle.URLs.DeltaCRLDistributionPoints) > 0 {
+		ext, err := certutil.CreateFreshestCRLExt(signingBundle.URLs.DeltaCRLDistributionPoints)
+		if err != nil {
+			return nextUpdate, errutil.InternalError{Err: fmt.Sprintf("could not create freshest CRL extension: %s", err)}
 		}
+		extensions = append(extensions, ext)
 	}
 
+	now := time.Now()
+	nextUpdate = now.Add(crlLifetime)
 	revocationListTemplate := &x509.RevocationList{
 		RevokedCertificates: revokedCerts,
 		Number:              big.NewInt(crlNumber),
-		ThisUpdate:          time.Now(),
-		NextUpdate:          time.Now().Add(crlLifetime),
+		ThisUpdate:          now,
+		NextUpdate:          nextUpdate,
+		ExtraExtensions:     extensions,
 	}
 
 	crlBytes, err := x509.CreateRevocationList(rand.Reader, revocationListTemplate, signingBundle.Certificate, signingBundle.PrivateKey)
 	if err != nil {
-		return errutil.InternalError{Err: fmt.Sprintf("error creating new CRL: %s", err)}
+		return nextUpdate, errutil.InternalError{Err: fmt.Sprintf("error creating new CRL: %s", err)}
 	}
 
 	writePath := "crls/" + identifier.String()
@@ -622,14 +892,25 @@ WRITE:
 		// old legacy path and allow it to be updated.
 		writePath = legacyCRLPath
 	}
+	if isDelta {
+		writePath += deltaCRLPathSuffix
+	}
 
 	err = req.Storage.Put(ctx, &logical.StorageEntry{
 		Key:   writePath,
 		Value: crlBytes,
 	})
 	if err != nil {
-		return errutil.InternalError{Err: fmt.Sprintf("error storing CRL: %s", err)}
+		return nextUpdate, errutil.InternalError{Err: fmt.Sprintf("error storing CRL: %s", err)}
 	}
 
-	return nil
+	// Don't leave a stale delta CRL around once deltas are no longer built
+	// against this complete CRL.
+	if !isDelta && thisIssuerId != legacyBundleShimID && (!crlInfo.EnableDelta || crlInfo.Disable) {
+		if err := req.Storage.Delete(ctx, writePath+deltaCRLPathSuffix); err != nil {
+			return nextUpdate, errutil.InternalError{Err: fmt.Sprintf("error removing stale delta CRL: %s", err)}
+		}
+	}
+
+	return nextUpdate, nil
 }
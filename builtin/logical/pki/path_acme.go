@@ -0,0 +1,325 @@
+package pki
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+/*
+ * The ACME (RFC 8555) server is exposed beneath a role, optionally pinned
+ * to an issuer:
+ *
+ *   roles/:role/acme/...                     uses the role's issuer_ref
+ *   issuer/:issuer_ref/roles/:role/acme/...  uses the issuer in the path
+ *
+ * These paths are unauthenticated: requests are instead authenticated by the
+ * JWS signature of an ACME account, and certificates are only issued for
+ * identifiers the account has proven control over via http-01 or dns-01
+ * challenges and which the role's policy allows.
+ */
+
+const (
+	acmeDirectoryPathSuffix = "/acme/"
+
+	acmeJSONContentType = "application/json"
+	acmePEMChainType    = "application/pem-certificate-chain"
+)
+
+// acmeContext holds the request-scoped configuration of the ACME directory
+// being accessed.
+type acmeContext struct {
+	// baseURL is the absolute URL of this directory, ending in "/acme/".
+	baseURL string
+	// pathPrefix is the mount-relative equivalent of baseURL.
+	pathPrefix string
+
+	config    *acmeConfigEntry
+	roleName  string
+	role      *roleEntry
+	issuerRef string
+}
+
+func (ac *acmeContext) requestURL(req *logical.Request) string {
+	return ac.baseURL + strings.TrimPrefix(req.Path, ac.pathPrefix)
+}
+
+func (ac *acmeContext) accountURL(accountId string) string {
+	return ac.baseURL + "account/" + accountId
+}
+
+func (ac *acmeContext) orderURL(orderId string) string {
+	return ac.baseURL + "order/" + orderId
+}
+
+func (ac *acmeContext) authorizationURL(authzId string) string {
+	return ac.baseURL + "authorization/" + authzId
+}
+
+func (ac *acmeContext) challengeURL(authzId string, challengeType string) string {
+	return ac.baseURL + "challenge/" + authzId + "/" + challengeType
+}
+
+type acmeOperation func(ctx context.Context, req *logical.Request, data *framework.FieldData, ac *acmeContext) (*logical.Response, error)
+
+func pathsAcme(b *backend) []*framework.Path {
+	var paths []*framework.Path
+	for _, prefix := range []string{
+		"roles/" + framework.GenericNameRegex("role") + acmeDirectoryPathSuffix,
+		"issuer/" + framework.GenericNameRegex(issuerRefParam) + "/roles/" + framework.GenericNameRegex("role") + acmeDirectoryPathSuffix,
+	} {
+		paths = append(paths,
+			pathAcmeDirectory(b, prefix),
+			pathAcmeNewNonce(b, prefix),
+			pathAcmeNewAccount(b, prefix),
+			pathAcmeAccount(b, prefix),
+			pathAcmeAccountOrders(b, prefix),
+			pathAcmeNewOrder(b, prefix),
+			pathAcmeOrder(b, prefix),
+			pathAcmeOrderFinalize(b, prefix),
+			pathAcmeOrderCert(b, prefix),
+			pathAcmeAuthorization(b, prefix),
+			pathAcmeChallenge(b, prefix),
+		)
+	}
+	return paths
+}
+
+func addAcmeFields(fields map[string]*framework.FieldSchema, prefix string) map[string]*framework.FieldSchema {
+	fields["role"] = &framework.FieldSchema{
+		Type:        framework.TypeString,
+		Description: `The role whose policy governs certificates issued through this ACME directory.`,
+	}
+
+	if strings.HasPrefix(prefix, "issuer/") {
+		fields = addIssuerRefField(fields)
+	}
+
+	return fields
+}
+
+func addAcmeJWSFields(fields map[string]*framework.FieldSchema, prefix string) map[string]*framework.FieldSchema {
+	fields = addAcmeFields(fields, prefix)
+
+	fields["protected"] = &framework.FieldSchema{
+		Type:        framework.TypeString,
+		Description: `The base64url-encoded JWS protected header.`,
+	}
+	fields["payload"] = &framework.FieldSchema{
+		Type:        framework.TypeString,
+		Description: `The base64url-encoded JWS payload.`,
+	}
+	fields["signature"] = &framework.FieldSchema{
+		Type:        framework.TypeString,
+		Description: `The base64url-encoded JWS signature.`,
+	}
+
+	return fields
+}
+
+func buildAcmeJWSPath(b *backend, pattern string, prefix string, fields map[string]*framework.FieldSchema, op acmeOperation, synopsis string) *framework.Path {
+	return &framework.Path{
+		Pattern: pattern,
+		Fields:  addAcmeJWSFields(fields, prefix),
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback:                  b.acmeWrapper(op),
+				ForwardPerformanceStandby: true,
+			},
+		},
+
+		HelpSynopsis:    synopsis,
+		HelpDescription: pathAcmeHelpDesc,
+	}
+}
+
+// acmeWrapper resolves the ACME directory being accessed and renders any
+// error returned by the operation as an ACME problem document.
+func (b *backend) acmeWrapper(op acmeOperation) framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+		config, err := getAcmeConfig(ctx, req.Storage)
+		if err != nil {
+			return nil, err
+		}
+		if !config.Enabled {
+			return logical.ErrorResponse("ACME is disabled on this mount"), nil
+		}
+		if b.useLegacyBundleCaStorage() {
+			return logical.ErrorResponse("ACME can not be used until migration has completed"), nil
+		}
+
+		ac, err := b.loadAcmeContext(ctx, req, data, config)
+		var resp *logical.Response
+		if err == nil {
+			resp, err = op(ctx, req, data, ac)
+		}
+		if err != nil {
+			resp, err = b.acmeErrorResponse(err)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if ac != nil {
+			if resp.Headers == nil {
+				resp.Headers = map[string][]string{}
+			}
+			resp.Headers["Link"] = append(resp.Headers["Link"], fmt.Sprintf("<%sdirectory>;rel=\"index\"", ac.baseURL))
+		}
+
+		return resp, nil
+	}
+}
+
+func (b *backend) loadAcmeContext(ctx context.Context, req *logical.Request, data *framework.FieldData, config *acmeConfigEntry) (*acmeContext, error) {
+	index := strings.Index(req.Path, acmeDirectoryPathSuffix)
+	if index < 0 {
+		return nil, fmt.Errorf("unexpected ACME request path: %v", req.Path)
+	}
+	pathPrefix := req.Path[:index+len(acmeDirectoryPathSuffix)]
+
+	roleName := data.Get("role").(string)
+	role, err := b.getRole(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, newAcmeError(acmeProblemMalformed, "unknown role: %v", roleName)
+	}
+
+	// Mirror the issuer selection of the sign/:role and
+	// issuer/:issuer_ref/sign/:role paths.
+	var issuerRef string
+	if _, ok := data.Schema[issuerRefParam]; ok {
+		issuerRef = getIssuerRef(data)
+	} else {
+		issuerRef = role.Issuer
+	}
+	if len(issuerRef) == 0 {
+		issuerRef = defaultRef
+	}
+
+	return &acmeContext{
+		baseURL:    config.BaseURL + "/" + pathPrefix,
+		pathPrefix: pathPrefix,
+		config:     config,
+		roleName:   roleName,
+		role:       role,
+		issuerRef:  issuerRef,
+	}, nil
+}
+
+// acmeRawResponse builds a raw HTTP response, attaching a fresh nonce as
+// every ACME response must carry one; see RFC 8555 Section 6.5.
+func (b *backend) acmeRawResponse(status int, contentType string, body []byte, headers map[string][]string) *logical.Response {
+	if headers == nil {
+		headers = map[string][]string{}
+	}
+
+	nonce, err := b.acmeState.getNonce()
+	if err != nil {
+		b.Logger().Error("failed to generate ACME nonce", "error", err)
+	} else {
+		headers["Replay-Nonce"] = []string{nonce}
+	}
+
+	data := map[string]interface{}{
+		logical.HTTPStatusCode: status,
+	}
+	if len(contentType) > 0 {
+		data[logical.HTTPContentType] = contentType
+		data[logical.HTTPRawBody] = body
+	}
+
+	return &logical.Response{
+		Data:    data,
+		Headers: headers,
+	}
+}
+
+func (b *backend) acmeJSONResponse(status int, body interface{}, headers map[string][]string) (*logical.Response, error) {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ACME response: %w", err)
+	}
+
+	return b.acmeRawResponse(status, acmeJSONContentType, encoded, headers), nil
+}
+
+func pathAcmeDirectory(b *backend, prefix string) *framework.Path {
+	return &framework.Path{
+		Pattern: prefix + "directory",
+		Fields:  addAcmeFields(map[string]*framework.FieldSchema{}, prefix),
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.acmeWrapper(b.acmeDirectoryHandler),
+			},
+		},
+
+		HelpSynopsis:    pathAcmeDirectoryHelpSyn,
+		HelpDescription: pathAcmeHelpDesc,
+	}
+}
+
+func (b *backend) acmeDirectoryHandler(_ context.Context, _ *logical.Request, _ *framework.FieldData, ac *acmeContext) (*logical.Response, error) {
+	return b.acmeJSONResponse(http.StatusOK, map[string]interface{}{
+		"newNonce":   ac.baseURL + "new-nonce",
+		"newAccount": ac.baseURL + "new-account",
+		"newOrder":   ac.baseURL + "new-order",
+		"meta": map[string]interface{}{
+			"externalAccountRequired": false,
+		},
+	}, nil)
+}
+
+func pathAcmeNewNonce(b *backend, prefix string) *framework.Path {
+	return &framework.Path{
+		Pattern: prefix + "new-nonce",
+		Fields:  addAcmeFields(map[string]*framework.FieldSchema{}, prefix),
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.acmeWrapper(b.acmeNewNonceHandler),
+			},
+			logical.HeaderOperation: &framework.PathOperation{
+				Callback: b.acmeWrapper(b.acmeNewNonceHandler),
+			},
+		},
+
+		HelpSynopsis:    pathAcmeNewNonceHelpSyn,
+		HelpDescription: pathAcmeHelpDesc,
+	}
+}
+
+func (b *backend) acmeNewNonceHandler(_ context.Context, req *logical.Request, _ *framework.FieldData, _ *acmeContext) (*logical.Response, error) {
+	// Per RFC 8555 Section 7.2, HEAD requests receive a 200 and GET requests
+	// a 204; both carry the nonce in the Replay-Nonce header.
+	if req.Operation == logical.HeaderOperation {
+		// The raw response handler requires a content type on non-204
+		// responses, even though the body of a HEAD response is dropped.
+		return b.acmeRawResponse(http.StatusOK, acmeJSONContentType, nil, nil), nil
+	}
+
+	return b.acmeRawResponse(http.StatusNoContent, "", nil, nil), nil
+}
+
+const (
+	pathAcmeDirectoryHelpSyn = `Read the ACME directory of this role.`
+	pathAcmeNewNonceHelpSyn  = `Fetch a fresh ACME anti-replay nonce.`
+	pathAcmeHelpDesc         = `
+These endpoints implement an RFC 8555 ACME server bound to a role and,
+optionally, an issuer. They are unauthenticated: ACME clients authenticate
+with their account key, and certificates are only issued for identifiers
+permitted by the role whose control has been proven through an http-01 or
+dns-01 challenge.
+
+ACME must first be enabled with config/acme.
+`
+)
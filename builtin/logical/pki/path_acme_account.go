@@ -0,0 +1,190 @@
+package pki
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func pathAcmeNewAccount(b *backend, prefix string) *framework.Path {
+	return buildAcmeJWSPath(b, prefix+"new-account", prefix, map[string]*framework.FieldSchema{},
+		b.acmeNewAccountHandler, pathAcmeNewAccountHelpSyn)
+}
+
+func pathAcmeAccount(b *backend, prefix string) *framework.Path {
+	return buildAcmeJWSPath(b, prefix+"account/"+framework.GenericNameRegex("kid"), prefix, map[string]*framework.FieldSchema{
+		"kid": {
+			Type:        framework.TypeString,
+			Description: `The ID of the ACME account.`,
+		},
+	}, b.acmeAccountHandler, pathAcmeAccountHelpSyn)
+}
+
+func pathAcmeAccountOrders(b *backend, prefix string) *framework.Path {
+	return buildAcmeJWSPath(b, prefix+"account/"+framework.GenericNameRegex("kid")+"/orders", prefix, map[string]*framework.FieldSchema{
+		"kid": {
+			Type:        framework.TypeString,
+			Description: `The ID of the ACME account.`,
+		},
+	}, b.acmeAccountOrdersHandler, pathAcmeAccountOrdersHelpSyn)
+}
+
+func (ac *acmeContext) formatAccount(account *acmeAccount) map[string]interface{} {
+	contact := account.Contact
+	if contact == nil {
+		contact = []string{}
+	}
+
+	return map[string]interface{}{
+		"status":               account.Status,
+		"contact":              contact,
+		"termsOfServiceAgreed": account.TermsOfServiceAgreed,
+		"orders":               ac.accountURL(account.ID) + "/orders",
+	}
+}
+
+func (b *backend) acmeNewAccountHandler(ctx context.Context, req *logical.Request, data *framework.FieldData, ac *acmeContext) (*logical.Response, error) {
+	jws, err := b.parseAcmeJWS(ctx, req, data, ac, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload struct {
+		Contact              []string `json:"contact"`
+		TermsOfServiceAgreed bool     `json:"termsOfServiceAgreed"`
+		OnlyReturnExisting   bool     `json:"onlyReturnExisting"`
+	}
+	if err := jws.decodePayload(&payload); err != nil {
+		return nil, err
+	}
+
+	thumbprint, err := acmeJWKThumbprint(jws.Jwk)
+	if err != nil {
+		return nil, err
+	}
+
+	b.acmeAccountLock.Lock()
+	defer b.acmeAccountLock.Unlock()
+
+	// Per RFC 8555 Section 7.3.1, a request for a key which already has an
+	// account returns that account rather than creating a new one.
+	existing, err := loadAcmeAccountByThumbprint(ctx, req.Storage, thumbprint)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		headers := map[string][]string{"Location": {ac.accountURL(existing.ID)}}
+		return b.acmeJSONResponse(http.StatusOK, ac.formatAccount(existing), headers)
+	}
+	if payload.OnlyReturnExisting {
+		return nil, newAcmeError(acmeProblemAccountDoesNotExist, "no account exists for the given key")
+	}
+
+	jwk, err := jws.Jwk.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	account := &acmeAccount{
+		ID:                   genUuid(),
+		Status:               acmeStatusValid,
+		Contact:              payload.Contact,
+		TermsOfServiceAgreed: payload.TermsOfServiceAgreed,
+		Jwk:                  jwk,
+		Thumbprint:           thumbprint,
+		CreatedDate:          time.Now(),
+	}
+	if err := saveAcmeAccount(ctx, req.Storage, account); err != nil {
+		return nil, err
+	}
+
+	headers := map[string][]string{"Location": {ac.accountURL(account.ID)}}
+	return b.acmeJSONResponse(http.StatusCreated, ac.formatAccount(account), headers)
+}
+
+// loadAcmeAccountFromPath verifies the request's JWS and ensures it was
+// signed by the account named in the path.
+func (b *backend) loadAcmeAccountFromPath(ctx context.Context, req *logical.Request, data *framework.FieldData, ac *acmeContext) (*acmeJWS, error) {
+	jws, err := b.parseAcmeJWS(ctx, req, data, ac, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if jws.Account.ID != data.Get("kid").(string) {
+		return nil, newAcmeError(acmeProblemUnauthorized, "request was not signed by the requested account")
+	}
+
+	return jws, nil
+}
+
+func (b *backend) acmeAccountHandler(ctx context.Context, req *logical.Request, data *framework.FieldData, ac *acmeContext) (*logical.Response, error) {
+	jws, err := b.loadAcmeAccountFromPath(ctx, req, data, ac)
+	if err != nil {
+		return nil, err
+	}
+	account := jws.Account
+
+	if !jws.isPostAsGet() {
+		var payload struct {
+			Contact              []string `json:"contact"`
+			TermsOfServiceAgreed *bool    `json:"termsOfServiceAgreed"`
+			Status               string   `json:"status"`
+		}
+		if err := jws.decodePayload(&payload); err != nil {
+			return nil, err
+		}
+
+		switch payload.Status {
+		case "":
+		case acmeStatusDeactivated:
+			account.Status = acmeStatusDeactivated
+		default:
+			return nil, newAcmeError(acmeProblemMalformed, "account status may only be updated to %v", acmeStatusDeactivated)
+		}
+		if payload.Contact != nil {
+			account.Contact = payload.Contact
+		}
+		if payload.TermsOfServiceAgreed != nil {
+			account.TermsOfServiceAgreed = *payload.TermsOfServiceAgreed
+		}
+
+		b.acmeAccountLock.Lock()
+		err = saveAcmeAccount(ctx, req.Storage, account)
+		b.acmeAccountLock.Unlock()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return b.acmeJSONResponse(http.StatusOK, ac.formatAccount(account), nil)
+}
+
+func (b *backend) acmeAccountOrdersHandler(ctx context.Context, req *logical.Request, data *framework.FieldData, ac *acmeContext) (*logical.Response, error) {
+	jws, err := b.loadAcmeAccountFromPath(ctx, req, data, ac)
+	if err != nil {
+		return nil, err
+	}
+
+	orderIds, err := listAcmeOrders(ctx, req.Storage, jws.Account.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	orders := []string{}
+	for _, orderId := range orderIds {
+		orders = append(orders, ac.orderURL(orderId))
+	}
+
+	return b.acmeJSONResponse(http.StatusOK, map[string]interface{}{
+		"orders": orders,
+	}, nil)
+}
+
+const (
+	pathAcmeNewAccountHelpSyn    = `Create or look up an ACME account.`
+	pathAcmeAccountHelpSyn       = `Read or update an ACME account.`
+	pathAcmeAccountOrdersHelpSyn = `List the orders of an ACME account.`
+)
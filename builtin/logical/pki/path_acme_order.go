@@ -0,0 +1,580 @@
+package pki
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func pathAcmeNewOrder(b *backend, prefix string) *framework.Path {
+	return buildAcmeJWSPath(b, prefix+"new-order", prefix, map[string]*framework.FieldSchema{},
+		b.acmeNewOrderHandler, pathAcmeNewOrderHelpSyn)
+}
+
+func addAcmeOrderIdField(fields map[string]*framework.FieldSchema) map[string]*framework.FieldSchema {
+	fields["order_id"] = &framework.FieldSchema{
+		Type:        framework.TypeString,
+		Description: `The ID of the ACME order.`,
+	}
+	return fields
+}
+
+func pathAcmeOrder(b *backend, prefix string) *framework.Path {
+	return buildAcmeJWSPath(b, prefix+"order/"+framework.GenericNameRegex("order_id"), prefix,
+		addAcmeOrderIdField(map[string]*framework.FieldSchema{}), b.acmeOrderHandler, pathAcmeOrderHelpSyn)
+}
+
+func pathAcmeOrderFinalize(b *backend, prefix string) *framework.Path {
+	return buildAcmeJWSPath(b, prefix+"order/"+framework.GenericNameRegex("order_id")+"/finalize", prefix,
+		addAcmeOrderIdField(map[string]*framework.FieldSchema{}), b.acmeOrderFinalizeHandler, pathAcmeOrderFinalizeHelpSyn)
+}
+
+func pathAcmeOrderCert(b *backend, prefix string) *framework.Path {
+	return buildAcmeJWSPath(b, prefix+"order/"+framework.GenericNameRegex("order_id")+"/cert", prefix,
+		addAcmeOrderIdField(map[string]*framework.FieldSchema{}), b.acmeOrderCertHandler, pathAcmeOrderCertHelpSyn)
+}
+
+func pathAcmeAuthorization(b *backend, prefix string) *framework.Path {
+	return buildAcmeJWSPath(b, prefix+"authorization/"+framework.GenericNameRegex("auth_id"), prefix, map[string]*framework.FieldSchema{
+		"auth_id": {
+			Type:        framework.TypeString,
+			Description: `The ID of the ACME authorization.`,
+		},
+	}, b.acmeAuthorizationHandler, pathAcmeAuthorizationHelpSyn)
+}
+
+func pathAcmeChallenge(b *backend, prefix string) *framework.Path {
+	return buildAcmeJWSPath(b, prefix+"challenge/"+framework.GenericNameRegex("auth_id")+"/"+framework.GenericNameRegex("challenge_type"), prefix, map[string]*framework.FieldSchema{
+		"auth_id": {
+			Type:        framework.TypeString,
+			Description: `The ID of the ACME authorization.`,
+		},
+		"challenge_type": {
+			Type:        framework.TypeString,
+			Description: `The type of challenge to respond to: http-01 or dns-01.`,
+		},
+	}, b.acmeChallengeHandler, pathAcmeChallengeHelpSyn)
+}
+
+func acmeRandomToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate challenge token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func (ac *acmeContext) formatOrder(order *acmeOrder) map[string]interface{} {
+	authorizations := []string{}
+	for _, authzId := range order.AuthorizationIDs {
+		authorizations = append(authorizations, ac.authorizationURL(authzId))
+	}
+
+	result := map[string]interface{}{
+		"status":         order.Status,
+		"expires":        order.Expires.Format(time.RFC3339),
+		"identifiers":    order.Identifiers,
+		"authorizations": authorizations,
+		"finalize":       ac.orderURL(order.ID) + "/finalize",
+	}
+	if order.Status == acmeStatusValid {
+		result["certificate"] = ac.orderURL(order.ID) + "/cert"
+	}
+	return result
+}
+
+func (ac *acmeContext) formatChallenge(authz *acmeAuthorization, challenge *acmeChallenge) map[string]interface{} {
+	result := map[string]interface{}{
+		"type":   challenge.Type,
+		"url":    ac.challengeURL(authz.ID, challenge.Type),
+		"token":  challenge.Token,
+		"status": challenge.Status,
+	}
+	if !challenge.Validated.IsZero() {
+		result["validated"] = challenge.Validated.Format(time.RFC3339)
+	}
+	if challenge.Error != nil {
+		result["error"] = challenge.Error
+	}
+	return result
+}
+
+func (ac *acmeContext) formatAuthorization(authz *acmeAuthorization) map[string]interface{} {
+	challenges := []map[string]interface{}{}
+	for _, challenge := range authz.Challenges {
+		challenges = append(challenges, ac.formatChallenge(authz, challenge))
+	}
+
+	result := map[string]interface{}{
+		"identifier": authz.Identifier,
+		"status":     authz.Status,
+		"expires":    authz.Expires.Format(time.RFC3339),
+		"challenges": challenges,
+	}
+	if authz.Wildcard {
+		result["wildcard"] = true
+	}
+	return result
+}
+
+// validateAcmeIdentifier checks the requested identifier against the role's
+// policy, returning it in normalized form.
+func (b *backend) validateAcmeIdentifier(req *logical.Request, ac *acmeContext, identifier acmeIdentifier) (acmeIdentifier, error) {
+	switch identifier.Type {
+	case "dns":
+		identifier.Value = strings.ToLower(strings.TrimSuffix(identifier.Value, "."))
+		if len(identifier.Value) == 0 || net.ParseIP(identifier.Value) != nil {
+			return identifier, newAcmeError(acmeProblemRejectedIdentifier, "invalid dns identifier: %q", identifier.Value)
+		}
+		if badName := validateNames(b, &inputBundle{role: ac.role, req: req}, []string{identifier.Value}); len(badName) != 0 {
+			return identifier, newAcmeError(acmeProblemRejectedIdentifier, "identifier %v not allowed by this role", badName)
+		}
+	case "ip":
+		ip := net.ParseIP(identifier.Value)
+		if ip == nil {
+			return identifier, newAcmeError(acmeProblemRejectedIdentifier, "invalid ip identifier: %q", identifier.Value)
+		}
+		if !ac.role.AllowIPSANs {
+			return identifier, newAcmeError(acmeProblemRejectedIdentifier, "IP identifiers are not allowed by this role")
+		}
+		identifier.Value = ip.String()
+	default:
+		return identifier, newAcmeError(acmeProblemUnsupportedIdentifier, "unsupported identifier type: %q", identifier.Type)
+	}
+
+	return identifier, nil
+}
+
+func (b *backend) acmeNewOrderHandler(ctx context.Context, req *logical.Request, data *framework.FieldData, ac *acmeContext) (*logical.Response, error) {
+	jws, err := b.parseAcmeJWS(ctx, req, data, ac, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload struct {
+		Identifiers []acmeIdentifier `json:"identifiers"`
+		NotBefore   string           `json:"notBefore"`
+		NotAfter    string           `json:"notAfter"`
+	}
+	if err := jws.decodePayload(&payload); err != nil {
+		return nil, err
+	}
+	if len(payload.Identifiers) == 0 {
+		return nil, newAcmeError(acmeProblemMalformed, "order must contain at least one identifier")
+	}
+	if len(payload.NotBefore) > 0 || len(payload.NotAfter) > 0 {
+		return nil, newAcmeError(acmeProblemMalformed, "notBefore and notAfter are not supported; validity is governed by the role")
+	}
+
+	now := time.Now()
+	order := &acmeOrder{
+		ID:        genUuid(),
+		AccountID: jws.Account.ID,
+		Status:    acmeStatusPending,
+		Expires:   now.Add(acmeOrderLifetime),
+		RoleName:  ac.roleName,
+		IssuerRef: ac.issuerRef,
+	}
+
+	seen := map[acmeIdentifier]bool{}
+	var authorizations []*acmeAuthorization
+	for _, requested := range payload.Identifiers {
+		identifier, err := b.validateAcmeIdentifier(req, ac, requested)
+		if err != nil {
+			return nil, err
+		}
+		if seen[identifier] {
+			continue
+		}
+		seen[identifier] = true
+		order.Identifiers = append(order.Identifiers, identifier)
+
+		// Per RFC 8555 Section 7.1.3, the authorization for a wildcard is
+		// for the base domain, and may only be satisfied via dns-01.
+		authz := &acmeAuthorization{
+			ID:         genUuid(),
+			AccountID:  jws.Account.ID,
+			Identifier: identifier,
+			Status:     acmeStatusPending,
+			Expires:    now.Add(acmeAuthorizationLifetime),
+		}
+		var challengeTypes []string
+		switch {
+		case identifier.Type == "ip":
+			challengeTypes = []string{acmeChallengeHTTP01}
+		case strings.HasPrefix(identifier.Value, "*."):
+			authz.Identifier.Value = strings.TrimPrefix(identifier.Value, "*.")
+			authz.Wildcard = true
+			challengeTypes = []string{acmeChallengeDNS01}
+		default:
+			challengeTypes = []string{acmeChallengeHTTP01, acmeChallengeDNS01}
+		}
+
+		// Both challenges of an authorization share a token, as either
+		// proves control of the identifier.
+		token, err := acmeRandomToken()
+		if err != nil {
+			return nil, err
+		}
+		for _, challengeType := range challengeTypes {
+			authz.Challenges = append(authz.Challenges, &acmeChallenge{
+				Type:   challengeType,
+				Token:  token,
+				Status: acmeStatusPending,
+			})
+		}
+
+		authorizations = append(authorizations, authz)
+		order.AuthorizationIDs = append(order.AuthorizationIDs, authz.ID)
+	}
+
+	for _, authz := range authorizations {
+		if err := saveAcmeAuthorization(ctx, req.Storage, authz); err != nil {
+			return nil, err
+		}
+	}
+	if err := saveAcmeOrder(ctx, req.Storage, order); err != nil {
+		return nil, err
+	}
+
+	headers := map[string][]string{"Location": {ac.orderURL(order.ID)}}
+	return b.acmeJSONResponse(http.StatusCreated, ac.formatOrder(order), headers)
+}
+
+// loadAcmeOrderForRequest verifies the request's JWS and loads the order in
+// the path, refreshing its status.
+func (b *backend) loadAcmeOrderForRequest(ctx context.Context, req *logical.Request, data *framework.FieldData, ac *acmeContext) (*acmeJWS, *acmeOrder, error) {
+	jws, err := b.parseAcmeJWS(ctx, req, data, ac, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	orderId := data.Get("order_id").(string)
+	order, err := loadAcmeOrder(ctx, req.Storage, jws.Account.ID, orderId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if order == nil {
+		return nil, nil, newAcmeError(acmeProblemMalformed, "order %v does not exist", orderId)
+	}
+	if order.RoleName != ac.roleName || order.IssuerRef != ac.issuerRef {
+		return nil, nil, newAcmeError(acmeProblemUnauthorized, "order %v was not created through this directory", orderId)
+	}
+
+	previous := order.Status
+	if err := refreshOrderStatus(ctx, req.Storage, order); err != nil {
+		return nil, nil, err
+	}
+	if order.Status != previous {
+		if err := saveAcmeOrder(ctx, req.Storage, order); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return jws, order, nil
+}
+
+func (b *backend) acmeOrderHandler(ctx context.Context, req *logical.Request, data *framework.FieldData, ac *acmeContext) (*logical.Response, error) {
+	_, order, err := b.loadAcmeOrderForRequest(ctx, req, data, ac)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.acmeJSONResponse(http.StatusOK, ac.formatOrder(order), nil)
+}
+
+// checkAcmeCSRIdentifiers ensures the CSR requests exactly the identifiers
+// of the order, and nothing else.
+func checkAcmeCSRIdentifiers(csr *x509.CertificateRequest, order *acmeOrder) error {
+	if len(csr.EmailAddresses) > 0 || len(csr.URIs) > 0 {
+		return newAcmeError(acmeProblemBadCSR, "CSR may only contain DNS and IP subject alternative names")
+	}
+
+	var requested []string
+	for _, name := range csr.DNSNames {
+		requested = append(requested, "dns:"+strings.ToLower(strings.TrimSuffix(name, ".")))
+	}
+	for _, ip := range csr.IPAddresses {
+		requested = append(requested, "ip:"+ip.String())
+	}
+
+	orderNames := map[string]bool{}
+	for _, identifier := range order.Identifiers {
+		orderNames[identifier.Type+":"+identifier.Value] = true
+	}
+
+	if cn := csr.Subject.CommonName; len(cn) > 0 {
+		cnName := "dns:" + strings.ToLower(strings.TrimSuffix(cn, "."))
+		if ip := net.ParseIP(cn); ip != nil {
+			cnName = "ip:" + ip.String()
+		}
+		if !orderNames[cnName] {
+			return newAcmeError(acmeProblemBadCSR, "CSR common name %v is not an identifier of the order", cn)
+		}
+		if !strings.HasPrefix(cnName, "ip:") {
+			requested = append(requested, cnName)
+		}
+	}
+
+	requestedNames := map[string]bool{}
+	for _, name := range requested {
+		if !orderNames[name] {
+			return newAcmeError(acmeProblemBadCSR, "CSR requests %v which is not an identifier of the order", name)
+		}
+		requestedNames[name] = true
+	}
+
+	var missing []string
+	for name := range orderNames {
+		if !requestedNames[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return newAcmeError(acmeProblemBadCSR, "CSR is missing identifiers of the order: %v", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+func (b *backend) acmeOrderFinalizeHandler(ctx context.Context, req *logical.Request, data *framework.FieldData, ac *acmeContext) (*logical.Response, error) {
+	jws, order, err := b.loadAcmeOrderForRequest(ctx, req, data, ac)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload struct {
+		CSR string `json:"csr"`
+	}
+	if err := jws.decodePayload(&payload); err != nil {
+		return nil, err
+	}
+
+	if order.Status != acmeStatusReady {
+		return nil, newAcmeError(acmeProblemOrderNotReady, "order is %v, not %v", order.Status, acmeStatusReady)
+	}
+
+	csrBytes, err := base64.RawURLEncoding.DecodeString(payload.CSR)
+	if err != nil {
+		return nil, newAcmeError(acmeProblemBadCSR, "failed to decode CSR: %v", err)
+	}
+	csr, err := x509.ParseCertificateRequest(csrBytes)
+	if err != nil {
+		return nil, newAcmeError(acmeProblemBadCSR, "failed to parse CSR: %v", err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, newAcmeError(acmeProblemBadCSR, "invalid CSR signature: %v", err)
+	}
+	if err := checkAcmeCSRIdentifiers(csr, order); err != nil {
+		return nil, err
+	}
+
+	issuerId, err := resolveIssuerReference(ctx, req.Storage, ac.issuerRef)
+	if err != nil {
+		return nil, newAcmeError(acmeProblemServerInternal, "unable to resolve issuer %v: %v", ac.issuerRef, err)
+	}
+	signingBundle, err := fetchCAInfoByIssuerId(ctx, b, req, issuerId, IssuanceUsage)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching CA certificate: %w", err)
+	}
+
+	// The CSR's names have been checked against the order, whose identifiers
+	// were each validated against the role and proven by the account; take
+	// them from the CSR so the role's remaining policy applies as it would
+	// to sign/:role.
+	role := *ac.role
+	role.UseCSRCommonName = true
+	role.UseCSRSANs = true
+	role.RequireCN = false
+
+	fields := addNonCACommonFields(map[string]*framework.FieldSchema{})
+	fields["csr"] = &framework.FieldSchema{Type: framework.TypeString}
+	apiData := &framework.FieldData{
+		Raw: map[string]interface{}{
+			"csr": string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrBytes})),
+		},
+		Schema: fields,
+	}
+
+	parsedBundle, err := signCert(b, &inputBundle{req: req, apiData: apiData, role: &role}, signingBundle, false, false)
+	if err != nil {
+		switch err.(type) {
+		case errutil.UserError:
+			return nil, newAcmeError(acmeProblemBadCSR, "%v", err)
+		default:
+			return nil, fmt.Errorf("error signing certificate: %w", err)
+		}
+	}
+
+	cb, err := parsedBundle.ToCertBundle()
+	if err != nil {
+		return nil, fmt.Errorf("error converting raw cert bundle to cert bundle: %w", err)
+	}
+
+	// Certificates issued via ACME are always stored, so that they may be
+	// downloaded from the order and revoked.
+	err = storeCertificate(ctx, b, req.Storage, parsedBundle, issuerId.String(), ac.roleName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to store certificate locally: %w", err)
+	}
+
+	order.Status = acmeStatusValid
+	order.CertificateSerialNumber = cb.SerialNumber
+	order.CertificateIssuerID = issuerId
+	if err := saveAcmeOrder(ctx, req.Storage, order); err != nil {
+		return nil, err
+	}
+
+	headers := map[string][]string{"Location": {ac.orderURL(order.ID)}}
+	return b.acmeJSONResponse(http.StatusOK, ac.formatOrder(order), headers)
+}
+
+func (b *backend) acmeOrderCertHandler(ctx context.Context, req *logical.Request, data *framework.FieldData, ac *acmeContext) (*logical.Response, error) {
+	_, order, err := b.loadAcmeOrderForRequest(ctx, req, data, ac)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != acmeStatusValid {
+		return nil, newAcmeError(acmeProblemOrderNotReady, "order is %v, not %v", order.Status, acmeStatusValid)
+	}
+
+	certEntry, err := fetchCertBySerial(ctx, b, req, "certs/", order.CertificateSerialNumber)
+	if err != nil {
+		return nil, err
+	}
+	if certEntry == nil {
+		return nil, fmt.Errorf("certificate %v of order %v is missing", order.CertificateSerialNumber, order.ID)
+	}
+
+	issuer, err := fetchIssuerById(ctx, req.Storage, order.CertificateIssuerID)
+	if err != nil {
+		return nil, err
+	}
+
+	var chain strings.Builder
+	chain.Write(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certEntry.Value}))
+	for _, caCert := range issuer.CAChain {
+		chain.WriteString(strings.TrimSpace(caCert))
+		chain.WriteString("\n")
+	}
+
+	return b.acmeRawResponse(http.StatusOK, acmePEMChainType, []byte(chain.String()), nil), nil
+}
+
+// loadAcmeAuthorizationForRequest verifies the request's JWS and loads the
+// authorization in the path, refreshing its status.
+func (b *backend) loadAcmeAuthorizationForRequest(ctx context.Context, req *logical.Request, data *framework.FieldData, ac *acmeContext) (*acmeJWS, *acmeAuthorization, error) {
+	jws, err := b.parseAcmeJWS(ctx, req, data, ac, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	authzId := data.Get("auth_id").(string)
+	authz, err := loadAcmeAuthorization(ctx, req.Storage, jws.Account.ID, authzId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if authz == nil {
+		return nil, nil, newAcmeError(acmeProblemMalformed, "authorization %v does not exist", authzId)
+	}
+	authz.refreshStatus()
+
+	return jws, authz, nil
+}
+
+func (b *backend) acmeAuthorizationHandler(ctx context.Context, req *logical.Request, data *framework.FieldData, ac *acmeContext) (*logical.Response, error) {
+	jws, authz, err := b.loadAcmeAuthorizationForRequest(ctx, req, data, ac)
+	if err != nil {
+		return nil, err
+	}
+
+	if !jws.isPostAsGet() {
+		var payload struct {
+			Status string `json:"status"`
+		}
+		if err := jws.decodePayload(&payload); err != nil {
+			return nil, err
+		}
+		if payload.Status != acmeStatusDeactivated {
+			return nil, newAcmeError(acmeProblemMalformed, "authorization status may only be updated to %v", acmeStatusDeactivated)
+		}
+		if authz.Status != acmeStatusPending && authz.Status != acmeStatusValid {
+			return nil, newAcmeError(acmeProblemMalformed, "authorization is %v and can not be deactivated", authz.Status)
+		}
+
+		authz.Status = acmeStatusDeactivated
+		if err := saveAcmeAuthorization(ctx, req.Storage, authz); err != nil {
+			return nil, err
+		}
+	}
+
+	return b.acmeJSONResponse(http.StatusOK, ac.formatAuthorization(authz), nil)
+}
+
+func (b *backend) acmeChallengeHandler(ctx context.Context, req *logical.Request, data *framework.FieldData, ac *acmeContext) (*logical.Response, error) {
+	jws, authz, err := b.loadAcmeAuthorizationForRequest(ctx, req, data, ac)
+	if err != nil {
+		return nil, err
+	}
+
+	challengeType := data.Get("challenge_type").(string)
+	var challenge *acmeChallenge
+	for _, candidate := range authz.Challenges {
+		if candidate.Type == challengeType {
+			challenge = candidate
+			break
+		}
+	}
+	if challenge == nil {
+		return nil, newAcmeError(acmeProblemMalformed, "authorization %v has no %v challenge", authz.ID, challengeType)
+	}
+
+	// Any non-POST-as-GET request asks us to attempt validation, which is
+	// only done while the authorization is outstanding. Validation is
+	// performed synchronously, so the client sees the result immediately.
+	if !jws.isPostAsGet() && authz.Status == acmeStatusPending && challenge.Status == acmeStatusPending {
+		err := b.acmeState.validator.validate(ctx, ac.config, challenge, authz.Identifier, jws.Account.Thumbprint)
+		if err != nil {
+			aErr, ok := err.(*acmeError)
+			if !ok {
+				return nil, err
+			}
+			challenge.Status = acmeStatusInvalid
+			challenge.Error = aErr.toProblem()
+			authz.Status = acmeStatusInvalid
+		} else {
+			challenge.Status = acmeStatusValid
+			challenge.Validated = time.Now()
+			authz.Status = acmeStatusValid
+		}
+
+		if err := saveAcmeAuthorization(ctx, req.Storage, authz); err != nil {
+			return nil, err
+		}
+	}
+
+	headers := map[string][]string{"Link": {fmt.Sprintf("<%s>;rel=\"up\"", ac.authorizationURL(authz.ID))}}
+	return b.acmeJSONResponse(http.StatusOK, ac.formatChallenge(authz, challenge), headers)
+}
+
+const (
+	pathAcmeNewOrderHelpSyn      = `Create an ACME order for a certificate.`
+	pathAcmeOrderHelpSyn         = `Read an ACME order.`
+	pathAcmeOrderFinalizeHelpSyn = `Finalize a ready ACME order with a CSR.`
+	pathAcmeOrderCertHelpSyn     = `Download the certificate chain of a valid ACME order.`
+	pathAcmeAuthorizationHelpSyn = `Read or deactivate an ACME authorization.`
+	pathAcmeChallengeHelpSyn     = `Read or respond to an ACME challenge.`
+)
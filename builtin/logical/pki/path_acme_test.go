@@ -0,0 +1,633 @@
+package pki
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+const acmeTestBaseURL = "https://vault.example.com/v1/pki"
+
+type acmeTestClient struct {
+	t      *testing.T
+	b      *backend
+	s      logical.Storage
+	prefix string
+	key    *ecdsa.PrivateKey
+	kid    string
+}
+
+func setupAcmeTest(t *testing.T) (*backend, logical.Storage) {
+	b, s := createBackendWithStorage(t)
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "root example.com",
+		"key_type":    "ec",
+		"ttl":         "720h",
+	})
+	requireSuccessNonNilResponse(t, resp, err, "failed generating root")
+
+	_, err = CBWrite(b, s, "roles/test", map[string]interface{}{
+		"allowed_domains":  "example.com",
+		"allow_subdomains": true,
+		"allow_localhost":  true,
+		"key_type":         "any",
+		"ttl":              "1h",
+	})
+	require.NoError(t, err, "failed creating role")
+
+	// The stand-in http-01 responders listen on loopback, which is denied
+	// by default.
+	resp, err = CBWrite(b, s, "config/acme", map[string]interface{}{
+		"enabled":              true,
+		"base_url":             acmeTestBaseURL,
+		"http01_allowed_cidrs": "127.0.0.0/8,::1/128",
+	})
+	requireSuccessNonNilResponse(t, resp, err, "failed enabling acme")
+
+	return b, s
+}
+
+func newAcmeTestClient(t *testing.T, b *backend, s logical.Storage, prefix string) *acmeTestClient {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	return &acmeTestClient{t: t, b: b, s: s, prefix: prefix, key: key}
+}
+
+func (c *acmeTestClient) nonce() string {
+	resp, err := c.b.HandleRequest(context.Background(), &logical.Request{
+		Operation:  logical.ReadOperation,
+		Path:       c.prefix + "new-nonce",
+		Storage:    c.s,
+		MountPoint: "pki/",
+	})
+	require.NoError(c.t, err)
+	require.Equal(c.t, http.StatusNoContent, resp.Data[logical.HTTPStatusCode])
+	require.Len(c.t, resp.Headers["Replay-Nonce"], 1)
+	return resp.Headers["Replay-Nonce"][0]
+}
+
+// post signs the payload (nil for POST-as-GET) and posts it to the given
+// directory-relative path, returning the status code and raw response.
+func (c *acmeTestClient) post(path string, payload interface{}) (int, *logical.Response) {
+	return c.postWithNonce(path, payload, c.nonce())
+}
+
+func (c *acmeTestClient) postWithNonce(path string, payload interface{}, nonce string) (int, *logical.Response) {
+	opts := (&jose.SignerOptions{}).
+		WithHeader("nonce", nonce).
+		WithHeader("url", acmeTestBaseURL+"/"+c.prefix+path)
+	if len(c.kid) > 0 {
+		opts = opts.WithHeader("kid", c.kid)
+	} else {
+		opts.EmbedJWK = true
+	}
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: c.key}, opts)
+	require.NoError(c.t, err)
+
+	var encoded []byte
+	if payload != nil {
+		encoded, err = json.Marshal(payload)
+		require.NoError(c.t, err)
+	}
+
+	signed, err := signer.Sign(encoded)
+	require.NoError(c.t, err)
+
+	var body map[string]interface{}
+	require.NoError(c.t, json.Unmarshal([]byte(signed.FullSerialize()), &body))
+
+	resp, err := c.b.HandleRequest(context.Background(), &logical.Request{
+		Operation:  logical.UpdateOperation,
+		Path:       c.prefix + path,
+		Storage:    c.s,
+		Data:       body,
+		MountPoint: "pki/",
+	})
+	require.NoError(c.t, err)
+	require.NotNil(c.t, resp)
+	require.NotEmpty(c.t, resp.Headers["Replay-Nonce"])
+
+	return resp.Data[logical.HTTPStatusCode].(int), resp
+}
+
+func (c *acmeTestClient) postJSON(path string, payload interface{}, expectedStatus int) map[string]interface{} {
+	status, resp := c.post(path, payload)
+
+	var result map[string]interface{}
+	require.NoError(c.t, json.Unmarshal(resp.Data[logical.HTTPRawBody].([]byte), &result))
+	require.Equal(c.t, expectedStatus, status, "unexpected response: %v", result)
+	return result
+}
+
+func (c *acmeTestClient) register() {
+	status, resp := c.post("new-account", map[string]interface{}{
+		"termsOfServiceAgreed": true,
+	})
+	require.Contains(c.t, []int{http.StatusCreated, http.StatusOK}, status)
+	require.Len(c.t, resp.Headers["Location"], 1)
+	c.kid = resp.Headers["Location"][0]
+}
+
+func (c *acmeTestClient) thumbprint() string {
+	jwk := jose.JSONWebKey{Key: c.key.Public()}
+	thumbprint, err := acmeJWKThumbprint(&jwk)
+	require.NoError(c.t, err)
+	return thumbprint
+}
+
+func (c *acmeTestClient) relative(url string) string {
+	require.True(c.t, strings.HasPrefix(url, acmeTestBaseURL+"/"+c.prefix), "url %v outside of directory", url)
+	return strings.TrimPrefix(url, acmeTestBaseURL+"/"+c.prefix)
+}
+
+func (c *acmeTestClient) finalize(order map[string]interface{}, names ...string) *x509.Certificate {
+	csrKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(c.t, err)
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: names[0]},
+		DNSNames: names,
+	}, csrKey)
+	require.NoError(c.t, err)
+
+	orderURL := c.relative(order["finalize"].(string))
+	order = c.postJSON(orderURL, map[string]interface{}{
+		"csr": base64.RawURLEncoding.EncodeToString(csr),
+	}, http.StatusOK)
+	require.Equal(c.t, acmeStatusValid, order["status"])
+
+	status, resp := c.post(c.relative(order["certificate"].(string)), nil)
+	require.Equal(c.t, http.StatusOK, status)
+	require.Equal(c.t, acmePEMChainType, resp.Data[logical.HTTPContentType])
+
+	chain := resp.Data[logical.HTTPRawBody].([]byte)
+	block, rest := pem.Decode(chain)
+	require.NotNil(c.t, block)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(c.t, err)
+
+	block, _ = pem.Decode(rest)
+	require.NotNil(c.t, block, "expected the issuer in the chain")
+	issuer, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(c.t, err)
+	require.NoError(c.t, cert.CheckSignatureFrom(issuer))
+
+	return cert
+}
+
+func TestAcme_HTTP01(t *testing.T) {
+	t.Parallel()
+	b, s := setupAcmeTest(t)
+	client := newAcmeTestClient(t, b, s, "roles/test/acme/")
+
+	status, resp := client.post("new-account", map[string]interface{}{"onlyReturnExisting": true})
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, acmeProblemContentType, resp.Data[logical.HTTPContentType])
+
+	client.register()
+
+	// Looking up an existing account returns the same one.
+	kid := client.kid
+	client.kid = ""
+	status, resp = client.post("new-account", map[string]interface{}{"onlyReturnExisting": true})
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, []string{kid}, resp.Headers["Location"])
+	client.kid = kid
+
+	// Names outside the role's policy are rejected up front.
+	problem := client.postJSON("new-order", map[string]interface{}{
+		"identifiers": []map[string]string{{"type": "dns", "value": "www.hashicorp.com"}},
+	}, http.StatusBadRequest)
+	require.Equal(t, acmeErrorPrefix+acmeProblemRejectedIdentifier, problem["type"])
+
+	order := client.postJSON("new-order", map[string]interface{}{
+		"identifiers": []map[string]string{{"type": "dns", "value": "localhost"}},
+	}, http.StatusCreated)
+	require.Equal(t, acmeStatusPending, order["status"])
+	require.Len(t, order["authorizations"], 1)
+
+	// Finalizing before the challenge is complete fails.
+	problem = client.postJSON(client.relative(order["finalize"].(string)), map[string]interface{}{
+		"csr": "",
+	}, http.StatusForbidden)
+	require.Equal(t, acmeErrorPrefix+acmeProblemOrderNotReady, problem["type"])
+
+	authzURL := client.relative(order["authorizations"].([]interface{})[0].(string))
+	authz := client.postJSON(authzURL, nil, http.StatusOK)
+	var challenge map[string]interface{}
+	for _, raw := range authz["challenges"].([]interface{}) {
+		if raw.(map[string]interface{})["type"] == acmeChallengeHTTP01 {
+			challenge = raw.(map[string]interface{})
+		}
+	}
+	require.NotNil(t, challenge)
+
+	token := challenge["token"].(string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/.well-known/acme-challenge/"+token {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(acmeKeyAuthorization(token, client.thumbprint())))
+	}))
+	defer server.Close()
+
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+	b.acmeState.validator.httpPort, err = strconv.Atoi(port)
+	require.NoError(t, err)
+
+	challenge = client.postJSON(client.relative(challenge["url"].(string)), map[string]interface{}{}, http.StatusOK)
+	require.Equal(t, acmeStatusValid, challenge["status"], "challenge failed: %v", challenge["error"])
+
+	order = client.postJSON(strings.TrimSuffix(client.relative(order["finalize"].(string)), "/finalize"), nil, http.StatusOK)
+	require.Equal(t, acmeStatusReady, order["status"])
+
+	cert := client.finalize(order, "localhost")
+	require.Equal(t, []string{"localhost"}, cert.DNSNames)
+
+	// The certificate is stored so that it can be fetched and revoked.
+	resp, err = CBRead(b, s, "cert/"+certutil.GetHexFormatted(cert.SerialNumber.Bytes(), ":"))
+	requireSuccessNonNilResponse(t, resp, err)
+
+	orders := client.postJSON(client.relative(client.kid)+"/orders", nil, http.StatusOK)
+	require.Len(t, orders["orders"], 1)
+}
+
+func TestAcme_DNS01Wildcard(t *testing.T) {
+	t.Parallel()
+	b, s := setupAcmeTest(t)
+	client := newAcmeTestClient(t, b, s, "issuer/default/roles/test/acme/")
+	client.register()
+
+	order := client.postJSON("new-order", map[string]interface{}{
+		"identifiers": []map[string]string{{"type": "dns", "value": "*.test.example.com"}},
+	}, http.StatusCreated)
+
+	authz := client.postJSON(client.relative(order["authorizations"].([]interface{})[0].(string)), nil, http.StatusOK)
+	require.Equal(t, true, authz["wildcard"])
+	require.Equal(t, "test.example.com", authz["identifier"].(map[string]interface{})["value"])
+	require.Len(t, authz["challenges"], 1)
+	challenge := authz["challenges"].([]interface{})[0].(map[string]interface{})
+	require.Equal(t, acmeChallengeDNS01, challenge["type"])
+
+	digest := sha256.Sum256([]byte(acmeKeyAuthorization(challenge["token"].(string), client.thumbprint())))
+	resolver := startAcmeTestDNSServer(t, "_acme-challenge.test.example.com.", base64.RawURLEncoding.EncodeToString(digest[:]))
+
+	resp, err := CBWrite(b, s, "config/acme", map[string]interface{}{
+		"enabled":      true,
+		"base_url":     acmeTestBaseURL,
+		"dns_resolver": resolver,
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+
+	challenge = client.postJSON(client.relative(challenge["url"].(string)), map[string]interface{}{}, http.StatusOK)
+	require.Equal(t, acmeStatusValid, challenge["status"], "challenge failed: %v", challenge["error"])
+
+	// A CSR for names outside the order is refused.
+	csrKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		DNSNames: []string{"*.test.example.com", "other.example.com"},
+	}, csrKey)
+	require.NoError(t, err)
+	problem := client.postJSON(client.relative(order["finalize"].(string)), map[string]interface{}{
+		"csr": base64.RawURLEncoding.EncodeToString(csr),
+	}, http.StatusBadRequest)
+	require.Equal(t, acmeErrorPrefix+acmeProblemBadCSR, problem["type"])
+
+	cert := client.finalize(order, "*.test.example.com")
+	require.Equal(t, []string{"*.test.example.com"}, cert.DNSNames)
+}
+
+func TestAcme_FailedChallenge(t *testing.T) {
+	t.Parallel()
+	b, s := setupAcmeTest(t)
+	client := newAcmeTestClient(t, b, s, "roles/test/acme/")
+	client.register()
+
+	order := client.postJSON("new-order", map[string]interface{}{
+		"identifiers": []map[string]string{{"type": "dns", "value": "localhost"}},
+	}, http.StatusCreated)
+	authz := client.postJSON(client.relative(order["authorizations"].([]interface{})[0].(string)), nil, http.StatusOK)
+	challenge := authz["challenges"].([]interface{})[0].(map[string]interface{})
+	require.Equal(t, acmeChallengeHTTP01, challenge["type"])
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("not the key authorization"))
+	}))
+	defer server.Close()
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+	b.acmeState.validator.httpPort, err = strconv.Atoi(port)
+	require.NoError(t, err)
+
+	challenge = client.postJSON(client.relative(challenge["url"].(string)), map[string]interface{}{}, http.StatusOK)
+	require.Equal(t, acmeStatusInvalid, challenge["status"])
+	require.Equal(t, acmeErrorPrefix+acmeProblemIncorrectResponse, challenge["error"].(map[string]interface{})["type"])
+
+	order = client.postJSON(strings.TrimSuffix(client.relative(order["finalize"].(string)), "/finalize"), nil, http.StatusOK)
+	require.Equal(t, acmeStatusInvalid, order["status"])
+}
+
+func TestAcme_HTTP01Redirects(t *testing.T) {
+	t.Parallel()
+
+	for _, target := range []string{
+		"ftp://example.com/token",
+		"http://example.com:8200/token",
+		"https://example.com:8443/token",
+	} {
+		req, err := http.NewRequest(http.MethodGet, target, nil)
+		require.NoError(t, err)
+		require.Error(t, checkAcmeRedirect(req, nil), "expected redirect to %v to be refused", target)
+	}
+
+	for _, target := range []string{
+		"http://example.com/token",
+		"https://example.com:443/token",
+		"https://10.0.0.1/token",
+	} {
+		req, err := http.NewRequest(http.MethodGet, target, nil)
+		require.NoError(t, err)
+		require.NoError(t, checkAcmeRedirect(req, nil), "expected redirect to %v to be followed", target)
+	}
+}
+
+func TestAcme_HTTP01Policy(t *testing.T) {
+	t.Parallel()
+
+	policy, err := newAcmeHTTP01Policy(&acmeConfigEntry{HTTP01DeniedCIDRs: defaultAcmeHTTP01DeniedCIDRs})
+	require.NoError(t, err)
+	for _, addr := range []string{"127.0.0.1", "::1", "169.254.169.254", "fe80::1", "0.0.0.0", "::ffff:127.0.0.1"} {
+		require.Error(t, policy.check(net.ParseIP(addr)), "expected %v to be denied by default", addr)
+	}
+	for _, addr := range []string{"10.0.0.1", "192.168.1.1", "93.184.216.34", "2001:db8::1"} {
+		require.NoError(t, policy.check(net.ParseIP(addr)), "expected %v to be permitted by default", addr)
+	}
+
+	// Allowed networks take precedence over, and replace, the denied ones.
+	policy, err = newAcmeHTTP01Policy(&acmeConfigEntry{
+		HTTP01AllowedCIDRs: []string{"10.0.0.0/8", "127.0.0.1/32"},
+		HTTP01DeniedCIDRs:  defaultAcmeHTTP01DeniedCIDRs,
+	})
+	require.NoError(t, err)
+	require.NoError(t, policy.check(net.ParseIP("10.1.2.3")))
+	require.NoError(t, policy.check(net.ParseIP("127.0.0.1")))
+	require.Error(t, policy.check(net.ParseIP("127.0.0.2")))
+	require.Error(t, policy.check(net.ParseIP("93.184.216.34")))
+
+	policy, err = newAcmeHTTP01Policy(&acmeConfigEntry{HTTP01DeniedCIDRs: []string{}})
+	require.NoError(t, err)
+	require.NoError(t, policy.check(net.ParseIP("127.0.0.1")))
+
+	// The configuration is validated, and an emptied deny list is kept.
+	b, s := createBackendWithStorage(t)
+	_, err = CBWrite(b, s, "config/acme", map[string]interface{}{"http01_denied_cidrs": "not-a-cidr"})
+	require.Error(t, err)
+	resp, err := CBRead(b, s, "config/acme")
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, defaultAcmeHTTP01DeniedCIDRs, resp.Data["http01_denied_cidrs"])
+	resp, err = CBWrite(b, s, "config/acme", map[string]interface{}{"http01_denied_cidrs": []string{}})
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Empty(t, resp.Data["http01_denied_cidrs"])
+}
+
+// runAcmeHTTP01Challenge responds to the http-01 challenge of a new order for
+// localhost with the given handler, returning the resulting challenge.
+func runAcmeHTTP01Challenge(t *testing.T, b *backend, client *acmeTestClient, handler func(token string) http.HandlerFunc) map[string]interface{} {
+	order := client.postJSON("new-order", map[string]interface{}{
+		"identifiers": []map[string]string{{"type": "dns", "value": "localhost"}},
+	}, http.StatusCreated)
+	authz := client.postJSON(client.relative(order["authorizations"].([]interface{})[0].(string)), nil, http.StatusOK)
+	challenge := authz["challenges"].([]interface{})[0].(map[string]interface{})
+	require.Equal(t, acmeChallengeHTTP01, challenge["type"])
+
+	server := httptest.NewServer(handler(challenge["token"].(string)))
+	t.Cleanup(server.Close)
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+	b.acmeState.validator.httpPort, err = strconv.Atoi(port)
+	require.NoError(t, err)
+
+	return client.postJSON(client.relative(challenge["url"].(string)), map[string]interface{}{}, http.StatusOK)
+}
+
+func TestAcme_HTTP01DeniedAddresses(t *testing.T) {
+	t.Parallel()
+
+	respond := func(client *acmeTestClient) func(token string) http.HandlerFunc {
+		return func(token string) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(acmeKeyAuthorization(token, client.thumbprint())))
+			}
+		}
+	}
+
+	// With the default policy, even the initial fetch may not connect to a
+	// loopback address.
+	b, s := setupAcmeTest(t)
+	resp, err := CBWrite(b, s, "config/acme", map[string]interface{}{"http01_allowed_cidrs": []string{}})
+	requireSuccessNonNilResponse(t, resp, err)
+	client := newAcmeTestClient(t, b, s, "roles/test/acme/")
+	client.register()
+
+	challenge := runAcmeHTTP01Challenge(t, b, client, respond(client))
+	require.Equal(t, acmeStatusInvalid, challenge["status"])
+	problem := challenge["error"].(map[string]interface{})
+	require.Equal(t, acmeErrorPrefix+acmeProblemConnection, problem["type"])
+	require.Contains(t, problem["detail"], "denied network")
+
+	// Once loopback is no longer denied, the same responder validates.
+	resp, err = CBWrite(b, s, "config/acme", map[string]interface{}{"http01_denied_cidrs": []string{}})
+	requireSuccessNonNilResponse(t, resp, err)
+	challenge = runAcmeHTTP01Challenge(t, b, client, respond(client))
+	require.Equal(t, acmeStatusValid, challenge["status"])
+
+	// Redirects are checked when connecting as well: only 127.0.0.0/8 is
+	// allowed, so a redirect to ::1 is refused.
+	resp, err = CBWrite(b, s, "config/acme", map[string]interface{}{"http01_allowed_cidrs": "127.0.0.0/8"})
+	requireSuccessNonNilResponse(t, resp, err)
+	challenge = runAcmeHTTP01Challenge(t, b, client, func(token string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "http://[::1]/.well-known/acme-challenge/"+token, http.StatusFound)
+		}
+	})
+	require.Equal(t, acmeStatusInvalid, challenge["status"])
+	problem = challenge["error"].(map[string]interface{})
+	require.Equal(t, acmeErrorPrefix+acmeProblemConnection, problem["type"])
+	require.Contains(t, problem["detail"], "not within the allowed networks")
+}
+
+func TestAcme_RequestValidation(t *testing.T) {
+	t.Parallel()
+	b, s := setupAcmeTest(t)
+	client := newAcmeTestClient(t, b, s, "roles/test/acme/")
+
+	// Nonces may only be used once.
+	nonce := client.nonce()
+	status, _ := client.postWithNonce("new-account", map[string]interface{}{}, nonce)
+	require.Equal(t, http.StatusCreated, status)
+	status, resp := client.postWithNonce("new-account", map[string]interface{}{}, nonce)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Contains(t, string(resp.Data[logical.HTTPRawBody].([]byte)), acmeProblemBadNonce)
+
+	// Requests for an unknown role fail.
+	unknown := newAcmeTestClient(t, b, s, "roles/unknown/acme/")
+	status, _ = unknown.postWithNonce("new-account", map[string]interface{}{}, client.nonce())
+	require.Equal(t, http.StatusBadRequest, status)
+
+	// Another account's objects can't be accessed.
+	client.register()
+	other := newAcmeTestClient(t, b, s, "roles/test/acme/")
+	other.register()
+	problem := other.postJSON(client.relative(client.kid), nil, http.StatusForbidden)
+	require.Equal(t, acmeErrorPrefix+acmeProblemUnauthorized, problem["type"])
+
+	// Once disabled, the directory is unavailable.
+	resp, err := CBWrite(b, s, "config/acme", map[string]interface{}{"enabled": false})
+	requireSuccessNonNilResponse(t, resp, err)
+	_, err = CBRead(b, s, "roles/test/acme/directory")
+	require.ErrorContains(t, err, "ACME is disabled")
+}
+
+// startAcmeTestDNSServer runs a UDP DNS server answering TXT queries for
+// name with the given value, returning its address.
+func startAcmeTestDNSServer(t *testing.T, name string, value string) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			var query dnsmessage.Message
+			if err := query.Unpack(buf[:n]); err != nil || len(query.Questions) == 0 {
+				continue
+			}
+
+			question := query.Questions[0]
+			reply := dnsmessage.Message{
+				Header: dnsmessage.Header{
+					ID:            query.ID,
+					Response:      true,
+					Authoritative: true,
+				},
+				Questions: []dnsmessage.Question{question},
+			}
+			if question.Type == dnsmessage.TypeTXT && strings.EqualFold(question.Name.String(), name) {
+				reply.Answers = []dnsmessage.Resource{{
+					Header: dnsmessage.ResourceHeader{
+						Name:  question.Name,
+						Type:  dnsmessage.TypeTXT,
+						Class: dnsmessage.ClassINET,
+						TTL:   60,
+					},
+					Body: &dnsmessage.TXTResource{TXT: []string{value}},
+				}}
+			} else {
+				reply.RCode = dnsmessage.RCodeNameError
+			}
+
+			packed, err := reply.Pack()
+			if err != nil {
+				continue
+			}
+			conn.WriteTo(packed, addr)
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func TestAcme_DirectoryOverHTTP(t *testing.T) {
+	t.Parallel()
+	coreConfig := &vault.CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"pki": Factory,
+		},
+	}
+	cluster := vault.NewTestCluster(t, coreConfig, &vault.TestClusterOptions{
+		HandlerFunc: vaulthttp.Handler,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+
+	client := cluster.Cores[0].Client
+	mountPKIEndpoint(t, client, "pki")
+
+	// The ACME headers must be allowed through on the mount.
+	err := client.Sys().TuneMount("pki", api.MountConfigInput{
+		AllowedResponseHeaders: []string{"Replay-Nonce", "Location", "Link"},
+	})
+	require.NoError(t, err)
+
+	_, err = client.Logical().Write("pki/root/generate/internal", map[string]interface{}{
+		"common_name": "root example.com",
+		"ttl":         "20h",
+	})
+	require.NoError(t, err)
+	_, err = client.Logical().Write("pki/roles/test", map[string]interface{}{
+		"allowed_domains":  "example.com",
+		"allow_subdomains": true,
+	})
+	require.NoError(t, err)
+	_, err = client.Logical().Write("pki/config/acme", map[string]interface{}{
+		"enabled":  true,
+		"base_url": client.Address() + "/v1/pki",
+	})
+	require.NoError(t, err)
+
+	// The directory is readable without a token.
+	unauthed, err := client.Clone()
+	require.NoError(t, err)
+	unauthed.ClearToken()
+
+	req := unauthed.NewRequest(http.MethodGet, "/v1/pki/roles/test/acme/directory")
+	resp, err := unauthed.RawRequest(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NotEmpty(t, resp.Header.Get("Replay-Nonce"))
+
+	var directory map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&directory))
+	require.Equal(t, client.Address()+"/v1/pki/roles/test/acme/new-nonce", directory["newNonce"])
+
+	// HEAD requests to new-nonce receive a 200 with a nonce.
+	req = unauthed.NewRequest(http.MethodHead, "/v1/pki/roles/test/acme/new-nonce")
+	resp, err = unauthed.RawRequest(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NotEmpty(t, resp.Header.Get("Replay-Nonce"))
+}
@@ -0,0 +1,187 @@
+package pki
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const storageAcmeConfig = "config/acme"
+
+type acmeConfigEntry struct {
+	Enabled            bool     `json:"enabled"`
+	BaseURL            string   `json:"base_url"`
+	DNSResolver        string   `json:"dns_resolver"`
+	HTTP01AllowedCIDRs []string `json:"http01_allowed_cidrs"`
+	HTTP01DeniedCIDRs  []string `json:"http01_denied_cidrs"`
+}
+
+func pathConfigAcme(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config/acme",
+		Fields: map[string]*framework.FieldSchema{
+			"enabled": {
+				Type:        framework.TypeBool,
+				Description: `Whether the ACME protocol endpoints are enabled on this mount; defaults to false.`,
+				Default:     false,
+			},
+			"base_url": {
+				Type: framework.TypeString,
+				Description: `The externally reachable URL of this mount, such as
+"https://vault.example.com:8200/v1/pki". ACME clients are handed absolute
+URLs derived from this value, so it is required when enabling ACME.`,
+			},
+			"dns_resolver": {
+				Type: framework.TypeString,
+				Description: `An optional host:port of a DNS resolver to use when
+validating dns-01 challenges. When empty, the system resolver is used.`,
+			},
+			"http01_allowed_cidrs": {
+				Type: framework.TypeCommaStringSlice,
+				Description: `The networks http-01 validation may connect to,
+including when following redirects. When set, connections to any other
+address are refused, and http01_denied_cidrs is not used.`,
+			},
+			"http01_denied_cidrs": {
+				Type: framework.TypeCommaStringSlice,
+				Description: `The networks http-01 validation refuses to connect
+to, including when following redirects, when http01_allowed_cidrs is not set.
+Defaults to the loopback, link-local, unspecified and multicast networks; set
+to an empty list to allow connecting to any address.`,
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathAcmeConfigRead,
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathAcmeConfigWrite,
+				// Read more about why these flags are set in backend.go.
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: true,
+			},
+		},
+
+		HelpSynopsis:    pathConfigAcmeHelpSyn,
+		HelpDescription: pathConfigAcmeHelpDesc,
+	}
+}
+
+func getAcmeConfig(ctx context.Context, s logical.Storage) (*acmeConfigEntry, error) {
+	entry, err := s.Get(ctx, storageAcmeConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &acmeConfigEntry{}
+	if entry != nil {
+		if err := entry.DecodeJSON(config); err != nil {
+			return nil, fmt.Errorf("unable to decode ACME configuration: %w", err)
+		}
+	}
+
+	// An explicitly emptied list is stored as such, so only configurations
+	// predating the field, or never written, get the default.
+	if config.HTTP01DeniedCIDRs == nil {
+		config.HTTP01DeniedCIDRs = defaultAcmeHTTP01DeniedCIDRs
+	}
+
+	return config, nil
+}
+
+func (b *backend) pathAcmeConfigRead(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	config, err := getAcmeConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"enabled":              config.Enabled,
+			"base_url":             config.BaseURL,
+			"dns_resolver":         config.DNSResolver,
+			"http01_allowed_cidrs": config.HTTP01AllowedCIDRs,
+			"http01_denied_cidrs":  config.HTTP01DeniedCIDRs,
+		},
+	}, nil
+}
+
+func (b *backend) pathAcmeConfigWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	config, err := getAcmeConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	if enabledRaw, ok := d.GetOk("enabled"); ok {
+		config.Enabled = enabledRaw.(bool)
+	}
+
+	if baseURLRaw, ok := d.GetOk("base_url"); ok {
+		config.BaseURL = strings.TrimSuffix(baseURLRaw.(string), "/")
+	}
+
+	if resolverRaw, ok := d.GetOk("dns_resolver"); ok {
+		config.DNSResolver = resolverRaw.(string)
+	}
+
+	if allowedRaw, ok := d.GetOk("http01_allowed_cidrs"); ok {
+		config.HTTP01AllowedCIDRs = allowedRaw.([]string)
+	}
+
+	if deniedRaw, ok := d.GetOk("http01_denied_cidrs"); ok {
+		config.HTTP01DeniedCIDRs = append([]string{}, deniedRaw.([]string)...)
+	}
+
+	if _, err := newAcmeHTTP01Policy(config); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	if len(config.BaseURL) > 0 {
+		parsed, err := url.Parse(config.BaseURL)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return logical.ErrorResponse(fmt.Sprintf("base_url (%v) must be an absolute URL", config.BaseURL)), nil
+		}
+	}
+
+	if config.Enabled && len(config.BaseURL) == 0 {
+		return logical.ErrorResponse("base_url is required when enabling ACME"), nil
+	}
+
+	if len(config.DNSResolver) > 0 {
+		if _, _, err := net.SplitHostPort(config.DNSResolver); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("dns_resolver (%v) must be of the form host:port: %v", config.DNSResolver, err)), nil
+		}
+	}
+
+	entry, err := logical.StorageEntryJSON(storageAcmeConfig, config)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return b.pathAcmeConfigRead(ctx, req, d)
+}
+
+const pathConfigAcmeHelpSyn = `
+Configuration of the ACME server endpoints.
+`
+
+const pathConfigAcmeHelpDesc = `
+This endpoint enables or disables the RFC 8555 ACME server endpoints of
+this mount, which are served under roles/:role/acme/ and
+issuer/:issuer_ref/roles/:role/acme/.
+
+Because ACME relies on the Replay-Nonce, Location and Link response headers,
+the mount must be tuned to pass them through, for example:
+
+    $ vault secrets tune -allowed-response-headers=Replay-Nonce \
+        -allowed-response-headers=Location -allowed-response-headers=Link pki
+`
@@ -12,8 +12,26 @@ import (
 
 // CRLConfig holds basic CRL configuration information
 type crlConfig struct {
-	Expiry  string `json:"expiry" mapstructure:"expiry"`
-	Disable bool   `json:"disable"`
+	Expiry                 string `json:"expiry" mapstructure:"expiry"`
+	Disable                bool   `json:"disable"`
+	OcspDisable            bool   `json:"ocsp_disable"`
+	OcspExpiry             string `json:"ocsp_expiry"`
+	AutoRebuild            bool   `json:"auto_rebuild"`
+	AutoRebuildGracePeriod string `json:"auto_rebuild_grace_period"`
+	EnableDelta            bool   `json:"enable_delta"`
+	DeltaRebuildInterval   string `json:"delta_rebuild_interval"`
+}
+
+// Default configuration
+var defaultCrlConfig = crlConfig{
+	Expiry:                 "72h",
+	Disable:                false,
+	OcspDisable:            false,
+	OcspExpiry:             "12h",
+	AutoRebuild:            false,
+	AutoRebuildGracePeriod: "12h",
+	EnableDelta:            false,
+	DeltaRebuildInterval:   "15m",
 }
 
 func pathConfigCRL(b *backend) *framework.Path {
@@ -30,6 +48,41 @@ valid; defaults to 72 hours`,
 				Type:        framework.TypeBool,
 				Description: `If set to true, disables generating the CRL entirely.`,
 			},
+			"ocsp_disable": {
+				Type:        framework.TypeBool,
+				Description: `If set to true, ocsp unauthorized responses will be returned.`,
+			},
+			"ocsp_expiry": {
+				Type: framework.TypeString,
+				Description: `The amount of time an OCSP response will be valid (controls
+the NextUpdate field); defaults to 12 hours. Set to 0 to omit NextUpdate,
+indicating newer revocation information is always available.`,
+				Default: "12h",
+			},
+			"auto_rebuild": {
+				Type: framework.TypeBool,
+				Description: `If set to true, enables automatic rebuilding of the CRL
+on a schedule rather than on every revocation.`,
+			},
+			"auto_rebuild_grace_period": {
+				Type: framework.TypeString,
+				Description: `The amount of time before the CRL expires that an
+automatic rebuild occurs; defaults to 12 hours. Must be shorter than the
+CRL expiry.`,
+				Default: "12h",
+			},
+			"enable_delta": {
+				Type: framework.TypeBool,
+				Description: `Whether to enable delta CRLs between complete CRL
+rebuilds. Requires auto_rebuild to be enabled.`,
+			},
+			"delta_rebuild_interval": {
+				Type: framework.TypeString,
+				Description: `The time between delta CRL rebuilds if a new
+revocation has occurred; defaults to 15 minutes. Must be shorter than the
+CRL expiry.`,
+				Default: "15m",
+			},
 		},
 
 		Operations: map[logical.Operation]framework.OperationHandler{
@@ -55,9 +108,8 @@ func (b *backend) CRL(ctx context.Context, s logical.Storage) (*crlConfig, error
 		return nil, err
 	}
 
-	var result crlConfig
+	result := defaultCrlConfig
 	result.Expiry = b.crlLifetime.String()
-	result.Disable = false
 
 	if entry == nil {
 		return &result, nil
@@ -78,8 +130,14 @@ func (b *backend) pathCRLRead(ctx context.Context, req *logical.Request, _ *fram
 
 	return &logical.Response{
 		Data: map[string]interface{}{
-			"expiry":  config.Expiry,
-			"disable": config.Disable,
+			"expiry":                    config.Expiry,
+			"disable":                   config.Disable,
+			"ocsp_disable":              config.OcspDisable,
+			"ocsp_expiry":               config.OcspExpiry,
+			"auto_rebuild":              config.AutoRebuild,
+			"auto_rebuild_grace_period": config.AutoRebuildGracePeriod,
+			"enable_delta":              config.EnableDelta,
+			"delta_rebuild_interval":    config.DeltaRebuildInterval,
 		},
 	}, nil
 }
@@ -105,6 +163,67 @@ func (b *backend) pathCRLWrite(ctx context.Context, req *logical.Request, d *fra
 		config.Disable = disableRaw.(bool)
 	}
 
+	if ocspDisableRaw, ok := d.GetOk("ocsp_disable"); ok {
+		config.OcspDisable = ocspDisableRaw.(bool)
+	}
+
+	if ocspExpiryRaw, ok := d.GetOk("ocsp_expiry"); ok {
+		ocspExpiry := ocspExpiryRaw.(string)
+		duration, err := time.ParseDuration(ocspExpiry)
+		if err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("given ocsp_expiry could not be decoded: %s", err)), nil
+		}
+		if duration < 0 {
+			return logical.ErrorResponse("ocsp_expiry must be greater than or equal to 0"), nil
+		}
+		config.OcspExpiry = ocspExpiry
+	}
+
+	oldAutoRebuild := config.AutoRebuild
+	if autoRebuildRaw, ok := d.GetOk("auto_rebuild"); ok {
+		config.AutoRebuild = autoRebuildRaw.(bool)
+	}
+
+	if autoRebuildGracePeriodRaw, ok := d.GetOk("auto_rebuild_grace_period"); ok {
+		autoRebuildGracePeriod := autoRebuildGracePeriodRaw.(string)
+		if _, err := time.ParseDuration(autoRebuildGracePeriod); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("given auto_rebuild_grace_period could not be decoded: %s", err)), nil
+		}
+		config.AutoRebuildGracePeriod = autoRebuildGracePeriod
+	}
+
+	oldEnableDelta := config.EnableDelta
+	if enableDeltaRaw, ok := d.GetOk("enable_delta"); ok {
+		config.EnableDelta = enableDeltaRaw.(bool)
+	}
+
+	if deltaRebuildIntervalRaw, ok := d.GetOk("delta_rebuild_interval"); ok {
+		deltaRebuildInterval := deltaRebuildIntervalRaw.(string)
+		if _, err := time.ParseDuration(deltaRebuildInterval); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("given delta_rebuild_interval could not be decoded: %s", err)), nil
+		}
+		config.DeltaRebuildInterval = deltaRebuildInterval
+	}
+
+	expiry, _ := time.ParseDuration(config.Expiry)
+	if config.AutoRebuild {
+		gracePeriod, _ := time.ParseDuration(config.AutoRebuildGracePeriod)
+		if gracePeriod >= expiry {
+			return logical.ErrorResponse(fmt.Sprintf("CRL auto-rebuilding grace period (%v) must be strictly shorter than CRL expiry (%v) value when auto-rebuilding of CRLs is enabled", config.AutoRebuildGracePeriod, config.Expiry)), nil
+		}
+	}
+
+	if config.EnableDelta {
+		if !config.AutoRebuild {
+			return logical.ErrorResponse("delta CRLs require auto_rebuild to be enabled"), nil
+		}
+
+		deltaRebuildInterval, _ := time.ParseDuration(config.DeltaRebuildInterval)
+		if deltaRebuildInterval >= expiry {
+			return logical.ErrorResponse(fmt.Sprintf("CRL delta rebuild window (%v) must be strictly shorter than CRL expiry (%v) value when delta CRLs are enabled", config.DeltaRebuildInterval, config.Expiry)), nil
+		}
+	}
+
 	entry, err := logical.StorageEntryJSON("config/crl", config)
 	if err != nil {
 		return nil, err
@@ -114,8 +233,10 @@ func (b *backend) pathCRLWrite(ctx context.Context, req *logical.Request, d *fra
 		return nil, err
 	}
 
-	if oldDisable != config.Disable {
-		// It wasn't disabled but now it is, rotate
+	// A change in whether the CRL is disabled, turning off auto-rebuild
+	// (which may leave revocations off the current CRL), or toggling delta
+	// CRLs (which changes the contents of the complete CRL) all rotate.
+	if oldDisable != config.Disable || (oldAutoRebuild && !config.AutoRebuild) || oldEnableDelta != config.EnableDelta {
 		crlErr := b.crlBuilder.rebuild(ctx, b, req, true)
 		if crlErr != nil {
 			switch crlErr.(type) {
@@ -131,9 +252,15 @@ func (b *backend) pathCRLWrite(ctx context.Context, req *logical.Request, d *fra
 }
 
 const pathConfigCRLHelpSyn = `
-Configure the CRL expiration.
+Configure the CRL and OCSP expiration and rebuilding.
 `
 
 const pathConfigCRLHelpDesc = `
-This endpoint allows configuration of the CRL lifetime.
+This endpoint allows configuration of the CRL lifetime, of whether and
+for how long OCSP responses are served, and of how CRLs are rebuilt.
+
+With auto_rebuild set, revocations no longer rebuild the CRL; instead it is
+periodically rebuilt auto_rebuild_grace_period before it expires. To publish
+revocations sooner, enable_delta additionally builds delta CRLs every
+delta_rebuild_interval.
 `
@@ -32,6 +32,13 @@ for the CRL distribution points attribute. See also RFC 5280 Section 4.2.1.13.`,
 				Description: `Comma-separated list of URLs to be used
 for the OCSP servers attribute. See also RFC 5280 Section 4.2.2.1.`,
 			},
+
+			"delta_crl_distribution_points": {
+				Type: framework.TypeCommaStringSlice,
+				Description: `Comma-separated list of URLs to be used
+for the Freshest CRL (delta CRL distribution points) attribute, on both
+issued certificates and complete CRLs. See also RFC 5280 Section 4.2.1.15.`,
+			},
 		},
 
 		Operations: map[logical.Operation]framework.OperationHandler{
@@ -138,12 +145,20 @@ func (b *backend) pathWriteURL(ctx context.Context, req *logical.Request, data *
 				"invalid URL found in OCSP servers: %s", badURL)), nil
 		}
 	}
+	if urlsInt, ok := data.GetOk("delta_crl_distribution_points"); ok {
+		entries.DeltaCRLDistributionPoints = urlsInt.([]string)
+		if badURL := validateURLs(entries.DeltaCRLDistributionPoints); badURL != "" {
+			return logical.ErrorResponse(fmt.Sprintf(
+				"invalid URL found in delta CRL distribution points: %s", badURL)), nil
+		}
+	}
 
 	return nil, writeURLs(ctx, req, entries)
 }
 
 const pathConfigURLsHelpSyn = `
-Set the URLs for the issuing CA, CRL distribution points, and OCSP servers.
+Set the URLs for the issuing CA, CRL distribution points, delta CRL
+distribution points, and OCSP servers.
 `
 
 const pathConfigURLsHelpDesc = `
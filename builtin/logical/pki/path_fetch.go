@@ -46,7 +46,7 @@ func pathFetchCAChain(b *backend) *framework.Path {
 // Returns the CRL in raw format
 func pathFetchCRL(b *backend) *framework.Path {
 	return &framework.Path{
-		Pattern: `crl(/pem)?`,
+		Pattern: `crl(/pem|/delta(/pem)?)?`,
 
 		Operations: map[logical.Operation]framework.OperationHandler{
 			logical.ReadOperation: &framework.PathOperation{
@@ -109,7 +109,7 @@ hyphen-separated octal`,
 // This returns the CRL in a non-raw format
 func pathFetchCRLViaCertPath(b *backend) *framework.Path {
 	return &framework.Path{
-		Pattern: `cert/crl`,
+		Pattern: `cert/(crl|delta-crl)`,
 
 		Operations: map[logical.Operation]framework.OperationHandler{
 			logical.ReadOperation: &framework.PathOperation{
@@ -185,9 +185,19 @@ func (b *backend) pathFetchRead(ctx context.Context, req *logical.Request, data
 			pemType = "X509 CRL"
 			contentType = "application/x-pem-file"
 		}
+	case req.Path == "crl/delta" || req.Path == "crl/delta/pem":
+		serial = deltaCRLPath
+		contentType = "application/pkix-crl"
+		if req.Path == "crl/delta/pem" {
+			pemType = "X509 CRL"
+			contentType = "application/x-pem-file"
+		}
 	case req.Path == "cert/crl":
 		serial = legacyCRLPath
 		pemType = "X509 CRL"
+	case req.Path == "cert/delta-crl":
+		serial = deltaCRLPath
+		pemType = "X509 CRL"
 	case strings.HasSuffix(req.Path, "/pem") || strings.HasSuffix(req.Path, "/raw"):
 		serial = data.Get("serial").(string)
 		contentType = "application/pkix-cert"
@@ -344,6 +354,8 @@ This allows certificates to be fetched. Use /cert/:serial for JSON responses.
 
 Using "ca" or "crl" as the value fetches the appropriate information in DER encoding. Add "/pem" to either to get PEM encoding.
 
+Using "crl/delta" fetches the delta CRL in DER encoding, if delta CRLs are enabled; add "/pem" to get PEM encoding, or use "cert/delta-crl" for a JSON response.
+
 Using "ca_chain" as the value fetches the certificate authority trust chain in PEM encoding.
 
 Otherwise, specify a serial number to fetch the specified certificate. Add "/raw" to get just the certificate in DER form, "/raw/pem" to get the PEM encoded certificate.
@@ -635,7 +635,7 @@ the certificate.
 )
 
 func pathGetIssuerCRL(b *backend) *framework.Path {
-	pattern := "issuer/" + framework.GenericNameRegex(issuerRefParam) + "/crl(/pem|/der)?"
+	pattern := "issuer/" + framework.GenericNameRegex(issuerRefParam) + "/crl(/pem|/der|/delta(/pem|/der)?)?"
 	return buildPathGetIssuerCRL(b, pattern)
 }
 
@@ -678,6 +678,10 @@ func (b *backend) pathGetIssuerCRL(ctx context.Context, req *logical.Request, da
 		return nil, err
 	}
 
+	if strings.Contains(req.Path, "/crl/delta") {
+		crlPath += deltaCRLPathSuffix
+	}
+
 	crlEntry, err := req.Storage.Get(ctx, crlPath)
 	if err != nil {
 		return nil, err
@@ -747,5 +751,8 @@ or its assigned name value.
  - /issuer/:ref/crl is JSON encoded and contains a PEM CRL,
  - /issuer/:ref/crl/pem contains the PEM-encoded CRL,
  - /issuer/:ref/crl/DER contains the raw DER-encoded (binary) CRL.
+
+Each of these may be suffixed with /delta (e.g., /issuer/:ref/crl/delta/pem)
+to instead fetch the issuer's delta CRL, if delta CRLs are enabled.
 `
 )
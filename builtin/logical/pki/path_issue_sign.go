@@ -139,6 +139,13 @@ this value to an empty list.`,
 		Description: `A comma-separated string or list of extended key usage oids.`,
 	}
 
+	ret.Fields["policy_identifiers"] = &framework.FieldSchema{
+		Type: framework.TypeCommaStringSlice,
+		Description: `A comma-separated string or list of policy OIDs, or a JSON list of qualified policy
+information, which must include an oid, and may include a notice and/or cps url. These
+replace any certificate policies in the CSR. Defaults to the role's policy_identifiers.`,
+	}
+
 	return ret
 }
 
@@ -217,6 +224,18 @@ func (b *backend) pathSignVerbatim(ctx context.Context, req *logical.Request, da
 		}
 		entry.NoStore = role.NoStore
 		entry.Issuer = role.Issuer
+		entry.PolicyIdentifiers = role.PolicyIdentifiers
+		entry.Extensions = role.Extensions
+		entry.ExtensionsTemplate = role.ExtensionsTemplate
+		entry.AllowedExtensions = role.AllowedExtensions
+	} else {
+		// Without a role, any extension Vault doesn't manage itself may be
+		// requested.
+		entry.AllowedExtensions = []string{"*"}
+	}
+
+	if _, ok := data.GetOk("policy_identifiers"); ok {
+		entry.PolicyIdentifiers = getPolicyIdentifier(data, nil)
 	}
 
 	if len(entry.Issuer) == 0 {
@@ -385,10 +404,7 @@ func (b *backend) pathIssueSignCert(ctx context.Context, req *logical.Request, d
 	}
 
 	if !role.NoStore {
-		err = req.Storage.Put(ctx, &logical.StorageEntry{
-			Key:   "certs/" + normalizeSerial(cb.SerialNumber),
-			Value: parsedBundle.CertificateBytes,
-		})
+		err = storeCertificate(ctx, b, req.Storage, parsedBundle, issuerName, data.Get("role").(string))
 		if err != nil {
 			return nil, fmt.Errorf("unable to store certificate locally: %w", err)
 		}
@@ -3,11 +3,17 @@ package pki
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/x509"
 	"encoding/pem"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
@@ -51,6 +57,80 @@ func pathCrossSignIntermediate(b *backend) *framework.Path {
 	return buildPathGenerateIntermediate(b, "intermediate/cross-sign")
 }
 
+func pathIssuerCrossSign(b *backend) *framework.Path {
+	ret := buildPathIssuerReissue(b, "issuer/"+framework.GenericNameRegex(issuerRefParam)+"/cross-sign", pathIssuerCrossSignHelpSyn, pathIssuerCrossSignHelpDesc)
+	ret.Fields["attach_chains"] = &framework.FieldSchema{
+		Type: framework.TypeBool,
+		Description: `Whether to set the manual chain of both the existing and
+the new issuer to include the other's chain, so that either presents both
+validation paths. Manual chains aren't updated as other issuers change;
+issuers whose manual chain is already set are left unchanged. Defaults to
+false, leaving both issuers' chains to automatic chain building.`,
+		Default: false,
+	}
+	return ret
+}
+
+func pathIssuerReissue(b *backend) *framework.Path {
+	return buildPathIssuerReissue(b, "issuer/"+framework.GenericNameRegex(issuerRefParam)+"/reissue", pathIssuerReissueHelpSyn, pathIssuerReissueHelpDesc)
+}
+
+func buildPathIssuerReissue(b *backend, pattern string, helpSyn string, helpDesc string) *framework.Path {
+	ret := &framework.Path{
+		Pattern: pattern,
+		Fields: map[string]*framework.FieldSchema{
+			"signing_issuer": {
+				Type: framework.TypeString,
+				Description: `Reference to the existing issuer whose key signs the
+new certificate; either "default" for the configured default issuer, an
+identifier or the name assigned to the issuer. Required when cross-signing;
+when reissuing, defaults to the issuer which signed the existing certificate.`,
+			},
+			"ttl": {
+				Type: framework.TypeDurationSecond,
+				Description: `The requested Time To Live for the new certificate.
+When cross-signing, defaults to the expiry of the existing certificate; when
+reissuing, to its original validity period.`,
+			},
+			"not_after": {
+				Type: framework.TypeString,
+				Description: `Set the not after field of the certificate with specified date value.
+The value format should be given in UTC format YYYY-MM-ddTHH:MM:SSZ`,
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathIssuerReissue,
+				// Read more about why these flags are set in backend.go
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: true,
+			},
+		},
+
+		HelpSynopsis:    helpSyn,
+		HelpDescription: helpDesc,
+	}
+
+	ret.Fields = addIssuerRefNameFields(ret.Fields)
+	return ret
+}
+
+func pathIssuersChainGraph(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "issuers/graph",
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathIssuersChainGraphRead,
+			},
+		},
+
+		HelpSynopsis:    pathIssuersChainGraphHelpSyn,
+		HelpDescription: pathIssuersChainGraphHelpDesc,
+	}
+}
+
 func buildPathGenerateIntermediate(b *backend, pattern string) *framework.Path {
 	ret := &framework.Path{
 		Pattern: pattern,
@@ -266,6 +346,249 @@ func (b *backend) pathImportIssuers(ctx context.Context, req *logical.Request, d
 	return response, nil
 }
 
+func (b *backend) pathIssuerReissue(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	// Since we're planning on adding an issuer here, grab the lock so we've
+	// got a consistent view.
+	b.issuersLock.Lock()
+	defer b.issuersLock.Unlock()
+
+	if b.useLegacyBundleCaStorage() {
+		return logical.ErrorResponse("Can not cross-sign or reissue issuers until migration has completed"), nil
+	}
+
+	crossSign := strings.HasSuffix(req.Path, "/cross-sign")
+
+	issuerName, err := getIssuerName(ctx, req.Storage, data)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	targetId, err := resolveIssuerReference(ctx, req.Storage, getIssuerRef(data))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+	target, err := fetchIssuerById(ctx, req.Storage, targetId)
+	if err != nil {
+		return nil, err
+	}
+	targetCert, err := target.GetCertificate()
+	if err != nil {
+		return nil, err
+	}
+
+	var signerId issuerID
+	signingRef := data.Get("signing_issuer").(string)
+	switch {
+	case len(signingRef) > 0:
+		signerId, err = resolveIssuerReference(ctx, req.Storage, signingRef)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+	case crossSign:
+		return logical.ErrorResponse("missing signing_issuer: the issuer whose key cross-signs this issuer must be specified"), nil
+	default:
+		signerId, err = findSigningIssuer(ctx, req.Storage, targetId, targetCert)
+		if err != nil {
+			return nil, err
+		}
+		if len(signerId) == 0 {
+			return logical.ErrorResponse(fmt.Sprintf("unable to find an issuer with a key which signed issuer %v; specify signing_issuer", targetId)), nil
+		}
+	}
+
+	if crossSign && signerId == targetId {
+		return logical.ErrorResponse("refusing to cross-sign an issuer with itself; use the reissue endpoint instead"), nil
+	}
+
+	signingBundle, err := fetchCAInfoByIssuerId(ctx, b, req, signerId, IssuanceUsage)
+	if err != nil {
+		switch err.(type) {
+		case errutil.UserError:
+			return logical.ErrorResponse(err.Error()), nil
+		default:
+			return nil, err
+		}
+	}
+
+	ttl := time.Duration(data.Get("ttl").(int)) * time.Second
+	notAfterRaw := data.Get("not_after").(string)
+	var notAfter time.Time
+	switch {
+	case ttl > 0 && len(notAfterRaw) > 0:
+		return logical.ErrorResponse("Either ttl or not_after should be provided. Both should not be provided in the same request."), nil
+	case len(notAfterRaw) > 0:
+		notAfter, err = time.Parse(time.RFC3339, notAfterRaw)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+	case ttl > 0:
+		notAfter = time.Now().Add(ttl)
+	case crossSign:
+		notAfter = targetCert.NotAfter
+	default:
+		notAfter = time.Now().Add(targetCert.NotAfter.Sub(targetCert.NotBefore))
+	}
+	if !notAfter.After(time.Now()) {
+		return logical.ErrorResponse("the requested expiration time for the new certificate is in the past"), nil
+	}
+
+	// Keep the existing certificate's subject, key, constraints and
+	// extensions, so that the new certificate is interchangeable with it
+	// in any chain.
+	template := *targetCert
+	template.SerialNumber, err = certutil.GenerateSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+	template.NotBefore = time.Now().Add(-30 * time.Second)
+	template.NotAfter = notAfter
+	template.AuthorityKeyId = signingBundle.Certificate.SubjectKeyId
+
+	urls := &certutil.URLEntries{}
+	if signingBundle.URLs != nil {
+		urls = signingBundle.URLs
+	}
+	template.IssuingCertificateURL = urls.IssuingCertificates
+	template.CRLDistributionPoints = urls.CRLDistributionPoints
+	template.OCSPServer = urls.OCSPServers
+
+	_, template.SignatureAlgorithm, err = publicKeyType(signingBundle.Certificate.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("error determining signing certificate algorithm type: %w", err)
+	}
+
+	newCert, err := x509.CreateCertificate(rand.Reader, &template, signingBundle.Certificate, targetCert.PublicKey, signingBundle.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("error signing certificate: %w", err)
+	}
+	parsedCert, err := x509.ParseCertificate(newCert)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing signed certificate: %w", err)
+	}
+	pemCert := strings.TrimSpace(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: newCert})))
+
+	// Importing the certificate links it to the existing issuer's key and
+	// rebuilds every issuer's chain, including the new certificate.
+	issuer, _, err := importIssuer(ctx, b, req.Storage, pemCert, issuerName)
+	if err != nil {
+		switch err.(type) {
+		case errutil.UserError:
+			return logical.ErrorResponse(err.Error()), nil
+		default:
+			return nil, err
+		}
+	}
+
+	issuer.LeafNotAfterBehavior = target.LeafNotAfterBehavior
+	issuer.Usage = target.Usage
+	if err := writeIssuer(ctx, req.Storage, issuer); err != nil {
+		return nil, err
+	}
+
+	if crossSign && data.Get("attach_chains").(bool) {
+		if err := attachCrossSignedIssuer(ctx, req.Storage, targetId, issuer.ID); err != nil {
+			return nil, err
+		}
+		issuer, err = fetchIssuerById(ctx, req.Storage, issuer.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Also store it as just the certificate identified by serial number, so it
+	// can be revoked
+	err = storeCertificate(ctx, b, req.Storage, &certutil.ParsedCertBundle{
+		Certificate:      parsedCert,
+		CertificateBytes: newCert,
+	}, signerId.String(), "")
+	if err != nil {
+		return nil, fmt.Errorf("unable to store certificate locally: %w", err)
+	}
+
+	if err := b.crlBuilder.rebuild(ctx, b, req, true); err != nil {
+		return nil, err
+	}
+
+	graph, err := fetchIssuerChainGraph(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"issuer_id":         issuer.ID,
+			"issuer_name":       issuer.Name,
+			"key_id":            issuer.KeyID,
+			"certificate":       pemCert,
+			"serial_number":     issuer.SerialNumber,
+			"signing_issuer_id": signerId,
+			"ca_chain":          issuer.CAChain,
+			"chain_graph":       issuerChainGraphToResponseData(graph),
+		},
+	}
+
+	if signingBundle.Certificate.NotAfter.Before(parsedCert.NotAfter) && signerId != targetId {
+		resp.AddWarning("The expiration time for the signed certificate is after the CA's expiration time. If the new certificate is not treated as a root, validation paths with the certificate past the issuing CA's expiration time will fail.")
+	}
+
+	return resp, nil
+}
+
+// findSigningIssuer returns the issuer with a key which signed the given
+// issuer's certificate, preferring the issuer itself when it is
+// self-signed. When no such issuer is known, an empty identifier is
+// returned.
+func findSigningIssuer(ctx context.Context, s logical.Storage, id issuerID, cert *x509.Certificate) (issuerID, error) {
+	if bytes.Equal(cert.RawIssuer, cert.RawSubject) && cert.CheckSignatureFrom(cert) == nil {
+		return id, nil
+	}
+
+	issuers, err := listIssuers(ctx, s)
+	if err != nil {
+		return "", err
+	}
+	sort.Slice(issuers, func(i, j int) bool {
+		return issuers[i] < issuers[j]
+	})
+
+	for _, candidate := range issuers {
+		entry, err := fetchIssuerById(ctx, s, candidate)
+		if err != nil {
+			return "", err
+		}
+		if len(entry.KeyID) == 0 {
+			continue
+		}
+
+		candidateCert, err := entry.GetCertificate()
+		if err != nil {
+			return "", err
+		}
+		if bytes.Equal(cert.RawIssuer, candidateCert.RawSubject) && cert.CheckSignatureFrom(candidateCert) == nil {
+			return candidate, nil
+		}
+	}
+
+	return "", nil
+}
+
+func (b *backend) pathIssuersChainGraphRead(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	if b.useLegacyBundleCaStorage() {
+		return logical.ErrorResponse("Can not read the issuer chain graph until migration has completed"), nil
+	}
+
+	graph, err := fetchIssuerChainGraph(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"chain_graph": issuerChainGraphToResponseData(graph),
+		},
+	}, nil
+}
+
 const (
 	pathImportIssuersHelpSyn  = `Import the specified issuing certificates.`
 	pathImportIssuersHelpDesc = `
@@ -280,3 +603,38 @@ either take PEM-formatted certificates, and, if :type="bundle", unencrypted
 secret-keys.
 `
 )
+
+const (
+	pathIssuerCrossSignHelpSyn  = `Cross-sign the specified issuer with another issuer's key.`
+	pathIssuerCrossSignHelpDesc = `
+This endpoint creates a new certificate for the specified issuer, with its
+subject, key and extensions, signed by the issuer given as signing_issuer.
+The new certificate is imported as an issuer sharing the existing issuer's
+key, and every issuer's CA chain is rebuilt to include it. With
+attach_chains set, the manual chains of both issuers are also set to present
+both validation paths.
+
+The response includes the resulting graph of issuers, as returned by the
+issuers/graph endpoint.
+`
+
+	pathIssuerReissueHelpSyn  = `Reissue the specified issuer with the same key and a new validity period.`
+	pathIssuerReissueHelpDesc = `
+This endpoint creates a new certificate for the specified issuer, with its
+subject, key and extensions but a new validity period. Unless signing_issuer
+is given, it is signed by the issuer which signed the existing certificate,
+or by the issuer itself when it is a root. The new certificate is imported as
+an issuer sharing the existing issuer's key, and every issuer's CA chain is
+rebuilt to include it.
+
+The response includes the resulting graph of issuers, as returned by the
+issuers/graph endpoint.
+`
+
+	pathIssuersChainGraphHelpSyn  = `Read the graph of issuers and their computed chains.`
+	pathIssuersChainGraphHelpDesc = `
+This endpoint returns, for every issuer, the issuers which signed it
+(parents), the issuers it signed (children) and the identifiers of the
+issuers making up its computed CA chain, in order.
+`
+)
@@ -0,0 +1,191 @@
+package pki
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssuerCrossSignAndReissue(t *testing.T) {
+	t.Parallel()
+
+	b, s := createBackendWithStorage(t)
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "Root A",
+		"issuer_name": "root-a",
+		"key_type":    "ec",
+		"ttl":         "40h",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	rootAID := resp.Data["issuer_id"].(issuerID)
+	resp, err = CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "Root B",
+		"issuer_name": "root-b",
+		"key_type":    "rsa",
+		"ttl":         "40h",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	rootBID := resp.Data["issuer_id"].(issuerID)
+
+	resp, err = CBWrite(b, s, "intermediate/generate/internal", map[string]interface{}{
+		"common_name": "Intermediate",
+		"key_type":    "ec",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	csr := resp.Data["csr"].(string)
+	resp, err = CBWrite(b, s, "issuer/root-a/sign-intermediate", map[string]interface{}{
+		"csr": csr,
+		"ttl": "20h",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	resp, err = CBWrite(b, s, "intermediate/set-signed", map[string]interface{}{
+		"certificate": resp.Data["certificate"],
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	intID := issuerID(resp.Data["imported_issuers"].([]string)[0])
+	_, err = CBWrite(b, s, "issuer/"+intID.String(), map[string]interface{}{
+		"issuer_name": "int",
+	})
+	require.NoError(t, err)
+
+	resp, err = CBRead(b, s, "issuer/int")
+	requireSuccessNonNilResponse(t, resp, err)
+	intKeyID := resp.Data["key_id"].(keyID)
+	intCert := parseCert(t, resp.Data["certificate"].(string))
+
+	// Cross-signing requires another issuer.
+	_, err = CBWrite(b, s, "issuer/int/cross-sign", map[string]interface{}{})
+	require.Error(t, err)
+	_, err = CBWrite(b, s, "issuer/int/cross-sign", map[string]interface{}{
+		"signing_issuer": "int",
+	})
+	require.Error(t, err)
+
+	resp, err = CBWrite(b, s, "issuer/int/cross-sign", map[string]interface{}{
+		"signing_issuer": "root-b",
+		"issuer_name":    "int-cross",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	crossID := resp.Data["issuer_id"].(issuerID)
+	require.Equal(t, "int-cross", resp.Data["issuer_name"])
+	require.Equal(t, intKeyID, resp.Data["key_id"])
+	require.Equal(t, rootBID, resp.Data["signing_issuer_id"])
+
+	crossCert := parseCert(t, resp.Data["certificate"].(string))
+	require.Equal(t, intCert.RawSubject, crossCert.RawSubject)
+	require.Equal(t, intCert.SubjectKeyId, crossCert.SubjectKeyId)
+	require.True(t, crossCert.IsCA)
+	require.Equal(t, "Root B", crossCert.Issuer.CommonName)
+	require.WithinDuration(t, intCert.NotAfter, crossCert.NotAfter, time.Second)
+	equal, err := certutil.ComparePublicKeysAndType(intCert.PublicKey, crossCert.PublicKey)
+	require.NoError(t, err)
+	require.True(t, equal)
+
+	graph := resp.Data["chain_graph"].(map[string]interface{})
+	crossNode := graph[crossID.String()].(map[string]interface{})
+	require.Equal(t, []issuerID{rootBID}, crossNode["parents"])
+	require.Equal(t, []issuerID{crossID, rootBID}, crossNode["ca_chain"])
+	intNode := graph[intID.String()].(map[string]interface{})
+	require.Equal(t, []issuerID{rootAID}, intNode["parents"])
+	require.Equal(t, []issuerID{intID, rootAID}, intNode["ca_chain"])
+
+	// Without attach_chains, neither chain is frozen with a manual chain.
+	for _, ref := range []string{"int", "int-cross"} {
+		resp, err = CBRead(b, s, "issuer/"+ref)
+		requireSuccessNonNilResponse(t, resp, err)
+		require.Empty(t, resp.Data["manual_chain"])
+	}
+	rootBNode := graph[rootBID.String()].(map[string]interface{})
+	require.Equal(t, []issuerID{crossID}, rootBNode["children"])
+
+	// The cross-signed certificate is stored so that it can be revoked.
+	resp, err = CBRead(b, s, "cert/"+certutil.GetHexFormatted(crossCert.SerialNumber.Bytes(), ":"))
+	requireSuccessNonNilResponse(t, resp, err)
+
+	// Reissuing the intermediate defaults to its original signer; a
+	// validity past that of the signer is permitted with a warning.
+	resp, err = CBWrite(b, s, "issuer/int/reissue", map[string]interface{}{
+		"ttl": "50h",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, rootAID, resp.Data["signing_issuer_id"])
+	require.Equal(t, intKeyID, resp.Data["key_id"])
+	require.NotEmpty(t, resp.Warnings)
+	reissuedCert := parseCert(t, resp.Data["certificate"].(string))
+	require.Equal(t, intCert.RawSubject, reissuedCert.RawSubject)
+	require.WithinDuration(t, time.Now().Add(50*time.Hour), reissuedCert.NotAfter, time.Minute)
+	require.NotEqual(t, intCert.SerialNumber, reissuedCert.SerialNumber)
+
+	// Reissuing a root signs it with its own key.
+	resp, err = CBWrite(b, s, "issuer/root-a/reissue", map[string]interface{}{
+		"issuer_name": "root-a-2",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, rootAID, resp.Data["signing_issuer_id"])
+	newRootID := resp.Data["issuer_id"].(issuerID)
+	newRootCert := parseCert(t, resp.Data["certificate"].(string))
+	require.NoError(t, newRootCert.CheckSignatureFrom(newRootCert))
+
+	resp, err = CBRead(b, s, "issuers/graph")
+	requireSuccessNonNilResponse(t, resp, err)
+	graph = resp.Data["chain_graph"].(map[string]interface{})
+	require.Len(t, graph, 6)
+	require.Equal(t, "root-a-2", graph[newRootID.String()].(map[string]interface{})["issuer_name"])
+	require.Contains(t, graph[newRootID.String()].(map[string]interface{})["ca_chain"], rootAID)
+
+	_, err = CBWrite(b, s, "issuer/int/reissue", map[string]interface{}{
+		"ttl":       "1h",
+		"not_after": "9999-12-31T23:59:59Z",
+	})
+	require.Error(t, err)
+}
+
+func TestIssuerCrossSignAttachChains(t *testing.T) {
+	t.Parallel()
+
+	b, s := createBackendWithStorage(t)
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "Root A",
+		"issuer_name": "root-a",
+		"key_type":    "ec",
+		"ttl":         "40h",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	rootAID := resp.Data["issuer_id"].(issuerID)
+	resp, err = CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "Root B",
+		"issuer_name": "root-b",
+		"key_type":    "ec",
+		"ttl":         "40h",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+
+	// Cross-signing a root with an operator-set manual chain keeps it.
+	_, err = CBWrite(b, s, "issuer/root-a", map[string]interface{}{
+		"issuer_name":  "root-a",
+		"manual_chain": "self",
+	})
+	require.NoError(t, err)
+
+	resp, err = CBWrite(b, s, "issuer/root-a/cross-sign", map[string]interface{}{
+		"signing_issuer": "root-b",
+		"issuer_name":    "root-a-cross",
+		"attach_chains":  true,
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	crossID := resp.Data["issuer_id"].(issuerID)
+
+	resp, err = CBRead(b, s, "issuer/root-a")
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, []string{rootAID.String()}, resp.Data["manual_chain"])
+
+	// The cross-signed issuer, without a manual chain, is still linked.
+	resp, err = CBRead(b, s, "issuer/root-a-cross")
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Contains(t, resp.Data["manual_chain"], rootAID.String())
+	require.Contains(t, resp.Data["manual_chain"], crossID.String())
+}
@@ -0,0 +1,347 @@
+package pki
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	ocspReqParam            = "req"
+	ocspResponseContentType = "application/ocsp-response"
+
+	// The largest POSTed OCSP request we're willing to read. A request for
+	// a single certificate is on the order of a hundred bytes.
+	ocspMaximumRequestSize = 2048
+)
+
+// ocspRespInfo holds the status of the certificate an OCSP request asked
+// about, along with the issuer which will sign the response.
+type ocspRespInfo struct {
+	serialNumber      *big.Int
+	ocspStatus        int
+	revocationTimeUTC time.Time
+	issuerID          issuerID
+}
+
+func pathOcspGet(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "ocsp/" + framework.MatchAllRegex(ocspReqParam),
+		Fields: map[string]*framework.FieldSchema{
+			ocspReqParam: {
+				Type:        framework.TypeString,
+				Description: `The base64-encoded (and then URL-encoded) DER OCSP request.`,
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.ocspHandler,
+			},
+		},
+
+		HelpSynopsis:    pathOcspHelpSyn,
+		HelpDescription: pathOcspHelpDesc,
+	}
+}
+
+func pathOcspPost(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "ocsp",
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.ocspHandler,
+			},
+		},
+
+		HelpSynopsis:    pathOcspHelpSyn,
+		HelpDescription: pathOcspHelpDesc,
+	}
+}
+
+func (b *backend) ocspHandler(ctx context.Context, request *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	cfg, err := b.CRL(ctx, request.Storage)
+	if err != nil {
+		return logAndReturnOcspInternalError(b, err), nil
+	}
+	if cfg.OcspDisable || b.useLegacyBundleCaStorage() {
+		return ocspErrorResponse(http.StatusUnauthorized, ocsp.UnauthorizedErrorResponse), nil
+	}
+
+	derReq, err := fetchDerEncodedOcspRequest(request, data)
+	if err != nil {
+		return ocspErrorResponse(http.StatusBadRequest, ocsp.MalformedRequestErrorResponse), nil
+	}
+
+	ocspReq, err := ocsp.ParseRequest(derReq)
+	if err != nil {
+		return ocspErrorResponse(http.StatusBadRequest, ocsp.MalformedRequestErrorResponse), nil
+	}
+
+	ocspStatus, err := b.lookupOcspStatus(ctx, request, ocspReq)
+	if err != nil {
+		return logAndReturnOcspInternalError(b, err), nil
+	}
+	if ocspStatus == nil {
+		// None of our issuers match the request; per RFC 6960 Section
+		// 2.3, we're not authoritative for it.
+		return ocspErrorResponse(http.StatusUnauthorized, ocsp.UnauthorizedErrorResponse), nil
+	}
+
+	signingBundle, err := fetchCAInfoByIssuerId(ctx, b, request, ocspStatus.issuerID, CRLSigningUsage)
+	if err != nil {
+		return logAndReturnOcspInternalError(b, err), nil
+	}
+
+	expiry, err := time.ParseDuration(cfg.OcspExpiry)
+	if err != nil {
+		return logAndReturnOcspInternalError(b, fmt.Errorf("failed to parse ocsp_expiry: %w", err)), nil
+	}
+
+	// Responses are signed by the issuer itself, so no responder
+	// certificate needs to be embedded.
+	now := time.Now()
+	template := ocsp.Response{
+		IssuerHash:   ocspReq.HashAlgorithm,
+		Status:       ocspStatus.ocspStatus,
+		SerialNumber: ocspStatus.serialNumber,
+		ThisUpdate:   now,
+	}
+	if expiry > 0 {
+		template.NextUpdate = now.Add(expiry)
+	}
+	if ocspStatus.ocspStatus == ocsp.Revoked {
+		template.RevokedAt = ocspStatus.revocationTimeUTC
+		template.RevocationReason = ocsp.Unspecified
+	}
+
+	issuerCert := signingBundle.Certificate
+	response, err := ocsp.CreateResponse(issuerCert, issuerCert, template, signingBundle.PrivateKey)
+	if err != nil {
+		return logAndReturnOcspInternalError(b, fmt.Errorf("failed to sign OCSP response: %w", err)), nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType: ocspResponseContentType,
+			logical.HTTPStatusCode:  http.StatusOK,
+			logical.HTTPRawBody:     response,
+		},
+	}, nil
+}
+
+func ocspErrorResponse(status int, body []byte) *logical.Response {
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType: ocspResponseContentType,
+			logical.HTTPStatusCode:  status,
+			logical.HTTPRawBody:     body,
+		},
+	}
+}
+
+func logAndReturnOcspInternalError(b *backend, err error) *logical.Response {
+	// Since OCSP requests are unauthenticated, we don't want to leak
+	// details back to the requester.
+	b.Logger().Warn("OCSP request failed", "error", err)
+	return ocspErrorResponse(http.StatusInternalServerError, ocsp.InternalErrorErrorResponse)
+}
+
+func fetchDerEncodedOcspRequest(request *logical.Request, data *framework.FieldData) ([]byte, error) {
+	switch request.Operation {
+	case logical.ReadOperation:
+		// Per RFC 6960 Appendix A.1, GET requests carry the base64 encoding
+		// of the DER request, which may have been URL-encoded along the way.
+		base64Req := data.Get(ocspReqParam).(string)
+		if len(base64Req) == 0 {
+			return nil, errors.New("no request in path")
+		}
+		base64Req = strings.ReplaceAll(base64Req, " ", "+")
+		return base64.StdEncoding.DecodeString(base64Req)
+	case logical.UpdateOperation:
+		// POST bodies carry the DER request directly; the HTTP layer passes
+		// the request through unparsed for the application/ocsp-request
+		// content type.
+		if request.HTTPRequest == nil || request.HTTPRequest.Body == nil {
+			return nil, errors.New("no data in request body")
+		}
+		defer request.HTTPRequest.Body.Close()
+
+		derReq, err := io.ReadAll(io.LimitReader(request.HTTPRequest.Body, ocspMaximumRequestSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(derReq) > ocspMaximumRequestSize {
+			return nil, errors.New("request is too large")
+		}
+		return derReq, nil
+	default:
+		return nil, fmt.Errorf("unsupported operation: %v", request.Operation)
+	}
+}
+
+// lookupOcspStatus finds the issuer the request refers to and the revocation
+// status of the requested serial number. It returns nil when no issuer
+// capable of signing revocation information matches the request.
+func (b *backend) lookupOcspStatus(ctx context.Context, req *logical.Request, ocspReq *ocsp.Request) (*ocspRespInfo, error) {
+	matchingIssuers, err := b.findOcspIssuers(ctx, req.Storage, ocspReq)
+	if err != nil {
+		return nil, err
+	}
+	if len(matchingIssuers) == 0 {
+		return nil, nil
+	}
+
+	info := &ocspRespInfo{
+		serialNumber: ocspReq.SerialNumber,
+		ocspStatus:   ocsp.Good,
+		issuerID:     matchingIssuers[0].ID,
+	}
+
+	serial := normalizeSerial(certutil.GetHexFormatted(ocspReq.SerialNumber.Bytes(), ":"))
+	revokedEntry, err := req.Storage.Get(ctx, revokedPath+serial)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch revocation entry for serial %v: %w", serial, err)
+	}
+	if revokedEntry == nil {
+		return info, nil
+	}
+
+	var revInfo revocationInfo
+	if err := revokedEntry.DecodeJSON(&revInfo); err != nil {
+		return nil, fmt.Errorf("error decoding revocation entry for serial %v: %w", serial, err)
+	}
+
+	revokedCert, err := x509.ParseCertificate(revInfo.CertificateBytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse stored revoked certificate with serial %v: %w", serial, err)
+	}
+
+	// Serial numbers are unique within the mount, so a revoked certificate
+	// from an unrelated issuer means the requested certificate isn't ours.
+	issuedByMatch := false
+	for _, issuer := range matchingIssuers {
+		if len(revInfo.CertificateIssuer) > 0 && revInfo.CertificateIssuer == issuer.ID {
+			issuedByMatch = true
+			break
+		}
+
+		issuerCert, err := issuer.GetCertificate()
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(revokedCert.RawIssuer, issuerCert.RawSubject) && revokedCert.CheckSignatureFrom(issuerCert) == nil {
+			issuedByMatch = true
+			break
+		}
+	}
+	if !issuedByMatch {
+		info.ocspStatus = ocsp.Unknown
+		return info, nil
+	}
+
+	info.ocspStatus = ocsp.Revoked
+	if !revInfo.RevocationTimeUTC.IsZero() {
+		info.revocationTimeUTC = revInfo.RevocationTimeUTC
+	} else {
+		info.revocationTimeUTC = time.Unix(revInfo.RevocationTime, 0).UTC()
+	}
+
+	return info, nil
+}
+
+// findOcspIssuers returns the issuers whose name and key hashes match the
+// request, and which hold a key able to sign revocation information.
+// Several issuers may match when an issuer has been reissued with the same
+// subject and key; any of them may sign the response.
+func (b *backend) findOcspIssuers(ctx context.Context, s logical.Storage, ocspReq *ocsp.Request) ([]*issuerEntry, error) {
+	if !ocspReq.HashAlgorithm.Available() {
+		return nil, nil
+	}
+
+	issuerIds, err := listIssuers(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	var matching []*issuerEntry
+	for _, issuerId := range issuerIds {
+		issuer, err := fetchIssuerById(ctx, s, issuerId)
+		if err != nil {
+			return nil, err
+		}
+		if len(issuer.KeyID) == 0 || !issuer.Usage.HasUsage(CRLSigningUsage) {
+			continue
+		}
+
+		issuerCert, err := issuer.GetCertificate()
+		if err != nil {
+			return nil, err
+		}
+
+		matches, err := ocspIssuerHashesMatch(ocspReq, issuerCert)
+		if err != nil {
+			return nil, err
+		}
+		if matches {
+			matching = append(matching, issuer)
+		}
+	}
+
+	return matching, nil
+}
+
+func ocspIssuerHashesMatch(ocspReq *ocsp.Request, issuerCert *x509.Certificate) (bool, error) {
+	// The issuer key hash is computed over the public key BIT STRING alone,
+	// excluding the algorithm identifier; see RFC 6960 Section 4.1.1.
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(issuerCert.RawSubjectPublicKeyInfo, &spki); err != nil {
+		return false, fmt.Errorf("failed to parse issuer public key: %w", err)
+	}
+
+	return bytes.Equal(ocspHash(ocspReq.HashAlgorithm, issuerCert.RawSubject), ocspReq.IssuerNameHash) &&
+		bytes.Equal(ocspHash(ocspReq.HashAlgorithm, spki.PublicKey.RightAlign()), ocspReq.IssuerKeyHash), nil
+}
+
+func ocspHash(hash crypto.Hash, data []byte) []byte {
+	h := hash.New()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+const pathOcspHelpSyn = `
+Query a certificate's revocation status through OCSP.
+`
+
+const pathOcspHelpDesc = `
+This endpoint implements an RFC 6960 OCSP responder, answering from the
+revocation information this mount maintains for its CRLs. Requests may
+either be POSTed as a DER-encoded application/ocsp-request body to ocsp, or
+sent as a GET to ocsp/<request>, where <request> is the base64 encoding of
+the DER request.
+
+Responses are signed by the issuer of the requested certificate, which must
+have the crl-signing usage. Certificates which have not been revoked are
+reported as good. OCSP may be disabled and its response lifetime set via
+config/crl.
+`
@@ -0,0 +1,264 @@
+package pki
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ocsp"
+)
+
+func setupOcspTest(t *testing.T) (*backend, logical.Storage, *x509.Certificate, *x509.Certificate, string) {
+	b, s := createBackendWithStorage(t)
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "root example.com",
+		"key_type":    "ec",
+		"ttl":         "40h",
+	})
+	require.NoError(t, err)
+	issuer := parseCert(t, resp.Data["certificate"].(string))
+
+	_, err = CBWrite(b, s, "roles/test", map[string]interface{}{
+		"allowed_domains":  "example.com",
+		"allow_subdomains": true,
+		"key_type":         "ec",
+		"ttl":              "1h",
+	})
+	require.NoError(t, err)
+
+	resp, err = CBWrite(b, s, "issue/test", map[string]interface{}{
+		"common_name": "leaf.example.com",
+	})
+	require.NoError(t, err)
+	leaf := parseCert(t, resp.Data["certificate"].(string))
+
+	return b, s, issuer, leaf, resp.Data["serial_number"].(string)
+}
+
+func sendOcspGet(t *testing.T, b *backend, s logical.Storage, ocspReq []byte) *logical.Response {
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation:  logical.ReadOperation,
+		Path:       "ocsp/" + base64.StdEncoding.EncodeToString(ocspReq),
+		Storage:    s,
+		MountPoint: "pki/",
+	})
+	require.NoError(t, err)
+	require.Equal(t, ocspResponseContentType, resp.Data[logical.HTTPContentType])
+	return resp
+}
+
+func sendOcspPost(t *testing.T, b *backend, s logical.Storage, ocspReq []byte) *logical.Response {
+	httpReq, err := http.NewRequest(http.MethodPost, "/v1/pki/ocsp", bytes.NewReader(ocspReq))
+	require.NoError(t, err)
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "ocsp",
+		Storage:     s,
+		MountPoint:  "pki/",
+		HTTPRequest: httpReq,
+	})
+	require.NoError(t, err)
+	require.Equal(t, ocspResponseContentType, resp.Data[logical.HTTPContentType])
+	return resp
+}
+
+func TestOcsp_StatusLifecycle(t *testing.T) {
+	t.Parallel()
+	b, s, issuer, leaf, serial := setupOcspTest(t)
+
+	ocspReq, err := ocsp.CreateRequest(leaf, issuer, &ocsp.RequestOptions{Hash: crypto.SHA256})
+	require.NoError(t, err)
+
+	for name, send := range map[string]func(*testing.T, *backend, logical.Storage, []byte) *logical.Response{
+		"get":  sendOcspGet,
+		"post": sendOcspPost,
+	} {
+		resp := send(t, b, s, ocspReq)
+		require.Equal(t, http.StatusOK, resp.Data[logical.HTTPStatusCode], name)
+
+		ocspResp, err := ocsp.ParseResponseForCert(resp.Data[logical.HTTPRawBody].([]byte), leaf, issuer)
+		require.NoError(t, err, name)
+		require.Equal(t, ocsp.Good, ocspResp.Status, name)
+		require.Equal(t, leaf.SerialNumber, ocspResp.SerialNumber, name)
+		require.Equal(t, crypto.SHA256, ocspResp.IssuerHash, name)
+		require.WithinDuration(t, time.Now().Add(12*time.Hour), ocspResp.NextUpdate, time.Minute, name)
+	}
+
+	resp, err := CBWrite(b, s, "revoke", map[string]interface{}{"serial_number": serial})
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+	revocationTime := time.Unix(resp.Data["revocation_time"].(int64), 0)
+
+	// SHA-1 is the hash most clients use.
+	ocspReq, err = ocsp.CreateRequest(leaf, issuer, nil)
+	require.NoError(t, err)
+	resp = sendOcspGet(t, b, s, ocspReq)
+	require.Equal(t, http.StatusOK, resp.Data[logical.HTTPStatusCode])
+
+	ocspResp, err := ocsp.ParseResponseForCert(resp.Data[logical.HTTPRawBody].([]byte), leaf, issuer)
+	require.NoError(t, err)
+	require.Equal(t, ocsp.Revoked, ocspResp.Status)
+	require.WithinDuration(t, revocationTime, ocspResp.RevokedAt, time.Second)
+}
+
+func TestOcsp_UnknownIssuerAndDisable(t *testing.T) {
+	t.Parallel()
+	b, s, issuer, leaf, _ := setupOcspTest(t)
+
+	// A request naming an issuer from another mount is not ours to answer.
+	otherB, otherS := createBackendWithStorage(t)
+	resp, err := CBWrite(otherB, otherS, "root/generate/internal", map[string]interface{}{
+		"common_name": "other root",
+		"key_type":    "ec",
+	})
+	require.NoError(t, err)
+	otherIssuer := parseCert(t, resp.Data["certificate"].(string))
+
+	ocspReq, err := ocsp.CreateRequest(leaf, otherIssuer, nil)
+	require.NoError(t, err)
+	resp = sendOcspGet(t, b, s, ocspReq)
+	require.Equal(t, http.StatusUnauthorized, resp.Data[logical.HTTPStatusCode])
+	require.Equal(t, ocsp.UnauthorizedErrorResponse, resp.Data[logical.HTTPRawBody])
+
+	// Garbage is rejected as malformed.
+	resp = sendOcspGet(t, b, s, []byte("not an ocsp request"))
+	require.Equal(t, http.StatusBadRequest, resp.Data[logical.HTTPStatusCode])
+	require.Equal(t, ocsp.MalformedRequestErrorResponse, resp.Data[logical.HTTPRawBody])
+
+	// Once disabled, every request is unauthorized.
+	_, err = CBWrite(b, s, "config/crl", map[string]interface{}{"ocsp_disable": true})
+	require.NoError(t, err)
+	ocspReq, err = ocsp.CreateRequest(leaf, issuer, nil)
+	require.NoError(t, err)
+	resp = sendOcspGet(t, b, s, ocspReq)
+	require.Equal(t, http.StatusUnauthorized, resp.Data[logical.HTTPStatusCode])
+
+	resp, err = CBRead(b, s, "config/crl")
+	require.NoError(t, err)
+	require.Equal(t, true, resp.Data["ocsp_disable"])
+	require.Equal(t, "12h", resp.Data["ocsp_expiry"])
+}
+
+func TestOcsp_PerIssuerResponses(t *testing.T) {
+	t.Parallel()
+	b, s, rootCert, _, _ := setupOcspTest(t)
+
+	// Issue from a second, intermediate issuer on the same mount; its
+	// responses must be signed by it rather than by the root.
+	resp, err := CBWrite(b, s, "intermediate/generate/internal", map[string]interface{}{
+		"common_name": "int example.com",
+		"key_type":    "ec",
+	})
+	require.NoError(t, err)
+	resp, err = CBWrite(b, s, "issuer/default/sign-intermediate", map[string]interface{}{
+		"csr":    resp.Data["csr"],
+		"format": "pem_bundle",
+		"ttl":    "20h",
+	})
+	require.NoError(t, err)
+	intCert := parseCert(t, resp.Data["certificate"].(string))
+	resp, err = CBWrite(b, s, "issuers/import/cert", map[string]interface{}{
+		"pem_bundle": resp.Data["certificate"],
+	})
+	require.NoError(t, err)
+	intIssuerId := resp.Data["imported_issuers"].([]string)[0]
+
+	resp, err = CBWrite(b, s, "issuer/"+intIssuerId+"/issue/test", map[string]interface{}{
+		"common_name": "int-leaf.example.com",
+	})
+	require.NoError(t, err)
+	intLeaf := parseCert(t, resp.Data["certificate"].(string))
+
+	ocspReq, err := ocsp.CreateRequest(intLeaf, intCert, nil)
+	require.NoError(t, err)
+	resp = sendOcspGet(t, b, s, ocspReq)
+	require.Equal(t, http.StatusOK, resp.Data[logical.HTTPStatusCode])
+
+	rawResp := resp.Data[logical.HTTPRawBody].([]byte)
+	ocspResp, err := ocsp.ParseResponseForCert(rawResp, intLeaf, intCert)
+	require.NoError(t, err)
+	require.Equal(t, ocsp.Good, ocspResp.Status)
+
+	_, err = ocsp.ParseResponseForCert(rawResp, intLeaf, rootCert)
+	require.Error(t, err, "response should not verify against the root")
+}
+
+func TestOcsp_HTTPPost(t *testing.T) {
+	t.Parallel()
+	coreConfig := &vault.CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"pki": Factory,
+		},
+	}
+	cluster := vault.NewTestCluster(t, coreConfig, &vault.TestClusterOptions{
+		HandlerFunc: vaulthttp.Handler,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+
+	client := cluster.Cores[0].Client
+	mountPKIEndpoint(t, client, "pki")
+
+	resp, err := client.Logical().Write("pki/root/generate/internal", map[string]interface{}{
+		"common_name": "root example.com",
+		"ttl":         "20h",
+	})
+	require.NoError(t, err)
+	issuer := parseCert(t, resp.Data["certificate"].(string))
+
+	_, err = client.Logical().Write("pki/roles/test", map[string]interface{}{
+		"allowed_domains":  "example.com",
+		"allow_subdomains": true,
+	})
+	require.NoError(t, err)
+	resp, err = client.Logical().Write("pki/issue/test", map[string]interface{}{
+		"common_name": "leaf.example.com",
+	})
+	require.NoError(t, err)
+	leaf := parseCert(t, resp.Data["certificate"].(string))
+
+	ocspReq, err := ocsp.CreateRequest(leaf, issuer, nil)
+	require.NoError(t, err)
+
+	// OCSP clients don't carry Vault tokens.
+	unauthed, err := client.Clone()
+	require.NoError(t, err)
+	unauthed.ClearToken()
+
+	checkResponse := func(httpResp *api.Response) {
+		defer httpResp.Body.Close()
+		require.Equal(t, http.StatusOK, httpResp.StatusCode)
+		require.Equal(t, ocspResponseContentType, httpResp.Header.Get("Content-Type"))
+
+		body, err := io.ReadAll(httpResp.Body)
+		require.NoError(t, err)
+		ocspResp, err := ocsp.ParseResponseForCert(body, leaf, issuer)
+		require.NoError(t, err)
+		require.Equal(t, ocsp.Good, ocspResp.Status)
+	}
+
+	req := unauthed.NewRequest(http.MethodPost, "/v1/pki/ocsp")
+	req.BodyBytes = ocspReq
+	req.Headers.Set("Content-Type", "application/ocsp-request")
+	httpResp, err := unauthed.RawRequest(req)
+	require.NoError(t, err)
+	checkResponse(httpResp)
+
+	req = unauthed.NewRequest(http.MethodGet, "/v1/pki/ocsp/"+base64.StdEncoding.EncodeToString(ocspReq))
+	httpResp, err = unauthed.RawRequest(req)
+	require.NoError(t, err)
+	checkResponse(httpResp)
+}
@@ -56,6 +56,23 @@ func pathRotateCRL(b *backend) *framework.Path {
 	}
 }
 
+func pathRotateDeltaCRL(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: `crl/rotate-delta`,
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathRotateDeltaCRLRead,
+				// See pathRotateCRL.
+				ForwardPerformanceStandby: true,
+			},
+		},
+
+		HelpSynopsis:    pathRotateDeltaCRLHelpSyn,
+		HelpDescription: pathRotateDeltaCRLHelpDesc,
+	}
+}
+
 func (b *backend) pathRevokeWrite(ctx context.Context, req *logical.Request, data *framework.FieldData, _ *roleEntry) (*logical.Response, error) {
 	serial := data.Get("serial_number").(string)
 	if len(serial) == 0 {
@@ -97,6 +114,36 @@ func (b *backend) pathRotateCRLRead(ctx context.Context, req *logical.Request, _
 	}, nil
 }
 
+func (b *backend) pathRotateDeltaCRLRead(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	config, err := b.CRL(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	if !config.EnableDelta {
+		return logical.ErrorResponse("delta CRLs are not enabled; enable them on config/crl first"), nil
+	}
+
+	b.revokeStorageLock.RLock()
+	defer b.revokeStorageLock.RUnlock()
+
+	crlErr := b.crlBuilder.rebuildDeltaCRLs(ctx, b, req, false)
+	if crlErr != nil {
+		switch crlErr.(type) {
+		case errutil.UserError:
+			return logical.ErrorResponse(fmt.Sprintf("Error during delta CRL building: %s", crlErr)), nil
+		default:
+			return nil, fmt.Errorf("error encountered during delta CRL building: %w", crlErr)
+		}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"success": true,
+		},
+	}, nil
+}
+
 const pathRevokeHelpSyn = `
 Revoke a certificate by serial number.
 `
@@ -112,3 +159,11 @@ Force a rebuild of the CRL.
 const pathRotateCRLHelpDesc = `
 Force a rebuild of the CRL. This can be used to remove expired certificates from it if no certificates have been revoked. A root token is required.
 `
+
+const pathRotateDeltaCRLHelpSyn = `
+Force a rebuild of the delta CRL.
+`
+
+const pathRotateDeltaCRLHelpDesc = `
+Force a rebuild of the delta CRL. This can be used to publish revocations ahead of the next scheduled delta CRL rebuild. Delta CRLs must be enabled.
+`
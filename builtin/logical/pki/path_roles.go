@@ -179,6 +179,38 @@ Any valid URI is accepted, these values support globbing.`,
 				Description: `If set, an array of allowed serial numbers to put in Subject. These values support globbing.`,
 			},
 
+			"identity_alt_names": {
+				Type: framework.TypeCommaStringSlice,
+				Description: `If set, an array of DNS names or email addresses added to
+the Subject Alternative Names of every certificate issued by this role. These
+values may be identity templates, populated from the requesting entity and
+its alias metadata, and must be permitted by allowed_domains.`,
+				DisplayAttrs: &framework.DisplayAttributes{
+					Name: "Identity Templated Subject Alternative Names",
+				},
+			},
+
+			"identity_uri_sans": {
+				Type: framework.TypeCommaStringSlice,
+				Description: `If set, an array of URIs added to the URI Subject
+Alternative Names of every certificate issued by this role. These values may
+be identity templates, and must be permitted by allowed_uri_sans.`,
+				DisplayAttrs: &framework.DisplayAttributes{
+					Name: "Identity Templated URI Subject Alternative Names",
+				},
+			},
+
+			"identity_other_sans": {
+				Type: framework.TypeCommaStringSlice,
+				Description: `If set, an array of other names added to the Subject
+Alternative Names of every certificate issued by this role, in the format
+<oid>;UTF8:<value>. The values may be identity templates, and must be
+permitted by allowed_other_sans.`,
+				DisplayAttrs: &framework.DisplayAttributes{
+					Name: "Identity Templated Other Subject Alternative Names",
+				},
+			},
+
 			"server_flag": {
 				Type:    framework.TypeBool,
 				Default: true,
@@ -309,6 +341,14 @@ this value in certificates issued by this role.`,
 				},
 			},
 
+			"ou_template": {
+				Type: framework.TypeBool,
+				Description: `If set, OU values can be specified using identity
+templates, populated from the requesting entity. Non-templated values are
+also permitted.`,
+				Default: false,
+			},
+
 			"organization": {
 				Type: framework.TypeCommaStringSlice,
 				Description: `If set, O (Organization) will be set to
@@ -391,6 +431,30 @@ information, which must include an oid, and may include a notice and/or cps url,
 [{"oid"="1.3.6.1.4.1.7.8","notice"="I am a user Notice"}, {"oid"="1.3.6.1.4.1.44947.1.2.4 ","cps"="https://example.com"}].`,
 			},
 
+			"extensions": {
+				Type: framework.TypeCommaStringSlice,
+				Description: `If set, an array of custom, non-critical extensions added to
+every certificate issued by this role, in the format <oid>;<type>:<value>. The
+type is one of utf8, ia5, printable, int, bool, oid, null, or der, the last
+of which takes a base64-encoded DER value. Extensions managed by Vault, such
+as Subject Alternative Name or Certificate Policies, can't be set.`,
+			},
+
+			"extensions_template": {
+				Type: framework.TypeBool,
+				Description: `If set, the values of extensions can be specified using
+identity templates, populated from the requesting entity.`,
+				Default: false,
+			},
+
+			"allowed_extensions": {
+				Type: framework.TypeCommaStringSlice,
+				Description: `If set, an array of extension OIDs which may be requested via
+the extensions parameter when issuing or signing certificates, or taken from
+the CSR when use_csr_sans is set. A single "*" allows any extension not
+managed by Vault.`,
+			},
+
 			"basic_constraints_valid_for_non_ca": {
 				Type:        framework.TypeBool,
 				Description: `Mark Basic Constraints valid when issuing non-CA certificates.`,
@@ -651,6 +715,7 @@ func (b *backend) pathRoleCreate(ctx context.Context, req *logical.Request, data
 		ExtKeyUsage:                   data.Get("ext_key_usage").([]string),
 		ExtKeyUsageOIDs:               data.Get("ext_key_usage_oids").([]string),
 		OU:                            data.Get("ou").([]string),
+		OUTemplate:                    data.Get("ou_template").(bool),
 		Organization:                  data.Get("organization").([]string),
 		Country:                       data.Get("country").([]string),
 		Locality:                      data.Get("locality").([]string),
@@ -661,7 +726,13 @@ func (b *backend) pathRoleCreate(ctx context.Context, req *logical.Request, data
 		NoStore:                       data.Get("no_store").(bool),
 		RequireCN:                     data.Get("require_cn").(bool),
 		AllowedSerialNumbers:          data.Get("allowed_serial_numbers").([]string),
+		IdentityAltNames:              data.Get("identity_alt_names").([]string),
+		IdentityURISANs:               data.Get("identity_uri_sans").([]string),
+		IdentityOtherSANs:             data.Get("identity_other_sans").([]string),
 		PolicyIdentifiers:             getPolicyIdentifier(data, nil),
+		Extensions:                    data.Get("extensions").([]string),
+		ExtensionsTemplate:            data.Get("extensions_template").(bool),
+		AllowedExtensions:             data.Get("allowed_extensions").([]string),
 		BasicConstraintsValidForNonCA: data.Get("basic_constraints_valid_for_non_ca").(bool),
 		NotBeforeDuration:             time.Duration(data.Get("not_before_duration").(int)) * time.Second,
 		NotAfter:                      data.Get("not_after").(string),
@@ -750,9 +821,38 @@ func validateRole(b *backend, entry *roleEntry, ctx context.Context, s logical.S
 	}
 
 	if len(entry.PolicyIdentifiers) > 0 {
-		_, err := certutil.CreatePolicyInformationExtensionFromStorageStrings(entry.PolicyIdentifiers)
-		if err != nil {
-			return nil, err
+		if err := validatePolicyIdentifiers(entry.PolicyIdentifiers); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("invalid policy_identifiers: %v", err)), nil
+		}
+	}
+
+	if err := validateRoleExtensions(entry.Extensions, entry.ExtensionsTemplate); err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("invalid extensions: %v", err)), nil
+	}
+
+	if err := validateAllowedExtensions(entry.AllowedExtensions); err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("invalid allowed_extensions: %v", err)), nil
+	}
+
+	identityTemplates := map[string][]string{
+		"identity_alt_names":  entry.IdentityAltNames,
+		"identity_uri_sans":   entry.IdentityURISANs,
+		"identity_other_sans": entry.IdentityOtherSANs,
+	}
+	if entry.OUTemplate {
+		identityTemplates["ou"] = entry.OU
+	}
+	for field, values := range identityTemplates {
+		for _, value := range values {
+			if _, err := framework.ValidateIdentityTemplate(value); err != nil {
+				return logical.ErrorResponse(fmt.Sprintf("%s value %q is not a valid identity template: %v", field, value, err)), nil
+			}
+		}
+	}
+
+	if len(entry.IdentityOtherSANs) > 0 {
+		if _, err := parseOtherSANs(entry.IdentityOtherSANs); err != nil {
+			return logical.ErrorResponse(fmt.Errorf("error parsing identity_other_sans: %w", err).Error()), nil
 		}
 	}
 
@@ -839,6 +939,7 @@ func (b *backend) pathRolePatch(ctx context.Context, req *logical.Request, data
 		ExtKeyUsage:                   getWithExplicitDefault(data, "ext_key_usage", oldEntry.ExtKeyUsage).([]string),
 		ExtKeyUsageOIDs:               getWithExplicitDefault(data, "ext_key_usage_oids", oldEntry.ExtKeyUsageOIDs).([]string),
 		OU:                            getWithExplicitDefault(data, "ou", oldEntry.OU).([]string),
+		OUTemplate:                    getWithExplicitDefault(data, "ou_template", oldEntry.OUTemplate).(bool),
 		Organization:                  getWithExplicitDefault(data, "organization", oldEntry.Organization).([]string),
 		Country:                       getWithExplicitDefault(data, "country", oldEntry.Country).([]string),
 		Locality:                      getWithExplicitDefault(data, "locality", oldEntry.Locality).([]string),
@@ -849,7 +950,13 @@ func (b *backend) pathRolePatch(ctx context.Context, req *logical.Request, data
 		NoStore:                       getWithExplicitDefault(data, "no_store", oldEntry.NoStore).(bool),
 		RequireCN:                     getWithExplicitDefault(data, "require_cn", oldEntry.RequireCN).(bool),
 		AllowedSerialNumbers:          getWithExplicitDefault(data, "allowed_serial_numbers", oldEntry.AllowedSerialNumbers).([]string),
+		IdentityAltNames:              getWithExplicitDefault(data, "identity_alt_names", oldEntry.IdentityAltNames).([]string),
+		IdentityURISANs:               getWithExplicitDefault(data, "identity_uri_sans", oldEntry.IdentityURISANs).([]string),
+		IdentityOtherSANs:             getWithExplicitDefault(data, "identity_other_sans", oldEntry.IdentityOtherSANs).([]string),
 		PolicyIdentifiers:             getPolicyIdentifier(data, &oldEntry.PolicyIdentifiers),
+		Extensions:                    getWithExplicitDefault(data, "extensions", oldEntry.Extensions).([]string),
+		ExtensionsTemplate:            getWithExplicitDefault(data, "extensions_template", oldEntry.ExtensionsTemplate).(bool),
+		AllowedExtensions:             getWithExplicitDefault(data, "allowed_extensions", oldEntry.AllowedExtensions).([]string),
 		BasicConstraintsValidForNonCA: getWithExplicitDefault(data, "basic_constraints_valid_for_non_ca", oldEntry.BasicConstraintsValidForNonCA).(bool),
 		NotBeforeDuration:             getTimeWithExplicitDefault(data, "not_before_duration", oldEntry.NotBeforeDuration),
 		NotAfter:                      getWithExplicitDefault(data, "not_after", oldEntry.NotAfter).(string),
@@ -1033,6 +1140,7 @@ type roleEntry struct {
 	ExtKeyUsage                   []string      `json:"extended_key_usage_list" mapstructure:"extended_key_usage"`
 	OUOld                         string        `json:"ou,omitempty"`
 	OU                            []string      `json:"ou_list" mapstructure:"ou"`
+	OUTemplate                    bool          `json:"ou_template" mapstructure:"ou_template"`
 	OrganizationOld               string        `json:"organization,omitempty"`
 	Organization                  []string      `json:"organization_list" mapstructure:"organization"`
 	Country                       []string      `json:"country" mapstructure:"country"`
@@ -1047,7 +1155,13 @@ type roleEntry struct {
 	AllowedSerialNumbers          []string      `json:"allowed_serial_numbers" mapstructure:"allowed_serial_numbers"`
 	AllowedURISANs                []string      `json:"allowed_uri_sans" mapstructure:"allowed_uri_sans"`
 	AllowedURISANsTemplate        bool          `json:"allowed_uri_sans_template"`
+	IdentityAltNames              []string      `json:"identity_alt_names" mapstructure:"identity_alt_names"`
+	IdentityURISANs               []string      `json:"identity_uri_sans" mapstructure:"identity_uri_sans"`
+	IdentityOtherSANs             []string      `json:"identity_other_sans" mapstructure:"identity_other_sans"`
 	PolicyIdentifiers             []string      `json:"policy_identifiers" mapstructure:"policy_identifiers"`
+	Extensions                    []string      `json:"extensions" mapstructure:"extensions"`
+	ExtensionsTemplate            bool          `json:"extensions_template" mapstructure:"extensions_template"`
+	AllowedExtensions             []string      `json:"allowed_extensions" mapstructure:"allowed_extensions"`
 	ExtKeyUsageOIDs               []string      `json:"ext_key_usage_oids" mapstructure:"ext_key_usage_oids"`
 	BasicConstraintsValidForNonCA bool          `json:"basic_constraints_valid_for_non_ca" mapstructure:"basic_constraints_valid_for_non_ca"`
 	NotBeforeDuration             time.Duration `json:"not_before_duration" mapstructure:"not_before_duration"`
@@ -1084,6 +1198,7 @@ func (r *roleEntry) ToResponseData() map[string]interface{} {
 		"ext_key_usage":                      r.ExtKeyUsage,
 		"ext_key_usage_oids":                 r.ExtKeyUsageOIDs,
 		"ou":                                 r.OU,
+		"ou_template":                        r.OUTemplate,
 		"organization":                       r.Organization,
 		"country":                            r.Country,
 		"locality":                           r.Locality,
@@ -1094,8 +1209,14 @@ func (r *roleEntry) ToResponseData() map[string]interface{} {
 		"allowed_other_sans":                 r.AllowedOtherSANs,
 		"allowed_serial_numbers":             r.AllowedSerialNumbers,
 		"allowed_uri_sans":                   r.AllowedURISANs,
+		"identity_alt_names":                 r.IdentityAltNames,
+		"identity_uri_sans":                  r.IdentityURISANs,
+		"identity_other_sans":                r.IdentityOtherSANs,
 		"require_cn":                         r.RequireCN,
 		"policy_identifiers":                 r.PolicyIdentifiers,
+		"extensions":                         r.Extensions,
+		"extensions_template":                r.ExtensionsTemplate,
+		"allowed_extensions":                 r.AllowedExtensions,
 		"basic_constraints_valid_for_non_ca": r.BasicConstraintsValidForNonCA,
 		"not_before_duration":                int64(r.NotBeforeDuration.Seconds()),
 		"not_after":                          r.NotAfter,
@@ -3,10 +3,12 @@ package pki
 import (
 	"context"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/asn1"
 	"encoding/base64"
 	"encoding/pem"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -937,6 +939,31 @@ func TestPki_RolePatch(t *testing.T) {
 			Before:  []string{"1.3.6.1.4.1.1.1"},
 			Patched: []string{"1.3.6.1.4.1.1.2"},
 		},
+		{
+			Field:   "extensions",
+			Before:  []string{"1.3.6.1.4.1.1.3;UTF8:before"},
+			Patched: []string{"1.3.6.1.4.1.1.3;INT:2", "1.3.6.1.4.1.1.4;BOOL:true"},
+		},
+		{
+			Field:   "extensions_template",
+			Before:  true,
+			Patched: false,
+		},
+		{
+			Field:   "allowed_extensions",
+			Before:  []string{"1.3.6.1.4.1.1.5"},
+			Patched: []string{"*"},
+		},
+		{
+			Field:   "identity_alt_names",
+			Before:  []string{"{{identity.entity.name}}.example.com"},
+			Patched: []string{"{{identity.entity.metadata.service}}.example.com"},
+		},
+		{
+			Field:   "ou_template",
+			Before:  false,
+			Patched: true,
+		},
 		{
 			Field:   "basic_constraints_valid_for_non_ca",
 			Before:  true,
@@ -1163,3 +1190,163 @@ func getPolicyInformationExtensionOffCertificate(resp logical.Response) ([]byte,
 	}
 	return *new([]byte), errors.New("No Policy Information Extension Found")
 }
+
+func TestPKI_RolePolicyInformation_Validation(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	for _, policies := range []string{
+		`[{"oid":"1.3.6.1.4.1.7.8","cps":"ftp://example.com/cps"}]`,
+		`[{"oid":"1.3.6.1.4.1.7.8","cps":"https://exämple.com/cps"}]`,
+		`[{"oid":"1.3.6.1.4.1.7.8","notice":"` + strings.Repeat("a", 201) + `"}]`,
+		`[{"oid":"not-an-oid","cps":"https://example.com/cps"}]`,
+	} {
+		_, err := CBWrite(b, storage, "roles/testrole", map[string]interface{}{
+			"policy_identifiers": policies,
+		})
+		require.Error(t, err, "expected error writing role with policy_identifiers %v", policies)
+	}
+
+	_, err := CBWrite(b, storage, "roles/testrole", map[string]interface{}{
+		"policy_identifiers": `[{"oid":"1.3.6.1.4.1.7.8","cps":"https://example.com/cps","notice":"` + strings.Repeat("a", 200) + `"}]`,
+	})
+	require.NoError(t, err)
+}
+
+func TestPKI_RoleCustomExtensions(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	_, err := CBWrite(b, storage, "root/generate/internal", map[string]interface{}{
+		"common_name": "myvault.com",
+		"ttl":         "5h",
+		"key_type":    "ec",
+	})
+	require.NoError(t, err)
+
+	// Extensions managed by Vault, or with invalid values, are rejected.
+	for _, data := range []map[string]interface{}{
+		{"extensions": "2.5.29.17;UTF8:example.com"},
+		{"extensions": "1.3.6.1.4.1.55555.1;INT:one"},
+		{"extensions": "1.3.6.1.4.1.55555.1;DER:AQ=="},
+		{"extensions": "1.3.6.1.4.1.55555.1;UNKNOWN:foo"},
+		{"extensions": "1.3.6.1.4.1.55555.1;UTF8:a,1.3.6.1.4.1.55555.1;UTF8:b"},
+		{"allowed_extensions": "2.5.29.32"},
+		{"allowed_extensions": "not-an-oid"},
+	} {
+		_, err := CBWrite(b, storage, "roles/testrole", data)
+		require.Error(t, err, "expected error writing role with %v", data)
+	}
+
+	derValue, err := asn1.Marshal([]string{"a", "b"})
+	require.NoError(t, err)
+
+	_, err = CBWrite(b, storage, "roles/testrole", map[string]interface{}{
+		"allow_any_name": true,
+		"key_type":       "ec",
+		"ttl":            "1h",
+		"extensions": []string{
+			"1.3.6.1.4.1.55555.1;UTF8:fixed",
+			"1.3.6.1.4.1.55555.2;DER:" + base64.StdEncoding.EncodeToString(derValue),
+		},
+		"allowed_extensions": "1.3.6.1.4.1.55555.3,1.3.6.1.4.1.55555.4",
+		"policy_identifiers": `[{"oid":"1.3.6.1.4.1.7.8","cps":"https://example.com/cps","notice":"Test notice"}]`,
+	})
+	require.NoError(t, err)
+
+	resp, err := CBWrite(b, storage, "issue/testrole", map[string]interface{}{
+		"common_name": "localhost",
+		"extensions":  "1.3.6.1.4.1.55555.3;INT:42",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	cert := parseCert(t, resp.Data["certificate"].(string))
+
+	extensions := map[string]pkix.Extension{}
+	for _, extension := range cert.Extensions {
+		extensions[extension.Id.String()] = extension
+	}
+
+	var fixed string
+	_, err = asn1.Unmarshal(extensions["1.3.6.1.4.1.55555.1"].Value, &fixed)
+	require.NoError(t, err)
+	require.Equal(t, "fixed", fixed)
+	require.Equal(t, derValue, extensions["1.3.6.1.4.1.55555.2"].Value)
+	var requested int
+	_, err = asn1.Unmarshal(extensions["1.3.6.1.4.1.55555.3"].Value, &requested)
+	require.NoError(t, err)
+	require.Equal(t, 42, requested)
+	require.False(t, extensions["1.3.6.1.4.1.55555.3"].Critical)
+	require.NotContains(t, extensions, "1.3.6.1.4.1.55555.4")
+
+	policies := extensions["2.5.29.32"].Value
+	require.Contains(t, string(policies), "https://example.com/cps")
+	require.Contains(t, string(policies), "Test notice")
+
+	// Requesters can't set extensions outside of allowed_extensions, nor
+	// override those set by the role.
+	for _, requested := range []string{
+		"1.3.6.1.4.1.55555.5;UTF8:foo",
+		"1.3.6.1.4.1.55555.1;UTF8:override",
+		"2.5.29.19;BOOL:true",
+	} {
+		_, err = CBWrite(b, storage, "issue/testrole", map[string]interface{}{
+			"common_name": "localhost",
+			"extensions":  requested,
+		})
+		require.Error(t, err, "expected error requesting extension %v", requested)
+	}
+
+	// With use_csr_sans, allowed extensions are also taken from the CSR.
+	csrExtension, err := asn1.MarshalWithParams("from-csr", "utf8")
+	require.NoError(t, err)
+	_, _, csrPem := generateCSR(t, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: "localhost"},
+		ExtraExtensions: []pkix.Extension{
+			{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 55555, 4}, Value: csrExtension},
+			{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 55555, 5}, Value: csrExtension},
+		},
+	}, "ec", 256)
+
+	resp, err = CBWrite(b, storage, "sign/testrole", map[string]interface{}{
+		"csr": csrPem,
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	cert = parseCert(t, resp.Data["certificate"].(string))
+
+	extensions = map[string]pkix.Extension{}
+	for _, extension := range cert.Extensions {
+		extensions[extension.Id.String()] = extension
+	}
+	require.Equal(t, csrExtension, extensions["1.3.6.1.4.1.55555.4"].Value)
+	require.NotContains(t, extensions, "1.3.6.1.4.1.55555.5")
+
+	// sign-verbatim applies the role's extensions and policies, with
+	// requested values replacing those in the CSR.
+	resp, err = CBWrite(b, storage, "sign-verbatim/testrole", map[string]interface{}{
+		"csr":        csrPem,
+		"extensions": "1.3.6.1.4.1.55555.4;UTF8:from-request",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	cert = parseCert(t, resp.Data["certificate"].(string))
+
+	extensions = map[string]pkix.Extension{}
+	for _, extension := range cert.Extensions {
+		_, duplicate := extensions[extension.Id.String()]
+		require.False(t, duplicate, "duplicate extension %v", extension.Id)
+		extensions[extension.Id.String()] = extension
+	}
+	var fromRequest string
+	_, err = asn1.Unmarshal(extensions["1.3.6.1.4.1.55555.4"].Value, &fromRequest)
+	require.NoError(t, err)
+	require.Equal(t, "from-request", fromRequest)
+	require.Contains(t, extensions, "1.3.6.1.4.1.55555.1")
+	require.Contains(t, string(extensions["2.5.29.32"].Value), "https://example.com/cps")
+
+	resp, err = CBWrite(b, storage, "sign-verbatim", map[string]interface{}{
+		"csr":                csrPem,
+		"policy_identifiers": "1.3.6.1.4.1.7.9",
+		"ttl":                "1h",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	cert = parseCert(t, resp.Data["certificate"].(string))
+	require.Len(t, cert.PolicyIdentifiers, 1)
+	require.Equal(t, "1.3.6.1.4.1.7.9", cert.PolicyIdentifiers[0].String())
+}
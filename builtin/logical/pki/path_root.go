@@ -235,10 +235,7 @@ func (b *backend) pathCAGenerateRoot(ctx context.Context, req *logical.Request,
 
 	// Also store it as just the certificate identified by serial number, so it
 	// can be revoked
-	err = req.Storage.Put(ctx, &logical.StorageEntry{
-		Key:   "certs/" + normalizeSerial(cb.SerialNumber),
-		Value: parsedBundle.CertificateBytes,
-	})
+	err = storeCertificate(ctx, b, req.Storage, parsedBundle, myIssuer.ID.String(), "")
 	if err != nil {
 		return nil, fmt.Errorf("unable to store certificate locally: %w", err)
 	}
@@ -413,10 +410,7 @@ func (b *backend) pathIssuerSignIntermediate(ctx context.Context, req *logical.R
 		return nil, fmt.Errorf("unsupported format argument: %s", format)
 	}
 
-	err = req.Storage.Put(ctx, &logical.StorageEntry{
-		Key:   "certs/" + normalizeSerial(cb.SerialNumber),
-		Value: parsedBundle.CertificateBytes,
-	})
+	err = storeCertificate(ctx, b, req.Storage, parsedBundle, issuerName, "")
 	if err != nil {
 		return nil, fmt.Errorf("unable to store certificate locally: %w", err)
 	}
@@ -0,0 +1,558 @@
+package pki
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/ryanuber/go-glob"
+)
+
+const (
+	searchRevocationStateAny     = "any"
+	searchRevocationStateRevoked = "revoked"
+	searchRevocationStateValid   = "valid"
+
+	defaultCertSearchLimit = 100
+	maxCertSearchLimit     = 1000
+)
+
+func pathSearchCerts(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "certs/search",
+
+		Fields: map[string]*framework.FieldSchema{
+			"common_name": {
+				Type: framework.TypeString,
+				Description: `If set, only certificates whose common name matches
+this value are returned. Globs (*) are supported.`,
+			},
+			"san": {
+				Type: framework.TypeString,
+				Description: `If set, only certificates with a DNS, email, IP or
+URI Subject Alternative Name matching this value are returned. Globs (*)
+are supported.`,
+			},
+			issuerRefParam: {
+				Type: framework.TypeString,
+				Description: `If set, only certificates issued by this issuer are
+returned, either by ID or by name.`,
+			},
+			"role": {
+				Type: framework.TypeString,
+				Description: `If set, only certificates issued through this role
+are returned.`,
+			},
+			"expires_within": {
+				Type: framework.TypeDurationSecond,
+				Description: `If set, only certificates expiring within this
+duration from now are returned.`,
+			},
+			"include_expired": {
+				Type:        framework.TypeBool,
+				Description: `Whether to include certificates which have already expired.`,
+				Default:     false,
+			},
+			"revocation_state": {
+				Type: framework.TypeString,
+				Description: `Restrict the results by revocation state; one of
+"any", "revoked" or "valid".`,
+				Default: searchRevocationStateAny,
+			},
+			"after": {
+				Type: framework.TypeString,
+				Description: `Only return certificates whose serial number sorts
+after this one; use the "next" value from a previous search to fetch the
+next page.`,
+			},
+			"limit": {
+				Type:        framework.TypeInt,
+				Description: `Maximum number of certificates to return, up to 1000.`,
+				Default:     defaultCertSearchLimit,
+			},
+			"format": {
+				Type: framework.TypeString,
+				Description: `Format of the results; either "json" or "pem_bundle",
+to also return the matching certificates as concatenated PEM.`,
+				Default: "json",
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathSearchCertsRead,
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathSearchCertsRead,
+			},
+		},
+
+		HelpSynopsis:    pathSearchCertsHelpSyn,
+		HelpDescription: pathSearchCertsHelpDesc,
+	}
+}
+
+type certSearchFilter struct {
+	CommonName      string
+	SAN             string
+	IssuerID        issuerID
+	Role            string
+	ExpiresBefore   time.Time
+	IncludeExpired  bool
+	RevocationState string
+}
+
+func (f *certSearchFilter) matches(index *certIndexEntry, now time.Time) bool {
+	if len(f.CommonName) > 0 && !glob.Glob(strings.ToLower(f.CommonName), strings.ToLower(index.CommonName)) {
+		return false
+	}
+
+	if len(f.SAN) > 0 {
+		pattern := strings.ToLower(f.SAN)
+		found := false
+		for _, names := range [][]string{index.DNSNames, index.EmailAddresses, index.IPAddresses, index.URIs} {
+			for _, name := range names {
+				if glob.Glob(pattern, strings.ToLower(name)) {
+					found = true
+					break
+				}
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(f.IssuerID) > 0 && index.IssuerID != f.IssuerID {
+		return false
+	}
+
+	if len(f.Role) > 0 && index.Role != f.Role {
+		return false
+	}
+
+	if !f.IncludeExpired && index.NotAfter.Before(now) {
+		return false
+	}
+
+	if !f.ExpiresBefore.IsZero() && index.NotAfter.After(f.ExpiresBefore) {
+		return false
+	}
+
+	switch f.RevocationState {
+	case searchRevocationStateRevoked:
+		return index.Revoked
+	case searchRevocationStateValid:
+		return !index.Revoked
+	}
+
+	return true
+}
+
+func (b *backend) pathSearchCertsRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	now := time.Now()
+	filter := &certSearchFilter{
+		CommonName:      data.Get("common_name").(string),
+		SAN:             data.Get("san").(string),
+		Role:            data.Get("role").(string),
+		IncludeExpired:  data.Get("include_expired").(bool),
+		RevocationState: data.Get("revocation_state").(string),
+	}
+
+	switch filter.RevocationState {
+	case searchRevocationStateAny, searchRevocationStateRevoked, searchRevocationStateValid:
+	default:
+		return logical.ErrorResponse(fmt.Sprintf("unknown revocation_state %q; must be one of %q, %q or %q", filter.RevocationState, searchRevocationStateAny, searchRevocationStateRevoked, searchRevocationStateValid)), nil
+	}
+
+	if expiresWithin := data.Get("expires_within").(int); expiresWithin > 0 {
+		filter.ExpiresBefore = now.Add(time.Duration(expiresWithin) * time.Second)
+	} else if expiresWithin < 0 {
+		return logical.ErrorResponse("expires_within must not be negative"), nil
+	}
+
+	if issuerRef := data.Get(issuerRefParam).(string); len(issuerRef) > 0 {
+		if b.useLegacyBundleCaStorage() {
+			return logical.ErrorResponse("cannot search by issuer until migration has completed"), nil
+		}
+
+		id, err := resolveIssuerReference(ctx, req.Storage, issuerRef)
+		if err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("unable to resolve issuer %q: %v", issuerRef, err)), nil
+		}
+		filter.IssuerID = id
+	}
+
+	limit := data.Get("limit").(int)
+	if limit < 1 || limit > maxCertSearchLimit {
+		return logical.ErrorResponse(fmt.Sprintf("limit must be between 1 and %d", maxCertSearchLimit)), nil
+	}
+
+	format := data.Get("format").(string)
+	if format != "json" && format != "pem_bundle" {
+		return logical.ErrorResponse(fmt.Sprintf("unknown format %q; must be \"json\" or \"pem_bundle\"", format)), nil
+	}
+
+	// Searches never write to storage: certificates which aren't indexed yet
+	// are indexed in memory, and left to the backfill to store.
+	backfiller := &certIndexBackfiller{
+		b:        b,
+		storage:  req.Storage,
+		readOnly: true,
+	}
+
+	indexComplete, err := certIndexComplete(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	serials, err := b.searchCandidates(ctx, req.Storage, filter, indexComplete)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(serials)
+
+	start := 0
+	if after := data.Get("after").(string); len(after) > 0 {
+		after = normalizeSerial(strings.ToLower(after))
+		start = sort.Search(len(serials), func(i int) bool {
+			return serials[i] > after
+		})
+	}
+
+	var keys []string
+	keyInfo := make(map[string]interface{})
+	var pemBundle strings.Builder
+	var next string
+	for i := start; i < len(serials); i++ {
+		serial := serials[i]
+		if len(keys) == limit {
+			next = denormalizeSerial(keys[len(keys)-1])
+			break
+		}
+
+		index, err := fetchCertIndexEntry(ctx, req.Storage, serial)
+		if err != nil {
+			return nil, err
+		}
+		if index == nil {
+			index, err = backfiller.backfill(ctx, serial)
+			if err != nil {
+				return nil, err
+			}
+			if index == nil {
+				// The certificate was removed since we listed the store.
+				continue
+			}
+		}
+
+		if !filter.matches(index, now) {
+			continue
+		}
+
+		if format == "pem_bundle" {
+			certEntry, err := req.Storage.Get(ctx, "certs/"+serial)
+			if err != nil {
+				return nil, fmt.Errorf("error fetching certificate %q: %w", serial, err)
+			}
+			if certEntry == nil {
+				continue
+			}
+			pemBundle.Write(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certEntry.Value}))
+		}
+
+		keys = append(keys, serial)
+		keyInfo[denormalizeSerial(serial)] = index.toResponseData()
+	}
+
+	for i := range keys {
+		keys[i] = denormalizeSerial(keys[i])
+	}
+
+	resp := logical.ListResponseWithInfo(keys, keyInfo)
+	resp.Data["index_complete"] = indexComplete
+	if !indexComplete {
+		resp.AddWarning("The certificate index is still being built; certificates were searched without it, which may be slow.")
+	}
+	if len(next) > 0 {
+		resp.Data["next"] = next
+	}
+	if format == "pem_bundle" {
+		resp.Data["pem_bundle"] = pemBundle.String()
+	}
+
+	return resp, nil
+}
+
+// searchCandidates returns the serials of the certificates which may match
+// the filter. When the filter names a common name, SAN, role or issuer, the
+// candidates come from the index's lookup keys; otherwise, or while the
+// index is incomplete, every stored certificate is a candidate.
+func (b *backend) searchCandidates(ctx context.Context, s logical.Storage, filter *certSearchFilter, indexComplete bool) ([]string, error) {
+	var lookups []func() ([]string, error)
+	if len(filter.CommonName) > 0 {
+		lookups = append(lookups, func() ([]string, error) {
+			return globCertIndexLookup(ctx, s, certIndexByCNPrefix, filter.CommonName)
+		})
+	}
+	if len(filter.SAN) > 0 {
+		lookups = append(lookups, func() ([]string, error) {
+			return globCertIndexLookup(ctx, s, certIndexBySANPrefix, filter.SAN)
+		})
+	}
+	if len(filter.Role) > 0 {
+		lookups = append(lookups, func() ([]string, error) {
+			return listCertIndexLookup(ctx, s, certIndexByRolePrefix, filter.Role)
+		})
+	}
+	if len(filter.IssuerID) > 0 {
+		lookups = append(lookups, func() ([]string, error) {
+			return listCertIndexLookup(ctx, s, certIndexByIssuerPrefix, filter.IssuerID.String())
+		})
+	}
+
+	if len(lookups) > 0 && indexComplete {
+		var candidates map[string]struct{}
+		for _, lookup := range lookups {
+			serials, err := lookup()
+			if err != nil {
+				return nil, err
+			}
+
+			found := make(map[string]struct{}, len(serials))
+			for _, serial := range serials {
+				if _, ok := candidates[serial]; candidates == nil || ok {
+					found[serial] = struct{}{}
+				}
+			}
+			candidates = found
+
+			if len(candidates) == 0 {
+				break
+			}
+		}
+
+		ret := make([]string, 0, len(candidates))
+		for serial := range candidates {
+			ret = append(ret, serial)
+		}
+		return ret, nil
+	}
+
+	serials, err := s.List(ctx, "certs/")
+	if err != nil {
+		return nil, fmt.Errorf("error fetching list of certs: %w", err)
+	}
+	return serials, nil
+}
+
+// globCertIndexLookup is listCertIndexLookup for values which may contain
+// globs; the matching names are found by listing the lookup prefix.
+func globCertIndexLookup(ctx context.Context, s logical.Storage, prefix string, pattern string) ([]string, error) {
+	if !strings.Contains(pattern, "*") {
+		return listCertIndexLookup(ctx, s, prefix, pattern)
+	}
+
+	names, err := s.List(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list certificate index: %w", err)
+	}
+
+	pattern = strings.ToLower(pattern)
+	var serials []string
+	for _, name := range names {
+		decoded, err := hex.DecodeString(strings.TrimSuffix(name, "/"))
+		value := string(decoded)
+		if err != nil || !glob.Glob(pattern, value) {
+			continue
+		}
+
+		found, err := listCertIndexLookup(ctx, s, prefix, value)
+		if err != nil {
+			return nil, err
+		}
+		serials = append(serials, found...)
+	}
+
+	return strutil.RemoveDuplicates(serials, false), nil
+}
+
+func (i *certIndexEntry) toResponseData() map[string]interface{} {
+	ret := map[string]interface{}{
+		"common_name":     i.CommonName,
+		"dns_names":       i.DNSNames,
+		"email_addresses": i.EmailAddresses,
+		"ip_addresses":    i.IPAddresses,
+		"uris":            i.URIs,
+		"issuer_id":       i.IssuerID,
+		"role":            i.Role,
+		"not_before":      i.NotBefore.Format(time.RFC3339),
+		"not_after":       i.NotAfter.Format(time.RFC3339),
+		"revoked":         i.Revoked,
+	}
+	if i.Revoked {
+		ret["revocation_time"] = i.RevocationTime.Unix()
+	}
+	return ret
+}
+
+// certIndexBackfiller builds index entries for certificates stored before
+// the certificate index existed, or whose index failed to be written. The
+// issuer map is only loaded once such a certificate is found. Once every
+// stored certificate has been indexed, a marker is written so that later
+// searches can rely on the lookup keys.
+type certIndexBackfiller struct {
+	b        *backend
+	storage  logical.Storage
+	readOnly bool
+
+	issuerIDCertMap map[issuerID]*x509.Certificate
+}
+
+func (c *certIndexBackfiller) backfill(ctx context.Context, serial string) (*certIndexEntry, error) {
+	certEntry, err := c.storage.Get(ctx, "certs/"+serial)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching certificate %q: %w", serial, err)
+	}
+	if certEntry == nil || len(certEntry.Value) == 0 {
+		return nil, nil
+	}
+
+	cert, err := x509.ParseCertificate(certEntry.Value)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse stored certificate with serial %q: %w", serial, err)
+	}
+
+	var revInfo revocationInfo
+	revokedEntry, err := c.storage.Get(ctx, revokedPath+serial)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch revoked cert with serial %q: %w", serial, err)
+	}
+	if revokedEntry != nil {
+		if err := revokedEntry.DecodeJSON(&revInfo); err != nil {
+			return nil, fmt.Errorf("error decoding revocation entry for serial %q: %w", serial, err)
+		}
+	}
+
+	if len(revInfo.CertificateIssuer) == 0 && !c.b.useLegacyBundleCaStorage() {
+		if c.issuerIDCertMap == nil {
+			c.issuerIDCertMap, err = fetchIssuerMapForRevocationChecking(ctx, c.storage)
+			if err != nil {
+				return nil, err
+			}
+		}
+		associateRevokedCertWithIssuer(&revInfo, cert, c.issuerIDCertMap)
+	}
+
+	index := newCertIndexEntry(cert, revInfo.CertificateIssuer, "")
+	if revokedEntry != nil {
+		index.Revoked = true
+		index.RevocationTime = revInfo.RevocationTimeUTC
+		if index.RevocationTime.IsZero() {
+			index.RevocationTime = time.Unix(revInfo.RevocationTime, 0)
+		}
+	}
+
+	if !c.readOnly {
+		if err := writeCertIndex(ctx, c.storage, index); err != nil {
+			return nil, fmt.Errorf("error indexing certificate with serial %q: %w", serial, err)
+		}
+	}
+
+	return index, nil
+}
+
+// certIndexComplete returns whether the certificate index covers the whole
+// certificate store.
+func certIndexComplete(ctx context.Context, s logical.Storage) (bool, error) {
+	entry, err := s.Get(ctx, certIndexBackfilledPath)
+	if err != nil {
+		return false, fmt.Errorf("error fetching certificate index state: %w", err)
+	}
+	return entry != nil, nil
+}
+
+// complete indexes any stored certificates lacking an index entry, then
+// marks the index as covering the whole certificate store.
+func (c *certIndexBackfiller) complete(ctx context.Context) error {
+	serials, err := c.storage.List(ctx, "certs/")
+	if err != nil {
+		return fmt.Errorf("error fetching list of certs: %w", err)
+	}
+	for _, serial := range serials {
+		index, err := fetchCertIndexEntry(ctx, c.storage, serial)
+		if err != nil {
+			return err
+		}
+		if index != nil {
+			continue
+		}
+		if _, err := c.backfill(ctx, serial); err != nil {
+			return err
+		}
+	}
+
+	if err := c.storage.Put(ctx, &logical.StorageEntry{Key: certIndexBackfilledPath}); err != nil {
+		return fmt.Errorf("error writing certificate index state: %w", err)
+	}
+
+	return nil
+}
+
+// periodicCertIndexBackfill is to be called by the periodic function; while
+// the certificate index is incomplete, it runs the backfill in the
+// background on the active node.
+func (b *backend) periodicCertIndexBackfill(ctx context.Context, request *logical.Request) error {
+	if b.System().ReplicationState().HasState(consts.ReplicationPerformanceStandby) ||
+		b.System().ReplicationState().HasState(consts.ReplicationDRSecondary) {
+		return nil
+	}
+
+	complete, err := certIndexComplete(ctx, request.Storage)
+	if err != nil || complete {
+		return err
+	}
+
+	// The backfill may still be running from a previous invocation.
+	if !atomic.CompareAndSwapUint32(b.certIndexCASGuard, 0, 1) {
+		return nil
+	}
+
+	backfiller := &certIndexBackfiller{
+		b:       b,
+		storage: request.Storage,
+	}
+	go func() {
+		defer atomic.StoreUint32(b.certIndexCASGuard, 0)
+
+		// Don't cancel when the periodic invocation returns
+		if err := backfiller.complete(context.Background()); err != nil {
+			b.Logger().Error("error building certificate index", "error", err)
+		}
+	}()
+
+	return nil
+}
+
+const pathSearchCertsHelpSyn = `
+Search the stored certificates.
+`
+
+const pathSearchCertsHelpDesc = `
+This endpoint searches the certificates stored by this mount, filtering by
+common name, Subject Alternative Name, issuer, role, expiry and revocation
+state. Results are paginated by serial number: when more certificates
+remain, the "next" value can be passed as "after" to fetch the next page.
+
+Certificates issued with a role setting no_store are not searchable.
+`
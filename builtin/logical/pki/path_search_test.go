@@ -0,0 +1,284 @@
+package pki
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchCerts(t *testing.T) {
+	t.Parallel()
+
+	b, s := createBackendWithStorage(t)
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "root example.com",
+		"issuer_name": "root-a",
+		"key_type":    "ec",
+		"ttl":         "40h",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	rootSerial := resp.Data["serial_number"].(string)
+	resp, err = CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "root example.com",
+		"issuer_name": "root-b",
+		"key_type":    "ec",
+		"ttl":         "40h",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	rootBID := string(resp.Data["issuer_id"].(issuerID))
+	rootBSerial := resp.Data["serial_number"].(string)
+
+	_, err = CBWrite(b, s, "roles/payments", map[string]interface{}{
+		"allowed_domains":  "payments.internal",
+		"allow_subdomains": true,
+		"key_type":         "ec",
+		"issuer_ref":       "root-a",
+		"ttl":              "10h",
+	})
+	require.NoError(t, err)
+	_, err = CBWrite(b, s, "roles/web", map[string]interface{}{
+		"allowed_domains":  "example.com",
+		"allow_subdomains": true,
+		"key_type":         "ec",
+		"issuer_ref":       "root-b",
+		"ttl":              "30h",
+	})
+	require.NoError(t, err)
+
+	issue := func(role string, cn string, altNames string) string {
+		resp, err := CBWrite(b, s, "issue/"+role, map[string]interface{}{
+			"common_name": cn,
+			"alt_names":   altNames,
+		})
+		requireSuccessNonNilResponse(t, resp, err)
+		return resp.Data["serial_number"].(string)
+	}
+	apiSerial := issue("payments", "api.payments.internal", "")
+	dbSerial := issue("payments", "db.payments.internal", "replica.payments.internal")
+	wwwSerial := issue("web", "www.example.com", "")
+
+	search := func(data map[string]interface{}) []string {
+		t.Helper()
+		resp, err := CBWrite(b, s, "certs/search", data)
+		requireSuccessNonNilResponse(t, resp, err)
+		keys, _ := resp.Data["keys"].([]string)
+		return keys
+	}
+
+	require.ElementsMatch(t, []string{apiSerial, dbSerial}, search(map[string]interface{}{"common_name": "*.payments.internal"}))
+	require.ElementsMatch(t, []string{dbSerial}, search(map[string]interface{}{"san": "replica.*"}))
+	require.ElementsMatch(t, []string{apiSerial, dbSerial}, search(map[string]interface{}{"role": "payments"}))
+	require.ElementsMatch(t, []string{rootBSerial, wwwSerial}, search(map[string]interface{}{"issuer_ref": rootBID}))
+	require.ElementsMatch(t, []string{rootSerial, apiSerial, dbSerial}, search(map[string]interface{}{"issuer_ref": "root-a"}))
+	require.ElementsMatch(t, []string{apiSerial, dbSerial}, search(map[string]interface{}{"expires_within": "20h"}))
+
+	resp, err = CBWrite(b, s, "certs/search", map[string]interface{}{
+		"common_name": "db.payments.internal",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	info := resp.Data["key_info"].(map[string]interface{})[dbSerial].(map[string]interface{})
+	require.Equal(t, "payments", info["role"])
+	require.Equal(t, []string{"db.payments.internal", "replica.payments.internal"}, info["dns_names"])
+	require.Equal(t, false, info["revoked"])
+
+	// Revocation is reflected in the index.
+	_, err = CBWrite(b, s, "revoke", map[string]interface{}{
+		"serial_number": apiSerial,
+	})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{apiSerial}, search(map[string]interface{}{"revocation_state": "revoked"}))
+	require.ElementsMatch(t, []string{dbSerial}, search(map[string]interface{}{"role": "payments", "revocation_state": "valid"}))
+
+	// Pagination walks all certificates in serial order.
+	var paged []string
+	after := ""
+	for pages := 0; pages < 10; pages++ {
+		resp, err := CBWrite(b, s, "certs/search", map[string]interface{}{
+			"limit": 1,
+			"after": after,
+		})
+		requireSuccessNonNilResponse(t, resp, err)
+		keys, _ := resp.Data["keys"].([]string)
+		paged = append(paged, keys...)
+		next, ok := resp.Data["next"]
+		if !ok {
+			break
+		}
+		require.Len(t, keys, 1)
+		after = next.(string)
+	}
+	require.Len(t, paged, 5)
+	require.ElementsMatch(t, paged, search(map[string]interface{}{}))
+
+	// PEM export contains each matching certificate.
+	resp, err = CBWrite(b, s, "certs/search", map[string]interface{}{
+		"role":   "payments",
+		"format": "pem_bundle",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	bundle := []byte(resp.Data["pem_bundle"].(string))
+	var exported []string
+	for {
+		var block *pem.Block
+		block, bundle = pem.Decode(bundle)
+		if block == nil {
+			break
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		require.NoError(t, err)
+		exported = append(exported, certutil.GetHexFormatted(cert.SerialNumber.Bytes(), ":"))
+	}
+	require.ElementsMatch(t, []string{apiSerial, dbSerial}, exported)
+
+	// Each searchable value has a lookup key for the certificate.
+	ctx := context.Background()
+	dbKey := normalizeSerial(dbSerial)
+	for _, prefix := range []string{
+		certIndexByCNPrefix + certIndexLookupName("db.payments.internal") + "/",
+		certIndexBySANPrefix + certIndexLookupName("replica.payments.internal") + "/",
+		certIndexByRolePrefix + certIndexLookupName("payments") + "/",
+	} {
+		serials, err := s.List(ctx, prefix)
+		require.NoError(t, err)
+		require.Contains(t, serials, dbKey)
+	}
+
+	// Removing the index entry removes its lookup keys.
+	require.NoError(t, deleteCertIndexEntry(ctx, s, dbSerial))
+	replicaPrefix := certIndexBySANPrefix + certIndexLookupName("replica.payments.internal") + "/"
+	serials, err := s.List(ctx, replicaPrefix)
+	require.NoError(t, err)
+	require.Empty(t, serials)
+
+	// Until certificates stored before the index existed are indexed,
+	// searches still find them, without writing to storage, and report the
+	// index as incomplete.
+	require.NoError(t, s.Delete(ctx, certIndexBackfilledPath))
+	resp, err = CBWrite(b, s, "certs/search", map[string]interface{}{"san": "replica.payments.internal", "issuer_ref": "root-a"})
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, []string{dbSerial}, resp.Data["keys"])
+	require.Equal(t, false, resp.Data["index_complete"])
+	require.NotEmpty(t, resp.Warnings)
+	index, err := fetchCertIndexEntry(ctx, s, dbSerial)
+	require.NoError(t, err)
+	require.Nil(t, index)
+
+	// The periodic function indexes them in the background.
+	require.NoError(t, b.periodicCertIndexBackfill(ctx, &logical.Request{Storage: s}))
+	require.Eventually(t, func() bool {
+		complete, err := certIndexComplete(ctx, s)
+		return err == nil && complete
+	}, 10*time.Second, 10*time.Millisecond)
+	index, err = fetchCertIndexEntry(ctx, s, dbSerial)
+	require.NoError(t, err)
+	require.NotNil(t, index)
+	require.Empty(t, index.Role)
+	serials, err = s.List(ctx, replicaPrefix)
+	require.NoError(t, err)
+	require.Equal(t, []string{dbKey}, serials)
+	resp, err = CBWrite(b, s, "certs/search", map[string]interface{}{"san": "replica.payments.internal"})
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, true, resp.Data["index_complete"])
+	require.Empty(t, resp.Warnings)
+
+	_, err = CBWrite(b, s, "certs/search", map[string]interface{}{
+		"revocation_state": "unknown",
+	})
+	require.Error(t, err)
+	_, err = CBWrite(b, s, "certs/search", map[string]interface{}{
+		"limit": 5000,
+	})
+	require.Error(t, err)
+}
+
+// failingIndexStorage fails writes to the certificate index while failing
+// is set.
+type failingIndexStorage struct {
+	logical.Storage
+	failing bool
+}
+
+func (s *failingIndexStorage) Put(ctx context.Context, entry *logical.StorageEntry) error {
+	if s.failing && strings.HasPrefix(entry.Key, certIndexPrefix) && entry.Key != certIndexBackfilledPath {
+		return errors.New("failing index write")
+	}
+	return s.Storage.Put(ctx, entry)
+}
+
+func TestSearchCerts_IndexKeys(t *testing.T) {
+	t.Parallel()
+
+	// Mount storage rejects keys with relative path segments, as the
+	// barrier view does.
+	storage := &failingIndexStorage{Storage: logical.NewStorageView(&logical.InmemStorage{}, "")}
+	config := logical.TestBackendConfig()
+	config.StorageView = storage
+	b := Backend(config)
+	require.NoError(t, b.Setup(context.Background(), config))
+	b.pkiStorageVersion.Store(1)
+	var s logical.Storage = storage
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "root example.com",
+		"key_type":    "ec",
+		"ttl":         "40h",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	_, err = CBWrite(b, s, "roles/workload", map[string]interface{}{
+		"allow_any_name":   true,
+		"allowed_uri_sans": "spiffe://*",
+		"key_type":         "ec",
+		"ttl":              "10h",
+	})
+	require.NoError(t, err)
+
+	issue := func(uriSAN string) string {
+		t.Helper()
+		resp, err := CBWrite(b, s, "issue/workload", map[string]interface{}{
+			"common_name": "a..b@example.com",
+			"uri_sans":    uriSAN,
+		})
+		requireSuccessNonNilResponse(t, resp, err)
+		return resp.Data["serial_number"].(string)
+	}
+	search := func(data map[string]interface{}) []string {
+		t.Helper()
+		resp, err := CBWrite(b, s, "certs/search", data)
+		requireSuccessNonNilResponse(t, resp, err)
+		keys, _ := resp.Data["keys"].([]string)
+		return keys
+	}
+
+	// Values with relative path segments can be indexed and searched.
+	relative := issue("spiffe://x/a/../b")
+	require.Equal(t, []string{relative}, search(map[string]interface{}{"san": "spiffe://x/a/../b"}))
+	require.Equal(t, []string{relative}, search(map[string]interface{}{"san": "spiffe://x/*/../b"}))
+	require.Equal(t, []string{relative}, search(map[string]interface{}{"common_name": "A..B@example.com"}))
+
+	// A failure to index a certificate doesn't fail its issuance; the
+	// backfill is scheduled to index it instead.
+	ctx := context.Background()
+	storage.failing = true
+	unindexed := issue("spiffe://x/c")
+	storage.failing = false
+	complete, err := certIndexComplete(ctx, s)
+	require.NoError(t, err)
+	require.False(t, complete)
+	require.Equal(t, []string{unindexed}, search(map[string]interface{}{"san": "spiffe://x/c"}))
+
+	backfiller := &certIndexBackfiller{b: b, storage: s}
+	require.NoError(t, backfiller.complete(ctx))
+	index, err := fetchCertIndexEntry(ctx, s, unindexed)
+	require.NoError(t, err)
+	require.NotNil(t, index)
+	require.Equal(t, []string{unindexed}, search(map[string]interface{}{"san": "spiffe://x/c"}))
+}
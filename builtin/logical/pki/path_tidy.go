@@ -3,48 +3,89 @@ package pki
 import (
 	"context"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"net/http"
 	"sync/atomic"
 	"time"
 
 	"github.com/armon/go-metrics"
+	"github.com/fatih/structs"
+	hclog "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
-func pathTidy(b *backend) *framework.Path {
-	return &framework.Path{
-		Pattern: "tidy$",
-		Fields: map[string]*framework.FieldSchema{
-			"tidy_cert_store": {
-				Type: framework.TypeBool,
-				Description: `Set to true to enable tidying up
-the certificate store`,
-			},
+const (
+	autoTidyConfigPath = "config/auto-tidy"
 
-			"tidy_revocation_list": {
-				Type:        framework.TypeBool,
-				Description: `Deprecated; synonym for 'tidy_revoked_certs`,
-			},
+	// The history of tidy operations is cluster-local, like the
+	// certificate and revocation stores being tidied.
+	tidyHistoryPath       = "tidy-history"
+	maxTidyHistoryEntries = 10
 
-			"tidy_revoked_certs": {
-				Type: framework.TypeBool,
-				Description: `Set to true to expire all revoked
-and expired certificates, removing them both from the CRL and from storage. The
-CRL will be rotated if this causes any values to be removed.`,
-			},
+	tidySourceManual = "manual"
+	tidySourceAuto   = "auto"
+)
 
-			"safety_buffer": {
-				Type: framework.TypeDurationSecond,
-				Description: `The amount of extra time that must have passed
-beyond certificate expiration before it is removed
-from the backend storage and/or revocation list.
-Defaults to 72 hours.`,
-				Default: 259200, // 72h, but TypeDurationSecond currently requires defaults to be int
-			},
-		},
+type tidyConfig struct {
+	// Enabled and Interval only apply to auto-tidy.
+	Enabled            bool          `json:"enabled"`
+	Interval           time.Duration `json:"interval_duration"`
+	CertStore          bool          `json:"tidy_cert_store"`
+	RevokedCerts       bool          `json:"tidy_revoked_certs"`
+	IssuerAssocs       bool          `json:"tidy_revoked_cert_issuer_associations"`
+	ExpiredIssuers     bool          `json:"tidy_expired_issuers"`
+	SafetyBuffer       time.Duration `json:"safety_buffer"`
+	IssuerSafetyBuffer time.Duration `json:"issuer_safety_buffer"`
+}
+
+var defaultTidyConfig = tidyConfig{
+	Enabled:            false,
+	Interval:           12 * time.Hour,
+	CertStore:          false,
+	RevokedCerts:       false,
+	IssuerAssocs:       false,
+	ExpiredIssuers:     false,
+	SafetyBuffer:       72 * time.Hour,
+	IssuerSafetyBuffer: 365 * 24 * time.Hour,
+}
+
+func (config *tidyConfig) anyOperationEnabled() bool {
+	return config.CertStore || config.RevokedCerts || config.IssuerAssocs || config.ExpiredIssuers
+}
+
+// tidyHistoryEntry records the outcome of a single tidy operation.
+type tidyHistoryEntry struct {
+	Source                            string    `json:"source" structs:"source"`
+	State                             string    `json:"state" structs:"state"`
+	Error                             string    `json:"error" structs:"error"`
+	TimeStarted                       time.Time `json:"time_started" structs:"time_started"`
+	TimeFinished                      time.Time `json:"time_finished" structs:"time_finished"`
+	Duration                          string    `json:"duration" structs:"duration"`
+	SafetyBuffer                      int       `json:"safety_buffer" structs:"safety_buffer"`
+	IssuerSafetyBuffer                int       `json:"issuer_safety_buffer" structs:"issuer_safety_buffer"`
+	TidyCertStore                     bool      `json:"tidy_cert_store" structs:"tidy_cert_store"`
+	TidyRevokedCerts                  bool      `json:"tidy_revoked_certs" structs:"tidy_revoked_certs"`
+	TidyRevokedCertIssuerAssociations bool      `json:"tidy_revoked_cert_issuer_associations" structs:"tidy_revoked_cert_issuer_associations"`
+	TidyExpiredIssuers                bool      `json:"tidy_expired_issuers" structs:"tidy_expired_issuers"`
+	CertStoreDeletedCount             uint      `json:"cert_store_deleted_count" structs:"cert_store_deleted_count"`
+	RevokedCertDeletedCount           uint      `json:"revoked_cert_deleted_count" structs:"revoked_cert_deleted_count"`
+	MissingIssuerCertCount            uint      `json:"missing_issuer_cert_count" structs:"missing_issuer_cert_count"`
+	ExpiredIssuerDeletedCount         uint      `json:"expired_issuer_deleted_count" structs:"expired_issuer_deleted_count"`
+}
+
+func pathTidy(b *backend) *framework.Path {
+	fields := addTidyFields(map[string]*framework.FieldSchema{})
+	fields["tidy_revocation_list"] = &framework.FieldSchema{
+		Type:        framework.TypeBool,
+		Description: `Deprecated; synonym for 'tidy_revoked_certs`,
+	}
+
+	return &framework.Path{
+		Pattern: "tidy$",
+		Fields:  fields,
 
 		Operations: map[logical.Operation]framework.OperationHandler{
 			logical.UpdateOperation: &framework.PathOperation{
@@ -72,17 +113,64 @@ func pathTidyStatus(b *backend) *framework.Path {
 	}
 }
 
+func pathConfigAutoTidy(b *backend) *framework.Path {
+	fields := addTidyFields(map[string]*framework.FieldSchema{})
+	fields["enabled"] = &framework.FieldSchema{
+		Type:        framework.TypeBool,
+		Description: `Set to true to enable automatic tidy operations.`,
+	}
+	fields["interval_duration"] = &framework.FieldSchema{
+		Type:        framework.TypeDurationSecond,
+		Description: `Interval at which to run an auto-tidy operation. This is the time between tidy invocations (after one finishes to the start of the next). Running a manual tidy will reset this duration.`,
+		Default:     int(defaultTidyConfig.Interval / time.Second), // TypeDurationSecond currently requires the default to be an int.
+	}
+
+	return &framework.Path{
+		Pattern: "config/auto-tidy",
+		Fields:  fields,
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathConfigAutoTidyRead,
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathConfigAutoTidyWrite,
+				// Read more about why these flags are set in backend.go.
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: true,
+			},
+		},
+
+		HelpSynopsis:    pathConfigAutoTidySyn,
+		HelpDescription: pathConfigAutoTidyDesc,
+	}
+}
+
 func (b *backend) pathTidyWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	safetyBuffer := d.Get("safety_buffer").(int)
 	tidyCertStore := d.Get("tidy_cert_store").(bool)
 	tidyRevokedCerts := d.Get("tidy_revoked_certs").(bool)
 	tidyRevocationList := d.Get("tidy_revocation_list").(bool)
+	tidyRevokedAssocs := d.Get("tidy_revoked_cert_issuer_associations").(bool)
+	tidyExpiredIssuers := d.Get("tidy_expired_issuers").(bool)
+	issuerSafetyBuffer := d.Get("issuer_safety_buffer").(int)
 
 	if safetyBuffer < 1 {
 		return logical.ErrorResponse("safety_buffer must be greater than zero"), nil
 	}
 
-	bufferDuration := time.Duration(safetyBuffer) * time.Second
+	if issuerSafetyBuffer < 1 {
+		return logical.ErrorResponse("issuer_safety_buffer must be greater than zero"), nil
+	}
+
+	config := &tidyConfig{
+		CertStore:          tidyCertStore,
+		RevokedCerts:       tidyRevokedCerts || tidyRevocationList,
+		IssuerAssocs:       tidyRevokedAssocs,
+		ExpiredIssuers:     tidyExpiredIssuers,
+		SafetyBuffer:       time.Duration(safetyBuffer) * time.Second,
+		IssuerSafetyBuffer: time.Duration(issuerSafetyBuffer) * time.Second,
+	}
 
 	if !atomic.CompareAndSwapUint32(b.tidyCASGuard, 0, 1) {
 		resp := &logical.Response{}
@@ -96,138 +184,43 @@ func (b *backend) pathTidyWrite(ctx context.Context, req *logical.Request, d *fr
 		Storage: req.Storage,
 	}
 
+	b.startTidyOperation(req, config, tidySourceManual)
+
+	resp := &logical.Response{}
+	resp.AddWarning("Tidy operation successfully started. Any information from the operation will be printed to Vault's server logs.")
+	return logical.RespondWithStatusCode(resp, req, http.StatusAccepted)
+}
+
+// startTidyOperation runs the tidy operation in the background; callers
+// must have already acquired the tidyCASGuard, which is released once the
+// operation finishes.
+func (b *backend) startTidyOperation(req *logical.Request, config *tidyConfig, source string) {
 	go func() {
 		defer atomic.StoreUint32(b.tidyCASGuard, 0)
 
-		b.tidyStatusStart(safetyBuffer, tidyCertStore, tidyRevokedCerts || tidyRevocationList)
+		b.tidyStatusStart(config, source)
 
 		// Don't cancel when the original client request goes away
-		ctx = context.Background()
+		ctx := context.Background()
 
 		logger := b.Logger().Named("tidy")
 
 		doTidy := func() error {
-			if tidyCertStore {
-				serials, err := req.Storage.List(ctx, "certs/")
-				if err != nil {
-					return fmt.Errorf("error fetching list of certs: %w", err)
-				}
-
-				serialCount := len(serials)
-				metrics.SetGauge([]string{"secrets", "pki", "tidy", "cert_store_total_entries"}, float32(serialCount))
-				for i, serial := range serials {
-					b.tidyStatusMessage(fmt.Sprintf("Tidying certificate store: checking entry %d of %d", i, serialCount))
-					metrics.SetGauge([]string{"secrets", "pki", "tidy", "cert_store_current_entry"}, float32(i))
-
-					certEntry, err := req.Storage.Get(ctx, "certs/"+serial)
-					if err != nil {
-						return fmt.Errorf("error fetching certificate %q: %w", serial, err)
-					}
-
-					if certEntry == nil {
-						logger.Warn("certificate entry is nil; tidying up since it is no longer useful for any server operations", "serial", serial)
-						if err := req.Storage.Delete(ctx, "certs/"+serial); err != nil {
-							return fmt.Errorf("error deleting nil entry with serial %s: %w", serial, err)
-						}
-						b.tidyStatusIncCertStoreCount()
-						continue
-					}
-
-					if certEntry.Value == nil || len(certEntry.Value) == 0 {
-						logger.Warn("certificate entry has no value; tidying up since it is no longer useful for any server operations", "serial", serial)
-						if err := req.Storage.Delete(ctx, "certs/"+serial); err != nil {
-							return fmt.Errorf("error deleting entry with nil value with serial %s: %w", serial, err)
-						}
-						b.tidyStatusIncCertStoreCount()
-						continue
-					}
-
-					cert, err := x509.ParseCertificate(certEntry.Value)
-					if err != nil {
-						return fmt.Errorf("unable to parse stored certificate with serial %q: %w", serial, err)
-					}
-
-					if time.Now().After(cert.NotAfter.Add(bufferDuration)) {
-						if err := req.Storage.Delete(ctx, "certs/"+serial); err != nil {
-							return fmt.Errorf("error deleting serial %q from storage: %w", serial, err)
-						}
-						b.tidyStatusIncCertStoreCount()
-					}
+			if config.CertStore {
+				if err := b.doTidyCertStore(ctx, req, logger, config); err != nil {
+					return err
 				}
 			}
 
-			if tidyRevokedCerts || tidyRevocationList {
-				b.revokeStorageLock.Lock()
-				defer b.revokeStorageLock.Unlock()
-
-				rebuildCRL := false
-
-				revokedSerials, err := req.Storage.List(ctx, "revoked/")
-				if err != nil {
-					return fmt.Errorf("error fetching list of revoked certs: %w", err)
-				}
-
-				revokedSerialsCount := len(revokedSerials)
-				metrics.SetGauge([]string{"secrets", "pki", "tidy", "revoked_cert_total_entries"}, float32(revokedSerialsCount))
-
-				var revInfo revocationInfo
-				for i, serial := range revokedSerials {
-					b.tidyStatusMessage(fmt.Sprintf("Tidying revoked certificates: checking certificate %d of %d", i, len(revokedSerials)))
-					metrics.SetGauge([]string{"secrets", "pki", "tidy", "revoked_cert_current_entry"}, float32(i))
-
-					revokedEntry, err := req.Storage.Get(ctx, "revoked/"+serial)
-					if err != nil {
-						return fmt.Errorf("unable to fetch revoked cert with serial %q: %w", serial, err)
-					}
-
-					if revokedEntry == nil {
-						logger.Warn("revoked entry is nil; tidying up since it is no longer useful for any server operations", "serial", serial)
-						if err := req.Storage.Delete(ctx, "revoked/"+serial); err != nil {
-							return fmt.Errorf("error deleting nil revoked entry with serial %s: %w", serial, err)
-						}
-						b.tidyStatusIncRevokedCertCount()
-						continue
-					}
-
-					if revokedEntry.Value == nil || len(revokedEntry.Value) == 0 {
-						logger.Warn("revoked entry has nil value; tidying up since it is no longer useful for any server operations", "serial", serial)
-						if err := req.Storage.Delete(ctx, "revoked/"+serial); err != nil {
-							return fmt.Errorf("error deleting revoked entry with nil value with serial %s: %w", serial, err)
-						}
-						b.tidyStatusIncRevokedCertCount()
-						continue
-					}
-
-					err = revokedEntry.DecodeJSON(&revInfo)
-					if err != nil {
-						return fmt.Errorf("error decoding revocation entry for serial %q: %w", serial, err)
-					}
-
-					revokedCert, err := x509.ParseCertificate(revInfo.CertificateBytes)
-					if err != nil {
-						return fmt.Errorf("unable to parse stored revoked certificate with serial %q: %w", serial, err)
-					}
-
-					// Only remove the entries from revoked/ and certs/ if we're
-					// past its NotAfter value. This is because we use the
-					// information on revoked/ to build the CRL and the
-					// information on certs/ for lookup.
-					if time.Now().After(revokedCert.NotAfter.Add(bufferDuration)) {
-						if err := req.Storage.Delete(ctx, "revoked/"+serial); err != nil {
-							return fmt.Errorf("error deleting serial %q from revoked list: %w", serial, err)
-						}
-						if err := req.Storage.Delete(ctx, "certs/"+serial); err != nil {
-							return fmt.Errorf("error deleting serial %q from store when tidying revoked: %w", serial, err)
-						}
-						rebuildCRL = true
-						b.tidyStatusIncRevokedCertCount()
-					}
+			if config.RevokedCerts || config.IssuerAssocs {
+				if err := b.doTidyRevocationStore(ctx, req, logger, config); err != nil {
+					return err
 				}
+			}
 
-				if rebuildCRL {
-					if err := b.crlBuilder.rebuild(ctx, b, req, false); err != nil {
-						return err
-					}
+			if config.ExpiredIssuers {
+				if err := b.doTidyExpiredIssuers(ctx, req, logger, config); err != nil {
+					return err
 				}
 			}
 
@@ -240,35 +233,309 @@ func (b *backend) pathTidyWrite(ctx context.Context, req *logical.Request, d *fr
 		} else {
 			b.tidyStatusStop(nil)
 		}
+
+		if err := b.recordTidyHistory(ctx, req.Storage); err != nil {
+			logger.Error("error recording tidy history", "error", err)
+		}
 	}()
+}
 
-	resp := &logical.Response{}
-	resp.AddWarning("Tidy operation successfully started. Any information from the operation will be printed to Vault's server logs.")
-	return logical.RespondWithStatusCode(resp, req, http.StatusAccepted)
+func (b *backend) doTidyCertStore(ctx context.Context, req *logical.Request, logger hclog.Logger, config *tidyConfig) error {
+	serials, err := req.Storage.List(ctx, "certs/")
+	if err != nil {
+		return fmt.Errorf("error fetching list of certs: %w", err)
+	}
+
+	serialCount := len(serials)
+	metrics.SetGauge([]string{"secrets", "pki", "tidy", "cert_store_total_entries"}, float32(serialCount))
+	for i, serial := range serials {
+		b.tidyStatusMessage(fmt.Sprintf("Tidying certificate store: checking entry %d of %d", i, serialCount))
+		metrics.SetGauge([]string{"secrets", "pki", "tidy", "cert_store_current_entry"}, float32(i))
+
+		certEntry, err := req.Storage.Get(ctx, "certs/"+serial)
+		if err != nil {
+			return fmt.Errorf("error fetching certificate %q: %w", serial, err)
+		}
+
+		if certEntry == nil {
+			logger.Warn("certificate entry is nil; tidying up since it is no longer useful for any server operations", "serial", serial)
+			if err := req.Storage.Delete(ctx, "certs/"+serial); err != nil {
+				return fmt.Errorf("error deleting nil entry with serial %s: %w", serial, err)
+			}
+			if err := deleteCertIndexEntry(ctx, req.Storage, serial); err != nil {
+				return fmt.Errorf("error deleting index entry for serial %s: %w", serial, err)
+			}
+			b.tidyStatusIncCertStoreCount()
+			continue
+		}
+
+		if certEntry.Value == nil || len(certEntry.Value) == 0 {
+			logger.Warn("certificate entry has no value; tidying up since it is no longer useful for any server operations", "serial", serial)
+			if err := req.Storage.Delete(ctx, "certs/"+serial); err != nil {
+				return fmt.Errorf("error deleting entry with nil value with serial %s: %w", serial, err)
+			}
+			if err := deleteCertIndexEntry(ctx, req.Storage, serial); err != nil {
+				return fmt.Errorf("error deleting index entry for serial %s: %w", serial, err)
+			}
+			b.tidyStatusIncCertStoreCount()
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(certEntry.Value)
+		if err != nil {
+			return fmt.Errorf("unable to parse stored certificate with serial %q: %w", serial, err)
+		}
+
+		if time.Now().After(cert.NotAfter.Add(config.SafetyBuffer)) {
+			if err := req.Storage.Delete(ctx, "certs/"+serial); err != nil {
+				return fmt.Errorf("error deleting serial %q from storage: %w", serial, err)
+			}
+			if err := deleteCertIndexEntry(ctx, req.Storage, serial); err != nil {
+				return fmt.Errorf("error deleting serial %q from certificate index: %w", serial, err)
+			}
+			b.tidyStatusIncCertStoreCount()
+		}
+	}
+
+	return nil
+}
+
+func (b *backend) doTidyRevocationStore(ctx context.Context, req *logical.Request, logger hclog.Logger, config *tidyConfig) error {
+	b.revokeStorageLock.Lock()
+	defer b.revokeStorageLock.Unlock()
+
+	// Fetch the issuers up front when validating associations; during the
+	// migration, there are no issuer IDs to associate with.
+	var issuerIDCertMap map[issuerID]*x509.Certificate
+	if config.IssuerAssocs {
+		if b.useLegacyBundleCaStorage() {
+			logger.Warn("skipping tidy of revoked certificate issuer associations until the PKI migration has completed")
+		} else {
+			var err error
+			issuerIDCertMap, err = fetchIssuerMapForRevocationChecking(ctx, req.Storage)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	rebuildCRL := false
+
+	revokedSerials, err := req.Storage.List(ctx, "revoked/")
+	if err != nil {
+		return fmt.Errorf("error fetching list of revoked certs: %w", err)
+	}
+
+	revokedSerialsCount := len(revokedSerials)
+	metrics.SetGauge([]string{"secrets", "pki", "tidy", "revoked_cert_total_entries"}, float32(revokedSerialsCount))
+
+	for i, serial := range revokedSerials {
+		b.tidyStatusMessage(fmt.Sprintf("Tidying revoked certificates: checking certificate %d of %d", i, len(revokedSerials)))
+		metrics.SetGauge([]string{"secrets", "pki", "tidy", "revoked_cert_current_entry"}, float32(i))
+
+		revokedEntry, err := req.Storage.Get(ctx, "revoked/"+serial)
+		if err != nil {
+			return fmt.Errorf("unable to fetch revoked cert with serial %q: %w", serial, err)
+		}
+
+		if revokedEntry == nil {
+			if !config.RevokedCerts {
+				continue
+			}
+			logger.Warn("revoked entry is nil; tidying up since it is no longer useful for any server operations", "serial", serial)
+			if err := req.Storage.Delete(ctx, "revoked/"+serial); err != nil {
+				return fmt.Errorf("error deleting nil revoked entry with serial %s: %w", serial, err)
+			}
+			b.tidyStatusIncRevokedCertCount()
+			continue
+		}
+
+		if revokedEntry.Value == nil || len(revokedEntry.Value) == 0 {
+			if !config.RevokedCerts {
+				continue
+			}
+			logger.Warn("revoked entry has nil value; tidying up since it is no longer useful for any server operations", "serial", serial)
+			if err := req.Storage.Delete(ctx, "revoked/"+serial); err != nil {
+				return fmt.Errorf("error deleting revoked entry with nil value with serial %s: %w", serial, err)
+			}
+			b.tidyStatusIncRevokedCertCount()
+			continue
+		}
+
+		var revInfo revocationInfo
+		err = revokedEntry.DecodeJSON(&revInfo)
+		if err != nil {
+			return fmt.Errorf("error decoding revocation entry for serial %q: %w", serial, err)
+		}
+
+		revokedCert, err := x509.ParseCertificate(revInfo.CertificateBytes)
+		if err != nil {
+			return fmt.Errorf("unable to parse stored revoked certificate with serial %q: %w", serial, err)
+		}
+
+		// Only remove the entries from revoked/ and certs/ if we're
+		// past its NotAfter value. This is because we use the
+		// information on revoked/ to build the CRL and the
+		// information on certs/ for lookup.
+		if config.RevokedCerts && time.Now().After(revokedCert.NotAfter.Add(config.SafetyBuffer)) {
+			if err := req.Storage.Delete(ctx, "revoked/"+serial); err != nil {
+				return fmt.Errorf("error deleting serial %q from revoked list: %w", serial, err)
+			}
+			if err := req.Storage.Delete(ctx, "certs/"+serial); err != nil {
+				return fmt.Errorf("error deleting serial %q from store when tidying revoked: %w", serial, err)
+			}
+			if err := deleteCertIndexEntry(ctx, req.Storage, serial); err != nil {
+				return fmt.Errorf("error deleting serial %q from certificate index when tidying revoked: %w", serial, err)
+			}
+			if err := req.Storage.Delete(ctx, deltaWALPath+serial); err != nil {
+				return fmt.Errorf("error deleting serial %q from delta WAL when tidying revoked: %w", serial, err)
+			}
+			rebuildCRL = true
+			b.tidyStatusIncRevokedCertCount()
+			continue
+		}
+
+		if issuerIDCertMap == nil {
+			continue
+		}
+
+		// Validate the issuer association, as the CRL builder and OCSP
+		// responder rely on it to avoid checking every issuer's signature.
+		if len(revInfo.CertificateIssuer) > 0 {
+			if _, issuerExists := issuerIDCertMap[revInfo.CertificateIssuer]; issuerExists {
+				continue
+			}
+		}
+
+		if !associateRevokedCertWithIssuer(&revInfo, revokedCert, issuerIDCertMap) {
+			b.tidyStatusIncMissingIssuerCertCount()
+			continue
+		}
+
+		revokedEntry, err = logical.StorageEntryJSON("revoked/"+serial, revInfo)
+		if err != nil {
+			return fmt.Errorf("error creating revocation entry for existing cert: %v", serial)
+		}
+
+		if err := req.Storage.Put(ctx, revokedEntry); err != nil {
+			return fmt.Errorf("error updating revoked certificate at existing location: %v", serial)
+		}
+	}
+
+	if rebuildCRL {
+		if err := b.crlBuilder.rebuild(ctx, b, req, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
-func (b *backend) pathTidyStatusRead(_ context.Context, _ *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+func (b *backend) doTidyExpiredIssuers(ctx context.Context, req *logical.Request, logger hclog.Logger, config *tidyConfig) error {
+	// Issuers are shared storage; only the primary (or a local mount) may
+	// remove them.
+	if b.System().ReplicationState().HasState(consts.ReplicationPerformanceSecondary) && !b.System().LocalMount() {
+		logger.Debug("skipping expired issuer tidy as we're not on the primary or secondary with a local mount")
+		return nil
+	}
+
+	if b.useLegacyBundleCaStorage() {
+		logger.Warn("skipping tidy of expired issuers until the PKI migration has completed")
+		return nil
+	}
+
+	b.issuersLock.Lock()
+	defer b.issuersLock.Unlock()
+
+	issuers, err := listIssuers(ctx, req.Storage)
+	if err != nil {
+		return fmt.Errorf("error fetching list of issuers: %w", err)
+	}
+
+	removedIssuers := false
+	for i, id := range issuers {
+		b.tidyStatusMessage(fmt.Sprintf("Tidying expired issuers: checking issuer %d of %d", i, len(issuers)))
+
+		issuer, err := fetchIssuerById(ctx, req.Storage, id)
+		if err != nil {
+			return err
+		}
+
+		issuerCert, err := issuer.GetCertificate()
+		if err != nil {
+			return fmt.Errorf("unable to parse issuer %v's certificate: %w", id, err)
+		}
+
+		if time.Now().Before(issuerCert.NotAfter.Add(config.IssuerSafetyBuffer)) {
+			continue
+		}
+
+		wasDefault, err := deleteIssuer(ctx, req.Storage, id)
+		if err != nil {
+			return fmt.Errorf("error deleting expired issuer %v: %w", id, err)
+		}
+		if wasDefault {
+			logger.Warn("expired issuer was the default; operations without an explicit issuer will not work until a new default is configured", "issuer_id", id, "issuer_name", issuer.Name)
+		}
+
+		removedIssuers = true
+		b.tidyStatusIncExpiredIssuerCount()
+	}
+
+	if removedIssuers {
+		// As in deleting an issuer, the remaining chains might've changed,
+		// and the CRLs of removed issuers need cleaning up.
+		if err := rebuildIssuersChains(ctx, req.Storage, nil); err != nil {
+			return err
+		}
+
+		if err := b.crlBuilder.rebuild(ctx, b, req, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (b *backend) pathTidyStatusRead(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
 	// If this node is a performance secondary return an ErrReadOnly so that the request gets forwarded,
 	// but only if the PKI backend is not a local mount.
 	if b.System().ReplicationState().HasState(consts.ReplicationPerformanceSecondary) && !b.System().LocalMount() {
 		return nil, logical.ErrReadOnly
 	}
 
+	history, err := getTidyHistory(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	historyData := make([]map[string]interface{}, 0, len(history))
+	for i := len(history) - 1; i >= 0; i-- {
+		historyData = append(historyData, structs.New(history[i]).Map())
+	}
+
 	b.tidyStatusLock.RLock()
 	defer b.tidyStatusLock.RUnlock()
 
 	resp := &logical.Response{
 		Data: map[string]interface{}{
-			"safety_buffer":              nil,
-			"tidy_cert_store":            nil,
-			"tidy_revoked_certs":         nil,
-			"state":                      "Inactive",
-			"error":                      nil,
-			"time_started":               nil,
-			"time_finished":              nil,
-			"message":                    nil,
-			"cert_store_deleted_count":   nil,
-			"revoked_cert_deleted_count": nil,
+			"source":                                nil,
+			"safety_buffer":                         nil,
+			"issuer_safety_buffer":                  nil,
+			"tidy_cert_store":                       nil,
+			"tidy_revoked_certs":                    nil,
+			"tidy_revoked_cert_issuer_associations": nil,
+			"tidy_expired_issuers":                  nil,
+			"state":                                 "Inactive",
+			"error":                                 nil,
+			"time_started":                          nil,
+			"time_finished":                         nil,
+			"message":                               nil,
+			"cert_store_deleted_count":              nil,
+			"revoked_cert_deleted_count":            nil,
+			"missing_issuer_cert_count":             nil,
+			"expired_issuer_deleted_count":          nil,
+			"history":                               historyData,
 		},
 	}
 
@@ -276,13 +543,19 @@ func (b *backend) pathTidyStatusRead(_ context.Context, _ *logical.Request, _ *f
 		return resp, nil
 	}
 
+	resp.Data["source"] = b.tidyStatus.source
 	resp.Data["safety_buffer"] = b.tidyStatus.safetyBuffer
+	resp.Data["issuer_safety_buffer"] = b.tidyStatus.issuerSafetyBuffer
 	resp.Data["tidy_cert_store"] = b.tidyStatus.tidyCertStore
 	resp.Data["tidy_revoked_certs"] = b.tidyStatus.tidyRevokedCerts
+	resp.Data["tidy_revoked_cert_issuer_associations"] = b.tidyStatus.tidyRevokedAssocs
+	resp.Data["tidy_expired_issuers"] = b.tidyStatus.tidyExpiredIssuers
 	resp.Data["time_started"] = b.tidyStatus.timeStarted
 	resp.Data["message"] = b.tidyStatus.message
 	resp.Data["cert_store_deleted_count"] = b.tidyStatus.certStoreDeletedCount
 	resp.Data["revoked_cert_deleted_count"] = b.tidyStatus.revokedCertDeletedCount
+	resp.Data["missing_issuer_cert_count"] = b.tidyStatus.missingIssuerCertCount
+	resp.Data["expired_issuer_deleted_count"] = b.tidyStatus.expiredIssuerDeletedCount
 
 	switch b.tidyStatus.state {
 	case tidyStatusStarted:
@@ -302,16 +575,238 @@ func (b *backend) pathTidyStatusRead(_ context.Context, _ *logical.Request, _ *f
 	return resp, nil
 }
 
-func (b *backend) tidyStatusStart(safetyBuffer int, tidyCertStore, tidyRevokedCerts bool) {
+func (b *backend) pathConfigAutoTidyRead(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	config, err := getAutoTidyConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: autoTidyResponseData(config),
+	}, nil
+}
+
+func (b *backend) pathConfigAutoTidyWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	config, err := getAutoTidyConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	if enabledRaw, ok := d.GetOk("enabled"); ok {
+		config.Enabled = enabledRaw.(bool)
+	}
+
+	if intervalRaw, ok := d.GetOk("interval_duration"); ok {
+		config.Interval = time.Duration(intervalRaw.(int)) * time.Second
+		if config.Interval < 0 {
+			return logical.ErrorResponse(fmt.Sprintf("given interval_duration must be greater than or equal to zero seconds; got: %v", intervalRaw)), nil
+		}
+	}
+
+	if certStoreRaw, ok := d.GetOk("tidy_cert_store"); ok {
+		config.CertStore = certStoreRaw.(bool)
+	}
+
+	if revokedCertsRaw, ok := d.GetOk("tidy_revoked_certs"); ok {
+		config.RevokedCerts = revokedCertsRaw.(bool)
+	}
+
+	if issuerAssocRaw, ok := d.GetOk("tidy_revoked_cert_issuer_associations"); ok {
+		config.IssuerAssocs = issuerAssocRaw.(bool)
+	}
+
+	if expiredIssuers, ok := d.GetOk("tidy_expired_issuers"); ok {
+		config.ExpiredIssuers = expiredIssuers.(bool)
+	}
+
+	if safetyBufferRaw, ok := d.GetOk("safety_buffer"); ok {
+		config.SafetyBuffer = time.Duration(safetyBufferRaw.(int)) * time.Second
+		if config.SafetyBuffer < 1*time.Second {
+			return logical.ErrorResponse(fmt.Sprintf("given safety_buffer must be greater than zero seconds; got: %v", safetyBufferRaw)), nil
+		}
+	}
+
+	if issuerSafetyBufferRaw, ok := d.GetOk("issuer_safety_buffer"); ok {
+		config.IssuerSafetyBuffer = time.Duration(issuerSafetyBufferRaw.(int)) * time.Second
+		if config.IssuerSafetyBuffer < 1*time.Second {
+			return logical.ErrorResponse(fmt.Sprintf("given issuer_safety_buffer must be greater than zero seconds; got: %v", issuerSafetyBufferRaw)), nil
+		}
+	}
+
+	if config.Enabled && !config.anyOperationEnabled() {
+		return logical.ErrorResponse("Auto-tidy enabled but no tidy operations were requested. Enable at least one tidy operation to be run (tidy_cert_store / tidy_revoked_certs / tidy_revoked_cert_issuer_associations / tidy_expired_issuers)."), nil
+	}
+
+	if err := setAutoTidyConfig(ctx, req.Storage, config); err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: autoTidyResponseData(config),
+	}, nil
+}
+
+func autoTidyResponseData(config *tidyConfig) map[string]interface{} {
+	return map[string]interface{}{
+		"enabled":                               config.Enabled,
+		"interval_duration":                     int(config.Interval / time.Second),
+		"tidy_cert_store":                       config.CertStore,
+		"tidy_revoked_certs":                    config.RevokedCerts,
+		"tidy_revoked_cert_issuer_associations": config.IssuerAssocs,
+		"tidy_expired_issuers":                  config.ExpiredIssuers,
+		"safety_buffer":                         int(config.SafetyBuffer / time.Second),
+		"issuer_safety_buffer":                  int(config.IssuerSafetyBuffer / time.Second),
+	}
+}
+
+// periodicAutoTidy is to be called by the periodic function; it starts a
+// tidy operation once the configured interval has passed since the last
+// one finished, whether that was automatic or manual.
+func (b *backend) periodicAutoTidy(ctx context.Context, request *logical.Request) error {
+	// As with the CRL, tidy runs on the active node of each cluster.
+	if b.System().ReplicationState().HasState(consts.ReplicationPerformanceStandby) ||
+		b.System().ReplicationState().HasState(consts.ReplicationDRSecondary) {
+		return nil
+	}
+
+	config, err := getAutoTidyConfig(ctx, request.Storage)
+	if err != nil {
+		return err
+	}
+
+	if !config.Enabled {
+		return nil
+	}
+
+	b.tidyStatusLock.RLock()
+	lastTidy := b.lastTidy
+	b.tidyStatusLock.RUnlock()
+
+	if time.Now().Before(lastTidy.Add(config.Interval)) {
+		return nil
+	}
+
+	// A manual tidy may already be running; we'll try again on the next
+	// invocation.
+	if !atomic.CompareAndSwapUint32(b.tidyCASGuard, 0, 1) {
+		return nil
+	}
+
+	b.startTidyOperation(&logical.Request{Storage: request.Storage}, config, tidySourceAuto)
+
+	return nil
+}
+
+func getAutoTidyConfig(ctx context.Context, s logical.Storage) (*tidyConfig, error) {
+	entry, err := s.Get(ctx, autoTidyConfigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	result := defaultTidyConfig
+	if entry == nil {
+		return &result, nil
+	}
+
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func setAutoTidyConfig(ctx context.Context, s logical.Storage, config *tidyConfig) error {
+	entry, err := logical.StorageEntryJSON(autoTidyConfigPath, config)
+	if err != nil {
+		return err
+	}
+
+	return s.Put(ctx, entry)
+}
+
+func getTidyHistory(ctx context.Context, s logical.Storage) ([]tidyHistoryEntry, error) {
+	entry, err := s.Get(ctx, tidyHistoryPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var history []tidyHistoryEntry
+	if entry == nil {
+		return history, nil
+	}
+
+	if err := entry.DecodeJSON(&history); err != nil {
+		return nil, fmt.Errorf("error decoding tidy history: %w", err)
+	}
+
+	return history, nil
+}
+
+// recordTidyHistory appends the just-finished tidy operation to the
+// history, keeping only the most recent maxTidyHistoryEntries.
+func (b *backend) recordTidyHistory(ctx context.Context, s logical.Storage) error {
+	b.tidyStatusLock.RLock()
+	status := *b.tidyStatus
+	b.tidyStatusLock.RUnlock()
+
+	if status.state != tidyStatusFinished && status.state != tidyStatusError {
+		return errors.New("tidy operation has not finished")
+	}
+
+	record := tidyHistoryEntry{
+		Source:                            status.source,
+		State:                             "Finished",
+		TimeStarted:                       status.timeStarted,
+		TimeFinished:                      status.timeFinished,
+		Duration:                          status.timeFinished.Sub(status.timeStarted).String(),
+		SafetyBuffer:                      status.safetyBuffer,
+		IssuerSafetyBuffer:                status.issuerSafetyBuffer,
+		TidyCertStore:                     status.tidyCertStore,
+		TidyRevokedCerts:                  status.tidyRevokedCerts,
+		TidyRevokedCertIssuerAssociations: status.tidyRevokedAssocs,
+		TidyExpiredIssuers:                status.tidyExpiredIssuers,
+		CertStoreDeletedCount:             status.certStoreDeletedCount,
+		RevokedCertDeletedCount:           status.revokedCertDeletedCount,
+		MissingIssuerCertCount:            status.missingIssuerCertCount,
+		ExpiredIssuerDeletedCount:         status.expiredIssuerDeletedCount,
+	}
+	if status.state == tidyStatusError {
+		record.State = "Error"
+		record.Error = status.err.Error()
+	}
+
+	history, err := getTidyHistory(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	history = append(history, record)
+	if len(history) > maxTidyHistoryEntries {
+		history = history[len(history)-maxTidyHistoryEntries:]
+	}
+
+	entry, err := logical.StorageEntryJSON(tidyHistoryPath, history)
+	if err != nil {
+		return err
+	}
+
+	return s.Put(ctx, entry)
+}
+
+func (b *backend) tidyStatusStart(config *tidyConfig, source string) {
 	b.tidyStatusLock.Lock()
 	defer b.tidyStatusLock.Unlock()
 
 	b.tidyStatus = &tidyStatus{
-		safetyBuffer:     safetyBuffer,
-		tidyCertStore:    tidyCertStore,
-		tidyRevokedCerts: tidyRevokedCerts,
-		state:            tidyStatusStarted,
-		timeStarted:      time.Now(),
+		source:             source,
+		safetyBuffer:       int(config.SafetyBuffer / time.Second),
+		issuerSafetyBuffer: int(config.IssuerSafetyBuffer / time.Second),
+		tidyCertStore:      config.CertStore,
+		tidyRevokedCerts:   config.RevokedCerts,
+		tidyRevokedAssocs:  config.IssuerAssocs,
+		tidyExpiredIssuers: config.ExpiredIssuers,
+		state:              tidyStatusStarted,
+		timeStarted:        time.Now(),
 	}
 
 	metrics.SetGauge([]string{"secrets", "pki", "tidy", "start_time_epoch"}, float32(b.tidyStatus.timeStarted.Unix()))
@@ -323,6 +818,9 @@ func (b *backend) tidyStatusStop(err error) {
 
 	b.tidyStatus.timeFinished = time.Now()
 	b.tidyStatus.err = err
+
+	// The auto-tidy interval is measured from the end of the last tidy.
+	b.lastTidy = b.tidyStatus.timeFinished
 	if err == nil {
 		b.tidyStatus.state = tidyStatusFinished
 	} else {
@@ -362,6 +860,20 @@ func (b *backend) tidyStatusIncRevokedCertCount() {
 	b.tidyStatus.revokedCertDeletedCount++
 }
 
+func (b *backend) tidyStatusIncMissingIssuerCertCount() {
+	b.tidyStatusLock.Lock()
+	defer b.tidyStatusLock.Unlock()
+
+	b.tidyStatus.missingIssuerCertCount++
+}
+
+func (b *backend) tidyStatusIncExpiredIssuerCount() {
+	b.tidyStatusLock.Lock()
+	defer b.tidyStatusLock.Unlock()
+
+	b.tidyStatus.expiredIssuerDeletedCount++
+}
+
 const pathTidyHelpSyn = `
 Tidy up the backend by removing expired certificates, revocation information,
 or both.
@@ -374,12 +886,16 @@ removed from the backend, freeing up storage and shortening CRLs.
 For safety, this function is a noop if called without parameters; cleanup from
 normal certificate storage must be enabled with 'tidy_cert_store' and cleanup
 from revocation information must be enabled with 'tidy_revocation_list'.
+Validation of the issuer associations of revocation entries is enabled with
+'tidy_revoked_cert_issuer_associations', and removal of expired issuers with
+'tidy_expired_issuers'.
 
 The 'safety_buffer' parameter is useful to ensure that clock skew amongst your
 hosts cannot lead to a certificate being removed from the CRL while it is still
 considered valid by other hosts (for instance, if their clocks are a few
 minutes behind). The 'safety_buffer' parameter can be an integer number of
-seconds or a string duration like "72h".
+seconds or a string duration like "72h". The 'issuer_safety_buffer' parameter
+serves the same purpose for expired issuers, defaulting to a year.
 
 All certificates and/or revocation information currently stored in the backend
 will be checked when this endpoint is hit. The expiration of the
@@ -398,15 +914,38 @@ This is a read only endpoint that returns information about the current tidy
 operation, or the most recent if none is currently running.
 
 The result includes the following fields:
+* 'source': "manual" or "auto", whether the operation was started by auto-tidy
 * 'safety_buffer': the value of this parameter when initiating the tidy operation
+* 'issuer_safety_buffer': the value of this parameter when initiating the tidy operation
 * 'tidy_cert_store': the value of this parameter when initiating the tidy operation
 * 'tidy_revoked_certs': the value of this parameter when initiating the tidy operation
+* 'tidy_revoked_cert_issuer_associations': the value of this parameter when initiating the tidy operation
+* 'tidy_expired_issuers': the value of this parameter when initiating the tidy operation
 * 'state': one of "Inactive", "Running", "Finished", "Error"
 * 'error': the error message, if the operation ran into an error
 * 'time_started': the time the operation started
 * 'time_finished': the time the operation finished
-* 'message': One of "Tidying certificate store: checking entry N of TOTAL" or
-  "Tidying revoked certificates: checking certificate N of TOTAL"
+* 'message': One of "Tidying certificate store: checking entry N of TOTAL",
+  "Tidying revoked certificates: checking certificate N of TOTAL", or
+  "Tidying expired issuers: checking issuer N of TOTAL"
 * 'cert_store_deleted_count': The number of certificate storage entries deleted
 * 'revoked_cert_deleted_count': The number of revoked certificate entries deleted
+* 'missing_issuer_cert_count': The number of revoked certificates whose issuer could not be found
+* 'expired_issuer_deleted_count': The number of expired issuers deleted
+* 'history': The most recent finished tidy operations on this cluster, newest
+  first, with the same fields and the 'duration' of each
+`
+
+const pathConfigAutoTidySyn = `
+Modifies the current configuration for automatic tidy execution.
+`
+
+const pathConfigAutoTidyDesc = `
+This endpoint accepts parameters to a tidy operation (see /tidy) that
+will be used for automatic tidy execution. This takes two extra parameters,
+enabled (to enable or disable auto-tidy) and interval_duration (which
+controls the frequency of auto-tidy execution).
+
+Once enabled, a tidy operation will be kicked off automatically, as if it
+were executed with the posted configuration.
 `
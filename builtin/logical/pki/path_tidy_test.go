@@ -0,0 +1,190 @@
+package pki
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoTidy(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	b, s := createBackendWithStorage(t)
+
+	// Auto-tidy can't be enabled without any operations to run.
+	_, err := CBWrite(b, s, "config/auto-tidy", map[string]interface{}{
+		"enabled": true,
+	})
+	require.Error(t, err, "expected error enabling auto-tidy without any operations")
+
+	resp, err := CBRead(b, s, "config/auto-tidy")
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, false, resp.Data["enabled"])
+	require.Equal(t, 43200, resp.Data["interval_duration"])
+	require.Equal(t, 259200, resp.Data["safety_buffer"])
+
+	_, err = CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "root example.com",
+		"key_type":    "ec",
+		"ttl":         "40h",
+	})
+	require.NoError(t, err)
+	_, err = CBWrite(b, s, "roles/test", map[string]interface{}{
+		"allowed_domains":  "example.com",
+		"allow_subdomains": true,
+		"key_type":         "ec",
+		"ttl":              "1s",
+		"max_ttl":          "1s",
+	})
+	require.NoError(t, err)
+
+	resp, err = CBWrite(b, s, "issue/test", map[string]interface{}{
+		"common_name": "leaf.example.com",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	leafSerial := resp.Data["serial_number"].(string)
+
+	resp, err = CBWrite(b, s, "config/auto-tidy", map[string]interface{}{
+		"enabled":           true,
+		"interval_duration": "1s",
+		"tidy_cert_store":   true,
+		"safety_buffer":     "1s",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, true, resp.Data["enabled"])
+	require.Equal(t, 1, resp.Data["interval_duration"])
+
+	// Wait for the leaf to expire past the safety buffer, which is also
+	// longer than the interval since the mount was created.
+	time.Sleep(3 * time.Second)
+
+	err = b.periodicFunc(ctx, &logical.Request{Storage: s})
+	require.NoError(t, err)
+
+	resp = waitForTidyToFinish(t, b, s)
+	require.Equal(t, "Finished", resp.Data["state"])
+	require.Equal(t, tidySourceAuto, resp.Data["source"])
+	require.Equal(t, true, resp.Data["tidy_cert_store"])
+	require.Equal(t, false, resp.Data["tidy_revoked_certs"])
+	require.Equal(t, uint(1), resp.Data["cert_store_deleted_count"])
+
+	history := resp.Data["history"].([]map[string]interface{})
+	require.Len(t, history, 1)
+	require.Equal(t, tidySourceAuto, history[0]["source"])
+	require.Equal(t, "Finished", history[0]["state"])
+	require.Equal(t, uint(1), history[0]["cert_store_deleted_count"])
+	require.NotEmpty(t, history[0]["duration"])
+
+	resp, err = CBRead(b, s, "cert/"+leafSerial)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	// A manual tidy resets the interval, so the next periodic invocation
+	// won't run another tidy.
+	_, err = CBWrite(b, s, "config/auto-tidy", map[string]interface{}{
+		"interval_duration": "1h",
+	})
+	require.NoError(t, err)
+	_, err = CBWrite(b, s, "tidy", map[string]interface{}{
+		"tidy_cert_store": true,
+	})
+	require.NoError(t, err)
+	resp = waitForTidyToFinish(t, b, s)
+	require.Equal(t, tidySourceManual, resp.Data["source"])
+
+	err = b.periodicFunc(ctx, &logical.Request{Storage: s})
+	require.NoError(t, err)
+
+	resp = waitForTidyToFinish(t, b, s)
+	require.Equal(t, tidySourceManual, resp.Data["source"])
+	history = resp.Data["history"].([]map[string]interface{})
+	require.Len(t, history, 2)
+	require.Equal(t, tidySourceManual, history[0]["source"])
+	require.Equal(t, tidySourceAuto, history[1]["source"])
+}
+
+func TestTidyHistoryBounded(t *testing.T) {
+	t.Parallel()
+
+	b, s := createBackendWithStorage(t)
+
+	for i := 0; i < maxTidyHistoryEntries+2; i++ {
+		_, err := CBWrite(b, s, "tidy", map[string]interface{}{
+			"tidy_cert_store": true,
+		})
+		require.NoError(t, err)
+		waitForTidyToFinish(t, b, s)
+	}
+
+	resp, err := CBRead(b, s, "tidy-status")
+	requireSuccessNonNilResponse(t, resp, err)
+	history := resp.Data["history"].([]map[string]interface{})
+	require.Len(t, history, maxTidyHistoryEntries)
+	require.True(t, resp.Data["time_started"].(time.Time).Equal(history[0]["time_started"].(time.Time)))
+}
+
+func TestTidyExpiredIssuers(t *testing.T) {
+	t.Parallel()
+
+	b, s := createBackendWithStorage(t)
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "root example.com",
+		"issuer_name": "expiring",
+		"key_type":    "ec",
+		"ttl":         "1s",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	expiringID := string(resp.Data["issuer_id"].(issuerID))
+
+	resp, err = CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "root example.com",
+		"issuer_name": "current",
+		"key_type":    "ec",
+		"ttl":         "40h",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	currentID := string(resp.Data["issuer_id"].(issuerID))
+
+	time.Sleep(3 * time.Second)
+
+	_, err = CBWrite(b, s, "tidy", map[string]interface{}{
+		"tidy_expired_issuers": true,
+		"issuer_safety_buffer": "1s",
+	})
+	require.NoError(t, err)
+
+	resp = waitForTidyToFinish(t, b, s)
+	require.Equal(t, "Finished", resp.Data["state"])
+	require.Equal(t, true, resp.Data["tidy_expired_issuers"])
+	require.Equal(t, 1, resp.Data["issuer_safety_buffer"])
+	require.Equal(t, uint(1), resp.Data["expired_issuer_deleted_count"])
+
+	resp, err = CBList(b, s, "issuers")
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, []string{currentID}, resp.Data["keys"])
+
+	_, err = CBRead(b, s, "issuer/"+expiringID)
+	require.Error(t, err)
+}
+
+func waitForTidyToFinish(t *testing.T, b *backend, s logical.Storage) *logical.Response {
+	t.Helper()
+
+	for i := 0; i < 50; i++ {
+		resp, err := CBRead(b, s, "tidy-status")
+		requireSuccessNonNilResponse(t, resp, err)
+		if resp.Data["state"] != "Running" && atomic.LoadUint32(b.tidyCASGuard) == 0 {
+			return resp
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	t.Fatal("timed out waiting for tidy to finish")
+	return nil
+}
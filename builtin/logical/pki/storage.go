@@ -5,9 +5,12 @@ import (
 	"context"
 	"crypto"
 	"crypto/x509"
+	"encoding/hex"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/go-secure-stdlib/strutil"
 	"github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/vault/sdk/helper/certutil"
 	"github.com/hashicorp/vault/sdk/helper/errutil"
@@ -20,6 +23,15 @@ const (
 	keyPrefix             = "config/key/"
 	issuerPrefix          = "config/issuer/"
 	storageLocalCRLConfig = "crls/config"
+	certIndexPrefix       = "cert-index/"
+
+	// Lookup keys under these prefixes have the form <prefix><name>/<serial>
+	// and map a searchable value to the certificates carrying it.
+	certIndexByCNPrefix     = certIndexPrefix + "by-cn/"
+	certIndexBySANPrefix    = certIndexPrefix + "by-san/"
+	certIndexByRolePrefix   = certIndexPrefix + "by-role/"
+	certIndexByIssuerPrefix = certIndexPrefix + "by-issuer/"
+	certIndexBackfilledPath = certIndexPrefix + "backfilled"
 
 	legacyMigrationBundleLogKey = "config/legacyMigrationBundleLog"
 	legacyCertBundlePath        = "config/ca_bundle"
@@ -148,8 +160,12 @@ type issuerEntry struct {
 }
 
 type localCRLConfigEntry struct {
-	IssuerIDCRLMap map[issuerID]crlID `json:"issuer_id_crl_map" structs:"issuer_id_crl_map" mapstructure:"issuer_id_crl_map"`
-	CRLNumberMap   map[crlID]int64    `json:"crl_number_map" structs:"crl_number_map" mapstructure:"crl_number_map"`
+	IssuerIDCRLMap        map[issuerID]crlID  `json:"issuer_id_crl_map" structs:"issuer_id_crl_map" mapstructure:"issuer_id_crl_map"`
+	CRLNumberMap          map[crlID]int64     `json:"crl_number_map" structs:"crl_number_map" mapstructure:"crl_number_map"`
+	LastCompleteNumberMap map[crlID]int64     `json:"last_complete_number_map" structs:"last_complete_number_map" mapstructure:"last_complete_number_map"`
+	CRLExpirationMap      map[crlID]time.Time `json:"crl_expiration_map" structs:"crl_expiration_map" mapstructure:"crl_expiration_map"`
+	LastModified          time.Time           `json:"last_modified" structs:"last_modified" mapstructure:"last_modified"`
+	DeltaLastModified     time.Time           `json:"delta_last_modified" structs:"delta_last_modified" mapstructure:"delta_last_modified"`
 }
 
 type keyConfigEntry struct {
@@ -700,6 +716,14 @@ func getLocalCRLConfig(ctx context.Context, s logical.Storage) (*localCRLConfigE
 		mapping.CRLNumberMap = make(map[crlID]int64)
 	}
 
+	if len(mapping.LastCompleteNumberMap) == 0 {
+		mapping.LastCompleteNumberMap = make(map[crlID]int64)
+	}
+
+	if len(mapping.CRLExpirationMap) == 0 {
+		mapping.CRLExpirationMap = make(map[crlID]time.Time)
+	}
+
 	return mapping, nil
 }
 
@@ -964,3 +988,200 @@ func checkForRolesReferencing(issuerId string, ctx context.Context, storage logi
 
 	return false, inUseBy, nil
 }
+
+// certIndexEntry is the secondary index entry kept alongside each stored
+// certificate, so that searching the certificate store doesn't require
+// fetching and parsing every certificate.
+type certIndexEntry struct {
+	SerialNumber   string    `json:"serial_number"`
+	CommonName     string    `json:"common_name"`
+	DNSNames       []string  `json:"dns_names"`
+	EmailAddresses []string  `json:"email_addresses"`
+	IPAddresses    []string  `json:"ip_addresses"`
+	URIs           []string  `json:"uris"`
+	IssuerID       issuerID  `json:"issuer_id"`
+	Role           string    `json:"role"`
+	NotBefore      time.Time `json:"not_before"`
+	NotAfter       time.Time `json:"not_after"`
+	Revoked        bool      `json:"revoked"`
+	RevocationTime time.Time `json:"revocation_time"`
+}
+
+func newCertIndexEntry(cert *x509.Certificate, issuerId issuerID, role string) *certIndexEntry {
+	entry := &certIndexEntry{
+		SerialNumber:   certutil.GetHexFormatted(cert.SerialNumber.Bytes(), ":"),
+		CommonName:     cert.Subject.CommonName,
+		DNSNames:       cert.DNSNames,
+		EmailAddresses: cert.EmailAddresses,
+		IssuerID:       issuerId,
+		Role:           role,
+		NotBefore:      cert.NotBefore,
+		NotAfter:       cert.NotAfter,
+	}
+
+	for _, ip := range cert.IPAddresses {
+		entry.IPAddresses = append(entry.IPAddresses, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		entry.URIs = append(entry.URIs, uri.String())
+	}
+
+	return entry
+}
+
+func fetchCertIndexEntry(ctx context.Context, s logical.Storage, serial string) (*certIndexEntry, error) {
+	entry, err := s.Get(ctx, certIndexPrefix+normalizeSerial(serial))
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to fetch certificate index entry: %v", err)}
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var index certIndexEntry
+	if err := entry.DecodeJSON(&index); err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to decode certificate index entry: %v", err)}
+	}
+
+	return &index, nil
+}
+
+func writeCertIndexEntry(ctx context.Context, s logical.Storage, index *certIndexEntry) error {
+	entry, err := logical.StorageEntryJSON(certIndexPrefix+normalizeSerial(index.SerialNumber), index)
+	if err != nil {
+		return err
+	}
+
+	return s.Put(ctx, entry)
+}
+
+// certIndexLookupName converts a searchable value into the name used in
+// its lookup keys. Names are case-insensitive and hex encoded, so that any
+// value, such as a URI containing slashes or "..", is a single valid path
+// segment.
+func certIndexLookupName(value string) string {
+	return hex.EncodeToString([]byte(strings.ToLower(value)))
+}
+
+// lookupKeys returns the keys under which this entry is found when
+// searching by common name, SAN, role or issuer.
+func (i *certIndexEntry) lookupKeys() []string {
+	serial := normalizeSerial(i.SerialNumber)
+
+	var keys []string
+	if len(i.CommonName) > 0 {
+		keys = append(keys, certIndexByCNPrefix+certIndexLookupName(i.CommonName)+"/"+serial)
+	}
+	for _, names := range [][]string{i.DNSNames, i.EmailAddresses, i.IPAddresses, i.URIs} {
+		for _, name := range names {
+			keys = append(keys, certIndexBySANPrefix+certIndexLookupName(name)+"/"+serial)
+		}
+	}
+	if len(i.Role) > 0 {
+		keys = append(keys, certIndexByRolePrefix+certIndexLookupName(i.Role)+"/"+serial)
+	}
+	if len(i.IssuerID) > 0 {
+		keys = append(keys, certIndexByIssuerPrefix+certIndexLookupName(i.IssuerID.String())+"/"+serial)
+	}
+
+	return strutil.RemoveDuplicatesStable(keys, false)
+}
+
+// writeCertIndex writes a certificate's lookup keys, then its index entry;
+// as the backfill indexes certificates lacking an entry, a partially
+// written index is repaired by it.
+func writeCertIndex(ctx context.Context, s logical.Storage, index *certIndexEntry) error {
+	for _, key := range index.lookupKeys() {
+		if err := s.Put(ctx, &logical.StorageEntry{Key: key}); err != nil {
+			return fmt.Errorf("error writing certificate index lookups: %w", err)
+		}
+	}
+
+	if err := writeCertIndexEntry(ctx, s, index); err != nil {
+		return fmt.Errorf("error writing certificate index entry: %w", err)
+	}
+
+	return nil
+}
+
+// listCertIndexLookup returns the normalized serials of the certificates
+// found under the given lookup prefix and value.
+func listCertIndexLookup(ctx context.Context, s logical.Storage, prefix string, value string) ([]string, error) {
+	serials, err := s.List(ctx, prefix+certIndexLookupName(value)+"/")
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to list certificate index: %v", err)}
+	}
+
+	return serials, nil
+}
+
+// deleteCertIndexEntry removes a certificate's index entry along with its
+// lookup keys.
+func deleteCertIndexEntry(ctx context.Context, s logical.Storage, serial string) error {
+	index, err := fetchCertIndexEntry(ctx, s, serial)
+	if err != nil {
+		return err
+	}
+	if index != nil {
+		for _, key := range index.lookupKeys() {
+			if err := s.Delete(ctx, key); err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.Delete(ctx, certIndexPrefix+normalizeSerial(serial))
+}
+
+// markCertIndexEntryRevoked records the revocation of a certificate in its
+// index entry. Certificates stored before the index existed have no entry;
+// theirs is built with the revocation state when first searched.
+func markCertIndexEntryRevoked(ctx context.Context, s logical.Storage, serial string, revocationTime time.Time) error {
+	index, err := fetchCertIndexEntry(ctx, s, serial)
+	if err != nil {
+		return err
+	}
+	if index == nil || index.Revoked {
+		return nil
+	}
+
+	index.Revoked = true
+	index.RevocationTime = revocationTime
+	return writeCertIndexEntry(ctx, s, index)
+}
+
+// storeCertificate writes an issued certificate into the certificate store,
+// along with its index entry. The issuer is a reference to the issuer which
+// signed the certificate; it is left empty in the index when it can't be
+// resolved, such as during the migration to the multi-issuer layout. As the
+// certificate is stored by then, failing to index it doesn't fail the
+// request; the backfill is instead scheduled to index it.
+func storeCertificate(ctx context.Context, b *backend, s logical.Storage, parsedBundle *certutil.ParsedCertBundle, issuerRef string, role string) error {
+	serial := certutil.GetHexFormatted(parsedBundle.Certificate.SerialNumber.Bytes(), ":")
+	err := s.Put(ctx, &logical.StorageEntry{
+		Key:   "certs/" + normalizeSerial(serial),
+		Value: parsedBundle.CertificateBytes,
+	})
+	if err != nil {
+		return err
+	}
+
+	var issuerId issuerID
+	if !b.useLegacyBundleCaStorage() && len(issuerRef) > 0 {
+		issuerId, err = resolveIssuerReference(ctx, s, issuerRef)
+		if err != nil {
+			b.Logger().Debug("unable to resolve issuer for certificate index", "serial", serial, "issuer", issuerRef, "error", err)
+			issuerId = ""
+		}
+	}
+
+	index := newCertIndexEntry(parsedBundle.Certificate, issuerId, role)
+	if err := writeCertIndex(ctx, s, index); err != nil {
+		b.Logger().Warn("unable to index certificate; scheduling the certificate index backfill", "serial", serial, "error", err)
+		if err := s.Delete(ctx, certIndexBackfilledPath); err != nil {
+			b.Logger().Error("unable to schedule the certificate index backfill", "error", err)
+		}
+	}
+
+	return nil
+}
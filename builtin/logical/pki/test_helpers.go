@@ -239,3 +239,11 @@ func getParsedCrlAtPath(t *testing.T, client *api.Client, path string) *pkix.Cer
 	}
 	return crl
 }
+
+func requireSuccessNonNilResponse(t *testing.T, resp *logical.Response, err error, msgAndArgs ...interface{}) {
+	require.NoError(t, err, msgAndArgs...)
+	if resp.IsError() {
+		require.Failf(t, "error returned in response", "%v: %v", msgAndArgs, resp.Error())
+	}
+	require.NotNil(t, resp, msgAndArgs...)
+}
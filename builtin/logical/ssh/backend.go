@@ -6,6 +6,7 @@ import (
 	"sync"
 
 	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/sdk/helper/salt"
 	"github.com/hashicorp/vault/sdk/logical"
 )
@@ -15,6 +16,11 @@ type backend struct {
 	view      logical.Storage
 	salt      *salt.Salt
 	saltMutex sync.RWMutex
+
+	// revokeStorageLock serializes revocations and tidies, which update the
+	// certificate records and the KRL version, against each other and the
+	// readers of the revoked certificates.
+	revokeStorageLock sync.RWMutex
 }
 
 func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
@@ -38,15 +44,20 @@ func Backend(conf *logical.BackendConfig) (*backend, error) {
 			Unauthenticated: []string{
 				"verify",
 				"public_key",
+				"krl",
 			},
 
 			LocalStorage: []string{
 				"otp/",
+				certsPrefix,
+				revokedPrefix,
+				storageKRLInfo,
 			},
 
 			SealWrapStorage: []string{
 				caPrivateKey,
 				caPrivateKeyStoragePath,
+				issuerPrefix,
 				"keys/",
 			},
 		},
@@ -60,8 +71,20 @@ func Backend(conf *logical.BackendConfig) (*backend, error) {
 			pathLookup(&b),
 			pathVerify(&b),
 			pathConfigCA(&b),
+			pathConfigIssuers(&b),
+			pathListIssuers(&b),
+			pathGenerateIssuer(&b),
+			pathImportIssuer(&b),
+			pathIssuer(&b),
 			pathSign(&b),
 			pathFetchPublicKey(&b),
+			pathKnownHosts(&b),
+			pathVerifyHostCertificate(&b),
+			pathListCerts(&b),
+			pathFetchCert(&b),
+			pathRevoke(&b),
+			pathFetchKRL(&b),
+			pathTidy(&b),
 		},
 
 		Secrets: []*framework.Secret{
@@ -69,8 +92,9 @@ func Backend(conf *logical.BackendConfig) (*backend, error) {
 			secretOTP(&b),
 		},
 
-		Invalidate:  b.invalidate,
-		BackendType: logical.TypeLogical,
+		InitializeFunc: b.initialize,
+		Invalidate:     b.invalidate,
+		BackendType:    logical.TypeLogical,
 	}
 	return &b, nil
 }
@@ -98,6 +122,21 @@ func (b *backend) Salt(ctx context.Context) (*salt.Salt, error) {
 	return salt, nil
 }
 
+func (b *backend) initialize(ctx context.Context, req *logical.InitializationRequest) error {
+	// Only migrate the CA keys where storage can be written to
+	if b.System().ReplicationState().HasState(consts.ReplicationDRSecondary|consts.ReplicationPerformanceStandby) ||
+		(!b.System().LocalMount() && b.System().ReplicationState().HasState(consts.ReplicationPerformanceSecondary)) {
+		return nil
+	}
+
+	if err := migrateLegacyCA(ctx, req.Storage); err != nil {
+		b.Logger().Error("failed to migrate the CA keys to issuers", "error", err)
+		return err
+	}
+
+	return nil
+}
+
 func (b *backend) invalidate(_ context.Context, key string) {
 	switch key {
 	case salt.DefaultLocation:
@@ -0,0 +1,378 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	storageIssuerConfig = "config/issuers"
+	issuerPrefix        = "config/issuer/"
+
+	issuerRefParam = "issuer_ref"
+	defaultRef     = "default"
+
+	// legacyIssuerID identifies the CA configured through config/ca before
+	// the mount's storage has been migrated to issuers.
+	legacyIssuerID = "legacy"
+)
+
+var issuerNameRegex = regexp.MustCompile("^" + framework.GenericNameRegex("name") + "$")
+
+// sshIssuer is a CA key pair used to sign SSH certificates.
+type sshIssuer struct {
+	ID         string `json:"id" structs:"id" mapstructure:"id"`
+	Name       string `json:"name" structs:"name" mapstructure:"name"`
+	PublicKey  string `json:"public_key" structs:"public_key" mapstructure:"public_key"`
+	PrivateKey string `json:"private_key" structs:"private_key" mapstructure:"private_key"`
+}
+
+type issuerConfigEntry struct {
+	DefaultIssuerID string `json:"default" structs:"default" mapstructure:"default"`
+}
+
+func (i *sshIssuer) signer() (ssh.Signer, error) {
+	signer, err := ssh.ParsePrivateKey([]byte(i.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse stored CA private key: %w", err)
+	}
+	return signer, nil
+}
+
+func listIssuers(ctx context.Context, s logical.Storage) ([]string, error) {
+	return s.List(ctx, issuerPrefix)
+}
+
+// fetchIssuerByID returns the issuer with the given identifier, or nil if it
+// does not exist.
+func fetchIssuerByID(ctx context.Context, s logical.Storage, id string) (*sshIssuer, error) {
+	entry, err := s.Get(ctx, issuerPrefix+id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read issuer %s: %w", id, err)
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var issuer sshIssuer
+	if err := entry.DecodeJSON(&issuer); err != nil {
+		return nil, fmt.Errorf("failed to decode issuer %s: %w", id, err)
+	}
+	return &issuer, nil
+}
+
+func writeIssuer(ctx context.Context, s logical.Storage, issuer *sshIssuer) error {
+	entry, err := logical.StorageEntryJSON(issuerPrefix+issuer.ID, issuer)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, entry)
+}
+
+// deleteIssuer removes the issuer, clearing the default issuer if it was the
+// default.
+func deleteIssuer(ctx context.Context, s logical.Storage, id string) (wasDefault bool, err error) {
+	config, err := getIssuersConfig(ctx, s)
+	if err != nil {
+		return false, err
+	}
+
+	if config.DefaultIssuerID == id {
+		wasDefault = true
+		config.DefaultIssuerID = ""
+		if err := setIssuersConfig(ctx, s, config); err != nil {
+			return wasDefault, err
+		}
+	}
+
+	return wasDefault, s.Delete(ctx, issuerPrefix+id)
+}
+
+func getIssuersConfig(ctx context.Context, s logical.Storage) (*issuerConfigEntry, error) {
+	entry, err := s.Get(ctx, storageIssuerConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &issuerConfigEntry{}
+	if entry != nil {
+		if err := entry.DecodeJSON(config); err != nil {
+			return nil, fmt.Errorf("failed to decode issuer configuration: %w", err)
+		}
+	}
+	return config, nil
+}
+
+func setIssuersConfig(ctx context.Context, s logical.Storage, config *issuerConfigEntry) error {
+	entry, err := logical.StorageEntryJSON(storageIssuerConfig, config)
+	if err != nil {
+		return err
+	}
+	if err := s.Put(ctx, entry); err != nil {
+		return err
+	}
+	return mirrorLegacyCA(ctx, s, config.DefaultIssuerID)
+}
+
+// mirrorLegacyCA writes the keys of the default issuer to the storage entries
+// of the CA configured through config/ca before issuers were introduced, or
+// removes them if there is no default issuer, so that older versions still
+// sign with the default issuer after a downgrade.
+func mirrorLegacyCA(ctx context.Context, s logical.Storage, defaultID string) error {
+	var issuer *sshIssuer
+	if defaultID != "" {
+		var err error
+		issuer, err = fetchIssuerByID(ctx, s, defaultID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if issuer == nil {
+		if err := s.Delete(ctx, caPrivateKeyStoragePath); err != nil {
+			return err
+		}
+		return s.Delete(ctx, caPublicKeyStoragePath)
+	}
+
+	for path, key := range map[string]string{
+		caPrivateKeyStoragePath: issuer.PrivateKey,
+		caPublicKeyStoragePath:  issuer.PublicKey,
+	} {
+		entry, err := logical.StorageEntryJSON(path, keyStorageEntry{Key: key})
+		if err != nil {
+			return err
+		}
+		if err := s.Put(ctx, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// issuersMigrated returns whether the mount stores its CA keys as issuers. The
+// issuer configuration is written when the first issuer is created or the
+// legacy CA is migrated.
+func issuersMigrated(ctx context.Context, s logical.Storage) (bool, error) {
+	entry, err := s.Get(ctx, storageIssuerConfig)
+	if err != nil {
+		return false, err
+	}
+	return entry != nil, nil
+}
+
+// legacyIssuer returns the CA configured through config/ca before issuers
+// were introduced, or nil if there is none.
+func legacyIssuer(ctx context.Context, s logical.Storage) (*sshIssuer, error) {
+	publicKeyEntry, err := caKey(ctx, s, caPublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA public key: %w", err)
+	}
+	privateKeyEntry, err := caKey(ctx, s, caPrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA private key: %w", err)
+	}
+	if publicKeyEntry == nil || publicKeyEntry.Key == "" || privateKeyEntry == nil || privateKeyEntry.Key == "" {
+		return nil, nil
+	}
+
+	return &sshIssuer{
+		ID:         legacyIssuerID,
+		PublicKey:  publicKeyEntry.Key,
+		PrivateKey: privateKeyEntry.Key,
+	}, nil
+}
+
+// migrateLegacyCA copies the CA configured through config/ca, if any, to an
+// issuer which becomes the default issuer. The legacy entries are kept, as a
+// mirror of the default issuer. It does nothing once the mount has been
+// migrated.
+func migrateLegacyCA(ctx context.Context, s logical.Storage) error {
+	migrated, err := issuersMigrated(ctx, s)
+	if err != nil || migrated {
+		return err
+	}
+
+	legacy, err := legacyIssuer(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	config := &issuerConfigEntry{}
+	if legacy != nil {
+		id, err := uuid.GenerateUUID()
+		if err != nil {
+			return err
+		}
+		legacy.ID = id
+		if err := writeIssuer(ctx, s, legacy); err != nil {
+			return err
+		}
+		config.DefaultIssuerID = id
+	}
+
+	return setIssuersConfig(ctx, s, config)
+}
+
+// resolveIssuerReference returns the identifier of the issuer referred to by
+// "default", its identifier or its name. The identifier is empty if no issuer
+// matches.
+func resolveIssuerReference(ctx context.Context, s logical.Storage, reference string) (string, error) {
+	if reference == "" || reference == defaultRef {
+		config, err := getIssuersConfig(ctx, s)
+		if err != nil {
+			return "", err
+		}
+		return config.DefaultIssuerID, nil
+	}
+
+	issuer, err := fetchIssuerByID(ctx, s, reference)
+	if err != nil {
+		return "", err
+	}
+	if issuer != nil {
+		return issuer.ID, nil
+	}
+
+	ids, err := listIssuers(ctx, s)
+	if err != nil {
+		return "", err
+	}
+	for _, id := range ids {
+		issuer, err := fetchIssuerByID(ctx, s, id)
+		if err != nil {
+			return "", err
+		}
+		if issuer != nil && issuer.Name == reference {
+			return issuer.ID, nil
+		}
+	}
+
+	return "", nil
+}
+
+// fetchIssuer returns the issuer referred to by the reference, or nil if it
+// does not exist. Until the mount has been migrated, the default issuer is
+// the CA configured through config/ca.
+func fetchIssuer(ctx context.Context, s logical.Storage, reference string) (*sshIssuer, error) {
+	migrated, err := issuersMigrated(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	if !migrated {
+		if reference == "" || reference == defaultRef || reference == legacyIssuerID {
+			return legacyIssuer(ctx, s)
+		}
+		return nil, nil
+	}
+
+	id, err := resolveIssuerReference(ctx, s, reference)
+	if err != nil || id == "" {
+		return nil, err
+	}
+	return fetchIssuerByID(ctx, s, id)
+}
+
+// fetchAllIssuers returns every issuer of the mount, sorted by identifier.
+func fetchAllIssuers(ctx context.Context, s logical.Storage) ([]*sshIssuer, error) {
+	migrated, err := issuersMigrated(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	if !migrated {
+		legacy, err := legacyIssuer(ctx, s)
+		if err != nil || legacy == nil {
+			return nil, err
+		}
+		return []*sshIssuer{legacy}, nil
+	}
+
+	ids, err := listIssuers(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(ids)
+
+	issuers := make([]*sshIssuer, 0, len(ids))
+	for _, id := range ids {
+		issuer, err := fetchIssuerByID(ctx, s, id)
+		if err != nil {
+			return nil, err
+		}
+		if issuer != nil {
+			issuers = append(issuers, issuer)
+		}
+	}
+	return issuers, nil
+}
+
+// validateIssuerName checks that name can be given to the issuer with the
+// given identifier.
+func validateIssuerName(ctx context.Context, s logical.Storage, name, id string) error {
+	if name == "" {
+		return nil
+	}
+	if name == defaultRef {
+		return errutil.UserError{Err: fmt.Sprintf("issuer name %q is reserved", defaultRef)}
+	}
+	if !issuerNameRegex.MatchString(name) {
+		return errutil.UserError{Err: fmt.Sprintf("invalid issuer name %q", name)}
+	}
+
+	existing, err := resolveIssuerReference(ctx, s, name)
+	if err != nil {
+		return err
+	}
+	if existing != "" && existing != id {
+		return errutil.UserError{Err: fmt.Sprintf("issuer name %q is already in use", name)}
+	}
+	return nil
+}
+
+// createIssuer stores a new issuer for the key pair. It becomes the default
+// issuer if there is none.
+func createIssuer(ctx context.Context, s logical.Storage, name, publicKey, privateKey string) (*sshIssuer, bool, error) {
+	if err := migrateLegacyCA(ctx, s); err != nil {
+		return nil, false, err
+	}
+	if err := validateIssuerName(ctx, s, name, ""); err != nil {
+		return nil, false, err
+	}
+
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, false, err
+	}
+
+	issuer := &sshIssuer{
+		ID:         id,
+		Name:       name,
+		PublicKey:  publicKey,
+		PrivateKey: privateKey,
+	}
+	if err := writeIssuer(ctx, s, issuer); err != nil {
+		return nil, false, err
+	}
+
+	config, err := getIssuersConfig(ctx, s)
+	if err != nil {
+		return nil, false, err
+	}
+	isDefault := config.DefaultIssuerID == ""
+	if isDefault {
+		config.DefaultIssuerID = id
+		if err := setIssuersConfig(ctx, s, config); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return issuer, isDefault, nil
+}
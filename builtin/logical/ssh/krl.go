@@ -0,0 +1,294 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	certsPrefix    = "certs/"
+	revokedPrefix  = "revoked/"
+	storageKRLInfo = "config/krl"
+)
+
+// OpenSSH Key Revocation List format, as described in PROTOCOL.krl of the
+// OpenSSH sources.
+const (
+	krlMagic                 = 0x5353484b524c0a00
+	krlFormatVersion         = 1
+	krlSectionCertificates   = 1
+	krlSectionCertSerialList = 0x20
+)
+
+// sshCertEntry records a certificate signed by the backend.
+type sshCertEntry struct {
+	SerialNumber    string   `json:"serial_number" structs:"serial_number" mapstructure:"serial_number"`
+	KeyID           string   `json:"key_id" structs:"key_id" mapstructure:"key_id"`
+	IssuerID        string   `json:"issuer_id" structs:"issuer_id" mapstructure:"issuer_id"`
+	CertType        string   `json:"cert_type" structs:"cert_type" mapstructure:"cert_type"`
+	ValidPrincipals []string `json:"valid_principals" structs:"valid_principals" mapstructure:"valid_principals"`
+	ValidBefore     uint64   `json:"valid_before" structs:"valid_before" mapstructure:"valid_before"`
+	RevocationTime  int64    `json:"revocation_time,omitempty" structs:"revocation_time" mapstructure:"revocation_time"`
+}
+
+type krlInfo struct {
+	Version uint64 `json:"version" structs:"version" mapstructure:"version"`
+}
+
+// normalizeSerial returns the canonical form of a hexadecimal certificate
+// serial number, as returned when signing, accepting colon separators.
+func normalizeSerial(serial string) (string, error) {
+	parsed, err := strconv.ParseUint(strings.ReplaceAll(strings.TrimSpace(serial), ":", ""), 16, 64)
+	if err != nil {
+		return "", errutil.UserError{Err: fmt.Sprintf("invalid serial number %q", serial)}
+	}
+	return strconv.FormatUint(parsed, 16), nil
+}
+
+func (e *sshCertEntry) expired(now time.Time) bool {
+	return e.ValidBefore != ssh.CertTimeInfinity && e.ValidBefore < uint64(now.Unix())
+}
+
+// storeCertificate records a signed certificate so that it can later be
+// revoked.
+func storeCertificate(ctx context.Context, s logical.Storage, issuerID string, cert *ssh.Certificate) error {
+	certType := "user"
+	if cert.CertType == ssh.HostCert {
+		certType = "host"
+	}
+
+	entry := &sshCertEntry{
+		SerialNumber:    strconv.FormatUint(cert.Serial, 16),
+		KeyID:           cert.KeyId,
+		IssuerID:        issuerID,
+		CertType:        certType,
+		ValidPrincipals: cert.ValidPrincipals,
+		ValidBefore:     cert.ValidBefore,
+	}
+	return writeCertEntry(ctx, s, certsPrefix, entry)
+}
+
+func fetchCertEntry(ctx context.Context, s logical.Storage, serial string) (*sshCertEntry, error) {
+	return fetchCertEntryFrom(ctx, s, certsPrefix, serial)
+}
+
+// fetchRevokedCertEntry returns the record of the certificate if it has been
+// revoked and not tidied since.
+func fetchRevokedCertEntry(ctx context.Context, s logical.Storage, serial string) (*sshCertEntry, error) {
+	return fetchCertEntryFrom(ctx, s, revokedPrefix, serial)
+}
+
+func fetchCertEntryFrom(ctx context.Context, s logical.Storage, prefix string, serial string) (*sshCertEntry, error) {
+	entry, err := s.Get(ctx, prefix+serial)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate %s: %w", serial, err)
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var cert sshCertEntry
+	if err := entry.DecodeJSON(&cert); err != nil {
+		return nil, fmt.Errorf("failed to decode certificate %s: %w", serial, err)
+	}
+	return &cert, nil
+}
+
+func writeCertEntry(ctx context.Context, s logical.Storage, prefix string, cert *sshCertEntry) error {
+	entry, err := logical.StorageEntryJSON(prefix+cert.SerialNumber, cert)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, entry)
+}
+
+// revokeCertificate marks the certificate as revoked and bumps the version of
+// the KRL. Revoking a certificate twice keeps its original revocation time.
+func revokeCertificate(ctx context.Context, s logical.Storage, cert *sshCertEntry, now time.Time) error {
+	if cert.RevocationTime != 0 {
+		return nil
+	}
+
+	cert.RevocationTime = now.Unix()
+	if err := writeCertEntry(ctx, s, certsPrefix, cert); err != nil {
+		return err
+	}
+	if err := writeCertEntry(ctx, s, revokedPrefix, cert); err != nil {
+		return err
+	}
+
+	info, err := getKRLInfo(ctx, s)
+	if err != nil {
+		return err
+	}
+	info.Version++
+	entry, err := logical.StorageEntryJSON(storageKRLInfo, info)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, entry)
+}
+
+// tidyCertificates deletes the records of the certificates which expired
+// before the cutoff, revoked or not, returning the number of certificates and
+// of revoked certificates deleted. Expired certificates are already left out of
+// the KRL, so its version is kept.
+func tidyCertificates(ctx context.Context, s logical.Storage, cutoff time.Time) (int, int, error) {
+	var deleted [2]int
+	for i, prefix := range []string{certsPrefix, revokedPrefix} {
+		serials, err := s.List(ctx, prefix)
+		if err != nil {
+			return 0, 0, err
+		}
+		for _, serial := range serials {
+			entry, err := s.Get(ctx, prefix+serial)
+			if err != nil {
+				return 0, 0, err
+			}
+			if entry == nil {
+				continue
+			}
+			var cert sshCertEntry
+			if err := entry.DecodeJSON(&cert); err != nil {
+				return 0, 0, fmt.Errorf("failed to decode certificate %s: %w", serial, err)
+			}
+			if !cert.expired(cutoff) {
+				continue
+			}
+			if err := s.Delete(ctx, prefix+serial); err != nil {
+				return 0, 0, fmt.Errorf("failed to delete certificate %s: %w", serial, err)
+			}
+			deleted[i]++
+		}
+	}
+	return deleted[0], deleted[1], nil
+}
+
+func getKRLInfo(ctx context.Context, s logical.Storage) (*krlInfo, error) {
+	entry, err := s.Get(ctx, storageKRLInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &krlInfo{}
+	if entry != nil {
+		if err := entry.DecodeJSON(info); err != nil {
+			return nil, fmt.Errorf("failed to decode KRL information: %w", err)
+		}
+	}
+	return info, nil
+}
+
+// buildKRL renders the KRL of the revoked certificates which have not expired
+// yet, with a certificates section for each issuer that signed one of them.
+// Certificates of deleted issuers are left out as their CA key is unknown.
+func buildKRL(ctx context.Context, s logical.Storage, now time.Time) ([]byte, error) {
+	info, err := getKRLInfo(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	serials, err := s.List(ctx, revokedPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked := make(map[string][]uint64)
+	for _, serial := range serials {
+		entry, err := s.Get(ctx, revokedPrefix+serial)
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			continue
+		}
+		var cert sshCertEntry
+		if err := entry.DecodeJSON(&cert); err != nil {
+			return nil, fmt.Errorf("failed to decode revoked certificate %s: %w", serial, err)
+		}
+		if cert.expired(now) {
+			continue
+		}
+
+		parsed, err := strconv.ParseUint(cert.SerialNumber, 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid serial number of revoked certificate %q: %w", cert.SerialNumber, err)
+		}
+		revoked[cert.IssuerID] = append(revoked[cert.IssuerID], parsed)
+	}
+
+	issuerIDs := make([]string, 0, len(revoked))
+	for id := range revoked {
+		issuerIDs = append(issuerIDs, id)
+	}
+	sort.Strings(issuerIDs)
+
+	var krl bytes.Buffer
+	krl.Write(krlHeader(info.Version, now))
+	for _, id := range issuerIDs {
+		issuer, err := fetchIssuer(ctx, s, id)
+		if err != nil {
+			return nil, err
+		}
+		if issuer == nil {
+			continue
+		}
+		caKey, err := parsePublicSSHKey(issuer.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key of issuer %s: %w", id, err)
+		}
+
+		krl.WriteByte(krlSectionCertificates)
+		writeKRLString(&krl, krlCertificatesSection(caKey, revoked[id]))
+	}
+
+	return krl.Bytes(), nil
+}
+
+func krlHeader(version uint64, generated time.Time) []byte {
+	var header bytes.Buffer
+	binary.Write(&header, binary.BigEndian, uint64(krlMagic))
+	binary.Write(&header, binary.BigEndian, uint32(krlFormatVersion))
+	binary.Write(&header, binary.BigEndian, version)
+	binary.Write(&header, binary.BigEndian, uint64(generated.Unix()))
+	// Flags
+	binary.Write(&header, binary.BigEndian, uint64(0))
+	// Reserved
+	writeKRLString(&header, nil)
+	// Comment
+	writeKRLString(&header, nil)
+	return header.Bytes()
+}
+
+func krlCertificatesSection(caKey ssh.PublicKey, serials []uint64) []byte {
+	sort.Slice(serials, func(i, j int) bool { return serials[i] < serials[j] })
+
+	var serialList bytes.Buffer
+	for _, serial := range serials {
+		binary.Write(&serialList, binary.BigEndian, serial)
+	}
+
+	var section bytes.Buffer
+	writeKRLString(&section, caKey.Marshal())
+	// Reserved
+	writeKRLString(&section, nil)
+	section.WriteByte(krlSectionCertSerialList)
+	writeKRLString(&section, serialList.Bytes())
+	return section.Bytes()
+}
+
+// writeKRLString writes s in the SSH wire format of strings.
+func writeKRLString(buf *bytes.Buffer, s []byte) {
+	binary.Write(buf, binary.BigEndian, uint32(len(s)))
+	buf.Write(s)
+}
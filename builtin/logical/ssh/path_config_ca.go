@@ -14,7 +14,6 @@ import (
 	"fmt"
 	"io"
 
-	multierror "github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
 	"golang.org/x/crypto/ssh"
@@ -73,6 +72,8 @@ func pathConfigCA(b *backend) *framework.Path {
 		HelpSynopsis: `Set the SSH private key used for signing certificates.`,
 		HelpDescription: `This sets the CA information used for certificates generated by this
 by this mount. The fields must be in the standard private and public SSH format.
+The keys are stored as the default issuer; further issuers can be managed
+through the issuers/ endpoints.
 
 For security reasons, the private key cannot be retrieved later.
 
@@ -81,29 +82,39 @@ Read operations will return the public key, if already stored/generated.`,
 }
 
 func (b *backend) pathConfigCARead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	publicKeyEntry, err := caKey(ctx, req.Storage, caPublicKey)
+	issuer, err := fetchIssuer(ctx, req.Storage, defaultRef)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read CA public key: %w", err)
 	}
 
-	if publicKeyEntry == nil {
+	if issuer == nil {
 		return logical.ErrorResponse("keys haven't been configured yet"), nil
 	}
 
 	response := &logical.Response{
 		Data: map[string]interface{}{
-			"public_key": publicKeyEntry.Key,
+			"public_key": issuer.PublicKey,
 		},
 	}
 
 	return response, nil
 }
 
+// pathConfigCADelete deletes the default issuer.
 func (b *backend) pathConfigCADelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	if err := req.Storage.Delete(ctx, caPrivateKeyStoragePath); err != nil {
+	if err := migrateLegacyCA(ctx, req.Storage); err != nil {
 		return nil, err
 	}
-	if err := req.Storage.Delete(ctx, caPublicKeyStoragePath); err != nil {
+
+	id, err := resolveIssuerReference(ctx, req.Storage, defaultRef)
+	if err != nil {
+		return nil, err
+	}
+	if id == "" {
+		return nil, nil
+	}
+
+	if _, err := deleteIssuer(ctx, req.Storage, id); err != nil {
 		return nil, err
 	}
 	return nil, nil
@@ -188,14 +199,8 @@ func (b *backend) pathConfigCAUpdate(ctx context.Context, req *logical.Request,
 			return logical.ErrorResponse("missing private_key"), nil
 		}
 
-		_, err := ssh.ParsePrivateKey([]byte(privateKey))
-		if err != nil {
-			return logical.ErrorResponse(fmt.Sprintf("Unable to parse private_key as an SSH private key: %v", err)), nil
-		}
-
-		_, err = parsePublicSSHKey(publicKey)
-		if err != nil {
-			return logical.ErrorResponse(fmt.Sprintf("Unable to parse public_key as an SSH public key: %v", err)), nil
+		if err := validateCAKeyPair(publicKey, privateKey); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
 		}
 
 	// not set and no public/private key provided so generate
@@ -221,55 +226,17 @@ func (b *backend) pathConfigCAUpdate(ctx context.Context, req *logical.Request,
 		return nil, fmt.Errorf("failed to generate or parse the keys")
 	}
 
-	publicKeyEntry, err := caKey(ctx, req.Storage, caPublicKey)
+	existing, err := fetchIssuer(ctx, req.Storage, defaultRef)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read CA public key: %w", err)
+		return nil, fmt.Errorf("failed to read CA keys: %w", err)
 	}
 
-	privateKeyEntry, err := caKey(ctx, req.Storage, caPrivateKey)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read CA private key: %w", err)
-	}
-
-	if (publicKeyEntry != nil && publicKeyEntry.Key != "") || (privateKeyEntry != nil && privateKeyEntry.Key != "") {
+	if existing != nil {
 		return logical.ErrorResponse("keys are already configured; delete them before reconfiguring"), nil
 	}
 
-	entry, err := logical.StorageEntryJSON(caPublicKeyStoragePath, &keyStorageEntry{
-		Key: publicKey,
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	// Save the public key
-	err = req.Storage.Put(ctx, entry)
-	if err != nil {
-		return nil, err
-	}
-
-	entry, err = logical.StorageEntryJSON(caPrivateKeyStoragePath, &keyStorageEntry{
-		Key: privateKey,
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	// Save the private key
-	err = req.Storage.Put(ctx, entry)
-	if err != nil {
-		var mErr *multierror.Error
-
-		mErr = multierror.Append(mErr, fmt.Errorf("failed to store CA private key: %w", err))
-
-		// If storing private key fails, the corresponding public key should be
-		// removed
-		if delErr := req.Storage.Delete(ctx, caPublicKeyStoragePath); delErr != nil {
-			mErr = multierror.Append(mErr, fmt.Errorf("failed to cleanup CA public key: %w", delErr))
-			return nil, mErr
-		}
-
-		return nil, err
+	if _, _, err := createIssuer(ctx, req.Storage, "", publicKey, privateKey); err != nil {
+		return nil, fmt.Errorf("failed to store CA keys: %w", err)
 	}
 
 	if generateSigningKey {
@@ -285,6 +252,21 @@ func (b *backend) pathConfigCAUpdate(ctx context.Context, req *logical.Request,
 	return nil, nil
 }
 
+// validateCAKeyPair checks that the keys can be parsed as an SSH key pair.
+func validateCAKeyPair(publicKey, privateKey string) error {
+	_, err := ssh.ParsePrivateKey([]byte(privateKey))
+	if err != nil {
+		return fmt.Errorf("Unable to parse private_key as an SSH private key: %v", err)
+	}
+
+	_, err = parsePublicSSHKey(publicKey)
+	if err != nil {
+		return fmt.Errorf("Unable to parse public_key as an SSH public key: %v", err)
+	}
+
+	return nil
+}
+
 func generateSSHKeyPair(randomSource io.Reader, keyType string, keyBits int) (string, string, error) {
 	if randomSource == nil {
 		randomSource = rand.Reader
@@ -2,6 +2,7 @@ package ssh
 
 import (
 	"context"
+	"strings"
 
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -11,6 +12,15 @@ func pathFetchPublicKey(b *backend) *framework.Path {
 	return &framework.Path{
 		Pattern: `public_key`,
 
+		Fields: map[string]*framework.FieldSchema{
+			issuerRefParam: issuerRefField(),
+			"all": {
+				Type: framework.TypeBool,
+				Description: `Return the public keys of all issuers, one per line, so that hosts
+can trust both the old and the new CA keys while rotating issuers.`,
+			},
+		},
+
 		Callbacks: map[logical.Operation]framework.OperationFunc{
 			logical.ReadOperation: b.pathFetchPublicKey,
 		},
@@ -21,18 +31,32 @@ func pathFetchPublicKey(b *backend) *framework.Path {
 }
 
 func (b *backend) pathFetchPublicKey(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	publicKeyEntry, err := caKey(ctx, req.Storage, caPublicKey)
-	if err != nil {
-		return nil, err
+	var publicKeys []string
+	if data.Get("all").(bool) {
+		issuers, err := fetchAllIssuers(ctx, req.Storage)
+		if err != nil {
+			return nil, err
+		}
+		for _, issuer := range issuers {
+			publicKeys = append(publicKeys, strings.TrimSpace(issuer.PublicKey)+"\n")
+		}
+	} else {
+		issuer, err := fetchIssuer(ctx, req.Storage, data.Get(issuerRefParam).(string))
+		if err != nil {
+			return nil, err
+		}
+		if issuer != nil && issuer.PublicKey != "" {
+			publicKeys = append(publicKeys, issuer.PublicKey)
+		}
 	}
-	if publicKeyEntry == nil || publicKeyEntry.Key == "" {
+	if len(publicKeys) == 0 {
 		return nil, nil
 	}
 
 	response := &logical.Response{
 		Data: map[string]interface{}{
 			logical.HTTPContentType: "text/plain",
-			logical.HTTPRawBody:     []byte(publicKeyEntry.Key),
+			logical.HTTPRawBody:     []byte(strings.Join(publicKeys, "")),
 			logical.HTTPStatusCode:  200,
 		},
 	}
@@ -0,0 +1,381 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"golang.org/x/crypto/ssh"
+)
+
+func issuerNameField() *framework.FieldSchema {
+	return &framework.FieldSchema{
+		Type: framework.TypeString,
+		Description: `Name of the issuer. The name must be unique across all issuers and
+not be the reserved value "default".`,
+	}
+}
+
+func issuerRefField() *framework.FieldSchema {
+	return &framework.FieldSchema{
+		Type: framework.TypeString,
+		Description: `Reference to an existing issuer; either "default" for the configured
+default issuer, an identifier or the name assigned to the issuer.`,
+		Default: defaultRef,
+	}
+}
+
+func pathListIssuers(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "issuers/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathListIssuers,
+		},
+
+		HelpSynopsis:    pathListIssuersHelpSyn,
+		HelpDescription: pathListIssuersHelpDesc,
+	}
+}
+
+func pathGenerateIssuer(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "issuers/generate",
+		Fields: map[string]*framework.FieldSchema{
+			"issuer_name": issuerNameField(),
+			"key_type": {
+				Type:        framework.TypeString,
+				Description: `Specifies the desired key type; could be a OpenSSH key type identifier (ssh-rsa, ecdsa-sha2-nistp256, ecdsa-sha2-nistp384, ecdsa-sha2-nistp521, or ssh-ed25519) or an algorithm (rsa, ec, ed25519).`,
+				Default:     "ssh-rsa",
+			},
+			"key_bits": {
+				Type:        framework.TypeInt,
+				Description: `Specifies the desired key bits for variable-length keys (such as when key_type="ssh-rsa") or which NIST P-curve to use when key_type="ec" (256, 384, or 521).`,
+				Default:     0,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathGenerateIssuer,
+		},
+
+		HelpSynopsis:    pathGenerateIssuerHelpSyn,
+		HelpDescription: pathGenerateIssuerHelpDesc,
+	}
+}
+
+func pathImportIssuer(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "issuers/import",
+		Fields: map[string]*framework.FieldSchema{
+			"issuer_name": issuerNameField(),
+			"private_key": {
+				Type:        framework.TypeString,
+				Description: `Private half of the SSH key that will be used to sign certificates.`,
+			},
+			"public_key": {
+				Type:        framework.TypeString,
+				Description: `Public half of the SSH key. Derived from the private key if not set.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathImportIssuer,
+		},
+
+		HelpSynopsis:    pathImportIssuerHelpSyn,
+		HelpDescription: pathImportIssuerHelpDesc,
+	}
+}
+
+func pathIssuer(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "issuer/" + framework.GenericNameRegex(issuerRefParam),
+		Fields: map[string]*framework.FieldSchema{
+			issuerRefParam: issuerRefField(),
+			"issuer_name":  issuerNameField(),
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathIssuerRead,
+			logical.UpdateOperation: b.pathIssuerUpdate,
+			logical.DeleteOperation: b.pathIssuerDelete,
+		},
+
+		HelpSynopsis:    pathIssuerHelpSyn,
+		HelpDescription: pathIssuerHelpDesc,
+	}
+}
+
+func pathConfigIssuers(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config/issuers",
+		Fields: map[string]*framework.FieldSchema{
+			defaultRef: {
+				Type:        framework.TypeString,
+				Description: `Reference (name or identifier) to the default issuer.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathConfigIssuersRead,
+			logical.UpdateOperation: b.pathConfigIssuersWrite,
+		},
+
+		HelpSynopsis:    pathConfigIssuersHelpSyn,
+		HelpDescription: pathConfigIssuersHelpDesc,
+	}
+}
+
+func (b *backend) pathListIssuers(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	issuers, err := fetchAllIssuers(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := getIssuersConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	keyInfo := make(map[string]interface{})
+	for _, issuer := range issuers {
+		keys = append(keys, issuer.ID)
+		keyInfo[issuer.ID] = map[string]interface{}{
+			"issuer_name": issuer.Name,
+			"is_default":  issuer.ID == config.DefaultIssuerID || issuer.ID == legacyIssuerID,
+		}
+	}
+
+	return logical.ListResponseWithInfo(keys, keyInfo), nil
+}
+
+func (b *backend) pathGenerateIssuer(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	publicKey, privateKey, err := generateSSHKeyPair(b.Backend.GetRandomReader(), data.Get("key_type").(string), data.Get("key_bits").(int))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	return b.storeIssuer(ctx, req, data.Get("issuer_name").(string), publicKey, privateKey)
+}
+
+func (b *backend) pathImportIssuer(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	privateKey := data.Get("private_key").(string)
+	publicKey := data.Get("public_key").(string)
+	if privateKey == "" {
+		return logical.ErrorResponse("missing private_key"), nil
+	}
+
+	if publicKey == "" {
+		signer, err := ssh.ParsePrivateKey([]byte(privateKey))
+		if err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("Unable to parse private_key as an SSH private key: %v", err)), nil
+		}
+		publicKey = string(ssh.MarshalAuthorizedKey(signer.PublicKey()))
+	}
+
+	if err := validateCAKeyPair(publicKey, privateKey); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	return b.storeIssuer(ctx, req, data.Get("issuer_name").(string), publicKey, privateKey)
+}
+
+func (b *backend) storeIssuer(ctx context.Context, req *logical.Request, name, publicKey, privateKey string) (*logical.Response, error) {
+	issuer, isDefault, err := createIssuer(ctx, req.Storage, name, publicKey, privateKey)
+	if err != nil {
+		if _, ok := err.(errutil.UserError); ok {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		return nil, err
+	}
+
+	return issuerResponse(issuer, isDefault), nil
+}
+
+func issuerResponse(issuer *sshIssuer, isDefault bool) *logical.Response {
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"issuer_id":   issuer.ID,
+			"issuer_name": issuer.Name,
+			"public_key":  issuer.PublicKey,
+			"is_default":  isDefault,
+		},
+	}
+}
+
+func (b *backend) pathIssuerRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	issuer, err := fetchIssuer(ctx, req.Storage, data.Get(issuerRefParam).(string))
+	if err != nil {
+		return nil, err
+	}
+	if issuer == nil {
+		return nil, nil
+	}
+
+	config, err := getIssuersConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	return issuerResponse(issuer, issuer.ID == config.DefaultIssuerID || issuer.ID == legacyIssuerID), nil
+}
+
+func (b *backend) pathIssuerUpdate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := migrateLegacyCA(ctx, req.Storage); err != nil {
+		return nil, err
+	}
+
+	issuer, err := fetchIssuer(ctx, req.Storage, data.Get(issuerRefParam).(string))
+	if err != nil {
+		return nil, err
+	}
+	if issuer == nil {
+		return logical.ErrorResponse("issuer not found"), nil
+	}
+
+	if name, ok := data.GetOk("issuer_name"); ok {
+		if err := validateIssuerName(ctx, req.Storage, name.(string), issuer.ID); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		issuer.Name = name.(string)
+	}
+
+	if err := writeIssuer(ctx, req.Storage, issuer); err != nil {
+		return nil, err
+	}
+
+	config, err := getIssuersConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	return issuerResponse(issuer, issuer.ID == config.DefaultIssuerID), nil
+}
+
+func (b *backend) pathIssuerDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := migrateLegacyCA(ctx, req.Storage); err != nil {
+		return nil, err
+	}
+
+	id, err := resolveIssuerReference(ctx, req.Storage, data.Get(issuerRefParam).(string))
+	if err != nil {
+		return nil, err
+	}
+	if id == "" {
+		return nil, nil
+	}
+
+	wasDefault, err := deleteIssuer(ctx, req.Storage, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if wasDefault {
+		resp := &logical.Response{}
+		resp.AddWarning("Deleted the default issuer; certificates cannot be signed by roles using the default issuer until a new default is configured.")
+		return resp, nil
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathConfigIssuersRead(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	migrated, err := issuersMigrated(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	defaultID := ""
+	if migrated {
+		config, err := getIssuersConfig(ctx, req.Storage)
+		if err != nil {
+			return nil, err
+		}
+		defaultID = config.DefaultIssuerID
+	} else {
+		legacy, err := legacyIssuer(ctx, req.Storage)
+		if err != nil {
+			return nil, err
+		}
+		if legacy != nil {
+			defaultID = legacy.ID
+		}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			defaultRef: defaultID,
+		},
+	}, nil
+}
+
+func (b *backend) pathConfigIssuersWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	newDefault := data.Get(defaultRef).(string)
+	if newDefault == "" || newDefault == defaultRef {
+		return logical.ErrorResponse("invalid issuer specification; must be an issuer name or identifier"), nil
+	}
+
+	if err := migrateLegacyCA(ctx, req.Storage); err != nil {
+		return nil, err
+	}
+
+	id, err := resolveIssuerReference(ctx, req.Storage, newDefault)
+	if err != nil {
+		return nil, err
+	}
+	if id == "" {
+		return logical.ErrorResponse(fmt.Sprintf("issuer %q not found", newDefault)), nil
+	}
+
+	config, err := getIssuersConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	config.DefaultIssuerID = id
+	if err := setIssuersConfig(ctx, req.Storage, config); err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			defaultRef: id,
+		},
+	}, nil
+}
+
+const (
+	pathListIssuersHelpSyn  = `List the CA issuers of this mount.`
+	pathListIssuersHelpDesc = `
+Lists the identifiers of the issuers that can sign SSH certificates, along with
+their names and which one is the default issuer.
+`
+
+	pathGenerateIssuerHelpSyn  = `Generate a new CA issuer.`
+	pathGenerateIssuerHelpDesc = `
+Generates a new CA key pair and stores it as an issuer. The issuer becomes the
+default issuer if none is configured.
+`
+
+	pathImportIssuerHelpSyn  = `Import a CA key pair as a new issuer.`
+	pathImportIssuerHelpDesc = `
+Stores an existing CA key pair as an issuer. The issuer becomes the default
+issuer if none is configured.
+`
+
+	pathIssuerHelpSyn  = `Read, rename or delete a CA issuer.`
+	pathIssuerHelpDesc = `
+Returns the public key of the issuer, sets its name, or deletes it. Deleting an
+issuer means certificates can no longer be signed with it, and it is no longer
+returned by the public_key endpoint.
+`
+
+	pathConfigIssuersHelpSyn  = `Read or set the default CA issuer.`
+	pathConfigIssuersHelpDesc = `
+The default issuer signs certificates for roles that do not select an issuer,
+and its public key is returned by the public_key and config/ca endpoints.
+`
+)
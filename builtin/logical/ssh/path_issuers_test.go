@@ -0,0 +1,245 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestSSH_Issuers(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Cannot create backend: %s", err)
+	}
+
+	doRequest := func(op logical.Operation, path string, data map[string]interface{}, errExpected bool) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Data:      data,
+			Storage:   config.StorageView,
+		})
+		if errExpected {
+			if err == nil && (resp == nil || !resp.IsError()) {
+				t.Fatalf("expected error for %s, got: %#v", path, resp)
+			}
+			return resp
+		}
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: %s: err: %v, resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+
+	// The first issuer becomes the default
+	resp := doRequest(logical.UpdateOperation, "issuers/generate", map[string]interface{}{
+		"issuer_name": "old",
+		"key_type":    "ed25519",
+	}, false)
+	oldID := resp.Data["issuer_id"].(string)
+	oldKey := resp.Data["public_key"].(string)
+	if !resp.Data["is_default"].(bool) {
+		t.Fatal("expected the first issuer to be the default")
+	}
+
+	resp = doRequest(logical.UpdateOperation, "issuers/import", map[string]interface{}{
+		"issuer_name": "new",
+		"private_key": testCAPrivateKey,
+	}, false)
+	newID := resp.Data["issuer_id"].(string)
+	newKey := resp.Data["public_key"].(string)
+	if resp.Data["is_default"].(bool) {
+		t.Fatal("expected the second issuer not to be the default")
+	}
+	if !sameSSHPublicKey(t, resp.Data["public_key"].(string), testCAPublicKey) {
+		t.Fatalf("bad derived public key: %v", resp.Data["public_key"])
+	}
+
+	// Names are unique and "default" is reserved
+	doRequest(logical.UpdateOperation, "issuers/generate", map[string]interface{}{"issuer_name": "new"}, true)
+	doRequest(logical.UpdateOperation, "issuers/generate", map[string]interface{}{"issuer_name": "default"}, true)
+
+	resp = doRequest(logical.ListOperation, "issuers/", nil, false)
+	if len(resp.Data["keys"].([]string)) != 2 {
+		t.Fatalf("bad issuer list: %#v", resp.Data)
+	}
+
+	// The default issuer is mirrored to the legacy CA entries, which older
+	// versions read
+	legacyKey := func() string {
+		t.Helper()
+		legacy, err := legacyIssuer(context.Background(), config.StorageView)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if legacy == nil {
+			return ""
+		}
+		return legacy.PublicKey
+	}
+	if legacyKey() != oldKey {
+		t.Fatalf("bad legacy CA public key: %v", legacyKey())
+	}
+
+	// config/ca and public_key return the default issuer
+	resp = doRequest(logical.ReadOperation, "config/ca", nil, false)
+	if resp.Data["public_key"] != oldKey {
+		t.Fatalf("bad CA public key: %v", resp.Data["public_key"])
+	}
+	resp = doRequest(logical.ReadOperation, "public_key", nil, false)
+	if string(resp.Data[logical.HTTPRawBody].([]byte)) != oldKey {
+		t.Fatalf("bad public key: %s", resp.Data[logical.HTTPRawBody])
+	}
+
+	// All issuers are returned during rotation
+	resp = doRequest(logical.ReadOperation, "public_key", map[string]interface{}{"all": true}, false)
+	allKeys := string(resp.Data[logical.HTTPRawBody].([]byte))
+	if strings.Count(allKeys, "\n") != 2 || !strings.Contains(allKeys, oldKey) || !strings.Contains(allKeys, newKey) {
+		t.Fatalf("bad public keys: %s", allKeys)
+	}
+
+	// A role can select its issuer
+	doRequest(logical.UpdateOperation, "roles/missing", map[string]interface{}{
+		"key_type":                "ca",
+		"allow_user_certificates": true,
+		"issuer_ref":              "missing",
+	}, true)
+	doRequest(logical.UpdateOperation, "roles/new", map[string]interface{}{
+		"key_type":                "ca",
+		"allow_user_certificates": true,
+		"allowed_users":           "*",
+		"issuer_ref":              "new",
+	}, false)
+	doRequest(logical.UpdateOperation, "roles/default", map[string]interface{}{
+		"key_type":                "ca",
+		"allow_user_certificates": true,
+		"allowed_users":           "*",
+	}, false)
+
+	signedBy := func(role string) string {
+		t.Helper()
+		resp := doRequest(logical.UpdateOperation, "sign/"+role, map[string]interface{}{
+			"public_key": testCAPublicKeyEd25519,
+		}, false)
+		parsed, _, _, _, err := ssh.ParseAuthorizedKey([]byte(resp.Data["signed_key"].(string)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(ssh.MarshalAuthorizedKey(parsed.(*ssh.Certificate).SignatureKey))
+	}
+	if signedBy("new") != newKey {
+		t.Fatal("expected the role to sign with its issuer")
+	}
+	if signedBy("default") != oldKey {
+		t.Fatal("expected the role to sign with the default issuer")
+	}
+
+	// Rotate the default issuer
+	resp = doRequest(logical.UpdateOperation, "config/issuers", map[string]interface{}{"default": "new"}, false)
+	if resp.Data["default"] != newID {
+		t.Fatalf("bad default issuer: %v", resp.Data["default"])
+	}
+	if signedBy("default") != newKey {
+		t.Fatal("expected the role to sign with the new default issuer")
+	}
+	if legacyKey() != newKey {
+		t.Fatalf("bad legacy CA public key after rotation: %v", legacyKey())
+	}
+
+	// Rename and delete the old issuer
+	doRequest(logical.UpdateOperation, "issuer/old", map[string]interface{}{"issuer_name": "retired"}, false)
+	resp = doRequest(logical.ReadOperation, "issuer/retired", nil, false)
+	if resp.Data["issuer_id"] != oldID || resp.Data["is_default"].(bool) {
+		t.Fatalf("bad issuer: %#v", resp.Data)
+	}
+	doRequest(logical.DeleteOperation, "issuer/retired", nil, false)
+	resp = doRequest(logical.ReadOperation, "public_key", map[string]interface{}{"all": true}, false)
+	if strings.Count(string(resp.Data[logical.HTTPRawBody].([]byte)), "\n") != 1 {
+		t.Fatalf("bad public keys after deletion: %s", resp.Data[logical.HTTPRawBody])
+	}
+
+	// Deleting config/ca deletes the default issuer
+	doRequest(logical.DeleteOperation, "config/ca", nil, false)
+	resp = doRequest(logical.ListOperation, "issuers/", nil, false)
+	if _, ok := resp.Data["keys"]; ok {
+		t.Fatalf("expected no issuers, got: %#v", resp.Data)
+	}
+	if legacyKey() != "" {
+		t.Fatalf("expected the legacy CA to be removed, got: %v", legacyKey())
+	}
+}
+
+func sameSSHPublicKey(t *testing.T, a, b string) bool {
+	t.Helper()
+	keyA, err := parsePublicSSHKey(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyB, err := parsePublicSSHKey(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes.Equal(keyA.Marshal(), keyB.Marshal())
+}
+
+func TestSSH_IssuersMigration(t *testing.T) {
+	ctx := context.Background()
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b, err := Factory(ctx, config)
+	if err != nil {
+		t.Fatalf("Cannot create backend: %s", err)
+	}
+
+	for path, key := range map[string]string{
+		caPublicKeyStoragePath:  testCAPublicKey,
+		caPrivateKeyStoragePath: testCAPrivateKey,
+	} {
+		entry, err := logical.StorageEntryJSON(path, &keyStorageEntry{Key: key})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := config.StorageView.Put(ctx, entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Before migration, the legacy CA is the default issuer
+	issuer, err := fetchIssuer(ctx, config.StorageView, defaultRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issuer == nil || issuer.PublicKey != testCAPublicKey {
+		t.Fatalf("expected the legacy CA, got: %#v", issuer)
+	}
+
+	if err := b.Initialize(ctx, &logical.InitializationRequest{Storage: config.StorageView}); err != nil {
+		t.Fatal(err)
+	}
+
+	issuer, err = fetchIssuer(ctx, config.StorageView, defaultRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issuer == nil || issuer.ID == legacyIssuerID || issuer.PublicKey != testCAPublicKey || issuer.PrivateKey != testCAPrivateKey {
+		t.Fatalf("expected the migrated CA, got: %#v", issuer)
+	}
+
+	// The legacy CA is kept, so that older versions still find it
+	legacy, err := legacyIssuer(ctx, config.StorageView)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if legacy == nil || legacy.PublicKey != testCAPublicKey || legacy.PrivateKey != testCAPrivateKey {
+		t.Fatalf("expected the legacy CA to be kept, got: %#v", legacy)
+	}
+}
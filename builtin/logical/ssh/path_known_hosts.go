@@ -0,0 +1,281 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"golang.org/x/crypto/ssh"
+)
+
+func pathKnownHosts(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "known_hosts",
+
+		Fields: map[string]*framework.FieldSchema{
+			"role": {
+				Type: framework.TypeString,
+				Description: `Only render the host patterns of the named role. By default, the
+patterns of all roles allowing host certificates are rendered.`,
+			},
+			"domains": {
+				Type: framework.TypeCommaStringSlice,
+				Description: `Host patterns to trust the issuers for, overriding the patterns
+derived from the roles.`,
+			},
+			issuerRefParam: {
+				Type: framework.TypeString,
+				Description: `Reference to the issuer to render the entry for. By default, an
+entry is rendered for every issuer, so that hosts signed by either the
+old or the new issuer are trusted while rotating issuers.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathKnownHostsRead,
+		},
+
+		HelpSynopsis:    pathKnownHostsHelpSyn,
+		HelpDescription: pathKnownHostsHelpDesc,
+	}
+}
+
+func pathVerifyHostCertificate(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "host_certificate/verify",
+
+		Fields: map[string]*framework.FieldSchema{
+			"certificate": {
+				Type:        framework.TypeString,
+				Description: "[Required] The SSH host certificate, in authorized_keys format.",
+			},
+			"hostname": {
+				Type:        framework.TypeString,
+				Description: "[Required] The hostname the certificate is presented for.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathVerifyHostCertificateWrite,
+		},
+
+		HelpSynopsis:    pathVerifyHostCertificateHelpSyn,
+		HelpDescription: pathVerifyHostCertificateHelpDesc,
+	}
+}
+
+func (b *backend) pathKnownHostsRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	patterns := data.Get("domains").([]string)
+	if len(patterns) == 0 {
+		var err error
+		patterns, err = b.knownHostsPatterns(ctx, req.Storage, data.Get("role").(string))
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+	}
+	patterns = strutil.RemoveDuplicates(patterns, false)
+	if len(patterns) == 0 {
+		return logical.ErrorResponse("no host patterns found; set allowed_domains on a role allowing host certificates or provide domains"), nil
+	}
+
+	var issuers []*sshIssuer
+	if ref := data.Get(issuerRefParam).(string); ref != "" {
+		issuer, err := fetchIssuer(ctx, req.Storage, ref)
+		if err != nil {
+			return nil, err
+		}
+		if issuer == nil {
+			return logical.ErrorResponse(fmt.Sprintf("issuer %q does not exist", ref)), nil
+		}
+		issuers = append(issuers, issuer)
+	} else {
+		var err error
+		issuers, err = fetchAllIssuers(ctx, req.Storage)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(issuers) == 0 {
+		return nil, nil
+	}
+
+	var knownHosts strings.Builder
+	for _, issuer := range issuers {
+		fmt.Fprintf(&knownHosts, "@cert-authority %s %s\n", strings.Join(patterns, ","), strings.TrimSpace(issuer.PublicKey))
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType: "text/plain",
+			logical.HTTPRawBody:     []byte(knownHosts.String()),
+			logical.HTTPStatusCode:  200,
+		},
+	}, nil
+}
+
+// knownHostsPatterns returns the known_hosts host patterns matching the hosts
+// that roles allow certificates to be signed for: each allowed domain when
+// bare domains are allowed, and its subdomains when subdomains are allowed.
+// Templated domains cannot be expanded without an entity and are skipped.
+func (b *backend) knownHostsPatterns(ctx context.Context, s logical.Storage, roleName string) ([]string, error) {
+	roleNames := []string{roleName}
+	if roleName == "" {
+		var err error
+		roleNames, err = s.List(ctx, "roles/")
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(roleNames)
+	}
+
+	var patterns []string
+	for _, name := range roleNames {
+		role, err := b.getRole(ctx, s, name)
+		if err != nil {
+			return nil, err
+		}
+		if role == nil {
+			if roleName != "" {
+				return nil, fmt.Errorf("role %q does not exist", roleName)
+			}
+			continue
+		}
+		if role.KeyType != KeyTypeCA || !role.AllowHostCertificates {
+			if roleName != "" {
+				return nil, fmt.Errorf("role %q does not allow host certificates", roleName)
+			}
+			continue
+		}
+
+		for _, domain := range strutil.ParseDedupAndSortStrings(role.AllowedDomains, ",") {
+			switch {
+			case domain == "*":
+				patterns = append(patterns, domain)
+			case strings.Contains(domain, "{{"):
+				continue
+			default:
+				if role.AllowBareDomains {
+					patterns = append(patterns, domain)
+				}
+				if role.AllowSubdomains {
+					patterns = append(patterns, "*."+domain)
+				}
+			}
+		}
+	}
+
+	return patterns, nil
+}
+
+func (b *backend) pathVerifyHostCertificateWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	hostname := data.Get("hostname").(string)
+	if hostname == "" {
+		return logical.ErrorResponse("missing hostname"), nil
+	}
+
+	certificate := data.Get("certificate").(string)
+	if certificate == "" {
+		return logical.ErrorResponse("missing certificate"), nil
+	}
+	parsed, _, _, _, err := ssh.ParseAuthorizedKey([]byte(certificate))
+	if err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("failed to parse certificate: %s", err)), nil
+	}
+	cert, ok := parsed.(*ssh.Certificate)
+	if !ok {
+		return logical.ErrorResponse("key is not a certificate"), nil
+	}
+
+	issuers, err := fetchAllIssuers(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	respData := map[string]interface{}{
+		"key_id":           cert.KeyId,
+		"serial_number":    strconv.FormatUint(cert.Serial, 16),
+		"valid_principals": cert.ValidPrincipals,
+		"valid_after":      certificateTime(cert.ValidAfter),
+		"valid_before":     certificateTime(cert.ValidBefore),
+	}
+	invalid := func(reason string) (*logical.Response, error) {
+		respData["valid"] = false
+		respData["reason"] = reason
+		return &logical.Response{Data: respData}, nil
+	}
+
+	if cert.CertType != ssh.HostCert {
+		return invalid("certificate is not a host certificate")
+	}
+
+	var signedBy *sshIssuer
+	signatureKey := cert.SignatureKey.Marshal()
+	for _, issuer := range issuers {
+		publicKey, err := parsePublicSSHKey(issuer.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key of issuer %s: %w", issuer.ID, err)
+		}
+		if bytes.Equal(publicKey.Marshal(), signatureKey) {
+			signedBy = issuer
+			break
+		}
+	}
+	if signedBy == nil {
+		return invalid("certificate was not signed by an issuer of this mount")
+	}
+	respData["issuer_id"] = signedBy.ID
+
+	// CheckCert verifies the validity period, the principals and the signature
+	checker := &ssh.CertChecker{}
+	if err := checker.CheckCert(hostname, cert); err != nil {
+		return invalid(err.Error())
+	}
+
+	b.revokeStorageLock.RLock()
+	revoked, err := fetchRevokedCertEntry(ctx, req.Storage, strconv.FormatUint(cert.Serial, 16))
+	b.revokeStorageLock.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	if revoked != nil && revoked.IssuerID == signedBy.ID {
+		return invalid("certificate has been revoked")
+	}
+
+	respData["valid"] = true
+	return &logical.Response{Data: respData}, nil
+}
+
+// certificateTime returns the time of an SSH certificate validity bound, or
+// "forever" for the maximum value.
+func certificateTime(t uint64) string {
+	if t == ssh.CertTimeInfinity {
+		return "forever"
+	}
+	return time.Unix(int64(t), 0).UTC().Format(time.RFC3339)
+}
+
+const pathKnownHostsHelpSyn = `Retrieve known_hosts entries trusting the issuers of this backend.`
+
+const pathKnownHostsHelpDesc = `
+Renders "@cert-authority" lines for the known_hosts file of SSH clients, so
+that they trust the host certificates signed by this backend. The host
+patterns are derived from the allowed domains of the roles allowing host
+certificates, or can be provided explicitly. As the role-derived patterns
+disclose the allowed domains of the roles, this endpoint requires a token.
+`
+
+const pathVerifyHostCertificateHelpSyn = `Check the validity of an SSH host certificate.`
+
+const pathVerifyHostCertificateHelpDesc = `
+Checks that a host certificate was signed by one of the issuers of this
+backend, is currently valid, is valid for the given hostname and has not been
+revoked. The certificate details are returned, along with the reason it is not
+valid if any.
+`
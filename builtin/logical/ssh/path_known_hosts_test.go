@@ -0,0 +1,224 @@
+package ssh
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestSSH_KnownHosts(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Cannot create backend: %s", err)
+	}
+
+	// The role-derived host patterns disclose the allowed domains of the
+	// roles, so the endpoint must not be reachable without a token.
+	for _, path := range b.SpecialPaths().Unauthenticated {
+		if path == "known_hosts" {
+			t.Fatalf("known_hosts must not be unauthenticated")
+		}
+	}
+
+	doRequest := func(op logical.Operation, path string, data map[string]interface{}, errExpected bool) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Data:      data,
+			Storage:   config.StorageView,
+		})
+		if errExpected {
+			if err == nil && (resp == nil || !resp.IsError()) {
+				t.Fatalf("expected error for %s, got: %#v", path, resp)
+			}
+			return resp
+		}
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: %s: err: %v, resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+	knownHosts := func(data map[string]interface{}) string {
+		t.Helper()
+		resp := doRequest(logical.ReadOperation, "known_hosts", data, false)
+		return string(resp.Data[logical.HTTPRawBody].([]byte))
+	}
+
+	resp := doRequest(logical.UpdateOperation, "issuers/generate", map[string]interface{}{
+		"issuer_name": "old",
+		"key_type":    "ed25519",
+	}, false)
+	oldKey := strings.TrimSpace(resp.Data["public_key"].(string))
+
+	// Host patterns are derived from roles allowing host certificates
+	doRequest(logical.ReadOperation, "known_hosts", nil, true)
+	doRequest(logical.UpdateOperation, "roles/hosts", map[string]interface{}{
+		"key_type":                "ca",
+		"allow_host_certificates": true,
+		"allowed_domains":         "example.com,internal.example.org",
+		"allow_bare_domains":      true,
+		"allow_subdomains":        true,
+	}, false)
+	doRequest(logical.UpdateOperation, "roles/subdomains", map[string]interface{}{
+		"key_type":                "ca",
+		"allow_host_certificates": true,
+		"allowed_domains":         "example.net",
+		"allow_subdomains":        true,
+	}, false)
+	doRequest(logical.UpdateOperation, "roles/users", map[string]interface{}{
+		"key_type":                "ca",
+		"allow_user_certificates": true,
+		"allowed_domains":         "users.example.com",
+	}, false)
+
+	expected := "@cert-authority *.example.com,*.example.net,*.internal.example.org,example.com,internal.example.org " + oldKey + "\n"
+	if actual := knownHosts(nil); actual != expected {
+		t.Fatalf("bad known_hosts:\nexpected: %s\nactual: %s", expected, actual)
+	}
+
+	expected = "@cert-authority *.example.net " + oldKey + "\n"
+	if actual := knownHosts(map[string]interface{}{"role": "subdomains"}); actual != expected {
+		t.Fatalf("bad known_hosts for role:\nexpected: %s\nactual: %s", expected, actual)
+	}
+	doRequest(logical.ReadOperation, "known_hosts", map[string]interface{}{"role": "users"}, true)
+
+	expected = "@cert-authority 10.0.0.*,host.example.com " + oldKey + "\n"
+	if actual := knownHosts(map[string]interface{}{"domains": "10.0.0.*,host.example.com"}); actual != expected {
+		t.Fatalf("bad known_hosts for domains:\nexpected: %s\nactual: %s", expected, actual)
+	}
+
+	// Every issuer is trusted unless one is selected
+	resp = doRequest(logical.UpdateOperation, "issuers/generate", map[string]interface{}{
+		"issuer_name": "new",
+		"key_type":    "ed25519",
+	}, false)
+	newKey := strings.TrimSpace(resp.Data["public_key"].(string))
+
+	actual := knownHosts(map[string]interface{}{"role": "subdomains"})
+	if strings.Count(actual, "\n") != 2 || !strings.Contains(actual, oldKey) || !strings.Contains(actual, newKey) {
+		t.Fatalf("bad known_hosts with two issuers: %s", actual)
+	}
+	expected = "@cert-authority *.example.net " + newKey + "\n"
+	if actual := knownHosts(map[string]interface{}{"role": "subdomains", "issuer_ref": "new"}); actual != expected {
+		t.Fatalf("bad known_hosts for issuer:\nexpected: %s\nactual: %s", expected, actual)
+	}
+}
+
+func TestSSH_VerifyHostCertificate(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Cannot create backend: %s", err)
+	}
+
+	doRequest := func(path string, data map[string]interface{}, errExpected bool) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Data:      data,
+			Storage:   config.StorageView,
+		})
+		if errExpected {
+			if err == nil && (resp == nil || !resp.IsError()) {
+				t.Fatalf("expected error for %s, got: %#v", path, resp)
+			}
+			return resp
+		}
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: %s: err: %v, resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+
+	resp := doRequest("issuers/generate", map[string]interface{}{"key_type": "ed25519"}, false)
+	issuerID := resp.Data["issuer_id"].(string)
+	doRequest("roles/hosts", map[string]interface{}{
+		"key_type":                "ca",
+		"allow_host_certificates": true,
+		"allow_user_certificates": true,
+		"allowed_users":           "*",
+		"allowed_domains":         "example.com",
+		"allow_subdomains":        true,
+	}, false)
+
+	sign := func(certType, principals string) string {
+		t.Helper()
+		resp := doRequest("sign/hosts", map[string]interface{}{
+			"public_key":       testCAPublicKeyEd25519,
+			"cert_type":        certType,
+			"valid_principals": principals,
+		}, false)
+		return resp.Data["signed_key"].(string)
+	}
+	verify := func(certificate, hostname string) *logical.Response {
+		t.Helper()
+		return doRequest("host_certificate/verify", map[string]interface{}{
+			"certificate": certificate,
+			"hostname":    hostname,
+		}, false)
+	}
+
+	hostCert := sign("host", "web.example.com")
+	resp = verify(hostCert, "web.example.com")
+	if !resp.Data["valid"].(bool) || resp.Data["issuer_id"] != issuerID {
+		t.Fatalf("expected a valid certificate, got: %#v", resp.Data)
+	}
+	if principals := resp.Data["valid_principals"].([]string); len(principals) != 1 || principals[0] != "web.example.com" {
+		t.Fatalf("bad principals: %#v", resp.Data["valid_principals"])
+	}
+
+	resp = verify(hostCert, "db.example.com")
+	if resp.Data["valid"].(bool) || resp.Data["reason"] == "" {
+		t.Fatalf("expected the certificate to be invalid for another host, got: %#v", resp.Data)
+	}
+
+	resp = verify(sign("user", "admin"), "web.example.com")
+	if resp.Data["valid"].(bool) {
+		t.Fatalf("expected a user certificate to be invalid, got: %#v", resp.Data)
+	}
+
+	// Revoked certificates are reported as invalid
+	resp = doRequest("sign/hosts", map[string]interface{}{
+		"public_key":       testCAPublicKeyEd25519,
+		"cert_type":        "host",
+		"valid_principals": "db.example.com",
+	}, false)
+	revokedCert := resp.Data["signed_key"].(string)
+	doRequest("revoke", map[string]interface{}{"serial_number": resp.Data["serial_number"]}, false)
+	resp = verify(revokedCert, "db.example.com")
+	if resp.Data["valid"].(bool) || resp.Data["reason"] != "certificate has been revoked" {
+		t.Fatalf("expected a revoked certificate to be invalid, got: %#v", resp.Data)
+	}
+	if resp = verify(hostCert, "web.example.com"); !resp.Data["valid"].(bool) {
+		t.Fatalf("expected an unrevoked certificate to remain valid, got: %#v", resp.Data)
+	}
+
+	// Certificates signed by a deleted issuer are no longer trusted
+	resp = doRequest("issuers/generate", map[string]interface{}{"key_type": "ed25519"}, false)
+	doRequest("config/issuers", map[string]interface{}{"default": resp.Data["issuer_id"]}, false)
+	if _, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.DeleteOperation,
+		Path:      "issuer/" + issuerID,
+		Storage:   config.StorageView,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	resp = verify(hostCert, "web.example.com")
+	if resp.Data["valid"].(bool) || resp.Data["issuer_id"] != nil {
+		t.Fatalf("expected the certificate not to be trusted, got: %#v", resp.Data)
+	}
+
+	doRequest("host_certificate/verify", map[string]interface{}{
+		"certificate": testCAPublicKeyEd25519,
+		"hostname":    "web.example.com",
+	}, true)
+}
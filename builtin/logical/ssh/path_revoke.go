@@ -0,0 +1,286 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func pathListCerts(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "certs/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathListCerts,
+		},
+
+		HelpSynopsis:    pathListCertsHelpSyn,
+		HelpDescription: pathListCertsHelpDesc,
+	}
+}
+
+func pathFetchCert(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: `cert/(?P<serial>[0-9A-Fa-f:]+)`,
+		Fields: map[string]*framework.FieldSchema{
+			"serial": {
+				Type:        framework.TypeString,
+				Description: `Serial number of the certificate, in hexadecimal.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathFetchCert,
+		},
+
+		HelpSynopsis:    pathFetchCertHelpSyn,
+		HelpDescription: pathFetchCertHelpDesc,
+	}
+}
+
+func pathRevoke(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "revoke",
+		Fields: map[string]*framework.FieldSchema{
+			"serial_number": {
+				Type:        framework.TypeString,
+				Description: `Serial number of the certificate to revoke, in hexadecimal.`,
+			},
+			"key_id": {
+				Type: framework.TypeString,
+				Description: `Key ID of the certificates to revoke. All the certificates signed
+with this key ID are revoked.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathRevokeWrite,
+		},
+
+		HelpSynopsis:    pathRevokeHelpSyn,
+		HelpDescription: pathRevokeHelpDesc,
+	}
+}
+
+func pathFetchKRL(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "krl",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathFetchKRL,
+		},
+
+		HelpSynopsis:    pathFetchKRLHelpSyn,
+		HelpDescription: pathFetchKRLHelpDesc,
+	}
+}
+
+func pathTidy(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "tidy",
+		Fields: map[string]*framework.FieldSchema{
+			"safety_buffer": {
+				Type: framework.TypeDurationSecond,
+				Description: `The amount of extra time that must have passed
+beyond certificate expiration before it is removed
+from the backend storage. Defaults to 72 hours.`,
+				Default: 259200, // 72h, but TypeDurationSecond currently requires defaults to be int
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathTidyWrite,
+		},
+
+		HelpSynopsis:    pathTidyHelpSyn,
+		HelpDescription: pathTidyHelpDesc,
+	}
+}
+
+func (b *backend) pathListCerts(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, certsPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathFetchCert(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	serial, err := normalizeSerial(data.Get("serial").(string))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	cert, err := fetchCertEntry(ctx, req.Storage, serial)
+	if err != nil {
+		return nil, err
+	}
+	if cert == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: certEntryResponseData(cert),
+	}, nil
+}
+
+func (b *backend) pathRevokeWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	serial := data.Get("serial_number").(string)
+	keyID := data.Get("key_id").(string)
+	if (serial == "") == (keyID == "") {
+		return logical.ErrorResponse("exactly one of serial_number or key_id must be provided"), nil
+	}
+
+	b.revokeStorageLock.Lock()
+	defer b.revokeStorageLock.Unlock()
+
+	var certs []*sshCertEntry
+	if serial != "" {
+		normalized, err := normalizeSerial(serial)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		cert, err := fetchCertEntry(ctx, req.Storage, normalized)
+		if err != nil {
+			return nil, err
+		}
+		if cert == nil {
+			return logical.ErrorResponse(fmt.Sprintf("certificate with serial %s not found", normalized)), nil
+		}
+		certs = append(certs, cert)
+	} else {
+		serials, err := req.Storage.List(ctx, certsPrefix)
+		if err != nil {
+			return nil, err
+		}
+		for _, serial := range serials {
+			cert, err := fetchCertEntry(ctx, req.Storage, serial)
+			if err != nil {
+				return nil, err
+			}
+			if cert != nil && cert.KeyID == keyID {
+				certs = append(certs, cert)
+			}
+		}
+		if len(certs) == 0 {
+			return logical.ErrorResponse(fmt.Sprintf("no certificate with key ID %q found", keyID)), nil
+		}
+	}
+
+	now := time.Now()
+	revokedSerials := make([]string, 0, len(certs))
+	for _, cert := range certs {
+		if err := revokeCertificate(ctx, req.Storage, cert, now); err != nil {
+			return nil, err
+		}
+		revokedSerials = append(revokedSerials, cert.SerialNumber)
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"serial_numbers": revokedSerials,
+		},
+	}
+	if len(certs) == 1 {
+		resp.Data["revocation_time"] = certs[0].RevocationTime
+	}
+	return resp, nil
+}
+
+func (b *backend) pathFetchKRL(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	b.revokeStorageLock.RLock()
+	defer b.revokeStorageLock.RUnlock()
+
+	krl, err := buildKRL(ctx, req.Storage, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType: "application/octet-stream",
+			logical.HTTPRawBody:     krl,
+			logical.HTTPStatusCode:  200,
+		},
+	}, nil
+}
+
+func (b *backend) pathTidyWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	safetyBuffer := data.Get("safety_buffer").(int)
+	if safetyBuffer < 1 {
+		return logical.ErrorResponse("safety_buffer must be greater than zero"), nil
+	}
+
+	b.revokeStorageLock.Lock()
+	defer b.revokeStorageLock.Unlock()
+
+	cutoff := time.Now().Add(-time.Duration(safetyBuffer) * time.Second)
+	certsDeleted, revokedDeleted, err := tidyCertificates(ctx, req.Storage, cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"cert_store_deleted_count":   certsDeleted,
+			"revoked_cert_deleted_count": revokedDeleted,
+		},
+	}, nil
+}
+
+func certEntryResponseData(cert *sshCertEntry) map[string]interface{} {
+	data := map[string]interface{}{
+		"serial_number":    cert.SerialNumber,
+		"key_id":           cert.KeyID,
+		"issuer_id":        cert.IssuerID,
+		"cert_type":        cert.CertType,
+		"valid_principals": cert.ValidPrincipals,
+		"valid_before":     certificateTime(cert.ValidBefore),
+		"revocation_time":  cert.RevocationTime,
+	}
+	if cert.RevocationTime != 0 {
+		data["revocation_time_rfc3339"] = time.Unix(cert.RevocationTime, 0).UTC().Format(time.RFC3339)
+	}
+	return data
+}
+
+const pathListCertsHelpSyn = `List the serial numbers of the certificates signed by this backend.`
+
+const pathListCertsHelpDesc = `
+Lists the hexadecimal serial numbers of the certificates signed by this
+backend, so that they can be read or revoked.
+`
+
+const pathFetchCertHelpSyn = `Read a certificate signed by this backend.`
+
+const pathFetchCertHelpDesc = `
+Returns the key ID, issuer, type, principals and expiry of the certificate with
+the given serial number, and its revocation time if it has been revoked.
+`
+
+const pathRevokeHelpSyn = `Revoke certificates signed by this backend.`
+
+const pathRevokeHelpDesc = `
+Revokes the certificate with the given serial number, or all the certificates
+signed with the given key ID. Revoked certificates are listed in the KRL
+returned by the "krl" endpoint until they expire.
+`
+
+const pathTidyHelpSyn = `Tidy up the records of expired certificates.`
+
+const pathTidyHelpDesc = `
+Deletes the records of the signed and revoked certificates which expired more
+than the safety buffer ago. Expired certificates can no longer be used, and are
+not listed in the KRL anymore.
+`
+
+const pathFetchKRLHelpSyn = `Retrieve the Key Revocation List of this backend.`
+
+const pathFetchKRLHelpDesc = `
+Returns the revoked certificates which have not expired yet as an OpenSSH Key
+Revocation List in binary format, to be used as the "RevokedKeys" file of
+sshd. This endpoint is unauthenticated.
+`
@@ -0,0 +1,333 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestSSH_RevokeAndKRL(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Cannot create backend: %s", err)
+	}
+
+	doRequest := func(op logical.Operation, path string, data map[string]interface{}, errExpected bool) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Data:      data,
+			Storage:   config.StorageView,
+		})
+		if errExpected {
+			if err == nil && (resp == nil || !resp.IsError()) {
+				t.Fatalf("expected error for %s, got: %#v", path, resp)
+			}
+			return resp
+		}
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: %s: err: %v, resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+	fetchKRL := func() []byte {
+		t.Helper()
+		resp := doRequest(logical.ReadOperation, "krl", nil, false)
+		if resp.Data[logical.HTTPContentType] != "application/octet-stream" {
+			t.Fatalf("bad content type: %v", resp.Data[logical.HTTPContentType])
+		}
+		return resp.Data[logical.HTTPRawBody].([]byte)
+	}
+
+	resp := doRequest(logical.UpdateOperation, "issuers/generate", map[string]interface{}{"key_type": "ed25519"}, false)
+	caKey, err := parsePublicSSHKey(resp.Data["public_key"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doRequest(logical.UpdateOperation, "roles/users", map[string]interface{}{
+		"key_type":                "ca",
+		"allow_user_certificates": true,
+		"allowed_users":           "*",
+		"allow_user_key_ids":      true,
+	}, false)
+
+	sign := func(keyID string) uint64 {
+		t.Helper()
+		resp := doRequest(logical.UpdateOperation, "sign/users", map[string]interface{}{
+			"public_key":       testCAPublicKeyEd25519,
+			"valid_principals": "admin",
+			"key_id":           keyID,
+		}, false)
+		serial, err := strconv.ParseUint(resp.Data["serial_number"].(string), 16, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return serial
+	}
+	first := sign("alice")
+	second := sign("bob")
+	third := sign("bob")
+
+	resp = doRequest(logical.ListOperation, "certs/", nil, false)
+	if len(resp.Data["keys"].([]string)) != 3 {
+		t.Fatalf("bad certificate list: %#v", resp.Data)
+	}
+	resp = doRequest(logical.ReadOperation, "cert/"+strconv.FormatUint(first, 16), nil, false)
+	if resp.Data["key_id"] != "alice" || resp.Data["cert_type"] != "user" || resp.Data["revocation_time"].(int64) != 0 {
+		t.Fatalf("bad certificate: %#v", resp.Data)
+	}
+
+	// An empty KRL is still valid
+	version, sections := parseTestKRL(t, fetchKRL())
+	if version != 0 || len(sections) != 0 {
+		t.Fatalf("expected an empty KRL, got version %d and %#v", version, sections)
+	}
+
+	doRequest(logical.UpdateOperation, "revoke", nil, true)
+	doRequest(logical.UpdateOperation, "revoke", map[string]interface{}{"serial_number": "abc"}, true)
+	doRequest(logical.UpdateOperation, "revoke", map[string]interface{}{"key_id": "carol"}, true)
+
+	resp = doRequest(logical.UpdateOperation, "revoke", map[string]interface{}{
+		"serial_number": strconv.FormatUint(first, 16),
+	}, false)
+	if resp.Data["revocation_time"].(int64) == 0 {
+		t.Fatalf("bad revocation: %#v", resp.Data)
+	}
+	resp = doRequest(logical.UpdateOperation, "revoke", map[string]interface{}{"key_id": "bob"}, false)
+	if len(resp.Data["serial_numbers"].([]string)) != 2 {
+		t.Fatalf("expected both certificates of the key ID to be revoked: %#v", resp.Data)
+	}
+
+	resp = doRequest(logical.ReadOperation, "cert/"+strconv.FormatUint(first, 16), nil, false)
+	if resp.Data["revocation_time"].(int64) == 0 {
+		t.Fatalf("expected the certificate to be revoked: %#v", resp.Data)
+	}
+
+	version, sections = parseTestKRL(t, fetchKRL())
+	if version != 3 {
+		t.Fatalf("bad KRL version: %d", version)
+	}
+	serials := sections[string(caKey.Marshal())]
+	expected := []uint64{first, second, third}
+	sort.Slice(expected, func(i, j int) bool { return expected[i] < expected[j] })
+	if !reflect.DeepEqual(serials, expected) {
+		t.Fatalf("bad revoked serials: expected %v, got %v", expected, serials)
+	}
+
+	// Expire the first certificate beyond the safety buffer, and the second
+	// one within it, so that tidying only deletes the records of the first.
+	now := time.Now()
+	for serial, expiry := range map[uint64]time.Time{first: now.Add(-time.Hour), second: now.Add(-time.Minute)} {
+		cert, err := fetchCertEntry(context.Background(), config.StorageView, strconv.FormatUint(serial, 16))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert.ValidBefore = uint64(expiry.Unix())
+		if err := writeCertEntry(context.Background(), config.StorageView, certsPrefix, cert); err != nil {
+			t.Fatal(err)
+		}
+		if err := writeCertEntry(context.Background(), config.StorageView, revokedPrefix, cert); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	doRequest(logical.UpdateOperation, "tidy", map[string]interface{}{"safety_buffer": 0}, true)
+	resp = doRequest(logical.UpdateOperation, "tidy", map[string]interface{}{"safety_buffer": "30m"}, false)
+	if resp.Data["cert_store_deleted_count"] != 1 || resp.Data["revoked_cert_deleted_count"] != 1 {
+		t.Fatalf("bad tidy: %#v", resp.Data)
+	}
+	resp = doRequest(logical.ListOperation, "certs/", nil, false)
+	if len(resp.Data["keys"].([]string)) != 2 {
+		t.Fatalf("bad certificate list after tidy: %#v", resp.Data)
+	}
+	if resp = doRequest(logical.ReadOperation, "cert/"+strconv.FormatUint(first, 16), nil, false); resp != nil {
+		t.Fatalf("expected the expired certificate to be tidied: %#v", resp.Data)
+	}
+	revoked, err := config.StorageView.List(context.Background(), revokedPrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revoked) != 2 {
+		t.Fatalf("bad revoked certificates after tidy: %v", revoked)
+	}
+}
+
+// parseTestKRL returns the version of the KRL and the revoked serials of each
+// CA key.
+// slowStorage delays returning the entries it reads, widening the window
+// between the reads and writes of concurrent requests.
+type slowStorage struct {
+	logical.Storage
+}
+
+func (s slowStorage) Get(ctx context.Context, key string) (*logical.StorageEntry, error) {
+	entry, err := s.Storage.Get(ctx, key)
+	time.Sleep(time.Millisecond)
+	return entry, err
+}
+
+func TestSSH_ConcurrentRevoke(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = slowStorage{&logical.InmemStorage{}}
+
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Cannot create backend: %s", err)
+	}
+
+	doRequest := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Data:      data,
+			Storage:   config.StorageView,
+		})
+		if err == nil && resp != nil && resp.IsError() {
+			err = resp.Error()
+		}
+		return resp, err
+	}
+
+	if _, err := doRequest(logical.UpdateOperation, "issuers/generate", map[string]interface{}{"key_type": "ed25519"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := doRequest(logical.UpdateOperation, "roles/users", map[string]interface{}{
+		"key_type":                "ca",
+		"allow_user_certificates": true,
+		"allowed_users":           "*",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	const count = 20
+	serials := make([]string, count)
+	for i := range serials {
+		resp, err := doRequest(logical.UpdateOperation, "sign/users", map[string]interface{}{
+			"public_key":       testCAPublicKeyEd25519,
+			"valid_principals": "admin",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		serials[i] = resp.Data["serial_number"].(string)
+	}
+
+	// Every revocation must bump the KRL version, even when racing with
+	// others and with tidies
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*count)
+	for _, serial := range serials {
+		wg.Add(2)
+		go func(serial string) {
+			defer wg.Done()
+			_, err := doRequest(logical.UpdateOperation, "revoke", map[string]interface{}{"serial_number": serial})
+			errs <- err
+		}(serial)
+		go func() {
+			defer wg.Done()
+			_, err := doRequest(logical.UpdateOperation, "tidy", nil)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resp, err := doRequest(logical.ReadOperation, "krl", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	version, sections := parseTestKRL(t, resp.Data[logical.HTTPRawBody].([]byte))
+	if version != count {
+		t.Fatalf("expected KRL version %d, got %d", count, version)
+	}
+	for _, revoked := range sections {
+		if len(revoked) != count {
+			t.Fatalf("expected %d revoked certificates, got %d", count, len(revoked))
+		}
+	}
+}
+
+func parseTestKRL(t *testing.T, krl []byte) (uint64, map[string][]uint64) {
+	t.Helper()
+
+	r := bytes.NewReader(krl)
+	read := func(v interface{}) {
+		t.Helper()
+		if err := binary.Read(r, binary.BigEndian, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	readString := func() []byte {
+		t.Helper()
+		var length uint32
+		read(&length)
+		s := make([]byte, length)
+		if _, err := r.Read(s); err != nil && length > 0 {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	var magic, version, generated, flags uint64
+	var formatVersion uint32
+	read(&magic)
+	read(&formatVersion)
+	read(&version)
+	read(&generated)
+	read(&flags)
+	if magic != krlMagic || formatVersion != krlFormatVersion {
+		t.Fatalf("bad KRL header: %x %d", magic, formatVersion)
+	}
+	readString()
+	readString()
+
+	sections := make(map[string][]uint64)
+	for r.Len() > 0 {
+		var sectionType byte
+		read(&sectionType)
+		section := bytes.NewReader(readString())
+		if sectionType != krlSectionCertificates {
+			t.Fatalf("unexpected section type %d", sectionType)
+		}
+
+		outer := r
+		r = section
+		caKey := readString()
+		if _, err := ssh.ParsePublicKey(caKey); err != nil {
+			t.Fatal(err)
+		}
+		readString()
+		for r.Len() > 0 {
+			var certSectionType byte
+			read(&certSectionType)
+			if certSectionType != krlSectionCertSerialList {
+				t.Fatalf("unexpected certificate section type %d", certSectionType)
+			}
+			list := readString()
+			for i := 0; i+8 <= len(list); i += 8 {
+				sections[string(caKey)] = append(sections[string(caKey)], binary.BigEndian.Uint64(list[i:]))
+			}
+		}
+		r = outer
+	}
+
+	return version, sections
+}
@@ -66,6 +66,7 @@ type sshRole struct {
 	AlgorithmSigner            string            `mapstructure:"algorithm_signer" json:"algorithm_signer"`
 	Version                    int               `mapstructure:"role_version" json:"role_version"`
 	NotBeforeDuration          time.Duration     `mapstructure:"not_before_duration" json:"not_before_duration"`
+	IssuerRef                  string            `mapstructure:"issuer_ref" json:"issuer_ref"`
 }
 
 func pathListRoles(b *backend) *framework.Path {
@@ -374,6 +375,17 @@ func pathRoles(b *backend) *framework.Path {
 					Value: 30,
 				},
 			},
+			issuerRefParam: {
+				Type:    framework.TypeString,
+				Default: defaultRef,
+				Description: `
+				[Not applicable for Dynamic type] [Not applicable for OTP type] [Optional for CA type]
+				Reference to the issuer used to sign certificates for this role; either "default"
+				for the configured default issuer, an identifier or the name of an issuer.`,
+				DisplayAttrs: &framework.DisplayAttributes{
+					Name: "Issuer",
+				},
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -530,6 +542,18 @@ func (b *backend) pathRoleWrite(ctx context.Context, req *logical.Request, d *fr
 		if errorResponse != nil {
 			return errorResponse, nil
 		}
+
+		// Named issuers must exist; the default issuer is resolved at
+		// signing time, so that it can be changed
+		if role.IssuerRef != defaultRef {
+			issuer, err := fetchIssuer(ctx, req.Storage, role.IssuerRef)
+			if err != nil {
+				return nil, err
+			}
+			if issuer == nil {
+				return logical.ErrorResponse(fmt.Sprintf("issuer %q not found", role.IssuerRef)), nil
+			}
+		}
 		roleEntry = *role
 	} else {
 		return logical.ErrorResponse("invalid key type"), nil
@@ -567,6 +591,7 @@ func (b *backend) createCARole(allowedUsers, defaultUser, signer string, data *f
 		AlgorithmSigner:           signer,
 		Version:                   roleEntryVersion,
 		NotBeforeDuration:         time.Duration(data.Get("not_before_duration").(int)) * time.Second,
+		IssuerRef:                 data.Get(issuerRefParam).(string),
 	}
 
 	if !role.AllowUserCertificates && !role.AllowHostCertificates {
@@ -655,17 +680,17 @@ func (b *backend) checkUpgrade(ctx context.Context, s logical.Storage, n string,
 		// signing key type as we want to make ssh-rsa an explicitly notated
 		// algorithm choice.
 		var publicKey ssh.PublicKey
-		publicKeyEntry, err := caKey(ctx, s, caPublicKey)
+		issuer, err := fetchIssuer(ctx, s, defaultRef)
 		if err != nil {
 			b.Logger().Debug(fmt.Sprintf("failed to load public key entry while attempting to migrate: %v", err))
 			goto SKIPVERSION2
 		}
-		if publicKeyEntry == nil || publicKeyEntry.Key == "" {
+		if issuer == nil || issuer.PublicKey == "" {
 			b.Logger().Debug(fmt.Sprintf("got empty public key entry while attempting to migrate"))
 			goto SKIPVERSION2
 		}
 
-		publicKey, err = parsePublicSSHKey(publicKeyEntry.Key)
+		publicKey, err = parsePublicSSHKey(issuer.PublicKey)
 		if err == nil {
 			// Move an empty signing algorithm to an explicit ssh-rsa (SHA-1)
 			// if this key is of type RSA. This isn't a secure default but
@@ -758,6 +783,7 @@ func (b *backend) parseRole(role *sshRole) (map[string]interface{}, error) {
 			"allowed_user_key_lengths":    role.AllowedUserKeyTypesLengths,
 			"algorithm_signer":            role.AlgorithmSigner,
 			"not_before_duration":         int64(role.NotBeforeDuration.Seconds()),
+			"issuer_ref":                  role.IssuerRef,
 		}
 	case KeyTypeDynamic:
 		result = map[string]interface{}{
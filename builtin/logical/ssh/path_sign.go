@@ -160,17 +160,17 @@ func (b *backend) pathSignCertificate(ctx context.Context, req *logical.Request,
 		return logical.ErrorResponse(err.Error()), nil
 	}
 
-	privateKeyEntry, err := caKey(ctx, req.Storage, caPrivateKey)
+	issuer, err := fetchIssuer(ctx, req.Storage, role.IssuerRef)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read CA private key: %w", err)
 	}
-	if privateKeyEntry == nil || privateKeyEntry.Key == "" {
+	if issuer == nil || issuer.PrivateKey == "" {
 		return nil, fmt.Errorf("failed to read CA private key")
 	}
 
-	signer, err := ssh.ParsePrivateKey([]byte(privateKeyEntry.Key))
+	signer, err := issuer.signer()
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse stored CA private key: %w", err)
+		return nil, err
 	}
 
 	cBundle := creationBundle{
@@ -195,6 +195,10 @@ func (b *backend) pathSignCertificate(ctx context.Context, req *logical.Request,
 		return nil, fmt.Errorf("error marshaling signed certificate")
 	}
 
+	if err := storeCertificate(ctx, req.Storage, issuer.ID, certificate); err != nil {
+		return nil, fmt.Errorf("unable to store certificate: %w", err)
+	}
+
 	response := &logical.Response{
 		Data: map[string]interface{}{
 			"serial_number": strconv.FormatUint(certificate.Serial, 16),
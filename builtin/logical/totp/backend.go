@@ -3,11 +3,10 @@ package totp
 import (
 	"context"
 	"strings"
-	"time"
 
 	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/locksutil"
 	"github.com/hashicorp/vault/sdk/logical"
-	cache "github.com/patrickmn/go-cache"
 )
 
 func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
@@ -32,6 +31,8 @@ func Backend() *backend {
 		Paths: []*framework.Path{
 			pathListKeys(&b),
 			pathKeys(&b),
+			pathKeyUnlock(&b),
+			pathKeyResync(&b),
 			pathCode(&b),
 		},
 
@@ -39,7 +40,7 @@ func Backend() *backend {
 		BackendType: logical.TypeLogical,
 	}
 
-	b.usedCodes = cache.New(0, 30*time.Second)
+	b.keyLocks = locksutil.CreateLocks()
 
 	return &b
 }
@@ -47,7 +48,9 @@ func Backend() *backend {
 type backend struct {
 	*framework.Backend
 
-	usedCodes *cache.Cache
+	// keyLocks serializes the validation of the codes of a key, so that a
+	// code cannot be used twice by concurrent requests.
+	keyLocks []*locksutil.LockEntry
 }
 
 const backendHelp = `
@@ -15,6 +15,7 @@ import (
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/mitchellh/mapstructure"
 	otplib "github.com/pquerna/otp"
+	hotplib "github.com/pquerna/otp/hotp"
 	totplib "github.com/pquerna/otp/totp"
 )
 
@@ -1238,3 +1239,247 @@ func testAccStepValidateCode(t *testing.T, name string, code string, valid, expe
 		},
 	}
 }
+
+func TestBackend_codeReplayAndLockout(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, _ := createKey()
+
+	keyData := map[string]interface{}{
+		"issuer":              "Vault",
+		"account_name":        "Test",
+		"key":                 key,
+		"generate":            false,
+		"max_failed_attempts": 2,
+	}
+
+	code, _ := generateCode(key, 30, otplib.DigitsSix, otplib.AlgorithmSHA1)
+	previousCode, _ := totplib.GenerateCodeCustom(key, time.Now().Add(-30*time.Second), totplib.ValidateOpts{
+		Period:    30,
+		Digits:    otplib.DigitsSix,
+		Algorithm: otplib.AlgorithmSHA1,
+	})
+	invalidCode := "12345678"
+
+	logicaltest.Test(t, logicaltest.TestCase{
+		LogicalBackend: b,
+		Steps: []logicaltest.TestStep{
+			testAccStepCreateKey(t, "test", keyData, false),
+			testAccStepReadKeyUsage(t, "test", false, 0, false),
+			// Codes of distinct time steps within the skew can both be used once
+			testAccStepValidateCode(t, "test", code, true, false),
+			testAccStepValidateCode(t, "test", previousCode, true, false),
+			testAccStepValidateCode(t, "test", code, false, true),
+			testAccStepValidateCode(t, "test", previousCode, false, true),
+			testAccStepReadKeyUsage(t, "test", true, 0, false),
+			// The key is locked after two consecutive failures
+			testAccStepValidateCode(t, "test", invalidCode, false, false),
+			testAccStepReadKeyUsage(t, "test", true, 1, false),
+			testAccStepValidateCode(t, "test", invalidCode, false, false),
+			testAccStepReadKeyUsage(t, "test", true, 2, true),
+			testAccStepValidateCode(t, "test", invalidCode, false, true),
+			{
+				Operation: logical.UpdateOperation,
+				Path:      "keys/test/unlock",
+			},
+			testAccStepReadKeyUsage(t, "test", true, 0, false),
+			testAccStepValidateCode(t, "test", invalidCode, false, false),
+			// Recreating the key resets its usage
+			testAccStepCreateKey(t, "test", keyData, false),
+			testAccStepReadKeyUsage(t, "test", false, 0, false),
+			testAccStepValidateCode(t, "test", code, true, false),
+		},
+	})
+}
+
+func testAccStepReadKeyUsage(t *testing.T, name string, validated bool, failedAttempts int, locked bool) logicaltest.TestStep {
+	return logicaltest.TestStep{
+		Operation: logical.ReadOperation,
+		Path:      "keys/" + name,
+		Check: func(resp *logical.Response) error {
+			if resp == nil {
+				return fmt.Errorf("bad: %#v", resp)
+			}
+
+			var d struct {
+				LastValidationTime string `mapstructure:"last_validation_time"`
+				FailedAttempts     int    `mapstructure:"failed_attempts"`
+				Locked             bool   `mapstructure:"locked"`
+			}
+
+			if err := mapstructure.Decode(resp.Data, &d); err != nil {
+				return err
+			}
+
+			switch {
+			case (d.LastValidationTime != "") != validated:
+				return fmt.Errorf("unexpected last_validation_time: %q", d.LastValidationTime)
+			case d.FailedAttempts != failedAttempts:
+				return fmt.Errorf("failed_attempts should equal: %d, got %d", failedAttempts, d.FailedAttempts)
+			case d.Locked != locked:
+				return fmt.Errorf("locked should equal: %t", locked)
+			}
+			return nil
+		},
+	}
+}
+
+func TestBackend_hotpKey(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, _ := createKey()
+	hotpCode := func(counter uint64) string {
+		code, err := hotplib.GenerateCodeCustom(key, counter, hotplib.ValidateOpts{
+			Digits:    otplib.DigitsSix,
+			Algorithm: otplib.AlgorithmSHA1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	keyData := map[string]interface{}{
+		"type":         "hotp",
+		"issuer":       "Vault",
+		"account_name": "Test",
+		"key":          key,
+		"generate":     false,
+		"counter":      5,
+		"look_ahead":   2,
+	}
+
+	logicaltest.Test(t, logicaltest.TestCase{
+		LogicalBackend: b,
+		Steps: []logicaltest.TestStep{
+			testAccStepCreateKey(t, "test", keyData, false),
+			testAccStepReadHOTPCounter(t, "test", 5),
+			testAccStepValidateCode(t, "test", hotpCode(5), true, false),
+			// Codes cannot be used twice
+			testAccStepValidateCode(t, "test", hotpCode(5), false, false),
+			// Codes within the look-ahead window are accepted
+			testAccStepValidateCode(t, "test", hotpCode(8), true, false),
+			testAccStepReadHOTPCounter(t, "test", 9),
+			testAccStepValidateCode(t, "test", hotpCode(12), false, false),
+			// Resynchronize with two consecutive codes beyond the window
+			{
+				Operation: logical.UpdateOperation,
+				Path:      "keys/test/resync",
+				Data: map[string]interface{}{
+					"code":      hotpCode(40),
+					"next_code": hotpCode(42),
+				},
+				ErrorOk: true,
+				Check: func(resp *logical.Response) error {
+					if resp == nil || !resp.IsError() {
+						return fmt.Errorf("expected non-consecutive codes to be rejected, got: %#v", resp)
+					}
+					return nil
+				},
+			},
+			{
+				Operation: logical.UpdateOperation,
+				Path:      "keys/test/resync",
+				Data: map[string]interface{}{
+					"code":      hotpCode(40),
+					"next_code": hotpCode(41),
+				},
+			},
+			testAccStepReadHOTPCounter(t, "test", 42),
+			testAccStepValidateCode(t, "test", hotpCode(42), true, false),
+			// Vault generates the codes of the following counter values
+			{
+				Operation: logical.ReadOperation,
+				Path:      "code/test",
+				Check: func(resp *logical.Response) error {
+					if resp.Data["code"] != hotpCode(43) {
+						return fmt.Errorf("bad code: %v", resp.Data["code"])
+					}
+					return nil
+				},
+			},
+			testAccStepReadHOTPCounter(t, "test", 44),
+		},
+	})
+}
+
+func TestBackend_hotpKeyURL(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyData := map[string]interface{}{
+		"type":         "hotp",
+		"issuer":       "Vault",
+		"account_name": "Test",
+		"generate":     true,
+		"key_size":     20,
+		"exported":     true,
+		"qr_size":      200,
+		"counter":      3,
+	}
+
+	var generatedURL string
+	logicaltest.Test(t, logicaltest.TestCase{
+		LogicalBackend: b,
+		Steps: []logicaltest.TestStep{
+			{
+				Operation: logical.UpdateOperation,
+				Path:      "keys/generated",
+				Data:      keyData,
+				Check: func(resp *logical.Response) error {
+					generatedURL = resp.Data["url"].(string)
+					urlObject, err := url.Parse(generatedURL)
+					if err != nil {
+						return err
+					}
+					if urlObject.Host != "hotp" || urlObject.Query().Get("counter") != "3" {
+						return fmt.Errorf("bad url: %s", generatedURL)
+					}
+					return nil
+				},
+			},
+			{
+				PreFlight: func(req *logical.Request) error {
+					req.Data = map[string]interface{}{"url": generatedURL}
+					return nil
+				},
+				Operation: logical.UpdateOperation,
+				Path:      "keys/imported",
+			},
+			testAccStepReadHOTPCounter(t, "imported", 3),
+		},
+	})
+}
+
+func testAccStepReadHOTPCounter(t *testing.T, name string, counter uint64) logicaltest.TestStep {
+	return logicaltest.TestStep{
+		Operation: logical.ReadOperation,
+		Path:      "keys/" + name,
+		Check: func(resp *logical.Response) error {
+			if resp == nil {
+				return fmt.Errorf("bad: %#v", resp)
+			}
+			if resp.Data["type"] != "hotp" {
+				return fmt.Errorf("type should equal: hotp, got %v", resp.Data["type"])
+			}
+			if resp.Data["counter"] != counter {
+				return fmt.Errorf("counter should equal: %d, got %v", counter, resp.Data["counter"])
+			}
+			return nil
+		},
+	}
+}
@@ -3,11 +3,15 @@ package totp
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/locksutil"
 	"github.com/hashicorp/vault/sdk/logical"
 	otplib "github.com/pquerna/otp"
+	hotplib "github.com/pquerna/otp/hotp"
 	totplib "github.com/pquerna/otp/totp"
 )
 
@@ -38,6 +42,10 @@ func pathCode(b *backend) *framework.Path {
 func (b *backend) pathReadCode(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	name := data.Get("name").(string)
 
+	lock := locksutil.LockForKey(b.keyLocks, name)
+	lock.Lock()
+	defer lock.Unlock()
+
 	// Get the key
 	key, err := b.Key(ctx, req.Storage, name)
 	if err != nil {
@@ -47,6 +55,10 @@ func (b *backend) pathReadCode(ctx context.Context, req *logical.Request, data *
 		return logical.ErrorResponse(fmt.Sprintf("unknown key: %s", name)), nil
 	}
 
+	if key.keyType() == keyTypeHOTP {
+		return b.readHOTPCode(ctx, req.Storage, name, key)
+	}
+
 	// Generate password using totp library
 	totpToken, err := totplib.GenerateCodeCustom(key.Key, time.Now(), totplib.ValidateOpts{
 		Period:    key.Period,
@@ -74,6 +86,10 @@ func (b *backend) pathValidateCode(ctx context.Context, req *logical.Request, da
 		return logical.ErrorResponse("the code value is required"), nil
 	}
 
+	lock := locksutil.LockForKey(b.keyLocks, name)
+	lock.Lock()
+	defer lock.Unlock()
+
 	// Get the key's stored values
 	key, err := b.Key(ctx, req.Storage, name)
 	if err != nil {
@@ -83,40 +99,175 @@ func (b *backend) pathValidateCode(ctx context.Context, req *logical.Request, da
 		return logical.ErrorResponse(fmt.Sprintf("unknown key: %s", name)), nil
 	}
 
-	usedName := fmt.Sprintf("%s_%s", name, code)
+	usage, err := b.keyUsage(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if usage.locked(now) {
+		return logical.ErrorResponse("key is locked after too many failed validation attempts"), nil
+	}
+	if usage.Locked {
+		// The lockout has expired
+		usage.Locked = false
+		usage.LockedUntil = time.Time{}
+		usage.FailedAttempts = 0
+	}
+
+	var valid bool
+	switch key.keyType() {
+	case keyTypeHOTP:
+		// Codes of counter values before the current one are rejected, so
+		// that each code can only be used once
+		var counter uint64
+		counter, valid, err = validateHOTPCode(key, code, usage.Counter, key.LookAhead)
+		if err != nil {
+			return logical.ErrorResponse("an error occurred while validating the code"), err
+		}
+		if valid {
+			usage.Counter = counter + 1
+		}
+
+	default:
+		var timeStep uint64
+		timeStep, valid, err = validateCode(key, code, now)
+		if err != nil {
+			return logical.ErrorResponse("an error occurred while validating the code"), err
+		}
 
-	_, ok := b.usedCodes.Get(usedName)
-	if ok {
-		return logical.ErrorResponse("code already used; wait until the next time period"), nil
+		// Time steps can no longer be used once they are outside of the
+		// validation window
+		usage.pruneTimeSteps(currentTimeStep(key, now) - uint64(key.Skew))
+
+		if valid && usage.used(timeStep) {
+			return logical.ErrorResponse("code already used; wait until the next time period"), nil
+		}
+		if valid {
+			usage.UsedTimeSteps = append(usage.UsedTimeSteps, timeStep)
+		}
 	}
 
-	valid, err := totplib.ValidateCustom(code, key.Key, time.Now(), totplib.ValidateOpts{
-		Period:    key.Period,
-		Skew:      key.Skew,
+	if valid {
+		usage.LastValidationTime = now
+		usage.FailedAttempts = 0
+	} else {
+		usage.FailedAttempts++
+		if key.MaxFailedAttempts > 0 && usage.FailedAttempts >= key.MaxFailedAttempts {
+			usage.Locked = true
+			if key.LockoutDuration > 0 {
+				usage.LockedUntil = now.Add(key.LockoutDuration)
+			}
+		}
+	}
+
+	if err := b.putKeyUsage(ctx, req.Storage, name, usage); err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"valid": valid,
+		},
+	}, nil
+}
+
+// validateCode checks the code against the time steps of the validation
+// window around now, returning the time step it was generated for if it is
+// valid.
+func validateCode(key *keyEntry, code string, now time.Time) (uint64, bool, error) {
+	current := currentTimeStep(key, now)
+	timeSteps := []uint64{current}
+	for i := uint64(1); i <= uint64(key.Skew); i++ {
+		timeSteps = append(timeSteps, current+i, current-i)
+	}
+
+	// A TOTP code is the HOTP code of its time step
+	for _, timeStep := range timeSteps {
+		valid, err := hotpCodeMatches(key, code, timeStep)
+		if err != nil || valid {
+			return timeStep, valid, err
+		}
+	}
+
+	return 0, false, nil
+}
+
+// readHOTPCode returns the code of the current counter value of the key and
+// moves the counter forward, as a token would.
+func (b *backend) readHOTPCode(ctx context.Context, s logical.Storage, name string, key *keyEntry) (*logical.Response, error) {
+	usage, err := b.keyUsage(ctx, s, name)
+	if err != nil {
+		return nil, err
+	}
+
+	hotpToken, err := hotplib.GenerateCodeCustom(key.Key, usage.Counter, hotplib.ValidateOpts{
 		Digits:    key.Digits,
 		Algorithm: key.Algorithm,
 	})
-	if err != nil && err != otplib.ErrValidateInputInvalidLength {
-		return logical.ErrorResponse("an error occurred while validating the code"), err
+	if err != nil {
+		return nil, err
 	}
 
-	// Take the key skew, add two for behind and in front, and multiple that by
-	// the period to cover the full possibility of the validity of the key
-	err = b.usedCodes.Add(usedName, nil, time.Duration(
-		int64(time.Second)*
-			int64(key.Period)*
-			int64((2+key.Skew))))
-	if err != nil {
-		return nil, fmt.Errorf("error adding code to used cache: %w", err)
+	usage.Counter++
+	if err := b.putKeyUsage(ctx, s, name, usage); err != nil {
+		return nil, err
 	}
 
 	return &logical.Response{
 		Data: map[string]interface{}{
-			"valid": valid,
+			"code": hotpToken,
 		},
 	}, nil
 }
 
+// validateHOTPCode checks the code against the counter values from counter to
+// counter+lookAhead, returning the counter value it was generated for if it is
+// valid.
+func validateHOTPCode(key *keyEntry, code string, counter uint64, lookAhead int) (uint64, bool, error) {
+	for c := counter; c <= counter+uint64(lookAhead); c++ {
+		valid, err := hotpCodeMatches(key, code, c)
+		if err != nil || valid {
+			return c, valid, err
+		}
+	}
+	return 0, false, nil
+}
+
+func hotpCodeMatches(key *keyEntry, code string, counter uint64) (bool, error) {
+	valid, err := hotplib.ValidateCustom(code, counter, key.Key, hotplib.ValidateOpts{
+		Digits:    key.Digits,
+		Algorithm: key.Algorithm,
+	})
+	if err == otplib.ErrValidateInputInvalidLength {
+		return false, nil
+	}
+	return valid, err
+}
+
+// generateHOTPKey generates a HOTP key whose url holds the initial counter
+// value, which authenticator applications require.
+func generateHOTPKey(opts hotplib.GenerateOpts, counter uint64) (*otplib.Key, error) {
+	keyObject, err := hotplib.Generate(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	keyURL, err := url.Parse(keyObject.String())
+	if err != nil {
+		return nil, err
+	}
+	query := keyURL.Query()
+	query.Set("counter", strconv.FormatUint(counter, 10))
+	keyURL.RawQuery = query.Encode()
+
+	return otplib.NewKeyFromURL(keyURL.String())
+}
+
+func currentTimeStep(key *keyEntry, now time.Time) uint64 {
+	return uint64(now.Unix()) / uint64(key.Period)
+}
+
 const pathCodeHelpSyn = `
 Request time-based one-time use password or validate a password for a certain key .
 `
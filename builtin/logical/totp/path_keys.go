@@ -10,13 +10,21 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/locksutil"
 	"github.com/hashicorp/vault/sdk/logical"
 	otplib "github.com/pquerna/otp"
+	hotplib "github.com/pquerna/otp/hotp"
 	totplib "github.com/pquerna/otp/totp"
 )
 
+const (
+	keyTypeTOTP = "totp"
+	keyTypeHOTP = "hotp"
+)
+
 func pathListKeys(b *backend) *framework.Path {
 	return &framework.Path{
 		Pattern: "keys/?$",
@@ -39,6 +47,12 @@ func pathKeys(b *backend) *framework.Path {
 				Description: "Name of the key.",
 			},
 
+			"type": {
+				Type:        framework.TypeString,
+				Default:     keyTypeTOTP,
+				Description: `The type of one-time passwords of the key: "totp" for time-based or "hotp" for counter-based passwords. Set from the url if one is passed.`,
+			},
+
 			"generate": {
 				Type:        framework.TypeBool,
 				Default:     false,
@@ -106,6 +120,30 @@ func pathKeys(b *backend) *framework.Path {
 				Type:        framework.TypeString,
 				Description: `A TOTP url string containing all of the parameters for key setup. Only used if generate is false.`,
 			},
+
+			"counter": {
+				Type:        framework.TypeInt,
+				Default:     0,
+				Description: `The initial counter value of a HOTP key. Only used if type is hotp.`,
+			},
+
+			"look_ahead": {
+				Type:        framework.TypeInt,
+				Default:     10,
+				Description: `The number of counter values after the current one that are accepted when validating a HOTP code, to allow for codes generated but not used. Only used if type is hotp.`,
+			},
+
+			"max_failed_attempts": {
+				Type:        framework.TypeInt,
+				Default:     0,
+				Description: `The number of consecutive failed validation attempts after which the key is locked. If this value is 0, the key is never locked.`,
+			},
+
+			"lockout_duration": {
+				Type:        framework.TypeDurationSecond,
+				Default:     0,
+				Description: `The length of time the key stays locked after max_failed_attempts consecutive failed validation attempts. If this value is 0, the key stays locked until it is unlocked.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -119,6 +157,57 @@ func pathKeys(b *backend) *framework.Path {
 	}
 }
 
+func pathKeyUnlock(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "keys/" + framework.GenericNameWithAtRegex("name") + "/unlock",
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the key.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathKeyUnlock,
+		},
+
+		HelpSynopsis:    pathKeyUnlockHelpSyn,
+		HelpDescription: pathKeyUnlockHelpDesc,
+	}
+}
+
+func pathKeyResync(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "keys/" + framework.GenericNameWithAtRegex("name") + "/resync",
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the key.",
+			},
+			"code": {
+				Type:        framework.TypeString,
+				Description: "A HOTP code generated by the token.",
+			},
+			"next_code": {
+				Type:        framework.TypeString,
+				Description: "The HOTP code generated by the token right after code.",
+			},
+			"look_ahead": {
+				Type:        framework.TypeInt,
+				Default:     100,
+				Description: "The number of counter values after the current one to search for the codes.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathKeyResync,
+		},
+
+		HelpSynopsis:    pathKeyResyncHelpSyn,
+		HelpDescription: pathKeyResyncHelpDesc,
+	}
+}
+
 func (b *backend) Key(ctx context.Context, s logical.Storage, n string) (*keyEntry, error) {
 	entry, err := s.Get(ctx, "key/"+n)
 	if err != nil {
@@ -136,15 +225,146 @@ func (b *backend) Key(ctx context.Context, s logical.Storage, n string) (*keyEnt
 	return &result, nil
 }
 
+// keyUsage returns the validation state of the key.
+func (b *backend) keyUsage(ctx context.Context, s logical.Storage, n string) (*keyUsageEntry, error) {
+	entry, err := s.Get(ctx, "usage/"+n)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &keyUsageEntry{}
+	if entry != nil {
+		if err := entry.DecodeJSON(result); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+func (b *backend) putKeyUsage(ctx context.Context, s logical.Storage, n string, usage *keyUsageEntry) error {
+	entry, err := logical.StorageEntryJSON("usage/"+n, usage)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, entry)
+}
+
 func (b *backend) pathKeyDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	err := req.Storage.Delete(ctx, "key/"+data.Get("name").(string))
+	name := data.Get("name").(string)
+
+	lock := locksutil.LockForKey(b.keyLocks, name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	err := req.Storage.Delete(ctx, "key/"+name)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := req.Storage.Delete(ctx, "usage/"+name); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathKeyUnlock(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+
+	lock := locksutil.LockForKey(b.keyLocks, name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	key, err := b.Key(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return logical.ErrorResponse(fmt.Sprintf("unknown key: %s", name)), nil
+	}
+
+	usage, err := b.keyUsage(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+
+	usage.Locked = false
+	usage.LockedUntil = time.Time{}
+	usage.FailedAttempts = 0
+	if err := b.putKeyUsage(ctx, req.Storage, name, usage); err != nil {
+		return nil, err
+	}
+
 	return nil, nil
 }
 
+func (b *backend) pathKeyResync(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+	code := data.Get("code").(string)
+	nextCode := data.Get("next_code").(string)
+	lookAhead := data.Get("look_ahead").(int)
+
+	if code == "" || nextCode == "" {
+		return logical.ErrorResponse("the code and next_code values are required"), nil
+	}
+	if lookAhead <= 0 {
+		return logical.ErrorResponse("the look_ahead value must be greater than zero"), nil
+	}
+
+	lock := locksutil.LockForKey(b.keyLocks, name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	key, err := b.Key(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return logical.ErrorResponse(fmt.Sprintf("unknown key: %s", name)), nil
+	}
+	if key.keyType() != keyTypeHOTP {
+		return logical.ErrorResponse("only hotp keys can be resynchronized"), nil
+	}
+
+	usage, err := b.keyUsage(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if usage.locked(time.Now()) {
+		return logical.ErrorResponse("key is locked after too many failed validation attempts"), nil
+	}
+
+	// Both codes must match consecutive counter values
+	var counter uint64
+	valid := false
+	for c := usage.Counter; c <= usage.Counter+uint64(lookAhead) && !valid; c++ {
+		valid, err = hotpCodeMatches(key, code, c)
+		if err == nil && valid {
+			valid, err = hotpCodeMatches(key, nextCode, c+1)
+		}
+		if err != nil {
+			return logical.ErrorResponse("an error occurred while validating the codes"), err
+		}
+		counter = c
+	}
+	if !valid {
+		return logical.ErrorResponse("the codes do not match consecutive counter values within the look-ahead window"), nil
+	}
+
+	usage.Counter = counter + 2
+	usage.FailedAttempts = 0
+	if err := b.putKeyUsage(ctx, req.Storage, name, usage); err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"counter": usage.Counter,
+		},
+	}, nil
+}
+
 func (b *backend) pathKeyRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	key, err := b.Key(ctx, req.Storage, data.Get("name").(string))
 	if err != nil {
@@ -154,19 +374,43 @@ func (b *backend) pathKeyRead(ctx context.Context, req *logical.Request, data *f
 		return nil, nil
 	}
 
+	usage, err := b.keyUsage(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+
 	// Translate algorithm back to string
 	algorithm := key.Algorithm.String()
 
 	// Return values of key
-	return &logical.Response{
+	resp := &logical.Response{
 		Data: map[string]interface{}{
-			"issuer":       key.Issuer,
-			"account_name": key.AccountName,
-			"period":       key.Period,
-			"algorithm":    algorithm,
-			"digits":       key.Digits,
+			"type":                 key.keyType(),
+			"issuer":               key.Issuer,
+			"account_name":         key.AccountName,
+			"algorithm":            algorithm,
+			"digits":               key.Digits,
+			"max_failed_attempts":  key.MaxFailedAttempts,
+			"lockout_duration":     int64(key.LockoutDuration.Seconds()),
+			"failed_attempts":      usage.FailedAttempts,
+			"locked":               usage.locked(time.Now()),
+			"last_validation_time": "",
 		},
-	}, nil
+	}
+	if key.keyType() == keyTypeHOTP {
+		resp.Data["counter"] = usage.Counter
+		resp.Data["look_ahead"] = key.LookAhead
+	} else {
+		resp.Data["period"] = key.Period
+	}
+	if !usage.LastValidationTime.IsZero() {
+		resp.Data["last_validation_time"] = usage.LastValidationTime.Format(time.RFC3339)
+	}
+	if usage.locked(time.Now()) && !usage.LockedUntil.IsZero() {
+		resp.Data["locked_until"] = usage.LockedUntil.Format(time.RFC3339)
+	}
+
+	return resp, nil
 }
 
 func (b *backend) pathKeyList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
@@ -192,6 +436,11 @@ func (b *backend) pathKeyCreate(ctx context.Context, req *logical.Request, data
 	qrSize := data.Get("qr_size").(int)
 	keySize := data.Get("key_size").(int)
 	inputURL := data.Get("url").(string)
+	maxFailedAttempts := data.Get("max_failed_attempts").(int)
+	lockoutDuration := time.Duration(data.Get("lockout_duration").(int)) * time.Second
+	keyType := data.Get("type").(string)
+	counter := data.Get("counter").(int)
+	lookAhead := data.Get("look_ahead").(int)
 
 	if generate {
 		if keyString != "" {
@@ -210,6 +459,13 @@ func (b *backend) pathKeyCreate(ctx context.Context, req *logical.Request, data
 			return logical.ErrorResponse("an error occurred while parsing url string"), err
 		}
 
+		// Read type
+		if urlObject.Host == keyTypeHOTP {
+			keyType = keyTypeHOTP
+		} else {
+			keyType = keyTypeTOTP
+		}
+
 		// Set up query object
 		urlQuery := urlObject.Query()
 		path := strings.TrimPrefix(urlObject.Path, "/")
@@ -260,6 +516,22 @@ func (b *backend) pathKeyCreate(ctx context.Context, req *logical.Request, data
 		if algorithmQuery != "" {
 			algorithm = algorithmQuery
 		}
+
+		// Read counter
+		counterQuery := urlQuery.Get("counter")
+		if counterQuery != "" {
+			counterInt, err := strconv.Atoi(counterQuery)
+			if err != nil {
+				return logical.ErrorResponse("an error occurred while parsing counter value in url"), err
+			}
+			counter = counterInt
+		}
+	}
+
+	switch keyType {
+	case keyTypeTOTP, keyTypeHOTP:
+	default:
+		return logical.ErrorResponse("the type value must be totp or hotp"), nil
 	}
 
 	// Translate digits and algorithm to a format the totp library understands
@@ -306,6 +578,22 @@ func (b *backend) pathKeyCreate(ctx context.Context, req *logical.Request, data
 		return logical.ErrorResponse("the key_size value must be greater than zero"), nil
 	}
 
+	if maxFailedAttempts < 0 {
+		return logical.ErrorResponse("the max_failed_attempts value must be greater than or equal to zero"), nil
+	}
+
+	if lockoutDuration < 0 {
+		return logical.ErrorResponse("the lockout_duration value must be greater than or equal to zero"), nil
+	}
+
+	if counter < 0 {
+		return logical.ErrorResponse("the counter value must be greater than or equal to zero"), nil
+	}
+
+	if lookAhead < 0 {
+		return logical.ErrorResponse("the look_ahead value must be greater than or equal to zero"), nil
+	}
+
 	// Period, Skew and Key Size need to be unsigned ints
 	uintPeriod := uint(period)
 	uintSkew := uint(skew)
@@ -325,15 +613,29 @@ func (b *backend) pathKeyCreate(ctx context.Context, req *logical.Request, data
 		}
 
 		// Generate a new key
-		keyObject, err := totplib.Generate(totplib.GenerateOpts{
-			Issuer:      issuer,
-			AccountName: accountName,
-			Period:      uintPeriod,
-			Digits:      keyDigits,
-			Algorithm:   keyAlgorithm,
-			SecretSize:  uintKeySize,
-			Rand:        b.GetRandomReader(),
-		})
+		var keyObject *otplib.Key
+		var err error
+		switch keyType {
+		case keyTypeHOTP:
+			keyObject, err = generateHOTPKey(hotplib.GenerateOpts{
+				Issuer:      issuer,
+				AccountName: accountName,
+				Digits:      keyDigits,
+				Algorithm:   keyAlgorithm,
+				SecretSize:  uintKeySize,
+				Rand:        b.GetRandomReader(),
+			}, uint64(counter))
+		default:
+			keyObject, err = totplib.Generate(totplib.GenerateOpts{
+				Issuer:      issuer,
+				AccountName: accountName,
+				Period:      uintPeriod,
+				Digits:      keyDigits,
+				Algorithm:   keyAlgorithm,
+				SecretSize:  uintKeySize,
+				Rand:        b.GetRandomReader(),
+			})
+		}
 		if err != nil {
 			return logical.ErrorResponse("an error occurred while generating a key"), err
 		}
@@ -386,15 +688,23 @@ func (b *backend) pathKeyCreate(ctx context.Context, req *logical.Request, data
 		}
 	}
 
+	lock := locksutil.LockForKey(b.keyLocks, name)
+	lock.Lock()
+	defer lock.Unlock()
+
 	// Store it
 	entry, err := logical.StorageEntryJSON("key/"+name, &keyEntry{
-		Key:         keyString,
-		Issuer:      issuer,
-		AccountName: accountName,
-		Period:      uintPeriod,
-		Algorithm:   keyAlgorithm,
-		Digits:      keyDigits,
-		Skew:        uintSkew,
+		Key:               keyString,
+		Issuer:            issuer,
+		AccountName:       accountName,
+		Period:            uintPeriod,
+		Algorithm:         keyAlgorithm,
+		Digits:            keyDigits,
+		Skew:              uintSkew,
+		MaxFailedAttempts: maxFailedAttempts,
+		LockoutDuration:   lockoutDuration,
+		Type:              keyType,
+		LookAhead:         lookAhead,
 	})
 	if err != nil {
 		return nil, err
@@ -403,6 +713,14 @@ func (b *backend) pathKeyCreate(ctx context.Context, req *logical.Request, data
 		return nil, err
 	}
 
+	// The validation state of a previous key with the same name does not
+	// apply to the new key
+	if err := b.putKeyUsage(ctx, req.Storage, name, &keyUsageEntry{
+		Counter: uint64(counter),
+	}); err != nil {
+		return nil, err
+	}
+
 	return response, nil
 }
 
@@ -414,6 +732,60 @@ type keyEntry struct {
 	Algorithm   otplib.Algorithm `json:"algorithm" mapstructure:"algorithm" structs:"algorithm"`
 	Digits      otplib.Digits    `json:"digits" mapstructure:"digits" structs:"digits"`
 	Skew        uint             `json:"skew" mapstructure:"skew" structs:"skew"`
+
+	MaxFailedAttempts int           `json:"max_failed_attempts" mapstructure:"max_failed_attempts" structs:"max_failed_attempts"`
+	LockoutDuration   time.Duration `json:"lockout_duration" mapstructure:"lockout_duration" structs:"lockout_duration"`
+
+	// Type is empty for the TOTP keys created before HOTP keys were
+	// supported.
+	Type      string `json:"type" mapstructure:"type" structs:"type"`
+	LookAhead int    `json:"look_ahead" mapstructure:"look_ahead" structs:"look_ahead"`
+}
+
+func (k *keyEntry) keyType() string {
+	if k.Type == "" {
+		return keyTypeTOTP
+	}
+	return k.Type
+}
+
+// keyUsageEntry tracks the validation of the codes of a key. It is stored
+// apart from the key so that it can be updated on every validation.
+type keyUsageEntry struct {
+	// UsedTimeSteps are the time steps of the codes validated within the
+	// validation window, which cannot be used again.
+	UsedTimeSteps      []uint64  `json:"used_time_steps" mapstructure:"used_time_steps" structs:"used_time_steps"`
+	LastValidationTime time.Time `json:"last_validation_time" mapstructure:"last_validation_time" structs:"last_validation_time"`
+	FailedAttempts     int       `json:"failed_attempts" mapstructure:"failed_attempts" structs:"failed_attempts"`
+	Locked             bool      `json:"locked" mapstructure:"locked" structs:"locked"`
+	// LockedUntil is zero if the key stays locked until it is unlocked.
+	LockedUntil time.Time `json:"locked_until" mapstructure:"locked_until" structs:"locked_until"`
+	// Counter is the next counter value of a HOTP key.
+	Counter uint64 `json:"counter" mapstructure:"counter" structs:"counter"`
+}
+
+func (u *keyUsageEntry) locked(now time.Time) bool {
+	return u.Locked && (u.LockedUntil.IsZero() || now.Before(u.LockedUntil))
+}
+
+func (u *keyUsageEntry) used(timeStep uint64) bool {
+	for _, used := range u.UsedTimeSteps {
+		if used == timeStep {
+			return true
+		}
+	}
+	return false
+}
+
+// pruneTimeSteps forgets the used time steps before the given one.
+func (u *keyUsageEntry) pruneTimeSteps(oldest uint64) {
+	var kept []uint64
+	for _, used := range u.UsedTimeSteps {
+		if used >= oldest {
+			kept = append(kept, used)
+		}
+	}
+	u.UsedTimeSteps = kept
 }
 
 const pathKeyHelpSyn = `
@@ -424,3 +796,23 @@ const pathKeyHelpDesc = `
 This path lets you manage the keys that can be created with this backend.
 
 `
+
+const pathKeyResyncHelpSyn = `
+Resynchronize the counter of a HOTP key with its token.
+`
+
+const pathKeyResyncHelpDesc = `
+This path resynchronizes the counter of a HOTP key whose token has generated
+more codes than the validation look-ahead window allows. Two consecutive codes
+of the token are searched for within a larger window; once found, the counter
+is moved past them.
+`
+
+const pathKeyUnlockHelpSyn = `
+Unlock a key locked after too many failed validation attempts.
+`
+
+const pathKeyUnlockHelpDesc = `
+This path unlocks a key which was locked after max_failed_attempts consecutive
+failed code validations, and resets its count of failed attempts.
+`
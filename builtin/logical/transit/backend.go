@@ -58,6 +58,10 @@ func Backend(ctx context.Context, conf *logical.BackendConfig) (*backend, error)
 			b.pathRandom(),
 			b.pathHash(),
 			b.pathHMAC(),
+			b.pathCMACVerify(),
+			b.pathCMAC(),
+			b.pathEncode(),
+			b.pathDecode(),
 			b.pathSign(),
 			b.pathVerify(),
 			b.pathBackup(),
@@ -0,0 +1,373 @@
+package transit
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/mitchellh/mapstructure"
+)
+
+// batchRequestCMACItem represents a request item for batch processing.
+// A map type allows us to distinguish between empty and missing values.
+type batchRequestCMACItem map[string]string
+
+// batchResponseCMACItem represents a response item for batch processing
+type batchResponseCMACItem struct {
+	// CMAC for the input present in the corresponding batch request item
+	CMAC string `json:"cmac,omitempty" mapstructure:"cmac"`
+
+	// Valid indicates whether the CMAC matches the CMAC derived from the input
+	Valid bool `json:"valid,omitempty" mapstructure:"valid"`
+
+	// Error, if set represents a failure encountered while processing a
+	// corresponding batch request item
+	Error string `json:"error,omitempty" mapstructure:"error"`
+
+	// See batchResponseHMACItem: 'err' should never be serialized.
+	err error
+}
+
+func (b *backend) pathCMAC() *framework.Path {
+	return &framework.Path{
+		Pattern: "cmac/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "The MAC key to use for the CMAC function",
+			},
+
+			"input": {
+				Type:        framework.TypeString,
+				Description: "The base64-encoded input data",
+			},
+
+			"mac_length": {
+				Type: framework.TypeInt,
+				Description: `The length of the MAC in bytes. Defaults to the full
+length for the key type: 16 bytes for AES-CMAC keys, 32 bytes for
+kmac128 keys and 64 bytes for kmac256 keys. AES-CMAC MACs may be
+truncated to between 8 and 16 bytes; KMAC MACs may be between 8
+and 64 bytes.`,
+			},
+
+			"key_version": {
+				Type: framework.TypeInt,
+				Description: `The version of the key to use for generating the CMAC.
+Must be 0 (for latest) or a value greater than or equal
+to the min_encryption_version configured on the key.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathCMACWrite,
+		},
+
+		HelpSynopsis:    pathCMACHelpSyn,
+		HelpDescription: pathCMACHelpDesc,
+	}
+}
+
+func (b *backend) pathCMACVerify() *framework.Path {
+	return &framework.Path{
+		Pattern: "cmac/verify/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "The MAC key to use for verifying the CMAC",
+			},
+
+			"input": {
+				Type:        framework.TypeString,
+				Description: "The base64-encoded input data",
+			},
+
+			"cmac": {
+				Type:        framework.TypeString,
+				Description: "The CMAC, including the vault header",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathCMACVerifyWrite,
+		},
+
+		HelpSynopsis:    pathCMACVerifyHelpSyn,
+		HelpDescription: pathCMACVerifyHelpDesc,
+	}
+}
+
+func (b *backend) getCMACPolicy(ctx context.Context, req *logical.Request, name string) (*keysutil.Policy, *logical.Response, error) {
+	p, _, err := b.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: req.Storage,
+		Name:    name,
+	}, b.GetRandomReader())
+	if err != nil {
+		return nil, nil, err
+	}
+	if p == nil {
+		return nil, logical.ErrorResponse("MAC key not found"), logical.ErrInvalidRequest
+	}
+	if !b.System().CachingDisabled() {
+		p.Lock(false)
+	}
+
+	if !p.Type.CMACSupported() {
+		p.Unlock()
+		return nil, logical.ErrorResponse(fmt.Sprintf("key type %v does not support CMAC", p.Type)), logical.ErrInvalidRequest
+	}
+
+	return p, nil, nil
+}
+
+func (b *backend) pathCMACWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	ver := d.Get("key_version").(int)
+	macLength := d.Get("mac_length").(int)
+
+	p, resp, err := b.getCMACPolicy(ctx, req, name)
+	if p == nil {
+		return resp, err
+	}
+
+	switch {
+	case ver == 0:
+		ver = p.LatestVersion
+	case ver < 0 || ver > p.LatestVersion:
+		p.Unlock()
+		return logical.ErrorResponse("cannot generate CMAC: key version does not exist"), logical.ErrInvalidRequest
+	case p.MinEncryptionVersion > 0 && ver < p.MinEncryptionVersion:
+		p.Unlock()
+		return logical.ErrorResponse("cannot generate CMAC: version is too old (disallowed by policy)"), logical.ErrInvalidRequest
+	}
+
+	batchInputRaw := d.Raw["batch_input"]
+	var batchInputItems []batchRequestCMACItem
+	if batchInputRaw != nil {
+		err = mapstructure.Decode(batchInputRaw, &batchInputItems)
+		if err != nil {
+			p.Unlock()
+			return nil, fmt.Errorf("failed to parse batch input: %w", err)
+		}
+
+		if len(batchInputItems) == 0 {
+			p.Unlock()
+			return logical.ErrorResponse("missing batch input to process"), logical.ErrInvalidRequest
+		}
+	} else {
+		valueRaw, ok := d.GetOk("input")
+		if !ok {
+			p.Unlock()
+			return logical.ErrorResponse("missing input for CMAC"), logical.ErrInvalidRequest
+		}
+
+		batchInputItems = make([]batchRequestCMACItem, 1)
+		batchInputItems[0] = batchRequestCMACItem{
+			"input": valueRaw.(string),
+		}
+	}
+
+	response := make([]batchResponseCMACItem, len(batchInputItems))
+
+	for i, item := range batchInputItems {
+		rawInput, ok := item["input"]
+		if !ok {
+			response[i].Error = "missing input for CMAC"
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		input, err := base64.StdEncoding.DecodeString(rawInput)
+		if err != nil {
+			response[i].Error = fmt.Sprintf("unable to decode input as base64: %s", err)
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		mac, err := p.CMAC(ver, input, macLength)
+		if err != nil {
+			response[i].Error = err.Error()
+			switch err.(type) {
+			case errutil.UserError:
+				response[i].err = logical.ErrInvalidRequest
+			default:
+				response[i].err = err
+			}
+			continue
+		}
+
+		response[i].CMAC = fmt.Sprintf("vault:v%s:%s", strconv.Itoa(ver), base64.StdEncoding.EncodeToString(mac))
+	}
+
+	p.Unlock()
+
+	// Generate the response
+	resp = &logical.Response{}
+	if batchInputRaw != nil {
+		resp.Data = map[string]interface{}{
+			"batch_results": response,
+		}
+	} else {
+		if response[0].Error != "" || response[0].err != nil {
+			if response[0].Error != "" {
+				return logical.ErrorResponse(response[0].Error), response[0].err
+			}
+			return nil, response[0].err
+		}
+		resp.Data = map[string]interface{}{
+			"cmac": response[0].CMAC,
+		}
+	}
+
+	return resp, nil
+}
+
+func (b *backend) pathCMACVerifyWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	p, resp, err := b.getCMACPolicy(ctx, req, name)
+	if p == nil {
+		return resp, err
+	}
+
+	batchInputRaw := d.Raw["batch_input"]
+	var batchInputItems []batchRequestCMACItem
+	if batchInputRaw != nil {
+		err := mapstructure.Decode(batchInputRaw, &batchInputItems)
+		if err != nil {
+			p.Unlock()
+			return nil, fmt.Errorf("failed to parse batch input: %w", err)
+		}
+
+		if len(batchInputItems) == 0 {
+			p.Unlock()
+			return logical.ErrorResponse("missing batch input to process"), logical.ErrInvalidRequest
+		}
+	} else {
+		// use empty string if input is missing - not an error
+		batchInputItems = make([]batchRequestCMACItem, 1)
+		batchInputItems[0] = batchRequestCMACItem{
+			"input": d.Get("input").(string),
+			"cmac":  d.Get("cmac").(string),
+		}
+	}
+
+	response := make([]batchResponseCMACItem, len(batchInputItems))
+
+	for i, item := range batchInputItems {
+		rawInput, ok := item["input"]
+		if !ok {
+			response[i].Error = "missing input"
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		input, err := base64.StdEncoding.DecodeString(rawInput)
+		if err != nil {
+			response[i].Error = fmt.Sprintf("unable to decode input as base64: %s", err)
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		verificationCMAC, ok := item["cmac"]
+		if !ok {
+			response[i].Error = "missing cmac"
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		// Verify the prefix
+		if !strings.HasPrefix(verificationCMAC, "vault:v") {
+			response[i].Error = "invalid CMAC to verify: no prefix"
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		splitVerificationCMAC := strings.SplitN(strings.TrimPrefix(verificationCMAC, "vault:v"), ":", 2)
+		if len(splitVerificationCMAC) != 2 {
+			response[i].Error = "invalid CMAC: wrong number of fields"
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		ver, err := strconv.Atoi(splitVerificationCMAC[0])
+		if err != nil {
+			response[i].Error = "invalid CMAC: version number could not be decoded"
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		verBytes, err := base64.StdEncoding.DecodeString(splitVerificationCMAC[1])
+		if err != nil {
+			response[i].Error = fmt.Sprintf("unable to decode verification CMAC as base64: %s", err)
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		if ver > p.LatestVersion {
+			response[i].Error = "invalid CMAC: version is too new"
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		if p.MinDecryptionVersion > 0 && ver < p.MinDecryptionVersion {
+			response[i].Error = "cannot verify CMAC: version is too old (disallowed by policy)"
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		// The MAC is recomputed at the length of the supplied MAC, so that
+		// truncated MACs can be verified.
+		mac, err := p.CMAC(ver, input, len(verBytes))
+		if err != nil {
+			response[i].Error = err.Error()
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+		response[i].Valid = subtle.ConstantTimeCompare(mac, verBytes) == 1
+	}
+
+	p.Unlock()
+
+	// Generate the response
+	resp = &logical.Response{}
+	if batchInputRaw != nil {
+		resp.Data = map[string]interface{}{
+			"batch_results": response,
+		}
+	} else {
+		if response[0].Error != "" || response[0].err != nil {
+			if response[0].Error != "" {
+				return logical.ErrorResponse(response[0].Error), response[0].err
+			}
+			return nil, response[0].err
+		}
+		resp.Data = map[string]interface{}{
+			"valid": response[0].Valid,
+		}
+	}
+
+	return resp, nil
+}
+
+const pathCMACHelpSyn = `Generate a CMAC for input data using the named MAC key`
+
+const pathCMACHelpDesc = `
+Generates a keyed MAC of the given input data using a MAC-only key: AES-CMAC
+for "aes128-cmac" and "aes256-cmac" keys, and KMAC for "kmac128" and
+"kmac256" keys.
+`
+
+const pathCMACVerifyHelpSyn = `Verify a CMAC for input data using the named MAC key`
+
+const pathCMACVerifyHelpDesc = `
+Verifies a keyed MAC previously generated by the cmac endpoint against the
+given input data. Truncated MACs are verified at their supplied length.
+`
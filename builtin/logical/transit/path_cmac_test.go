@@ -0,0 +1,127 @@
+package transit
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestTransit_CMAC(t *testing.T) {
+	b, storage := createBackendWithSysView(t)
+
+	req := &logical.Request{
+		Storage:   storage,
+		Operation: logical.UpdateOperation,
+		Path:      "keys/foo",
+		Data: map[string]interface{}{
+			"type": "aes128-cmac",
+		},
+	}
+	_, err := b.HandleRequest(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Now, change the key value to the RFC 4493 test key
+	p, _, err := b.GetPolicy(context.Background(), keysutil.PolicyRequest{
+		Storage: storage,
+		Name:    "foo",
+	}, b.GetRandomReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	latestVersion := strconv.Itoa(p.LatestVersion)
+	keyEntry := p.Keys[latestVersion]
+	keyEntry.Key, _ = hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	p.Keys[latestVersion] = keyEntry
+	if err = p.Persist(context.Background(), storage); err != nil {
+		t.Fatal(err)
+	}
+
+	message, _ := hex.DecodeString("6bc1bee22e409f96e93d7e117393172a")
+	input := base64.StdEncoding.EncodeToString(message)
+	expected, _ := hex.DecodeString("070a16b46b4d4144f79bdd9dd04a287c")
+
+	doRequest := func(path string, data map[string]interface{}, errExpected bool) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   storage,
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Data:      data,
+		})
+		if errExpected {
+			if err == nil && (resp == nil || !resp.IsError()) {
+				t.Fatalf("expected error for %s, got: %#v", path, resp)
+			}
+			return nil
+		}
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err: %v, resp: %#v", err, resp)
+		}
+		return resp
+	}
+
+	resp := doRequest("cmac/foo", map[string]interface{}{"input": input}, false)
+	mac := resp.Data["cmac"].(string)
+	if mac != "vault:v1:"+base64.StdEncoding.EncodeToString(expected) {
+		t.Fatalf("bad: unexpected CMAC %s", mac)
+	}
+
+	resp = doRequest("cmac/verify/foo", map[string]interface{}{"input": input, "cmac": mac}, false)
+	if !resp.Data["valid"].(bool) {
+		t.Fatal("expected CMAC to be valid")
+	}
+	resp = doRequest("cmac/verify/foo", map[string]interface{}{"input": "dGhlIHF1aWNrIGJyb3duIGZveA==", "cmac": mac}, false)
+	if resp.Data["valid"].(bool) {
+		t.Fatal("expected CMAC over different input to be invalid")
+	}
+
+	// Truncated MACs verify at their own length
+	resp = doRequest("cmac/foo", map[string]interface{}{"input": input, "mac_length": 8}, false)
+	truncated := resp.Data["cmac"].(string)
+	if truncated != "vault:v1:"+base64.StdEncoding.EncodeToString(expected[:8]) {
+		t.Fatalf("bad: unexpected truncated CMAC %s", truncated)
+	}
+	resp = doRequest("cmac/verify/foo", map[string]interface{}{"input": input, "cmac": truncated}, false)
+	if !resp.Data["valid"].(bool) {
+		t.Fatal("expected truncated CMAC to be valid")
+	}
+	doRequest("cmac/foo", map[string]interface{}{"input": input, "mac_length": 4}, true)
+
+	// Batch input
+	resp = doRequest("cmac/foo", map[string]interface{}{
+		"batch_input": []interface{}{
+			map[string]interface{}{"input": input},
+			map[string]interface{}{"input": "not base64"},
+		},
+	}, false)
+	results := resp.Data["batch_results"].([]batchResponseCMACItem)
+	if results[0].CMAC != mac || results[1].Error == "" {
+		t.Fatalf("bad: batch results %#v", results)
+	}
+
+	// MAC-only keys cannot be used to encrypt or sign, and other keys
+	// cannot be used for CMAC
+	doRequest("encrypt/foo", map[string]interface{}{"plaintext": input}, true)
+	doRequest("sign/foo", map[string]interface{}{"input": input}, true)
+	doRequest("hmac/foo", map[string]interface{}{"input": input}, true)
+	doRequest("hmac/verify/foo", map[string]interface{}{"input": input, "hmac": mac}, true)
+	doRequest("keys/bar", map[string]interface{}{}, false)
+	doRequest("cmac/bar", map[string]interface{}{"input": input}, true)
+
+	// KMAC keys use the same endpoints
+	doRequest("keys/kmac", map[string]interface{}{"type": "kmac256"}, false)
+	resp = doRequest("cmac/kmac", map[string]interface{}{"input": input}, false)
+	mac = resp.Data["cmac"].(string)
+	resp = doRequest("cmac/verify/kmac", map[string]interface{}{"input": input, "cmac": mac}, false)
+	if !resp.Data["valid"].(bool) {
+		t.Fatal("expected KMAC to be valid")
+	}
+	doRequest("hmac/kmac", map[string]interface{}{"input": input}, true)
+}
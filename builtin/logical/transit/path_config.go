@@ -8,6 +8,7 @@ import (
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/keysutil"
 	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/mitchellh/mapstructure"
 )
 
 func (b *backend) pathConfig() *framework.Path {
@@ -44,6 +45,11 @@ the latest version of the key is allowed.`,
 				Description: `Enables export of the key. Once set, this cannot be disabled.`,
 			},
 
+			"allow_wrapped_export": {
+				Type:        framework.TypeBool,
+				Description: `Enables export of the key wrapped under a caller-supplied RSA public key. Once set, this cannot be disabled.`,
+			},
+
 			"allow_plaintext_backup": {
 				Type:        framework.TypeBool,
 				Description: `Enables taking a backup of the named key in plaintext format. Once set, this cannot be disabled.`,
@@ -55,6 +61,19 @@ the latest version of the key is allowed.`,
 being automatically rotated. A value of 0
 disables automatic rotation for the key.`,
 			},
+
+			"usage_policy": {
+				Type: framework.TypeMap,
+				Description: `Restricts the operations the key may be used
+for. Maps each allowed operation ("encrypt", "decrypt",
+"rewrap", "datakey", "sign", "verify", "hmac", "cmac",
+"encode" or "decode") to a rule with optional
+"min_version" and "max_version" bounds on the key versions
+used and, for derived keys, a list of "context_prefixes"
+the derivation context must start with.
+Operations not listed are denied. An empty map removes all
+restrictions.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -91,7 +110,9 @@ func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, d *
 	originalMinEncryptionVersion := p.MinEncryptionVersion
 	originalDeletionAllowed := p.DeletionAllowed
 	originalExportable := p.Exportable
+	originalAllowWrappedExport := p.AllowWrappedExport
 	originalAllowPlaintextBackup := p.AllowPlaintextBackup
+	originalUsagePolicy := p.UsagePolicy
 
 	defer func() {
 		if retErr != nil || (resp != nil && resp.IsError()) {
@@ -99,7 +120,9 @@ func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, d *
 			p.MinEncryptionVersion = originalMinEncryptionVersion
 			p.DeletionAllowed = originalDeletionAllowed
 			p.Exportable = originalExportable
+			p.AllowWrappedExport = originalAllowWrappedExport
 			p.AllowPlaintextBackup = originalAllowPlaintextBackup
+			p.UsagePolicy = originalUsagePolicy
 		}
 	}()
 
@@ -183,6 +206,16 @@ func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, d *
 		}
 	}
 
+	allowWrappedExportRaw, ok := d.GetOk("allow_wrapped_export")
+	if ok {
+		allowWrappedExport := allowWrappedExportRaw.(bool)
+		// Don't unset the already set value
+		if allowWrappedExport && !p.AllowWrappedExport {
+			p.AllowWrappedExport = allowWrappedExport
+			persistNeeded = true
+		}
+	}
+
 	allowPlaintextBackupRaw, ok := d.GetOk("allow_plaintext_backup")
 	if ok {
 		allowPlaintextBackup := allowPlaintextBackupRaw.(bool)
@@ -210,6 +243,20 @@ func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, d *
 		}
 	}
 
+	usagePolicyRaw, ok := d.GetOk("usage_policy")
+	if ok {
+		usagePolicy, err := parseUsagePolicy(usagePolicyRaw.(map[string]interface{}))
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+		if err := p.ValidateUsagePolicy(usagePolicy); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		p.UsagePolicy = usagePolicy
+		persistNeeded = true
+	}
+
 	if !persistNeeded {
 		return nil, nil
 	}
@@ -228,10 +275,38 @@ func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, d *
 	return resp, p.Persist(ctx, req.Storage)
 }
 
+func parseUsagePolicy(raw map[string]interface{}) (map[keysutil.KeyUsage]keysutil.KeyUsageRule, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	usagePolicy := make(map[keysutil.KeyUsage]keysutil.KeyUsageRule, len(raw))
+	for usage, ruleRaw := range raw {
+		var rule keysutil.KeyUsageRule
+		decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			WeaklyTypedInput: true,
+			ErrorUnused:      true,
+			Result:           &rule,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := decoder.Decode(ruleRaw); err != nil {
+			return nil, fmt.Errorf("invalid usage policy rule for operation %q: %w", usage, err)
+		}
+
+		usagePolicy[keysutil.KeyUsage(usage)] = rule
+	}
+
+	return usagePolicy, nil
+}
+
 const pathConfigHelpSyn = `Configure a named encryption key`
 
 const pathConfigHelpDesc = `
 This path is used to configure the named key. Currently, this
 supports adjusting the minimum version of the key allowed to
-be used for decryption via the min_decryption_version parameter.
+be used for decryption via the min_decryption_version parameter,
+and restricting the operations the key may be used for via the
+usage_policy parameter.
 `
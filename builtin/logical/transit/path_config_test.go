@@ -13,6 +13,7 @@ import (
 	uuid "github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/vault/api"
 	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/hashicorp/vault/vault"
 )
@@ -403,3 +404,96 @@ func TestTransit_UpdateKeyConfigWithAutorotation(t *testing.T) {
 		})
 	}
 }
+
+func TestTransit_ConfigUsagePolicy(t *testing.T) {
+	b, s := createBackendWithSysView(t)
+
+	doRequest := func(path string, data map[string]interface{}, errExpected bool) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   s,
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Data:      data,
+		})
+		if errExpected {
+			if err == nil && (resp == nil || !resp.IsError()) {
+				t.Fatalf("expected error for %s, got: %#v", path, resp)
+			}
+			return nil
+		}
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err: %v, resp: %#v", err, resp)
+		}
+		return resp
+	}
+
+	plaintext := "dGhlIHF1aWNrIGJyb3duIGZveA=="
+	tenantA := "dGVuYW50LWEvMQ==" // tenant-a/1
+	tenantB := "dGVuYW50LWIvMQ==" // tenant-b/1
+
+	doRequest("keys/foo", map[string]interface{}{"derived": true}, false)
+	resp := doRequest("encrypt/foo", map[string]interface{}{"plaintext": plaintext, "context": tenantA}, false)
+	ciphertext := resp.Data["ciphertext"].(string)
+
+	// An encrypt-only key for tenant-a contexts, which may still be rewrapped
+	doRequest("keys/foo/config", map[string]interface{}{
+		"usage_policy": map[string]interface{}{
+			"encrypt": map[string]interface{}{"context_prefixes": []string{"tenant-a/"}},
+			"rewrap":  map[string]interface{}{},
+		},
+	}, false)
+
+	doRequest("encrypt/foo", map[string]interface{}{"plaintext": plaintext, "context": tenantA}, false)
+	doRequest("encrypt/foo", map[string]interface{}{"plaintext": plaintext, "context": tenantB}, true)
+	doRequest("decrypt/foo", map[string]interface{}{"ciphertext": ciphertext, "context": tenantA}, true)
+	doRequest("datakey/plaintext/foo", map[string]interface{}{"context": tenantA}, true)
+	doRequest("rewrap/foo", map[string]interface{}{"ciphertext": ciphertext, "context": tenantA}, false)
+	doRequest("hmac/foo", map[string]interface{}{"input": plaintext}, true)
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Storage:   s,
+		Operation: logical.ReadOperation,
+		Path:      "keys/foo",
+	})
+	if err != nil || resp == nil {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	usagePolicy := resp.Data["usage_policy"].(map[keysutil.KeyUsage]keysutil.KeyUsageRule)
+	if len(usagePolicy) != 2 || usagePolicy[keysutil.KeyUsageEncrypt].ContextPrefixes[0] != "tenant-a/" {
+		t.Fatalf("bad: usage policy %#v", usagePolicy)
+	}
+
+	// Version bounds apply per operation
+	doRequest("keys/foo/rotate", nil, false)
+	doRequest("keys/foo/config", map[string]interface{}{
+		"usage_policy": map[string]interface{}{
+			"encrypt": map[string]interface{}{"max_version": 1},
+			"decrypt": map[string]interface{}{"min_version": 2},
+		},
+	}, false)
+	doRequest("encrypt/foo", map[string]interface{}{"plaintext": plaintext, "context": tenantA}, true)
+	resp = doRequest("encrypt/foo", map[string]interface{}{"plaintext": plaintext, "context": tenantA, "key_version": 1}, false)
+	doRequest("decrypt/foo", map[string]interface{}{"ciphertext": resp.Data["ciphertext"], "context": tenantA}, true)
+
+	// Invalid policies are rejected
+	for _, usagePolicy := range []map[string]interface{}{
+		{"launch": map[string]interface{}{}},
+		{"sign": map[string]interface{}{}},
+		{"encrypt": map[string]interface{}{"min_version": 3, "max_version": 2}},
+		{"encrypt": map[string]interface{}{"max_uses": 1}},
+		{"hmac": map[string]interface{}{"context_prefixes": []string{"tenant-a/"}}},
+	} {
+		doRequest("keys/foo/config", map[string]interface{}{"usage_policy": usagePolicy}, true)
+	}
+	doRequest("keys/bar", nil, false)
+	doRequest("keys/bar/config", map[string]interface{}{
+		"usage_policy": map[string]interface{}{
+			"encrypt": map[string]interface{}{"context_prefixes": []string{"tenant-a/"}},
+		},
+	}, true)
+
+	// An empty policy lifts all restrictions
+	doRequest("keys/foo/config", map[string]interface{}{"usage_policy": map[string]interface{}{}}, false)
+	doRequest("decrypt/foo", map[string]interface{}{"ciphertext": ciphertext, "context": tenantA}, false)
+}
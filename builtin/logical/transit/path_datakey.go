@@ -131,7 +131,7 @@ func (b *backend) pathDatakeyWrite(ctx context.Context, req *logical.Request, d
 		return nil, err
 	}
 
-	ciphertext, err := p.Encrypt(ver, context, nonce, base64.StdEncoding.EncodeToString(newKey))
+	ciphertext, err := p.EncryptForUsage(keysutil.KeyUsageDatakey, ver, context, nonce, base64.StdEncoding.EncodeToString(newKey), nil)
 	if err != nil {
 		switch err.(type) {
 		case errutil.UserError:
@@ -181,4 +181,7 @@ or 512 bits can be specified; if not specified, the default
 is 256 bits. Call with the the "wrapped" path to prevent the
 (base64-encoded) plaintext key from being returned along with
 the encrypted key, the "plaintext" path returns both.
+
+When the named key is an ML-KEM key, the data key is encrypted
+under a shared key freshly encapsulated to the ML-KEM public key.
 `
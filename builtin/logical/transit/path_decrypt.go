@@ -51,6 +51,38 @@ Base64 encoded nonce value used during encryption. Must be provided if
 convergent encryption is enabled for this key and the key was generated with
 Vault 0.6.1. Not required for keys created in 0.6.2+.`,
 			},
+
+			"associated_data": {
+				Type: framework.TypeString,
+				Description: `
+Base64 encoded associated data supplied when the ciphertext was encrypted.
+Only supported for "aes128-gcm96", "aes256-gcm96" and "chacha20-poly1305"
+keys.`,
+			},
+
+			"stream": {
+				Type: framework.TypeBool,
+				Description: `
+If set, the request decrypts the ciphertext as one chunk of a chunked stream, as described
+by stream_header, chunk_index and final_chunk, instead of a standalone
+ciphertext. Batch input is not supported in this mode.`,
+			},
+
+			"stream_header": {
+				Type: framework.TypeString,
+				Description: `
+The stream header returned when the stream was started.`,
+			},
+
+			"chunk_index": {
+				Type:        framework.TypeInt,
+				Description: "The position of the chunk in the stream, starting at 0.",
+			},
+
+			"final_chunk": {
+				Type:        framework.TypeBool,
+				Description: "Whether the chunk is the last one of the stream.",
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -63,6 +95,10 @@ Vault 0.6.1. Not required for keys created in 0.6.2+.`,
 }
 
 func (b *backend) pathDecryptWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if d.Get("stream").(bool) {
+		return b.pathDecryptStreamWrite(ctx, req, d)
+	}
+
 	batchInputRaw := d.Raw["batch_input"]
 	var batchInputItems []BatchRequestItem
 	var err error
@@ -83,9 +119,10 @@ func (b *backend) pathDecryptWrite(ctx context.Context, req *logical.Request, d
 
 		batchInputItems = make([]BatchRequestItem, 1)
 		batchInputItems[0] = BatchRequestItem{
-			Ciphertext: ciphertext,
-			Context:    d.Get("context").(string),
-			Nonce:      d.Get("nonce").(string),
+			Ciphertext:     ciphertext,
+			Context:        d.Get("context").(string),
+			Nonce:          d.Get("nonce").(string),
+			AssociatedData: d.Get("associated_data").(string),
 		}
 	}
 
@@ -125,6 +162,16 @@ func (b *backend) pathDecryptWrite(ctx context.Context, req *logical.Request, d
 				continue
 			}
 		}
+
+		// Decode the associated data
+		if len(item.AssociatedData) != 0 {
+			batchInputItems[i].DecodedAssociatedData, err = base64.StdEncoding.DecodeString(item.AssociatedData)
+			if err != nil {
+				userErrorInBatch = true
+				batchResponseItems[i].Error = err.Error()
+				continue
+			}
+		}
 	}
 
 	// Get the policy
@@ -147,7 +194,7 @@ func (b *backend) pathDecryptWrite(ctx context.Context, req *logical.Request, d
 			continue
 		}
 
-		plaintext, err := p.Decrypt(item.DecodedContext, item.DecodedNonce, item.Ciphertext)
+		plaintext, err := p.DecryptWithAssociatedData(item.DecodedContext, item.DecodedNonce, item.Ciphertext, item.DecodedAssociatedData)
 		if err != nil {
 			switch err.(type) {
 			case errutil.InternalError:
@@ -197,9 +244,62 @@ func (b *backend) pathDecryptWrite(ctx context.Context, req *logical.Request, d
 	return resp, nil
 }
 
+// pathDecryptStreamWrite decrypts a single chunk of a stream produced by the
+// encrypt endpoint in stream mode.
+func (b *backend) pathDecryptStreamWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(d.Get("ciphertext").(string))
+	if err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("failed to base64-decode ciphertext: %s", err)), logical.ErrInvalidRequest
+	}
+
+	header := d.Get("stream_header").(string)
+	if header == "" {
+		return logical.ErrorResponse("missing stream_header"), logical.ErrInvalidRequest
+	}
+
+	context, index, resp := decodeStreamChunkRequest(d)
+	if resp != nil {
+		return resp, logical.ErrInvalidRequest
+	}
+
+	p, _, err := b.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: req.Storage,
+		Name:    d.Get("name").(string),
+	}, b.GetRandomReader())
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return logical.ErrorResponse("encryption key not found"), logical.ErrInvalidRequest
+	}
+	if !b.System().CachingDisabled() {
+		p.Lock(false)
+	}
+	defer p.Unlock()
+
+	plaintext, err := p.DecryptStreamChunk(context, header, index, d.Get("final_chunk").(bool), ciphertext)
+	if err != nil {
+		switch err.(type) {
+		case errutil.UserError:
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		default:
+			return nil, err
+		}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"plaintext": base64.StdEncoding.EncodeToString(plaintext),
+		},
+	}, nil
+}
+
 const pathDecryptHelpSyn = `Decrypt a ciphertext value using a named key`
 
 const pathDecryptHelpDesc = `
 This path uses the named key from the request path to decrypt a user
 provided ciphertext. The plaintext is returned base64 encoded.
+
+Chunks encrypted in stream mode are decrypted one at a time, in stream mode,
+with the stream header, index and final flag they were encrypted with.
 `
@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
 	"reflect"
 
@@ -39,6 +40,12 @@ type BatchRequestItem struct {
 
 	// DecodedNonce is the base64 decoded version of Nonce
 	DecodedNonce []byte
+
+	// Associated data to authenticate along with the ciphertext
+	AssociatedData string `json:"associated_data" structs:"associated_data" mapstructure:"associated_data"`
+
+	// DecodedAssociatedData is the base64 decoded version of AssociatedData
+	DecodedAssociatedData []byte
 }
 
 // EncryptBatchResponseItem represents a response item for batch processing
@@ -85,6 +92,14 @@ encryption key) this nonce value is **never reused**.
 `,
 			},
 
+			"associated_data": {
+				Type: framework.TypeString,
+				Description: `
+Base64 encoded associated data to authenticate along with the ciphertext;
+the same value must be supplied to decrypt it. Only supported for
+"aes128-gcm96", "aes256-gcm96" and "chacha20-poly1305" keys.`,
+			},
+
 			"type": {
 				Type:    framework.TypeString,
 				Default: "aes256-gcm96",
@@ -113,6 +128,31 @@ will severely impact the ciphertext's security.`,
 Must be 0 (for latest) or a value greater than or equal
 to the min_encryption_version configured on the key.`,
 			},
+
+			"stream": {
+				Type: framework.TypeBool,
+				Description: `
+If set, the request encrypts the plaintext as one chunk of a chunked stream, as described
+by stream_header, chunk_index and final_chunk, instead of a standalone
+ciphertext. Batch input is not supported in this mode.`,
+			},
+
+			"stream_header": {
+				Type: framework.TypeString,
+				Description: `
+The stream header returned when the stream was started. If empty, a new
+stream is started; chunk_index must then be 0 and the new header is returned.`,
+			},
+
+			"chunk_index": {
+				Type:        framework.TypeInt,
+				Description: "The position of the chunk in the stream, starting at 0.",
+			},
+
+			"final_chunk": {
+				Type:        framework.TypeBool,
+				Description: "Whether the chunk is the last one of the stream.",
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -205,6 +245,15 @@ func decodeBatchRequestItems(src interface{}, requirePlaintext bool, requireCiph
 			}
 		}
 
+		if v, has := item["associated_data"]; has {
+			if !reflect.ValueOf(v).IsValid() {
+			} else if casted, ok := v.(string); ok {
+				(*dst)[i].AssociatedData = casted
+			} else {
+				errs.Errors = append(errs.Errors, fmt.Sprintf("'[%d].associated_data' expected type 'string', got unconvertible type '%T'", i, item["associated_data"]))
+			}
+		}
+
 		if v, has := item["key_version"]; has {
 			if !reflect.ValueOf(v).IsValid() {
 			} else if casted, ok := v.(int); ok {
@@ -247,6 +296,10 @@ func (b *backend) pathEncryptExistenceCheck(ctx context.Context, req *logical.Re
 }
 
 func (b *backend) pathEncryptWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if d.Get("stream").(bool) {
+		return b.pathEncryptStreamWrite(ctx, req, d)
+	}
+
 	name := d.Get("name").(string)
 	var err error
 	batchInputRaw := d.Raw["batch_input"]
@@ -271,10 +324,11 @@ func (b *backend) pathEncryptWrite(ctx context.Context, req *logical.Request, d
 
 		batchInputItems = make([]BatchRequestItem, 1)
 		batchInputItems[0] = BatchRequestItem{
-			Plaintext:  valueRaw.(string),
-			Context:    d.Get("context").(string),
-			Nonce:      d.Get("nonce").(string),
-			KeyVersion: d.Get("key_version").(int),
+			Plaintext:      valueRaw.(string),
+			Context:        d.Get("context").(string),
+			Nonce:          d.Get("nonce").(string),
+			KeyVersion:     d.Get("key_version").(int),
+			AssociatedData: d.Get("associated_data").(string),
 		}
 	}
 
@@ -319,6 +373,16 @@ func (b *backend) pathEncryptWrite(ctx context.Context, req *logical.Request, d
 				continue
 			}
 		}
+
+		// Decode the associated data
+		if len(item.AssociatedData) != 0 {
+			batchInputItems[i].DecodedAssociatedData, err = base64.StdEncoding.DecodeString(item.AssociatedData)
+			if err != nil {
+				userErrorInBatch = true
+				batchResponseItems[i].Error = err.Error()
+				continue
+			}
+		}
 	}
 
 	// Get the policy
@@ -384,7 +448,7 @@ func (b *backend) pathEncryptWrite(ctx context.Context, req *logical.Request, d
 			warnAboutNonceUsage = true
 		}
 
-		ciphertext, err := p.Encrypt(item.KeyVersion, item.DecodedContext, item.DecodedNonce, item.Plaintext)
+		ciphertext, err := p.EncryptWithAssociatedData(item.KeyVersion, item.DecodedContext, item.DecodedNonce, item.Plaintext, item.DecodedAssociatedData)
 		if err != nil {
 			switch err.(type) {
 			case errutil.InternalError:
@@ -457,6 +521,95 @@ func (b *backend) pathEncryptWrite(ctx context.Context, req *logical.Request, d
 	return resp, nil
 }
 
+// pathEncryptStreamWrite encrypts a single chunk of a chunked stream. Vault
+// keeps no state for a stream: the stream key travels, encrypted under the
+// named key, in the stream header that accompanies every chunk.
+func (b *backend) pathEncryptStreamWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	plaintext, err := base64.StdEncoding.DecodeString(d.Get("plaintext").(string))
+	if err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("failed to base64-decode plaintext: %s", err)), logical.ErrInvalidRequest
+	}
+
+	context, index, resp := decodeStreamChunkRequest(d)
+	if resp != nil {
+		return resp, logical.ErrInvalidRequest
+	}
+
+	p, _, err := b.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: req.Storage,
+		Name:    d.Get("name").(string),
+	}, b.GetRandomReader())
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return logical.ErrorResponse("encryption key not found"), logical.ErrInvalidRequest
+	}
+	if !b.System().CachingDisabled() {
+		p.Lock(false)
+	}
+	defer p.Unlock()
+
+	header := d.Get("stream_header").(string)
+	if header == "" {
+		if index != 0 {
+			return logical.ErrorResponse("a new stream must start at chunk_index 0"), logical.ErrInvalidRequest
+		}
+
+		header, err = p.NewStreamHeader(d.Get("key_version").(int), context)
+		if err != nil {
+			switch err.(type) {
+			case errutil.UserError:
+				return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+			default:
+				return nil, err
+			}
+		}
+	}
+
+	ciphertext, err := p.EncryptStreamChunk(context, header, index, d.Get("final_chunk").(bool), plaintext)
+	if err != nil {
+		switch err.(type) {
+		case errutil.UserError:
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		default:
+			return nil, err
+		}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"stream_header": header,
+			"ciphertext":    base64.StdEncoding.EncodeToString(ciphertext),
+		},
+	}, nil
+}
+
+// decodeStreamChunkRequest validates and decodes the parameters shared by
+// stream chunk encryption and decryption, returning an error response if they
+// are invalid.
+func decodeStreamChunkRequest(d *framework.FieldData) ([]byte, uint32, *logical.Response) {
+	if d.Raw["batch_input"] != nil {
+		return nil, 0, logical.ErrorResponse("batch_input is not supported for streams")
+	}
+
+	var context []byte
+	if encoded := d.Get("context").(string); encoded != "" {
+		var err error
+		context, err = base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, 0, logical.ErrorResponse(fmt.Sprintf("failed to base64-decode context: %s", err))
+		}
+	}
+
+	index := d.Get("chunk_index").(int)
+	if index < 0 || index > math.MaxUint32 {
+		return nil, 0, logical.ErrorResponse("chunk_index out of range")
+	}
+
+	return context, uint32(index), nil
+}
+
 // shouldWarnAboutNonceUsage attempts to determine if we will use a provided nonce or not. Ideally this
 // would be information returned through p.Encrypt but that would require an SDK api change and this is
 // transit specific
@@ -491,4 +644,8 @@ blocks using a named key`
 const pathEncryptHelpDesc = `
 This path uses the named key from the request path to encrypt a user provided
 plaintext or a batch of plaintext blocks. The plaintext must be base64 encoded.
+
+Payloads too large for a single request can be encrypted in stream mode, one
+chunk per request. The first request returns a stream header, which must be
+supplied with every later chunk and when decrypting.
 `
@@ -3,6 +3,7 @@ package transit
 import (
 	"context"
 	"encoding/json"
+	"net/http"
 	"reflect"
 	"strings"
 	"testing"
@@ -937,3 +938,269 @@ func TestShouldWarnAboutNonceUsage(t *testing.T) {
 		}
 	}
 }
+
+func TestTransit_AssociatedData(t *testing.T) {
+	b, s := createBackendWithSysView(t)
+
+	doRequest := func(path string, data map[string]interface{}, errExpected bool) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   s,
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Data:      data,
+		})
+		if errExpected {
+			if err == nil && (resp == nil || !resp.IsError()) {
+				t.Fatalf("expected error for %s, got: %#v", path, resp)
+			}
+			return nil
+		}
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err: %v, resp: %#v", err, resp)
+		}
+		return resp
+	}
+
+	plaintext := "dGhlIHF1aWNrIGJyb3duIGZveA=="
+	aad := "aGVhZGVyIGRhdGE="
+	otherAAD := "b3RoZXIgZGF0YQ=="
+
+	for _, keyType := range []string{"aes128-gcm96", "aes256-gcm96", "chacha20-poly1305"} {
+		doRequest("keys/"+keyType, map[string]interface{}{"type": keyType}, false)
+
+		resp := doRequest("encrypt/"+keyType, map[string]interface{}{
+			"plaintext":       plaintext,
+			"associated_data": aad,
+		}, false)
+		ciphertext := resp.Data["ciphertext"].(string)
+
+		resp = doRequest("decrypt/"+keyType, map[string]interface{}{
+			"ciphertext":      ciphertext,
+			"associated_data": aad,
+		}, false)
+		if resp.Data["plaintext"] != plaintext {
+			t.Fatalf("%s: bad plaintext %v", keyType, resp.Data["plaintext"])
+		}
+
+		// Missing or different associated data must fail authentication
+		doRequest("decrypt/"+keyType, map[string]interface{}{"ciphertext": ciphertext}, true)
+		doRequest("decrypt/"+keyType, map[string]interface{}{
+			"ciphertext":      ciphertext,
+			"associated_data": otherAAD,
+		}, true)
+
+		// Rewrapping keeps the ciphertext bound to the associated data
+		doRequest("keys/"+keyType+"/rotate", nil, false)
+		resp = doRequest("rewrap/"+keyType, map[string]interface{}{
+			"ciphertext":      ciphertext,
+			"associated_data": aad,
+		}, false)
+		rewrapped := resp.Data["ciphertext"].(string)
+		if !strings.HasPrefix(rewrapped, "vault:v2:") {
+			t.Fatalf("%s: expected rewrapped ciphertext to use the latest version, got %s", keyType, rewrapped)
+		}
+		doRequest("decrypt/"+keyType, map[string]interface{}{"ciphertext": rewrapped}, true)
+		resp = doRequest("decrypt/"+keyType, map[string]interface{}{
+			"ciphertext":      rewrapped,
+			"associated_data": aad,
+		}, false)
+		if resp.Data["plaintext"] != plaintext {
+			t.Fatalf("%s: bad plaintext after rewrap %v", keyType, resp.Data["plaintext"])
+		}
+	}
+
+	// Batch items carry their own associated data
+	resp := doRequest("encrypt/aes256-gcm96", map[string]interface{}{
+		"batch_input": []interface{}{
+			map[string]interface{}{"plaintext": plaintext, "associated_data": aad},
+			map[string]interface{}{"plaintext": plaintext, "associated_data": otherAAD},
+		},
+	}, false)
+	encResults := resp.Data["batch_results"].([]EncryptBatchResponseItem)
+	resp = doRequest("decrypt/aes256-gcm96", map[string]interface{}{
+		"batch_input": []interface{}{
+			map[string]interface{}{"ciphertext": encResults[0].Ciphertext, "associated_data": aad},
+			map[string]interface{}{"ciphertext": encResults[1].Ciphertext, "associated_data": otherAAD},
+		},
+	}, false)
+	for i, result := range resp.Data["batch_results"].([]DecryptBatchResponseItem) {
+		if result.Plaintext != plaintext || result.Error != "" {
+			t.Fatalf("bad: batch result %d %#v", i, result)
+		}
+	}
+
+	// Swapping the associated data between items fails both
+	resp = doRequest("decrypt/aes256-gcm96", map[string]interface{}{
+		"batch_input": []interface{}{
+			map[string]interface{}{"ciphertext": encResults[0].Ciphertext, "associated_data": otherAAD},
+			map[string]interface{}{"ciphertext": encResults[1].Ciphertext, "associated_data": aad},
+		},
+	}, false)
+	if resp.Data[logical.HTTPStatusCode] != http.StatusBadRequest {
+		t.Fatalf("expected bad request for mismatched associated data, got %#v", resp.Data)
+	}
+
+	// Key types whose ciphers cannot authenticate associated data reject it
+	for _, keyType := range []string{"rsa-2048"} {
+		doRequest("keys/"+keyType, map[string]interface{}{"type": keyType}, false)
+		doRequest("encrypt/"+keyType, map[string]interface{}{
+			"plaintext":       plaintext,
+			"associated_data": aad,
+		}, true)
+	}
+
+	// Convergent nonces ignore associated data, so encrypting the same
+	// plaintext under different associated data would reuse a nonce
+	doRequest("keys/convergent", map[string]interface{}{
+		"derived":               true,
+		"convergent_encryption": true,
+	}, false)
+	derivationContext := "Y29udGV4dA=="
+	doRequest("encrypt/convergent", map[string]interface{}{
+		"plaintext":       plaintext,
+		"context":         derivationContext,
+		"associated_data": aad,
+	}, true)
+	resp = doRequest("encrypt/convergent", map[string]interface{}{
+		"plaintext": plaintext,
+		"context":   derivationContext,
+	}, false)
+	doRequest("decrypt/convergent", map[string]interface{}{
+		"ciphertext":      resp.Data["ciphertext"],
+		"context":         derivationContext,
+		"associated_data": aad,
+	}, true)
+}
+
+func TestTransit_EncryptStream(t *testing.T) {
+	b, s := createBackendWithSysView(t)
+
+	doRequest := func(path string, data map[string]interface{}, errExpected bool) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   s,
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Data:      data,
+		})
+		if errExpected {
+			if err == nil && (resp == nil || !resp.IsError()) {
+				t.Fatalf("expected error for %s, got: %#v", path, resp)
+			}
+			return nil
+		}
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err: %v, resp: %#v", err, resp)
+		}
+		return resp
+	}
+
+	doRequest("keys/foo", nil, false)
+
+	chunks := []string{"Y2h1bmsgb25l", "Y2h1bmsgdHdv", "Y2h1bmsgdGhyZWU="}
+	var header string
+	ciphertexts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		resp := doRequest("encrypt/foo", map[string]interface{}{
+			"stream":        true,
+			"stream_header": header,
+			"chunk_index":   i,
+			"final_chunk":   i == len(chunks)-1,
+			"plaintext":     chunk,
+		}, false)
+		if header == "" {
+			header = resp.Data["stream_header"].(string)
+		} else if resp.Data["stream_header"] != header {
+			t.Fatalf("expected stream header to be unchanged, got %v", resp.Data["stream_header"])
+		}
+		ciphertexts[i] = resp.Data["ciphertext"].(string)
+	}
+	if !strings.HasPrefix(header, "vault:v1:") {
+		t.Fatalf("bad: stream header %s", header)
+	}
+
+	decryptChunk := func(index int, final bool, ciphertext string, errExpected bool) *logical.Response {
+		t.Helper()
+		return doRequest("decrypt/foo", map[string]interface{}{
+			"stream":        true,
+			"stream_header": header,
+			"chunk_index":   index,
+			"final_chunk":   final,
+			"ciphertext":    ciphertext,
+		}, errExpected)
+	}
+
+	for i, ciphertext := range ciphertexts {
+		resp := decryptChunk(i, i == len(ciphertexts)-1, ciphertext, false)
+		if resp.Data["plaintext"] != chunks[i] {
+			t.Fatalf("bad: chunk %d decrypted to %v", i, resp.Data["plaintext"])
+		}
+	}
+
+	// After a rotation, the header can be rewrapped to the new key version
+	// and still decrypts the stream's chunks
+	doRequest("keys/foo/rotate", nil, false)
+	resp := doRequest("rewrap/foo", map[string]interface{}{
+		"ciphertext": header,
+	}, false)
+	header = resp.Data["ciphertext"].(string)
+	if !strings.HasPrefix(header, "vault:v2:") {
+		t.Fatalf("bad: rewrapped stream header %s", header)
+	}
+	doRequest("keys/foo/config", map[string]interface{}{
+		"min_decryption_version": 2,
+	}, false)
+	for i, ciphertext := range ciphertexts {
+		resp := decryptChunk(i, i == len(ciphertexts)-1, ciphertext, false)
+		if resp.Data["plaintext"] != chunks[i] {
+			t.Fatalf("bad: chunk %d decrypted to %v after rewrap", i, resp.Data["plaintext"])
+		}
+	}
+
+	// Reordered chunks, a truncated stream and a missing header are rejected
+	decryptChunk(1, false, ciphertexts[0], true)
+	decryptChunk(1, true, ciphertexts[1], true)
+	doRequest("decrypt/foo", map[string]interface{}{
+		"stream":     true,
+		"ciphertext": ciphertexts[0],
+	}, true)
+
+	// New streams start at the first chunk, and chunks of one stream cannot
+	// be decrypted under the header of another
+	doRequest("encrypt/foo", map[string]interface{}{
+		"stream":      true,
+		"chunk_index": 1,
+		"plaintext":   chunks[0],
+	}, true)
+	resp = doRequest("encrypt/foo", map[string]interface{}{
+		"stream":    true,
+		"plaintext": chunks[0],
+	}, false)
+	doRequest("decrypt/foo", map[string]interface{}{
+		"stream":        true,
+		"stream_header": resp.Data["stream_header"],
+		"ciphertext":    ciphertexts[0],
+	}, true)
+
+	// Other ciphertexts, such as data keys, are not accepted as headers, and
+	// the header format cannot be produced by a regular encryption
+	resp = doRequest("datakey/wrapped/foo", nil, false)
+	doRequest("encrypt/foo", map[string]interface{}{
+		"stream":        true,
+		"stream_header": resp.Data["ciphertext"],
+		"chunk_index":   1,
+		"plaintext":     chunks[0],
+	}, true)
+	doRequest("encrypt/foo", map[string]interface{}{
+		// "vault-transit-stream-header:v1:" followed by 32 zero bytes
+		"plaintext": "dmF1bHQtdHJhbnNpdC1zdHJlYW0taGVhZGVyOnYxOgAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA",
+	}, true)
+
+	// Streams are not available for key types that cannot encrypt
+	doRequest("keys/signing", map[string]interface{}{"type": "ed25519"}, false)
+	doRequest("encrypt/signing", map[string]interface{}{
+		"stream":    true,
+		"plaintext": chunks[0],
+	}, true)
+}
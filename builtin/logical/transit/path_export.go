@@ -3,6 +3,7 @@ package transit
 import (
 	"context"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rsa"
 	"crypto/x509"
@@ -10,9 +11,13 @@ import (
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"hash"
+	"io"
 	"strconv"
 	"strings"
 
+	"github.com/google/tink/go/kwp/subtle"
+	uuid "github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/keysutil"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -40,10 +45,30 @@ func (b *backend) pathExportKeys() *framework.Path {
 				Type:        framework.TypeString,
 				Description: "Version of the key",
 			},
+			"public_key": {
+				Type: framework.TypeString,
+				Description: `PEM-encoded RSA public key to wrap the exported keys under. Only
+used when writing to this path; exactly one of public_key and
+wrapping_key must be set.`,
+			},
+			"wrapping_key": {
+				Type: framework.TypeString,
+				Description: `Name of an RSA key in this mount whose latest version's public key
+the exported keys are wrapped under. Only used when writing to this
+path; exactly one of public_key and wrapping_key must be set.`,
+			},
+			"hash_function": {
+				Type:    framework.TypeString,
+				Default: "SHA256",
+				Description: `The hash function used as a random oracle in the OAEP wrapping of
+the ephemeral AES key. Can be one of "SHA1", "SHA224", "SHA256" (default),
+"SHA384", or "SHA512".`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
-			logical.ReadOperation: b.pathPolicyExportRead,
+			logical.ReadOperation:   b.pathPolicyExportRead,
+			logical.UpdateOperation: b.pathPolicyExportWrite,
 		},
 
 		HelpSynopsis:    pathExportHelpSyn,
@@ -51,7 +76,98 @@ func (b *backend) pathExportKeys() *framework.Path {
 	}
 }
 
+// exportWrapping describes the RSA public key exported keys are wrapped
+// under, using the same RSA-OAEP and AES-KWP scheme accepted by the import
+// endpoints.
+type exportWrapping struct {
+	publicKey *rsa.PublicKey
+	hashFn    hash.Hash
+}
+
 func (b *backend) pathPolicyExportRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	return b.exportPolicyKeys(ctx, req, d, nil)
+}
+
+func (b *backend) pathPolicyExportWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	hashFn, err := parseHashFn(d.Get("hash_function").(string))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	publicKey, resp, err := b.getExportWrappingKey(ctx, req, d)
+	if publicKey == nil {
+		return resp, err
+	}
+
+	return b.exportPolicyKeys(ctx, req, d, &exportWrapping{
+		publicKey: publicKey,
+		hashFn:    hashFn,
+	})
+}
+
+func (b *backend) getExportWrappingKey(ctx context.Context, req *logical.Request, d *framework.FieldData) (*rsa.PublicKey, *logical.Response, error) {
+	publicKeyPEM := d.Get("public_key").(string)
+	wrappingKeyName := d.Get("wrapping_key").(string)
+
+	var publicKey *rsa.PublicKey
+	switch {
+	case publicKeyPEM != "" && wrappingKeyName != "":
+		return nil, logical.ErrorResponse("only one of public_key and wrapping_key may be set"), logical.ErrInvalidRequest
+
+	case publicKeyPEM != "":
+		block, _ := pem.Decode([]byte(publicKeyPEM))
+		if block == nil {
+			return nil, logical.ErrorResponse("could not decode PEM public key"), logical.ErrInvalidRequest
+		}
+		parsedKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, logical.ErrorResponse(fmt.Sprintf("error parsing public key: %s", err)), logical.ErrInvalidRequest
+		}
+		rsaKey, ok := parsedKey.(*rsa.PublicKey)
+		if !ok {
+			return nil, logical.ErrorResponse("public key must be an RSA public key"), logical.ErrInvalidRequest
+		}
+		publicKey = rsaKey
+
+	case wrappingKeyName != "":
+		p, _, err := b.GetPolicy(ctx, keysutil.PolicyRequest{
+			Storage: req.Storage,
+			Name:    wrappingKeyName,
+		}, b.GetRandomReader())
+		if err != nil {
+			return nil, nil, err
+		}
+		if p == nil {
+			return nil, logical.ErrorResponse("wrapping key not found"), logical.ErrInvalidRequest
+		}
+		if !b.System().CachingDisabled() {
+			p.Lock(false)
+		}
+		defer p.Unlock()
+
+		switch p.Type {
+		case keysutil.KeyType_RSA2048, keysutil.KeyType_RSA3072, keysutil.KeyType_RSA4096:
+		default:
+			return nil, logical.ErrorResponse(fmt.Sprintf("wrapping key must be an RSA key, not %v", p.Type)), logical.ErrInvalidRequest
+		}
+		key, ok := p.Keys[strconv.Itoa(p.LatestVersion)]
+		if !ok || key.RSAKey == nil {
+			return nil, nil, errors.New("wrapping key version not found")
+		}
+		publicKey = &key.RSAKey.PublicKey
+
+	default:
+		return nil, logical.ErrorResponse("one of public_key or wrapping_key is required"), logical.ErrInvalidRequest
+	}
+
+	if publicKey.N.BitLen() < 2048 {
+		return nil, logical.ErrorResponse("wrapping public key must be at least 2048 bits"), logical.ErrInvalidRequest
+	}
+
+	return publicKey, nil, nil
+}
+
+func (b *backend) exportPolicyKeys(ctx context.Context, req *logical.Request, d *framework.FieldData, wrapping *exportWrapping) (*logical.Response, error) {
 	exportType := d.Get("type").(string)
 	name := d.Get("name").(string)
 	version := d.Get("version").(string)
@@ -79,7 +195,7 @@ func (b *backend) pathPolicyExportRead(ctx context.Context, req *logical.Request
 	}
 	defer p.Unlock()
 
-	if !p.Exportable {
+	if !p.Exportable && (wrapping == nil || !p.AllowWrappedExport) {
 		return logical.ErrorResponse("key is not exportable"), nil
 	}
 
@@ -98,7 +214,7 @@ func (b *backend) pathPolicyExportRead(ctx context.Context, req *logical.Request
 	switch version {
 	case "":
 		for k, v := range p.Keys {
-			exportKey, err := getExportKey(p, &v, exportType)
+			exportKey, err := b.formatExportKey(p, &v, exportType, wrapping)
 			if err != nil {
 				return nil, err
 			}
@@ -125,7 +241,7 @@ func (b *backend) pathPolicyExportRead(ctx context.Context, req *logical.Request
 			return logical.ErrorResponse("version does not exist or cannot be found"), logical.ErrInvalidRequest
 		}
 
-		exportKey, err := getExportKey(p, &key, exportType)
+		exportKey, err := b.formatExportKey(p, &key, exportType, wrapping)
 		if err != nil {
 			return nil, err
 		}
@@ -144,6 +260,118 @@ func (b *backend) pathPolicyExportRead(ctx context.Context, req *logical.Request
 	return resp, nil
 }
 
+// formatExportKey returns the key in its plaintext export format or, when
+// wrapping is set, wrapped and base64 encoded.
+func (b *backend) formatExportKey(policy *keysutil.Policy, key *keysutil.KeyEntry, exportType string, wrapping *exportWrapping) (string, error) {
+	if wrapping == nil {
+		return getExportKey(policy, key, exportType)
+	}
+
+	keyBytes, err := getExportKeyBytes(policy, key, exportType)
+	if err != nil {
+		return "", err
+	}
+
+	wrappedKey, err := wrapExportKey(b.GetRandomReader(), wrapping, keyBytes)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(wrappedKey), nil
+}
+
+// getExportKeyBytes returns the key material in the format accepted by the
+// import endpoints: raw bytes for symmetric keys and PKCS#8 DER for RSA,
+// ECDSA and Ed25519 keys.
+func getExportKeyBytes(policy *keysutil.Policy, key *keysutil.KeyEntry, exportType string) ([]byte, error) {
+	if policy == nil {
+		return nil, errors.New("nil policy provided")
+	}
+
+	switch exportType {
+	case exportTypeHMACKey:
+		return key.HMACKey, nil
+
+	case exportTypeEncryptionKey:
+		switch policy.Type {
+		case keysutil.KeyType_AES128_GCM96, keysutil.KeyType_AES256_GCM96, keysutil.KeyType_ChaCha20_Poly1305,
+			keysutil.KeyType_ML_KEM_768, keysutil.KeyType_ML_KEM_1024:
+			return key.Key, nil
+
+		case keysutil.KeyType_RSA2048, keysutil.KeyType_RSA3072, keysutil.KeyType_RSA4096:
+			return x509.MarshalPKCS8PrivateKey(key.RSAKey)
+		}
+
+	case exportTypeSigningKey:
+		switch policy.Type {
+		case keysutil.KeyType_ECDSA_P256, keysutil.KeyType_ECDSA_P384, keysutil.KeyType_ECDSA_P521:
+			curve := elliptic.P256()
+			switch policy.Type {
+			case keysutil.KeyType_ECDSA_P384:
+				curve = elliptic.P384()
+			case keysutil.KeyType_ECDSA_P521:
+				curve = elliptic.P521()
+			}
+			return x509.MarshalPKCS8PrivateKey(&ecdsa.PrivateKey{
+				PublicKey: ecdsa.PublicKey{
+					Curve: curve,
+					X:     key.EC_X,
+					Y:     key.EC_Y,
+				},
+				D: key.EC_D,
+			})
+
+		case keysutil.KeyType_ED25519:
+			return x509.MarshalPKCS8PrivateKey(ed25519.PrivateKey(key.Key))
+
+		case keysutil.KeyType_ML_DSA_44, keysutil.KeyType_ML_DSA_65, keysutil.KeyType_ML_DSA_87:
+			return key.Key, nil
+
+		case keysutil.KeyType_ED25519_ML_DSA_65:
+			return append(append([]byte{}, key.Key...), key.HybridMLDSAKey...), nil
+
+		case keysutil.KeyType_RSA2048, keysutil.KeyType_RSA3072, keysutil.KeyType_RSA4096:
+			return x509.MarshalPKCS8PrivateKey(key.RSAKey)
+		}
+	}
+
+	return nil, fmt.Errorf("unknown key type %v", policy.Type)
+}
+
+// wrapExportKey wraps the key material under a fresh ephemeral AES-256 key
+// with AES-KWP, and the ephemeral key under the RSA public key with OAEP. The
+// result is the wrapped ephemeral key followed by the wrapped key material.
+func wrapExportKey(randReader io.Reader, wrapping *exportWrapping, key []byte) ([]byte, error) {
+	ephKey, err := uuid.GenerateRandomBytesWithReader(32, randReader)
+	if err != nil {
+		return nil, err
+	}
+
+	// Zero out the ephemeral AES key, as is done when importing keys
+	defer func() {
+		for i := range ephKey {
+			ephKey[i] = 0
+		}
+	}()
+
+	wrappedEphKey, err := rsa.EncryptOAEP(wrapping.hashFn, randReader, wrapping.publicKey, ephKey, []byte{})
+	if err != nil {
+		return nil, err
+	}
+
+	kwp, err := subtle.NewKWP(ephKey)
+	if err != nil {
+		return nil, err
+	}
+
+	wrappedKey, err := kwp.Wrap(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(wrappedEphKey, wrappedKey...), nil
+}
+
 func getExportKey(policy *keysutil.Policy, key *keysutil.KeyEntry, exportType string) (string, error) {
 	if policy == nil {
 		return "", errors.New("nil policy provided")
@@ -160,6 +388,9 @@ func getExportKey(policy *keysutil.Policy, key *keysutil.KeyEntry, exportType st
 
 		case keysutil.KeyType_RSA2048, keysutil.KeyType_RSA3072, keysutil.KeyType_RSA4096:
 			return encodeRSAPrivateKey(key.RSAKey), nil
+
+		case keysutil.KeyType_ML_KEM_768, keysutil.KeyType_ML_KEM_1024:
+			return strings.TrimSpace(base64.StdEncoding.EncodeToString(key.Key)), nil
 		}
 
 	case exportTypeSigningKey:
@@ -180,9 +411,12 @@ func getExportKey(policy *keysutil.Policy, key *keysutil.KeyEntry, exportType st
 			}
 			return ecKey, nil
 
-		case keysutil.KeyType_ED25519:
+		case keysutil.KeyType_ED25519, keysutil.KeyType_ML_DSA_44, keysutil.KeyType_ML_DSA_65, keysutil.KeyType_ML_DSA_87:
 			return strings.TrimSpace(base64.StdEncoding.EncodeToString(key.Key)), nil
 
+		case keysutil.KeyType_ED25519_ML_DSA_65:
+			return strings.TrimSpace(base64.StdEncoding.EncodeToString(append(append([]byte{}, key.Key...), key.HybridMLDSAKey...))), nil
+
 		case keysutil.KeyType_RSA2048, keysutil.KeyType_RSA3072, keysutil.KeyType_RSA4096:
 			return encodeRSAPrivateKey(key.RSAKey), nil
 		}
@@ -236,4 +470,9 @@ const pathExportHelpSyn = `Export named encryption or signing key`
 const pathExportHelpDesc = `
 This path is used to export the named keys that are configured as
 exportable.
+
+Writing to this path instead exports the keys wrapped under an RSA public
+key, supplied directly or as the name of another transit key, using the
+same RSA-OAEP and AES-KWP scheme as the import endpoints. This is allowed for
+keys configured as exportable or with allow_wrapped_export set.
 `
@@ -1,12 +1,20 @@
 package transit
 
 import (
+	"bytes"
 	"context"
+	"crypto/rsa"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
 	"fmt"
 	"reflect"
 	"strconv"
 	"testing"
 
+	"github.com/google/tink/go/kwp/subtle"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
@@ -374,3 +382,117 @@ func TestTransit_Export_EncryptionKey_DoesNotExportHMACKey(t *testing.T) {
 		t.Fatal("Encryption key data matched hmac key data")
 	}
 }
+
+func TestTransit_Export_Wrapped(t *testing.T) {
+	source, sourceStorage := createBackendWithSysView(t)
+	destination, destinationStorage := createBackendWithSysView(t)
+
+	doRequest := func(b *backend, s logical.Storage, op logical.Operation, path string, data map[string]interface{}, errExpected bool) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   s,
+			Operation: op,
+			Path:      path,
+			Data:      data,
+		})
+		if errExpected {
+			if err == nil && (resp == nil || !resp.IsError()) {
+				t.Fatalf("expected error for %s, got: %#v", path, resp)
+			}
+			return nil
+		}
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err: %v, resp: %#v", err, resp)
+		}
+		return resp
+	}
+
+	doRequest(source, sourceStorage, logical.UpdateOperation, "keys/aes", map[string]interface{}{"allow_wrapped_export": true}, false)
+	doRequest(source, sourceStorage, logical.UpdateOperation, "keys/ecdsa", map[string]interface{}{"type": "ecdsa-p256"}, false)
+
+	// Wrapped export does not make keys exportable in plaintext, and needs
+	// to be enabled on the key
+	doRequest(source, sourceStorage, logical.ReadOperation, "export/encryption-key/aes", nil, true)
+
+	resp := doRequest(destination, destinationStorage, logical.ReadOperation, "wrapping_key", nil, false)
+	publicKey := resp.Data["public_key"].(string)
+
+	doRequest(source, sourceStorage, logical.UpdateOperation, "export/signing-key/ecdsa", map[string]interface{}{"public_key": publicKey}, true)
+	doRequest(source, sourceStorage, logical.UpdateOperation, "keys/ecdsa/config", map[string]interface{}{"allow_wrapped_export": true}, false)
+
+	// Move both keys to the destination through its wrapping key
+	plaintext := "dGhlIHF1aWNrIGJyb3duIGZveA=="
+	for _, test := range []struct {
+		name       string
+		exportType string
+		keyType    string
+	}{
+		{"aes", "encryption-key", "aes256-gcm96"},
+		{"ecdsa", "signing-key", "ecdsa-p256"},
+	} {
+		resp = doRequest(source, sourceStorage, logical.UpdateOperation, "export/"+test.exportType+"/"+test.name+"/latest", map[string]interface{}{"public_key": publicKey}, false)
+		wrapped := resp.Data["keys"].(map[string]string)["1"]
+		doRequest(destination, destinationStorage, logical.UpdateOperation, "keys/"+test.name+"/import", map[string]interface{}{
+			"type":       test.keyType,
+			"ciphertext": wrapped,
+		}, false)
+	}
+
+	resp = doRequest(source, sourceStorage, logical.UpdateOperation, "encrypt/aes", map[string]interface{}{"plaintext": plaintext}, false)
+	resp = doRequest(destination, destinationStorage, logical.UpdateOperation, "decrypt/aes", map[string]interface{}{"ciphertext": resp.Data["ciphertext"]}, false)
+	if resp.Data["plaintext"] != plaintext {
+		t.Fatalf("bad: plaintext %v", resp.Data["plaintext"])
+	}
+
+	resp = doRequest(source, sourceStorage, logical.UpdateOperation, "sign/ecdsa", map[string]interface{}{"input": plaintext}, false)
+	resp = doRequest(destination, destinationStorage, logical.UpdateOperation, "verify/ecdsa", map[string]interface{}{"input": plaintext, "signature": resp.Data["signature"]}, false)
+	if !resp.Data["valid"].(bool) {
+		t.Fatal("expected signature to verify with the imported key")
+	}
+
+	// Keys may also be wrapped under another RSA key of the mount
+	doRequest(source, sourceStorage, logical.UpdateOperation, "keys/wrapper", map[string]interface{}{"type": "rsa-2048", "exportable": true}, false)
+	resp = doRequest(source, sourceStorage, logical.UpdateOperation, "export/hmac-key/aes/1", map[string]interface{}{
+		"wrapping_key":  "wrapper",
+		"hash_function": "SHA512",
+	}, false)
+	wrapped, err := base64.StdEncoding.DecodeString(resp.Data["keys"].(map[string]string)["1"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp = doRequest(source, sourceStorage, logical.ReadOperation, "export/encryption-key/wrapper/1", nil, false)
+	block, _ := pem.Decode([]byte(resp.Data["keys"].(map[string]string)["1"]))
+	wrapperKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ephKey, err := rsa.DecryptOAEP(sha512.New(), nil, wrapperKey, wrapped[:wrapperKey.Size()], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kwp, err := subtle.NewKWP(ephKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hmacKey, err := kwp.Unwrap(wrapped[wrapperKey.Size():])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, _, err := source.GetPolicy(context.Background(), keysutil.PolicyRequest{
+		Storage: sourceStorage,
+		Name:    "aes",
+	}, source.GetRandomReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(hmacKey, p.Keys["1"].HMACKey) {
+		t.Fatal("unwrapped HMAC key does not match")
+	}
+
+	// The wrapping key must be an RSA key, given exactly once
+	doRequest(source, sourceStorage, logical.UpdateOperation, "export/encryption-key/aes", map[string]interface{}{"wrapping_key": "ecdsa"}, true)
+	doRequest(source, sourceStorage, logical.UpdateOperation, "export/encryption-key/aes", map[string]interface{}{"wrapping_key": "wrapper", "public_key": publicKey}, true)
+	doRequest(source, sourceStorage, logical.UpdateOperation, "export/encryption-key/aes", nil, true)
+}
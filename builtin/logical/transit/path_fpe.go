@@ -0,0 +1,338 @@
+package transit
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/mitchellh/mapstructure"
+)
+
+const fpeDefaultAlphabet = "0123456789"
+
+// batchRequestFPEItem represents a request item for batch processing.
+// A map type allows us to distinguish between empty and missing values.
+type batchRequestFPEItem map[string]string
+
+// batchResponseFPEItem represents a response item for batch processing
+type batchResponseFPEItem struct {
+	// EncodedValue is the result of encoding the corresponding batch request
+	// item
+	EncodedValue string `json:"encoded_value,omitempty" mapstructure:"encoded_value"`
+
+	// DecodedValue is the result of decoding the corresponding batch request
+	// item
+	DecodedValue string `json:"decoded_value,omitempty" mapstructure:"decoded_value"`
+
+	// Error, if set represents a failure encountered while processing a
+	// corresponding batch request item
+	Error string `json:"error,omitempty" mapstructure:"error"`
+
+	// See batchResponseHMACItem: 'err' should never be serialized.
+	err error
+}
+
+func (b *backend) pathFPEFields() map[string]*framework.FieldSchema {
+	return map[string]*framework.FieldSchema{
+		"name": {
+			Type:        framework.TypeString,
+			Description: "The format-preserving encryption key to use",
+		},
+
+		"value": {
+			Type:        framework.TypeString,
+			Description: "The value to process, made up of characters of the alphabet",
+		},
+
+		"alphabet": {
+			Type:    framework.TypeString,
+			Default: fpeDefaultAlphabet,
+			Description: `The characters the value is made up of, each listed once. Must
+contain between 2 and 65536 characters. Defaults to the decimal digits.`,
+		},
+
+		"template": {
+			Type: framework.TypeString,
+			Description: `A regular expression the whole value must match. If it contains
+capture groups, only the characters matched by the groups are
+processed, as a single string; all other characters are left as they
+are. Groups may not be nested.`,
+		},
+
+		"tweak": {
+			Type: framework.TypeString,
+			Description: `Base64 encoded tweak. Values encoded with a tweak can only be decoded
+with the same tweak. Must be 7 bytes for "aes256-ff3-1" keys, where it
+defaults to 7 zero bytes; optional and of any length for "aes256-ff1"
+keys.`,
+		},
+
+		"key_version": {
+			Type: framework.TypeInt,
+			Description: `The version of the key to use. Defaults to the latest version. As
+encoded values do not record the key version, values must be decoded
+with the version they were encoded with.`,
+		},
+
+		"batch_input": {
+			Type: framework.TypeSlice,
+			Description: `
+Specifies a list of items to be processed in a single batch. When this
+parameter is set, the 'value' and 'tweak' parameters are ignored; each
+item instead holds its own 'value' and optional 'tweak'. The alphabet,
+template and key version apply to all items.`,
+		},
+	}
+}
+
+func (b *backend) pathEncode() *framework.Path {
+	return &framework.Path{
+		Pattern: "encode/" + framework.GenericNameRegex("name"),
+		Fields:  b.pathFPEFields(),
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathFPEWrite(keysutil.KeyUsageEncode),
+		},
+
+		HelpSynopsis:    pathEncodeHelpSyn,
+		HelpDescription: pathEncodeHelpDesc,
+	}
+}
+
+func (b *backend) pathDecode() *framework.Path {
+	return &framework.Path{
+		Pattern: "decode/" + framework.GenericNameRegex("name"),
+		Fields:  b.pathFPEFields(),
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathFPEWrite(keysutil.KeyUsageDecode),
+		},
+
+		HelpSynopsis:    pathDecodeHelpSyn,
+		HelpDescription: pathDecodeHelpDesc,
+	}
+}
+
+func (b *backend) pathFPEWrite(usage keysutil.KeyUsage) framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		name := d.Get("name").(string)
+		ver := d.Get("key_version").(int)
+		alphabet := d.Get("alphabet").(string)
+
+		var template *regexp.Regexp
+		if rawTemplate := d.Get("template").(string); rawTemplate != "" {
+			var err error
+			template, err = regexp.Compile("^(?:" + rawTemplate + ")$")
+			if err != nil {
+				return logical.ErrorResponse(fmt.Sprintf("invalid template: %s", err)), logical.ErrInvalidRequest
+			}
+		}
+
+		batchInputRaw := d.Raw["batch_input"]
+		var batchInputItems []batchRequestFPEItem
+		if batchInputRaw != nil {
+			err := mapstructure.Decode(batchInputRaw, &batchInputItems)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse batch input: %w", err)
+			}
+
+			if len(batchInputItems) == 0 {
+				return logical.ErrorResponse("missing batch input to process"), logical.ErrInvalidRequest
+			}
+		} else {
+			valueRaw, ok := d.GetOk("value")
+			if !ok {
+				return logical.ErrorResponse("missing value to process"), logical.ErrInvalidRequest
+			}
+
+			batchInputItems = make([]batchRequestFPEItem, 1)
+			batchInputItems[0] = batchRequestFPEItem{
+				"value": valueRaw.(string),
+			}
+			if tweak, ok := d.GetOk("tweak"); ok {
+				batchInputItems[0]["tweak"] = tweak.(string)
+			}
+		}
+
+		p, _, err := b.GetPolicy(ctx, keysutil.PolicyRequest{
+			Storage: req.Storage,
+			Name:    name,
+		}, b.GetRandomReader())
+		if err != nil {
+			return nil, err
+		}
+		if p == nil {
+			return logical.ErrorResponse("encryption key not found"), logical.ErrInvalidRequest
+		}
+		if !b.System().CachingDisabled() {
+			p.Lock(false)
+		}
+
+		if !p.Type.FPESupported() {
+			p.Unlock()
+			return logical.ErrorResponse(fmt.Sprintf("key type %v does not support format-preserving encryption", p.Type)), logical.ErrInvalidRequest
+		}
+
+		response := make([]batchResponseFPEItem, len(batchInputItems))
+
+		for i, item := range batchInputItems {
+			value, ok := item["value"]
+			if !ok {
+				response[i].Error = "missing value to process"
+				response[i].err = logical.ErrInvalidRequest
+				continue
+			}
+
+			var tweak []byte
+			if rawTweak, ok := item["tweak"]; ok {
+				tweak, err = base64.StdEncoding.DecodeString(rawTweak)
+				if err != nil {
+					response[i].Error = fmt.Sprintf("unable to decode tweak as base64: %s", err)
+					response[i].err = logical.ErrInvalidRequest
+					continue
+				}
+			}
+
+			result, err := fpeApplyTemplate(template, value, func(input string) (string, error) {
+				if usage == keysutil.KeyUsageEncode {
+					return p.FPEEncrypt(ver, tweak, alphabet, input)
+				}
+				return p.FPEDecrypt(ver, tweak, alphabet, input)
+			})
+			if err != nil {
+				response[i].Error = err.Error()
+				switch err.(type) {
+				case errutil.UserError:
+					response[i].err = logical.ErrInvalidRequest
+				default:
+					response[i].err = err
+				}
+				continue
+			}
+
+			if usage == keysutil.KeyUsageEncode {
+				response[i].EncodedValue = result
+			} else {
+				response[i].DecodedValue = result
+			}
+		}
+
+		if ver == 0 {
+			ver = p.LatestVersion
+		}
+
+		p.Unlock()
+
+		// Generate the response
+		resp := &logical.Response{}
+		if batchInputRaw != nil {
+			resp.Data = map[string]interface{}{
+				"batch_results": response,
+			}
+			return resp, nil
+		}
+
+		if response[0].Error != "" || response[0].err != nil {
+			if response[0].Error != "" {
+				return logical.ErrorResponse(response[0].Error), response[0].err
+			}
+			return nil, response[0].err
+		}
+
+		if usage == keysutil.KeyUsageEncode {
+			resp.Data = map[string]interface{}{
+				"encoded_value": response[0].EncodedValue,
+				"key_version":   ver,
+			}
+		} else {
+			resp.Data = map[string]interface{}{
+				"decoded_value": response[0].DecodedValue,
+			}
+		}
+
+		return resp, nil
+	}
+}
+
+// fpeApplyTemplate runs transform on the part of value selected by the
+// template: the concatenation of the text matched by its capture groups, or
+// the whole value if the template is nil or has no groups. The result of the
+// transformation is split back into the groups, leaving the rest of the value
+// untouched.
+func fpeApplyTemplate(template *regexp.Regexp, value string, transform func(string) (string, error)) (string, error) {
+	if template == nil || template.NumSubexp() == 0 {
+		if template != nil && !template.MatchString(value) {
+			return "", errutil.UserError{Err: "value does not match the template"}
+		}
+		return transform(value)
+	}
+
+	match := template.FindStringSubmatchIndex(value)
+	if match == nil {
+		return "", errutil.UserError{Err: "value does not match the template"}
+	}
+
+	// Collect the spans of the groups that took part in the match
+	var spans [][2]int
+	prevEnd := 0
+	for g := 1; g <= template.NumSubexp(); g++ {
+		start, end := match[2*g], match[2*g+1]
+		if start < 0 {
+			continue
+		}
+		if start < prevEnd {
+			return "", errutil.UserError{Err: "template capture groups may not be nested"}
+		}
+		spans = append(spans, [2]int{start, end})
+		prevEnd = end
+	}
+
+	var selected strings.Builder
+	for _, span := range spans {
+		selected.WriteString(value[span[0]:span[1]])
+	}
+
+	transformed, err := transform(selected.String())
+	if err != nil {
+		return "", err
+	}
+
+	// The transformation preserves the number of characters, so each group
+	// is replaced by as many characters as it matched
+	result := []rune(transformed)
+	var out strings.Builder
+	pos := 0
+	for _, span := range spans {
+		out.WriteString(value[pos:span[0]])
+		n := len([]rune(value[span[0]:span[1]]))
+		out.WriteString(string(result[:n]))
+		result = result[n:]
+		pos = span[1]
+	}
+	out.WriteString(value[pos:])
+
+	return out.String(), nil
+}
+
+const pathEncodeHelpSyn = `Encode a value using a format-preserving encryption key`
+
+const pathEncodeHelpDesc = `
+Encrypts a value with the FF1 or FF3-1 format-preserving encryption mode of
+the named key. The result has the same length as the value and is made up of
+characters of the same alphabet, so that it can be stored where the original
+value was expected. A template can be used to only encrypt parts of a value,
+for example the digits of a formatted credit card number.
+`
+
+const pathDecodeHelpSyn = `Decode a value using a format-preserving encryption key`
+
+const pathDecodeHelpDesc = `
+Decrypts a value encoded by the encode endpoint. The alphabet, template, tweak
+and key version must be the same as were used to encode the value.
+`
@@ -0,0 +1,157 @@
+package transit
+
+import (
+	"context"
+	"encoding/base64"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestTransit_FPE(t *testing.T) {
+	b, storage := createBackendWithSysView(t)
+
+	doRequest := func(path string, data map[string]interface{}, errExpected bool) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   storage,
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Data:      data,
+		})
+		if errExpected {
+			if err == nil && (resp == nil || !resp.IsError()) {
+				t.Fatalf("expected error for %s, got: %#v", path, resp)
+			}
+			return resp
+		}
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: %s: err: %v, resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+
+	for _, keyType := range []string{"aes256-ff1", "aes256-ff3-1"} {
+		doRequest("keys/"+keyType, map[string]interface{}{"type": keyType}, false)
+
+		// Plain digits
+		resp := doRequest("encode/"+keyType, map[string]interface{}{
+			"value": "4111111111111111",
+		}, false)
+		encoded := resp.Data["encoded_value"].(string)
+		if len(encoded) != 16 || encoded == "4111111111111111" {
+			t.Fatalf("%s: bad encoded value %q", keyType, encoded)
+		}
+		if resp.Data["key_version"].(int) != 1 {
+			t.Fatalf("%s: bad key version: %v", keyType, resp.Data["key_version"])
+		}
+		resp = doRequest("decode/"+keyType, map[string]interface{}{
+			"value": encoded,
+		}, false)
+		if resp.Data["decoded_value"].(string) != "4111111111111111" {
+			t.Fatalf("%s: bad decoded value: %v", keyType, resp.Data["decoded_value"])
+		}
+
+		// A template keeps the separators and only encrypts the groups
+		template := `(\d{4})-(\d{4})-(\d{4})-(\d{4})`
+		tweak := base64.StdEncoding.EncodeToString([]byte("7 bytes"))
+		resp = doRequest("encode/"+keyType, map[string]interface{}{
+			"value":    "4111-1111-1111-1111",
+			"template": template,
+			"tweak":    tweak,
+		}, false)
+		encoded = resp.Data["encoded_value"].(string)
+		if len(encoded) != 19 || encoded[4] != '-' || encoded[9] != '-' || encoded[14] != '-' {
+			t.Fatalf("%s: template format not preserved: %q", keyType, encoded)
+		}
+		resp = doRequest("decode/"+keyType, map[string]interface{}{
+			"value":    encoded,
+			"template": template,
+			"tweak":    tweak,
+		}, false)
+		if resp.Data["decoded_value"].(string) != "4111-1111-1111-1111" {
+			t.Fatalf("%s: bad decoded value: %v", keyType, resp.Data["decoded_value"])
+		}
+
+		// Custom alphabet in a batch
+		resp = doRequest("encode/"+keyType, map[string]interface{}{
+			"alphabet": "abcdefghijklmnopqrstuvwxyz",
+			"batch_input": []interface{}{
+				map[string]interface{}{"value": "helloworld"},
+				map[string]interface{}{"value": "Hello"},
+			},
+		}, false)
+		results := resp.Data["batch_results"].([]batchResponseFPEItem)
+		if results[0].Error != "" || len(results[0].EncodedValue) != 10 {
+			t.Fatalf("%s: bad batch result: %#v", keyType, results[0])
+		}
+		if results[1].Error == "" {
+			t.Fatalf("%s: expected error for a character outside the alphabet", keyType)
+		}
+
+		doRequest("encode/"+keyType, map[string]interface{}{
+			"value":    "4111-1111",
+			"template": template,
+		}, true)
+		doRequest("encode/"+keyType, map[string]interface{}{
+			"value": "12345",
+		}, true)
+	}
+
+	// FF3-1 tweaks must be 7 bytes
+	doRequest("encode/aes256-ff3-1", map[string]interface{}{
+		"value": "1234567890",
+		"tweak": base64.StdEncoding.EncodeToString([]byte("tweak")),
+	}, true)
+
+	// Other key types cannot be used
+	doRequest("keys/aes", nil, false)
+	doRequest("encode/aes", map[string]interface{}{
+		"value": "1234567890",
+	}, true)
+}
+
+func TestTransit_FPETemplate(t *testing.T) {
+	mask := func(s string) (string, error) {
+		out := []rune(s)
+		for i := range out {
+			out[i] = 'X'
+		}
+		return string(out), nil
+	}
+
+	cases := []struct {
+		template string
+		value    string
+		expected string
+		errors   bool
+	}{
+		{"", "1234", "XXXX", false},
+		{`\d+`, "1234", "XXXX", false},
+		{`\d+`, "12a4", "", true},
+		{`(\d{3})-\d{2}-(\d{4})`, "123-45-6789", "XXX-45-XXXX", false},
+		{`(\d+)(?:-(\d+))?`, "123", "XXX", false},
+		{`((\d+)-\d+)`, "12-34", "", true},
+	}
+
+	for _, c := range cases {
+		var template *regexp.Regexp
+		if c.template != "" {
+			template = regexp.MustCompile("^(?:" + c.template + ")$")
+		}
+		result, err := fpeApplyTemplate(template, c.value, mask)
+		if c.errors {
+			if err == nil {
+				t.Fatalf("expected error for template %q and value %q", c.template, c.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result != c.expected {
+			t.Fatalf("template %q and value %q: expected %q, got %q", c.template, c.value, c.expected, result)
+		}
+	}
+}
@@ -35,7 +35,9 @@ func (b *backend) pathImport() *framework.Path {
 				Default: "aes256-gcm96",
 				Description: `The type of key being imported. Currently, "aes128-gcm96" (symmetric), "aes256-gcm96" (symmetric), "ecdsa-p256"
 (asymmetric), "ecdsa-p384" (asymmetric), "ecdsa-p521" (asymmetric), "ed25519" (asymmetric), "rsa-2048" (asymmetric), "rsa-3072"
-(asymmetric), "rsa-4096" (asymmetric) are supported.  Defaults to "aes256-gcm96".
+(asymmetric), "rsa-4096" (asymmetric), "aes128-cmac" (MAC), "aes256-cmac" (MAC), "kmac128" (MAC),
+"kmac256" (MAC), "aes256-ff1" (format-preserving) and "aes256-ff3-1" (format-preserving) are supported.
+Defaults to "aes256-gcm96".
 `,
 			},
 			"hash_function": {
@@ -177,6 +179,18 @@ func (b *backend) pathImportWrite(ctx context.Context, req *logical.Request, d *
 		polReq.KeyType = keysutil.KeyType_RSA3072
 	case "rsa-4096":
 		polReq.KeyType = keysutil.KeyType_RSA4096
+	case "aes128-cmac":
+		polReq.KeyType = keysutil.KeyType_AES128_CMAC
+	case "aes256-cmac":
+		polReq.KeyType = keysutil.KeyType_AES256_CMAC
+	case "kmac128":
+		polReq.KeyType = keysutil.KeyType_KMAC128
+	case "kmac256":
+		polReq.KeyType = keysutil.KeyType_KMAC256
+	case "aes256-ff1":
+		polReq.KeyType = keysutil.KeyType_AES256_FF1
+	case "aes256-ff3-1":
+		polReq.KeyType = keysutil.KeyType_AES256_FF3_1
 	default:
 		return logical.ErrorResponse(fmt.Sprintf("unknown key type: %v", keyType)), logical.ErrInvalidRequest
 	}
@@ -46,7 +46,11 @@ func (b *backend) pathKeys() *framework.Path {
 				Description: `
 The type of key to create. Currently, "aes128-gcm96" (symmetric), "aes256-gcm96" (symmetric), "ecdsa-p256"
 (asymmetric), "ecdsa-p384" (asymmetric), "ecdsa-p521" (asymmetric), "ed25519" (asymmetric), "rsa-2048" (asymmetric), "rsa-3072"
-(asymmetric), "rsa-4096" (asymmetric) are supported.  Defaults to "aes256-gcm96".
+(asymmetric), "rsa-4096" (asymmetric), "ml-dsa-44" (asymmetric), "ml-dsa-65" (asymmetric), "ml-dsa-87"
+(asymmetric), "ed25519-ml-dsa-65" (asymmetric, hybrid), "ml-kem-768" (asymmetric), "ml-kem-1024"
+(asymmetric), "aes128-cmac" (MAC), "aes256-cmac" (MAC), "kmac128" (MAC), "kmac256" (MAC),
+"aes256-ff1" (format-preserving) and "aes256-ff3-1" (format-preserving) are supported.  Defaults
+to "aes256-gcm96".
 `,
 			},
 
@@ -80,6 +84,14 @@ This allows for all the valid keys
 in the key ring to be exported.`,
 			},
 
+			"allow_wrapped_export": {
+				Type: framework.TypeBool,
+				Description: `Enables the keys to be exported
+wrapped under a caller-supplied RSA
+public key, without making them
+exportable in plaintext.`,
+			},
+
 			"allow_plaintext_backup": {
 				Type: framework.TypeBool,
 				Description: `Enables taking a backup of the named
@@ -131,6 +143,7 @@ func (b *backend) pathPolicyWrite(ctx context.Context, req *logical.Request, d *
 	convergent := d.Get("convergent_encryption").(bool)
 	keyType := d.Get("type").(string)
 	exportable := d.Get("exportable").(bool)
+	allowWrappedExport := d.Get("allow_wrapped_export").(bool)
 	allowPlaintextBackup := d.Get("allow_plaintext_backup").(bool)
 	autoRotatePeriod := time.Second * time.Duration(d.Get("auto_rotate_period").(int))
 
@@ -149,6 +162,7 @@ func (b *backend) pathPolicyWrite(ctx context.Context, req *logical.Request, d *
 		Derived:              derived,
 		Convergent:           convergent,
 		Exportable:           exportable,
+		AllowWrappedExport:   allowWrappedExport,
 		AllowPlaintextBackup: allowPlaintextBackup,
 		AutoRotatePeriod:     autoRotatePeriod,
 	}
@@ -173,6 +187,30 @@ func (b *backend) pathPolicyWrite(ctx context.Context, req *logical.Request, d *
 		polReq.KeyType = keysutil.KeyType_RSA3072
 	case "rsa-4096":
 		polReq.KeyType = keysutil.KeyType_RSA4096
+	case "ml-dsa-44":
+		polReq.KeyType = keysutil.KeyType_ML_DSA_44
+	case "ml-dsa-65":
+		polReq.KeyType = keysutil.KeyType_ML_DSA_65
+	case "ml-dsa-87":
+		polReq.KeyType = keysutil.KeyType_ML_DSA_87
+	case "ed25519-ml-dsa-65":
+		polReq.KeyType = keysutil.KeyType_ED25519_ML_DSA_65
+	case "ml-kem-768":
+		polReq.KeyType = keysutil.KeyType_ML_KEM_768
+	case "ml-kem-1024":
+		polReq.KeyType = keysutil.KeyType_ML_KEM_1024
+	case "aes128-cmac":
+		polReq.KeyType = keysutil.KeyType_AES128_CMAC
+	case "aes256-cmac":
+		polReq.KeyType = keysutil.KeyType_AES256_CMAC
+	case "kmac128":
+		polReq.KeyType = keysutil.KeyType_KMAC128
+	case "kmac256":
+		polReq.KeyType = keysutil.KeyType_KMAC256
+	case "aes256-ff1":
+		polReq.KeyType = keysutil.KeyType_AES256_FF1
+	case "aes256-ff3-1":
+		polReq.KeyType = keysutil.KeyType_AES256_FF3_1
 	default:
 		return logical.ErrorResponse(fmt.Sprintf("unknown key type %v", keyType)), logical.ErrInvalidRequest
 	}
@@ -233,6 +271,8 @@ func (b *backend) pathPolicyRead(ctx context.Context, req *logical.Request, d *f
 			"min_encryption_version": p.MinEncryptionVersion,
 			"latest_version":         p.LatestVersion,
 			"exportable":             p.Exportable,
+			"allow_wrapped_export":   p.AllowWrappedExport,
+			"usage_policy":           p.UsagePolicy,
 			"allow_plaintext_backup": p.AllowPlaintextBackup,
 			"supports_encryption":    p.Type.EncryptionSupported(),
 			"supports_decryption":    p.Type.DecryptionSupported(),
@@ -284,14 +324,17 @@ func (b *backend) pathPolicyRead(ctx context.Context, req *logical.Request, d *f
 	}
 
 	switch p.Type {
-	case keysutil.KeyType_AES128_GCM96, keysutil.KeyType_AES256_GCM96, keysutil.KeyType_ChaCha20_Poly1305:
+	case keysutil.KeyType_AES128_GCM96, keysutil.KeyType_AES256_GCM96, keysutil.KeyType_ChaCha20_Poly1305,
+		keysutil.KeyType_AES128_CMAC, keysutil.KeyType_AES256_CMAC, keysutil.KeyType_KMAC128, keysutil.KeyType_KMAC256,
+		keysutil.KeyType_AES256_FF1, keysutil.KeyType_AES256_FF3_1:
 		retKeys := map[string]int64{}
 		for k, v := range p.Keys {
 			retKeys[k] = v.DeprecatedCreationTime
 		}
 		resp.Data["keys"] = retKeys
 
-	case keysutil.KeyType_ECDSA_P256, keysutil.KeyType_ECDSA_P384, keysutil.KeyType_ECDSA_P521, keysutil.KeyType_ED25519, keysutil.KeyType_RSA2048, keysutil.KeyType_RSA3072, keysutil.KeyType_RSA4096:
+	case keysutil.KeyType_ECDSA_P256, keysutil.KeyType_ECDSA_P384, keysutil.KeyType_ECDSA_P521, keysutil.KeyType_ED25519, keysutil.KeyType_RSA2048, keysutil.KeyType_RSA3072, keysutil.KeyType_RSA4096,
+		keysutil.KeyType_ML_DSA_44, keysutil.KeyType_ML_DSA_65, keysutil.KeyType_ML_DSA_87, keysutil.KeyType_ED25519_ML_DSA_65, keysutil.KeyType_ML_KEM_768, keysutil.KeyType_ML_KEM_1024:
 		retKeys := map[string]map[string]interface{}{}
 		for k, v := range p.Keys {
 			key := asymKey{
@@ -352,6 +395,12 @@ func (b *backend) pathPolicyRead(ctx context.Context, req *logical.Request, d *f
 					return nil, fmt.Errorf("failed to PEM-encode RSA public key")
 				}
 				key.PublicKey = string(pemBytes)
+
+			default:
+				// Post-quantum public keys are returned base64-encoded in
+				// their raw encoding; for hybrid keys, the ed25519 public key
+				// is followed by the ML-DSA public key.
+				key.Name = p.Type.String()
 			}
 
 			retKeys[k] = structs.New(key).Map()
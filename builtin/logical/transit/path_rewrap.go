@@ -37,6 +37,14 @@ func (b *backend) pathRewrap() *framework.Path {
 				Description: "Nonce for when convergent encryption is used",
 			},
 
+			"associated_data": {
+				Type: framework.TypeString,
+				Description: `
+Base64 encoded associated data supplied when the ciphertext was encrypted.
+The same associated data is bound to the rewrapped ciphertext. Only supported
+for "aes128-gcm96", "aes256-gcm96" and "chacha20-poly1305" keys.`,
+			},
+
 			"key_version": {
 				Type: framework.TypeInt,
 				Description: `The version of the key to use for encryption.
@@ -75,10 +83,11 @@ func (b *backend) pathRewrapWrite(ctx context.Context, req *logical.Request, d *
 
 		batchInputItems = make([]BatchRequestItem, 1)
 		batchInputItems[0] = BatchRequestItem{
-			Ciphertext: ciphertext,
-			Context:    d.Get("context").(string),
-			Nonce:      d.Get("nonce").(string),
-			KeyVersion: d.Get("key_version").(int),
+			Ciphertext:     ciphertext,
+			Context:        d.Get("context").(string),
+			Nonce:          d.Get("nonce").(string),
+			KeyVersion:     d.Get("key_version").(int),
+			AssociatedData: d.Get("associated_data").(string),
 		}
 	}
 
@@ -112,6 +121,15 @@ func (b *backend) pathRewrapWrite(ctx context.Context, req *logical.Request, d *
 				continue
 			}
 		}
+
+		// Decode the associated data
+		if len(item.AssociatedData) != 0 {
+			batchInputItems[i].DecodedAssociatedData, err = base64.StdEncoding.DecodeString(item.AssociatedData)
+			if err != nil {
+				batchResponseItems[i].Error = err.Error()
+				continue
+			}
+		}
 	}
 
 	// Get the policy
@@ -135,7 +153,7 @@ func (b *backend) pathRewrapWrite(ctx context.Context, req *logical.Request, d *
 			continue
 		}
 
-		plaintext, err := p.Decrypt(item.DecodedContext, item.DecodedNonce, item.Ciphertext)
+		plaintext, err := p.DecryptForUsage(keysutil.KeyUsageRewrap, item.DecodedContext, item.DecodedNonce, item.Ciphertext, item.DecodedAssociatedData)
 		if err != nil {
 			switch err.(type) {
 			case errutil.UserError:
@@ -151,7 +169,7 @@ func (b *backend) pathRewrapWrite(ctx context.Context, req *logical.Request, d *
 			warnAboutNonceUsage = true
 		}
 
-		ciphertext, err := p.Encrypt(item.KeyVersion, item.DecodedContext, item.DecodedNonce, plaintext)
+		ciphertext, err := p.ReencryptForUsage(keysutil.KeyUsageRewrap, item.KeyVersion, item.DecodedContext, item.DecodedNonce, plaintext, item.DecodedAssociatedData)
 		if err != nil {
 			switch err.(type) {
 			case errutil.UserError:
@@ -0,0 +1,238 @@
+//go:build go1.27
+
+package transit
+
+import (
+	"context"
+	"crypto/mldsa"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestBackend_datakey_MLKEM(t *testing.T) {
+	for _, keyType := range []string{"ml-kem-768", "ml-kem-1024"} {
+		b, storage := createBackendWithSysView(t)
+
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   storage,
+			Operation: logical.UpdateOperation,
+			Path:      "keys/kem",
+			Data: map[string]interface{}{
+				"type": keyType,
+			},
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err: %v, resp: %#v", err, resp)
+		}
+
+		resp, err = b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   storage,
+			Operation: logical.UpdateOperation,
+			Path:      "datakey/plaintext/kem",
+			Data: map[string]interface{}{
+				"bits": 512,
+			},
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("%s: err: %v, resp: %#v", keyType, err, resp)
+		}
+		plaintext := resp.Data["plaintext"].(string)
+		ciphertext := resp.Data["ciphertext"].(string)
+
+		resp, err = b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   storage,
+			Operation: logical.UpdateOperation,
+			Path:      "decrypt/kem",
+			Data: map[string]interface{}{
+				"ciphertext": ciphertext,
+			},
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("%s: err: %v, resp: %#v", keyType, err, resp)
+		}
+		if resp.Data["plaintext"] != plaintext {
+			t.Fatalf("%s: bad: expected %s, got %s", keyType, plaintext, resp.Data["plaintext"])
+		}
+
+		// Tampering with the encapsulated key must fail decryption
+		raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(ciphertext, "vault:v1:"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw[0] ^= 0xff
+		resp, err = b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   storage,
+			Operation: logical.UpdateOperation,
+			Path:      "decrypt/kem",
+			Data: map[string]interface{}{
+				"ciphertext": "vault:v1:" + base64.StdEncoding.EncodeToString(raw),
+			},
+		})
+		if err == nil && (resp == nil || !resp.IsError()) {
+			t.Fatalf("%s: expected error decrypting tampered ciphertext", keyType)
+		}
+	}
+}
+
+func TestTransit_AssociatedData_MLKEM(t *testing.T) {
+	b, storage := createBackendWithSysView(t)
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Storage:   storage,
+		Operation: logical.UpdateOperation,
+		Path:      "keys/kem",
+		Data: map[string]interface{}{
+			"type": "ml-kem-768",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+
+	// ML-KEM ciphertexts cannot authenticate associated data, so it's rejected
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Storage:   storage,
+		Operation: logical.UpdateOperation,
+		Path:      "encrypt/kem",
+		Data: map[string]interface{}{
+			"plaintext":       "dGhlIHF1aWNrIGJyb3duIGZveA==",
+			"associated_data": "aGVhZGVyIGRhdGE=",
+		},
+	})
+	if err == nil && (resp == nil || !resp.IsError()) {
+		t.Fatalf("expected error encrypting with associated data, got: %#v", resp)
+	}
+}
+
+func TestTransit_SignVerify_PostQuantum(t *testing.T) {
+	for _, keyType := range []string{"ml-dsa-44", "ml-dsa-65", "ml-dsa-87", "ed25519-ml-dsa-65"} {
+		keyType := keyType
+		t.Run(keyType, func(t *testing.T) {
+			testTransit_SignVerify_PostQuantum(t, keyType)
+		})
+	}
+}
+
+func testTransit_SignVerify_PostQuantum(t *testing.T, keyType string) {
+	b, storage := createBackendWithSysView(t)
+
+	req := &logical.Request{
+		Storage:   storage,
+		Operation: logical.UpdateOperation,
+		Path:      "keys/foo",
+		Data: map[string]interface{}{
+			"type": keyType,
+		},
+	}
+	_, err := b.HandleRequest(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Derivation is not supported for post-quantum keys
+	req.Path = "keys/bar"
+	req.Data["derived"] = true
+	resp, err := b.HandleRequest(context.Background(), req)
+	if err == nil && (resp == nil || !resp.IsError()) {
+		t.Fatalf("expected error creating derived %s key", keyType)
+	}
+
+	input := base64.StdEncoding.EncodeToString([]byte("the quick brown fox"))
+	req = &logical.Request{
+		Storage:   storage,
+		Operation: logical.UpdateOperation,
+		Path:      "sign/foo",
+		Data: map[string]interface{}{
+			"input": input,
+		},
+	}
+	resp, err = b.HandleRequest(context.Background(), req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	sig := resp.Data["signature"].(string)
+
+	verify := func(input, sig string) bool {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   storage,
+			Operation: logical.UpdateOperation,
+			Path:      "verify/foo",
+			Data: map[string]interface{}{
+				"input":     input,
+				"signature": sig,
+			},
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err: %v, resp: %#v", err, resp)
+		}
+		return resp.Data["valid"].(bool)
+	}
+
+	if !verify(input, sig) {
+		t.Fatal("expected signature to be valid")
+	}
+	if verify(base64.StdEncoding.EncodeToString([]byte("the quick brown dog")), sig) {
+		t.Fatal("expected signature over different input to be invalid")
+	}
+
+	sigBytes, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(sig, "vault:v1:"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Verify against the public key returned by the key endpoint.
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Storage:   storage,
+		Operation: logical.ReadOperation,
+		Path:      "keys/foo",
+	})
+	if err != nil || resp == nil {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	keyInfo := resp.Data["keys"].(map[string]map[string]interface{})["1"]
+	if keyInfo["name"] != keyType {
+		t.Fatalf("bad: key name %v", keyInfo["name"])
+	}
+	pubKey, err := base64.StdEncoding.DecodeString(keyInfo["public_key"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params := mldsa.MLDSA65()
+	opts := &mldsa.Options{}
+	switch keyType {
+	case "ml-dsa-44":
+		params = mldsa.MLDSA44()
+	case "ml-dsa-87":
+		params = mldsa.MLDSA87()
+	case "ed25519-ml-dsa-65":
+		// Both halves of a hybrid signature must be present.
+		if verify(input, "vault:v1:"+base64.StdEncoding.EncodeToString(sigBytes[ed25519.SignatureSize:])) {
+			t.Fatal("expected signature without ed25519 half to be invalid")
+		}
+		if verify(input, "vault:v1:"+base64.StdEncoding.EncodeToString(sigBytes[:ed25519.SignatureSize])) {
+			t.Fatal("expected signature without ML-DSA half to be invalid")
+		}
+
+		if !ed25519.Verify(pubKey[:ed25519.PublicKeySize], []byte("the quick brown fox"), sigBytes[:ed25519.SignatureSize]) {
+			t.Fatal("expected ed25519 half to verify against the public key")
+		}
+		pubKey = pubKey[ed25519.PublicKeySize:]
+		sigBytes = sigBytes[ed25519.SignatureSize:]
+		opts.Context = "vault-transit-ed25519-ml-dsa-65"
+	}
+
+	pqKey, err := mldsa.NewPublicKey(params, pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mldsa.Verify(pqKey, []byte("the quick brown fox"), sigBytes, opts); err != nil {
+		t.Fatalf("expected ML-DSA signature to verify against the public key: %v", err)
+	}
+}
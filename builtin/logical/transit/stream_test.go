@@ -0,0 +1,75 @@
+package transit_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/builtin/logical/transit"
+	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault"
+)
+
+func TestTransit_StreamClient(t *testing.T) {
+	coreConfig := &vault.CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"transit": transit.Factory,
+		},
+	}
+	cluster := vault.NewTestCluster(t, coreConfig, &vault.TestClusterOptions{
+		HandlerFunc: vaulthttp.Handler,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+	cores := cluster.Cores
+	vault.TestWaitActive(t, cores[0].Core)
+	client := cores[0].Client
+	err := client.Sys().Mount("transit", &api.MountInput{
+		Type: "transit",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Logical().Write("transit/keys/foo", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	stream := client.TransitStream("foo")
+	stream.ChunkSize = 1000
+
+	// Cover an empty payload, a payload that is an exact number of chunks
+	// and one with a partial final chunk
+	for _, size := range []int{0, 3000, 4321} {
+		plaintext := make([]byte, size)
+		if _, err := rand.Read(plaintext); err != nil {
+			t.Fatal(err)
+		}
+
+		var encrypted bytes.Buffer
+		if err := stream.Encrypt(&encrypted, bytes.NewReader(plaintext)); err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(encrypted.Bytes(), plaintext) && size > 0 {
+			t.Fatal("plaintext found in encrypted stream")
+		}
+
+		var decrypted bytes.Buffer
+		if err := stream.Decrypt(&decrypted, bytes.NewReader(encrypted.Bytes())); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decrypted.Bytes(), plaintext) {
+			t.Fatalf("bad: %d byte payload did not round trip", size)
+		}
+
+		if size == 4321 {
+			// Dropping the final chunk must be detected, as the new last
+			// chunk was not encrypted as the final one
+			truncated := encrypted.Bytes()[:encrypted.Len()-(321+4+28)]
+			if err := stream.Decrypt(&bytes.Buffer{}, bytes.NewReader(truncated)); err == nil {
+				t.Fatal("expected error decrypting truncated stream")
+			}
+		}
+	}
+}
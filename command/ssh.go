@@ -1,6 +1,7 @@
 package command
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -8,6 +9,7 @@ import (
 	"os"
 	"os/exec"
 	"os/user"
+	"path/filepath"
 	"strings"
 	"syscall"
 
@@ -19,6 +21,10 @@ import (
 	"github.com/posener/complete"
 )
 
+// sshModeKnownHosts is the mode which configures the trusted host CA keys of
+// the SSH client instead of establishing a connection.
+const sshModeKnownHosts = "known-hosts"
+
 var (
 	_ cli.Command             = (*SSHCommand)(nil)
 	_ cli.CommandAutocomplete = (*SSHCommand)(nil)
@@ -51,6 +57,7 @@ func (c *SSHCommand) Synopsis() string {
 func (c *SSHCommand) Help() string {
 	helpText := `
 Usage: vault ssh [options] username@ip [ssh options]
+       vault ssh -mode=known-hosts [options]
 
   Establishes an SSH connection with the target machine.
 
@@ -75,6 +82,11 @@ Usage: vault ssh [options] username@ip [ssh options]
           -host-key-hostnames=example.com \
           user@example.com
 
+  Trust the host certificates signed by a mount in the user's known_hosts
+  file, for the domains allowed by its roles:
+
+      $ vault ssh -mode=known-hosts -host-key-mount-point=host-signer
+
   For the full list of options and arguments, please see the documentation.
 
 ` + c.Flags().Help()
@@ -95,8 +107,10 @@ func (c *SSHCommand) Flags() *FlagSets {
 		Target:     &c.flagMode,
 		Default:    "",
 		EnvVar:     "",
-		Completion: complete.PredictSet("ca", "dynamic", "otp"),
-		Usage:      "Name of the authentication mode (ca, dynamic, otp).",
+		Completion: complete.PredictSet("ca", "dynamic", "otp", sshModeKnownHosts),
+		Usage: "Name of the authentication mode (ca, dynamic, otp). The " +
+			"\"known-hosts\" mode does not establish a connection; it writes the " +
+			"host CA keys of -host-key-mount-point to the user's known hosts file.",
 	})
 
 	f.StringVar(&StringVar{
@@ -192,7 +206,9 @@ func (c *SSHCommand) Flags() *FlagSets {
 		Completion: complete.PredictAnything,
 		Usage: "List of hostnames to delegate for the CA. The default value " +
 			"allows all domains and IPs. This is specified as a comma-separated " +
-			"list of values.",
+			"list of values. In the \"known-hosts\" mode, the default value " +
+			"delegates the domains allowed by the roles of the mount, or by the " +
+			"role given with -role.",
 	})
 
 	f.StringVar(&StringVar{
@@ -248,6 +264,10 @@ func (c *SSHCommand) Run(args []string) int {
 	c.flagPublicKeyPath = expandPath(c.flagPublicKeyPath)
 	c.flagPrivateKeyPath = expandPath(c.flagPrivateKeyPath)
 
+	if strings.ToLower(c.flagMode) == sshModeKnownHosts {
+		return c.handleKnownHosts()
+	}
+
 	args = f.Args()
 	if len(args) < 1 {
 		c.UI.Error(fmt.Sprintf("Not enough arguments, (expected 1-n, got %d)", len(args)))
@@ -711,6 +731,101 @@ func (c *SSHCommand) generateCredential(username, ip string) (*api.Secret, *SSHC
 	return secret, &resp, nil
 }
 
+// handleKnownHosts writes the known_hosts entries of the host key mount to the
+// user's known hosts file, replacing the entries previously written for the
+// mount.
+func (c *SSHCommand) handleKnownHosts() int {
+	mountPoint := strings.Trim(c.flagHostKeyMountPoint, "/")
+	if mountPoint == "" {
+		c.UI.Error("Missing -host-key-mount-point for the known-hosts mode")
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	r := client.NewRequest("GET", "/v1/"+mountPoint+"/known_hosts")
+	if c.flagRole != "" {
+		r.Params.Set("role", c.flagRole)
+	}
+	if c.flagHostKeyHostnames != "" && c.flagHostKeyHostnames != "*" {
+		r.Params.Set("domains", c.flagHostKeyHostnames)
+	}
+	resp, err := client.RawRequest(r)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error reading known hosts entries: %s", err))
+		return 2
+	}
+	entries, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error reading known hosts entries: %s", err))
+		return 2
+	}
+	if len(bytes.TrimSpace(entries)) == 0 {
+		c.UI.Error(fmt.Sprintf("No host signing keys configured at %s", mountPoint))
+		return 2
+	}
+
+	knownHostsFile := c.flagUserKnownHostsFile
+	if knownHostsFile == "" {
+		knownHostsFile = expandPath("~/.ssh/known_hosts")
+	}
+	if err := updateKnownHostsFile(knownHostsFile, mountPoint, string(entries)); err != nil {
+		c.UI.Error(fmt.Sprintf("Error updating %s: %s", knownHostsFile, err))
+		return 1
+	}
+
+	c.UI.Output(fmt.Sprintf("Success! Trusted the host keys signed by %s in %s", mountPoint, knownHostsFile))
+	return 0
+}
+
+// updateKnownHostsFile replaces the block of entries managed for the mount
+// point in the known_hosts file at path, adding it if the file has none.
+// Entries outside of the block are left untouched.
+func updateKnownHostsFile(path, mountPoint, entries string) error {
+	begin := fmt.Sprintf("# BEGIN Vault SSH host CA %s", mountPoint)
+	end := fmt.Sprintf("# END Vault SSH host CA %s", mountPoint)
+
+	perms := os.FileMode(0o644)
+	existing, err := ioutil.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	default:
+		if info, err := os.Stat(path); err == nil {
+			perms = info.Mode().Perm()
+		}
+	}
+
+	var lines []string
+	if trimmed := strings.TrimRight(string(existing), "\n"); trimmed != "" {
+		inBlock := false
+		for _, line := range strings.Split(trimmed, "\n") {
+			switch {
+			case line == begin:
+				inBlock = true
+			case line == end:
+				inBlock = false
+			case !inBlock:
+				lines = append(lines, line)
+			}
+		}
+	}
+
+	lines = append(lines, begin, strings.TrimRight(entries, "\n"), end)
+	return ioutil.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), perms)
+}
+
 // writeTemporaryFile writes a file to a temp location with the given data and
 // file permissions.
 func (c *SSHCommand) writeTemporaryFile(name string, data []byte, perms os.FileMode) (string, error, func() error) {
@@ -1,6 +1,9 @@
 package command
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/mitchellh/cli"
@@ -214,3 +217,62 @@ func TestIsSingleSSHArg(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateKnownHostsFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), ".ssh", "known_hosts")
+
+	read := func() string {
+		t.Helper()
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	// The file and its directory are created if missing
+	if err := updateKnownHostsFile(path, "host-signer", "@cert-authority *.example.com ssh-ed25519 AAAA1\n"); err != nil {
+		t.Fatal(err)
+	}
+	expected := "# BEGIN Vault SSH host CA host-signer\n" +
+		"@cert-authority *.example.com ssh-ed25519 AAAA1\n" +
+		"# END Vault SSH host CA host-signer\n"
+	if actual := read(); actual != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, actual)
+	}
+
+	// Other entries are kept and the block of the mount is replaced
+	if err := ioutil.WriteFile(path, []byte("example.org ssh-ed25519 AAAA0\n"+read()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := updateKnownHostsFile(path, "other-signer", "@cert-authority *.example.net ssh-ed25519 AAAA3\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := updateKnownHostsFile(path, "host-signer", "@cert-authority *.example.com ssh-ed25519 AAAA1\n@cert-authority *.example.com ssh-ed25519 AAAA2\n"); err != nil {
+		t.Fatal(err)
+	}
+	expected = "example.org ssh-ed25519 AAAA0\n" +
+		"# BEGIN Vault SSH host CA other-signer\n" +
+		"@cert-authority *.example.net ssh-ed25519 AAAA3\n" +
+		"# END Vault SSH host CA other-signer\n" +
+		"# BEGIN Vault SSH host CA host-signer\n" +
+		"@cert-authority *.example.com ssh-ed25519 AAAA1\n" +
+		"@cert-authority *.example.com ssh-ed25519 AAAA2\n" +
+		"# END Vault SSH host CA host-signer\n"
+	if actual := read(); actual != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, actual)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected the file permissions to be kept, got %v", info.Mode().Perm())
+	}
+}
@@ -1174,6 +1174,17 @@ func isForm(head []byte, contentType string) bool {
 	return true
 }
 
+// isOcspRequest returns whether the request body is a DER-encoded OCSP
+// request, which must be passed through to the backend unparsed.
+func isOcspRequest(contentType string) bool {
+	contentType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return contentType == "application/ocsp-request"
+}
+
 func respondError(w http.ResponseWriter, status int, err error) {
 	logical.RespondError(w, status, err)
 }
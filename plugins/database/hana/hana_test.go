@@ -6,12 +6,14 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/hashicorp/vault/sdk/database/dbplugin/v5"
 	dbtesting "github.com/hashicorp/vault/sdk/database/dbplugin/v5/testing"
+	"github.com/hashicorp/vault/sdk/helper/template"
 )
 
 func TestHANA_Initialize(t *testing.T) {
@@ -296,3 +298,58 @@ DROP USER {{name}} CASCADE;`
 
 const testHANAUpdate = `
 ALTER USER {{name}} PASSWORD "{{password}}" NO FORCE_FIRST_PASSWORD_CHANGE;`
+
+func TestHANA_UsernameGeneration(t *testing.T) {
+	type testCase struct {
+		usernameTemplate string
+		data             dbplugin.UsernameMetadata
+		expectedRegex    string
+	}
+
+	tests := map[string]testCase{
+		"default template": {
+			usernameTemplate: defaultUserNameTemplate,
+			data: dbplugin.UsernameMetadata{
+				DisplayName: "token",
+				RoleName:    "myrole",
+			},
+			expectedRegex: `^v_token_myrole_[a-zA-Z0-9]{20}_[0-9]{10}$`,
+		},
+		"default template truncates display name": {
+			usernameTemplate: defaultUserNameTemplate,
+			data: dbplugin.UsernameMetadata{
+				DisplayName: "abcdefghijklmnopqrstuvwxyz0123456789",
+				RoleName:    "myrole",
+			},
+			expectedRegex: `^v_abcdefghijklmnopqrstuvwxyz012345_myrole_[a-zA-Z0-9]{20}_[0-9]{10}$`,
+		},
+		"identity template": {
+			usernameTemplate: `{{ printf "v_%s_%s" (index .EntityMetadata "team") (.EntityID | truncate 8) }}`,
+			data: dbplugin.UsernameMetadata{
+				DisplayName:    "token",
+				RoleName:       "myrole",
+				EntityID:       "0e4b0bfe-7e07-4f6b-a0ba-6a44bdfae7b2",
+				EntityMetadata: map[string]string{"team": "ops"},
+				MountAccessor:  "database_8a3c6b1e",
+			},
+			expectedRegex: `^v_ops_0e4b0bfe$`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			up, err := template.NewTemplate(template.Template(test.usernameTemplate))
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+
+			username, err := up.Generate(test.data)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if !regexp.MustCompile(test.expectedRegex).MatchString(username) {
+				t.Fatalf("username %q does not match %q", username, test.expectedRegex)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
 	stdmysql "github.com/go-sql-driver/mysql"
@@ -105,6 +106,10 @@ func (m *MySQL) Initialize(ctx context.Context, req dbplugin.InitializeRequest)
 	resp := dbplugin.InitializeResponse{
 		Config: req.Config,
 	}
+	resp.SetSupportedCredentialTypes([]dbplugin.CredentialType{
+		dbplugin.CredentialTypePassword,
+		dbplugin.CredentialTypeClientCertificate,
+	})
 
 	return resp, nil
 }
@@ -123,10 +128,16 @@ func (m *MySQL) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (dbplu
 
 	expirationStr := req.Expiration.Format("2006-01-02 15:04:05-0700")
 
+	subject, err := opensslSubject(req.Subject)
+	if err != nil {
+		return dbplugin.NewUserResponse{}, err
+	}
+
 	queryMap := map[string]string{
 		"name":       username,
 		"username":   username,
 		"password":   password,
+		"subject":    subject,
 		"expiration": expirationStr,
 	}
 
@@ -189,7 +200,7 @@ func (m *MySQL) DeleteUser(ctx context.Context, req dbplugin.DeleteUserRequest)
 }
 
 func (m *MySQL) UpdateUser(ctx context.Context, req dbplugin.UpdateUserRequest) (dbplugin.UpdateUserResponse, error) {
-	if req.Password == nil && req.Expiration == nil {
+	if req.Password == nil && req.Subject == nil && req.Expiration == nil {
 		return dbplugin.UpdateUserResponse{}, fmt.Errorf("no change requested")
 	}
 
@@ -200,6 +211,13 @@ func (m *MySQL) UpdateUser(ctx context.Context, req dbplugin.UpdateUserRequest)
 		}
 	}
 
+	if req.Subject != nil {
+		err := m.changeUserSubject(ctx, req.Username, req.Subject.NewSubject, req.Subject.Statements.Commands)
+		if err != nil {
+			return dbplugin.UpdateUserResponse{}, fmt.Errorf("failed to change subject: %w", err)
+		}
+	}
+
 	// Expiration change/update is currently a no-op
 
 	return dbplugin.UpdateUserResponse{}, nil
@@ -226,6 +244,95 @@ func (m *MySQL) changeUserPassword(ctx context.Context, username, password strin
 	return nil
 }
 
+// changeUserSubject runs the statements changing the client certificate
+// credential of the user, such as "ALTER USER ... REQUIRE SUBJECT". Users
+// only required to present a certificate signed by a trusted CA need none.
+func (m *MySQL) changeUserSubject(ctx context.Context, username, subject string, rotateStatements []string) error {
+	if username == "" || subject == "" {
+		return errors.New("must provide both username and subject")
+	}
+
+	subject, err := opensslSubject(subject)
+	if err != nil {
+		return err
+	}
+
+	queryMap := map[string]string{
+		"name":     username,
+		"username": username,
+		"subject":  subject,
+	}
+
+	return m.executePreparedStatementsWithMap(ctx, rotateStatements, queryMap)
+}
+
+// opensslAttributeNames maps the attribute types Go renders in RFC 2253
+// distinguished names to the short names used by OpenSSL, where they differ.
+var opensslAttributeNames = map[string]string{
+	"SERIALNUMBER": "serialNumber",
+	"STREET":       "street",
+	"POSTALCODE":   "postalCode",
+}
+
+// opensslSubject converts a distinguished name from the RFC 2253 form sent by
+// Vault to the OpenSSL form MySQL compares REQUIRE SUBJECT against, in which
+// the attributes are listed in certificate order, each preceded by a slash:
+// "CN=foo,O=bar" becomes "/O=bar/CN=foo".
+func opensslSubject(dn string) (string, error) {
+	if dn == "" {
+		return "", nil
+	}
+
+	var rdns [][]string
+	var attrs []string
+	var attr strings.Builder
+	for i := 0; i < len(dn); i++ {
+		switch c := dn[i]; c {
+		case '\\':
+			if i+1 >= len(dn) {
+				return "", fmt.Errorf("invalid subject %q: trailing escape", dn)
+			}
+			if i+2 < len(dn) && isHexDigit(dn[i+1]) && isHexDigit(dn[i+2]) {
+				b, _ := strconv.ParseUint(dn[i+1:i+3], 16, 8)
+				attr.WriteByte(byte(b))
+				i += 2
+			} else {
+				attr.WriteByte(dn[i+1])
+				i++
+			}
+		case '+':
+			attrs = append(attrs, attr.String())
+			attr.Reset()
+		case ',':
+			rdns = append(rdns, append(attrs, attr.String()))
+			attrs = nil
+			attr.Reset()
+		default:
+			attr.WriteByte(c)
+		}
+	}
+	rdns = append(rdns, append(attrs, attr.String()))
+
+	var subject strings.Builder
+	for i := len(rdns) - 1; i >= 0; i-- {
+		for _, attr := range rdns[i] {
+			typ, value, ok := strings.Cut(attr, "=")
+			if !ok {
+				return "", fmt.Errorf("invalid subject %q: attribute %q has no value", dn, attr)
+			}
+			if name, ok := opensslAttributeNames[typ]; ok {
+				typ = name
+			}
+			subject.WriteString("/" + typ + "=" + value)
+		}
+	}
+	return subject.String(), nil
+}
+
+func isHexDigit(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
 // executePreparedStatementsWithMap loops through the given templated SQL statements and
 // applies the map to them, interpolating values into the templates, returning
 // the resulting username and password
@@ -2,8 +2,10 @@ package mysql
 
 import (
 	"context"
+	"crypto/x509/pkix"
 	"database/sql"
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -742,6 +744,122 @@ func TestMySQL_UpdateUser(t *testing.T) {
 	}
 }
 
+func TestMySQL_UpdateUser_Subject(t *testing.T) {
+	cleanup, connURL := mysqlhelper.PrepareTestContainer(t, false, "secret")
+	defer cleanup()
+
+	dbUser := "vaultstatictest"
+	createStatements := `
+		CREATE USER '{{name}}'@'%' IDENTIFIED BY '{{password}}';
+		GRANT SELECT ON *.* TO '{{name}}'@'%';`
+	createTestMySQLUser(t, connURL, dbUser, "password", createStatements)
+
+	initReq := dbplugin.InitializeRequest{
+		Config: map[string]interface{}{
+			"connection_url": connURL,
+		},
+		VerifyConnection: true,
+	}
+
+	// Give a timeout just in case the test decides to be problematic
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	db := newMySQL(DefaultUserNameTemplate)
+	defer db.Close()
+	initResp, err := db.Initialize(context.Background(), initReq)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	supported := initResp.Config[dbplugin.SupportedCredentialTypesKey]
+	if !reflect.DeepEqual(supported, []interface{}{"password", "client_certificate"}) {
+		t.Fatalf("bad supported credential types: %v", supported)
+	}
+
+	// Vault sends the subject in RFC 2253 format, as rendered by Go
+	subjectName := pkix.Name{
+		CommonName:   "vaultstatictest",
+		Organization: []string{"Vault"},
+	}
+	updateReq := dbplugin.UpdateUserRequest{
+		Username:       dbUser,
+		CredentialType: dbplugin.CredentialTypeClientCertificate,
+		Subject: &dbplugin.ChangeSubject{
+			NewSubject: subjectName.String(),
+			Statements: dbplugin.Statements{
+				Commands: []string{`
+					ALTER USER '{{name}}'@'%' REQUIRE SUBJECT '{{subject}}';`},
+			},
+		},
+	}
+	if _, err := db.UpdateUser(ctx, updateReq); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	conn, err := sql.Open("mysql", connURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	var subject string
+	if err := conn.QueryRow("SELECT x509_subject FROM mysql.user WHERE user = ?", dbUser).Scan(&subject); err != nil {
+		t.Fatal(err)
+	}
+	if subject != "/O=Vault/CN=vaultstatictest" {
+		t.Fatalf("bad subject: %q", subject)
+	}
+}
+
+func TestMySQL_OpenSSLSubject(t *testing.T) {
+	tests := map[string]struct {
+		name     pkix.Name
+		expected string
+	}{
+		"common name": {
+			name:     pkix.Name{CommonName: "vault-role-token"},
+			expected: "/CN=vault-role-token",
+		},
+		"certificate order": {
+			name: pkix.Name{
+				Country:            []string{"US"},
+				Organization:       []string{"HashiCorp"},
+				OrganizationalUnit: []string{"Vault"},
+				CommonName:         "vault-role-token",
+			},
+			expected: "/C=US/O=HashiCorp/OU=Vault/CN=vault-role-token",
+		},
+		"escaped characters": {
+			name:     pkix.Name{CommonName: `a,b+c"d\e<f>g;h`, Organization: []string{" #Vault "}},
+			expected: `/O= #Vault /CN=a,b+c"d\e<f>g;h`,
+		},
+		"renamed attributes": {
+			name: pkix.Name{
+				ExtraNames: []pkix.AttributeTypeAndValue{
+					{Type: []int{2, 5, 4, 3}, Value: "vault"},
+				},
+				SerialNumber: "1234",
+			},
+			expected: "/serialNumber=1234/CN=vault",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			subject, err := opensslSubject(test.name.String())
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if subject != test.expected {
+				t.Fatalf("expected %q for %q, got %q", test.expected, test.name.String(), subject)
+			}
+		})
+	}
+
+	if _, err := opensslSubject(`CN=vault\`); err == nil {
+		t.Fatal("expected error for trailing escape")
+	}
+}
+
 func createTestMySQLUser(t *testing.T, connURL, username, password, query string) {
 	t.Helper()
 	db, err := sql.Open("mysql", connURL)
@@ -100,6 +100,11 @@ func (p *PostgreSQL) Initialize(ctx context.Context, req dbplugin.InitializeRequ
 	resp := dbplugin.InitializeResponse{
 		Config: newConf,
 	}
+	resp.SetSupportedCredentialTypes([]dbplugin.CredentialType{
+		dbplugin.CredentialTypePassword,
+		dbplugin.CredentialTypeClientCertificate,
+	})
+	resp.SetSelfManagedPasswordSupported()
 	return resp, nil
 }
 
@@ -120,13 +125,17 @@ func (p *PostgreSQL) UpdateUser(ctx context.Context, req dbplugin.UpdateUserRequ
 	if req.Username == "" {
 		return dbplugin.UpdateUserResponse{}, fmt.Errorf("missing username")
 	}
-	if req.Password == nil && req.Expiration == nil {
+	if req.Password == nil && req.Subject == nil && req.Expiration == nil {
 		return dbplugin.UpdateUserResponse{}, fmt.Errorf("no changes requested")
 	}
 
 	merr := &multierror.Error{}
 	if req.Password != nil {
-		err := p.changeUserPassword(ctx, req.Username, req.Password)
+		err := p.changeUserPassword(ctx, req.Username, req.Password, req.SelfManagedPassword)
+		merr = multierror.Append(merr, err)
+	}
+	if req.Subject != nil {
+		err := p.changeUserSubject(ctx, req.Username, req.Subject)
 		merr = multierror.Append(merr, err)
 	}
 	if req.Expiration != nil {
@@ -136,7 +145,7 @@ func (p *PostgreSQL) UpdateUser(ctx context.Context, req dbplugin.UpdateUserRequ
 	return dbplugin.UpdateUserResponse{}, merr.ErrorOrNil()
 }
 
-func (p *PostgreSQL) changeUserPassword(ctx context.Context, username string, changePass *dbplugin.ChangePassword) error {
+func (p *PostgreSQL) changeUserPassword(ctx context.Context, username string, changePass *dbplugin.ChangePassword, selfManagedPassword string) error {
 	stmts := changePass.Statements.Commands
 	if len(stmts) == 0 {
 		stmts = []string{defaultChangePasswordStatement}
@@ -150,9 +159,21 @@ func (p *PostgreSQL) changeUserPassword(ctx context.Context, username string, ch
 	p.Lock()
 	defer p.Unlock()
 
-	db, err := p.getConnection(ctx)
-	if err != nil {
-		return fmt.Errorf("unable to get connection: %w", err)
+	var db *sql.DB
+	var err error
+	if selfManagedPassword != "" {
+		// Self-managed users change their own password, so connect as the
+		// user rather than with the credentials of the connection
+		db, err = p.StaticConnection(ctx, username, selfManagedPassword)
+		if err != nil {
+			return fmt.Errorf("unable to get connection as self-managed user: %w", err)
+		}
+		defer db.Close()
+	} else {
+		db, err = p.getConnection(ctx)
+		if err != nil {
+			return fmt.Errorf("unable to get connection: %w", err)
+		}
 	}
 
 	// Check if the role exists
@@ -193,6 +214,53 @@ func (p *PostgreSQL) changeUserPassword(ctx context.Context, username string, ch
 	return nil
 }
 
+// changeUserSubject runs the statements changing the client certificate
+// credential of the user. PostgreSQL maps client certificates to roles through
+// the "cert" authentication method of pg_hba.conf, so there is nothing to run
+// by default.
+func (p *PostgreSQL) changeUserSubject(ctx context.Context, username string, changeSubject *dbplugin.ChangeSubject) error {
+	if changeSubject.NewSubject == "" {
+		return fmt.Errorf("missing subject")
+	}
+	if len(changeSubject.Statements.Commands) == 0 {
+		return nil
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	db, err := p.getConnection(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to get connection: %w", err)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("unable to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range changeSubject.Statements.Commands {
+		for _, query := range strutil.ParseArbitraryStringSlice(stmt, ";") {
+			query = strings.TrimSpace(query)
+			if len(query) == 0 {
+				continue
+			}
+
+			m := map[string]string{
+				"name":     username,
+				"username": username,
+				"subject":  changeSubject.NewSubject,
+			}
+			if err := dbtxn.ExecuteTxQueryDirect(ctx, tx, m, query); err != nil {
+				return fmt.Errorf("failed to execute query: %w", err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
 func (p *PostgreSQL) changeUserExpiration(ctx context.Context, username string, changeExp *dbplugin.ChangeExpiration) error {
 	p.Lock()
 	defer p.Unlock()
@@ -271,6 +339,7 @@ func (p *PostgreSQL) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (
 				"name":       username,
 				"username":   username,
 				"password":   req.Password,
+				"subject":    req.Subject,
 				"expiration": expirationStr,
 			}
 			if err := dbtxn.ExecuteTxQueryDirect(ctx, tx, m, stmt); err != nil {
@@ -289,6 +358,7 @@ func (p *PostgreSQL) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (
 				"name":       username,
 				"username":   username,
 				"password":   req.Password,
+				"subject":    req.Subject,
 				"expiration": expirationStr,
 			}
 			if err := dbtxn.ExecuteTxQueryDirect(ctx, tx, m, query); err != nil {
@@ -403,6 +403,165 @@ func TestUpdateUser_Password(t *testing.T) {
 	})
 }
 
+func TestUpdateUser_SelfManagedPassword(t *testing.T) {
+	cleanup, connURL := postgresql.PrepareTestContainer(t, "13.4-buster")
+	defer cleanup()
+
+	req := dbplugin.InitializeRequest{
+		Config: map[string]interface{}{
+			"connection_url": strings.Replace(connURL, "postgres:secret", "{{username}}:{{password}}", 1),
+			"username":       "postgres",
+			"password":       "secret",
+		},
+		VerifyConnection: true,
+	}
+	db := new()
+	initResp := dbtesting.AssertInitialize(t, db, req)
+	require.Equal(t, true, initResp.Config[dbplugin.SelfManagedPasswordSupportedKey])
+
+	initialPass := "myreallysecurepassword"
+	createReq := dbplugin.NewUserRequest{
+		UsernameConfig: dbplugin.UsernameMetadata{
+			DisplayName: "test",
+			RoleName:    "test",
+		},
+		Statements: dbplugin.Statements{
+			Commands: []string{createAdminUser},
+		},
+		Password:   initialPass,
+		Expiration: time.Now().Add(time.Minute),
+	}
+	createResp := dbtesting.AssertNewUser(t, db, createReq)
+	assertCredsExist(t, connURL, createResp.Username, initialPass)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("wrong current password", func(t *testing.T) {
+		updateReq := dbplugin.UpdateUserRequest{
+			Username: createResp.Username,
+			Password: &dbplugin.ChangePassword{
+				NewPassword: "somenewpassword",
+			},
+			SelfManagedPassword: "wrongpassword",
+		}
+		if _, err := db.UpdateUser(ctx, updateReq); err == nil {
+			t.Fatalf("err expected, got nil")
+		}
+		assertCredsExist(t, connURL, createResp.Username, initialPass)
+	})
+
+	t.Run("current password", func(t *testing.T) {
+		newPass := "somenewpassword"
+		updateReq := dbplugin.UpdateUserRequest{
+			Username: createResp.Username,
+			Password: &dbplugin.ChangePassword{
+				NewPassword: newPass,
+			},
+			SelfManagedPassword: initialPass,
+		}
+		if _, err := db.UpdateUser(ctx, updateReq); err != nil {
+			t.Fatalf("no error expected, got: %s", err)
+		}
+		assertCredsExist(t, connURL, createResp.Username, newPass)
+		assertCredsDoNotExist(t, connURL, createResp.Username, initialPass)
+	})
+}
+
+func TestUpdateUser_Subject(t *testing.T) {
+	cleanup, connURL := postgresql.PrepareTestContainer(t, "13.4-buster")
+	defer cleanup()
+
+	req := dbplugin.InitializeRequest{
+		Config: map[string]interface{}{
+			"connection_url": connURL,
+		},
+		VerifyConnection: true,
+	}
+	db := new()
+	initResp := dbtesting.AssertInitialize(t, db, req)
+	require.Equal(t, []interface{}{"password", "client_certificate"}, initResp.Config[dbplugin.SupportedCredentialTypesKey])
+
+	createReq := dbplugin.NewUserRequest{
+		UsernameConfig: dbplugin.UsernameMetadata{
+			DisplayName: "test",
+			RoleName:    "test",
+		},
+		Statements: dbplugin.Statements{
+			Commands: []string{`
+				CREATE ROLE "{{name}}" WITH LOGIN;
+				COMMENT ON ROLE "{{name}}" IS '{{subject}}';`,
+			},
+		},
+		CredentialType: dbplugin.CredentialTypeClientCertificate,
+		Subject:        "CN=test",
+		Expiration:     time.Now().Add(time.Minute),
+	}
+	createResp := dbtesting.AssertNewUser(t, db, createReq)
+	require.Equal(t, "CN=test", getRoleComment(t, connURL, createResp.Username))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("missing subject", func(t *testing.T) {
+		updateReq := dbplugin.UpdateUserRequest{
+			Username:       createResp.Username,
+			CredentialType: dbplugin.CredentialTypeClientCertificate,
+			Subject:        &dbplugin.ChangeSubject{},
+		}
+		if _, err := db.UpdateUser(ctx, updateReq); err == nil {
+			t.Fatalf("err expected, got nil")
+		}
+	})
+
+	t.Run("no statements", func(t *testing.T) {
+		updateReq := dbplugin.UpdateUserRequest{
+			Username:       createResp.Username,
+			CredentialType: dbplugin.CredentialTypeClientCertificate,
+			Subject: &dbplugin.ChangeSubject{
+				NewSubject: "CN=unchanged",
+			},
+		}
+		if _, err := db.UpdateUser(ctx, updateReq); err != nil {
+			t.Fatalf("no error expected, got: %s", err)
+		}
+		require.Equal(t, "CN=test", getRoleComment(t, connURL, createResp.Username))
+	})
+
+	t.Run("statements", func(t *testing.T) {
+		updateReq := dbplugin.UpdateUserRequest{
+			Username:       createResp.Username,
+			CredentialType: dbplugin.CredentialTypeClientCertificate,
+			Subject: &dbplugin.ChangeSubject{
+				NewSubject: "CN=rotated",
+				Statements: dbplugin.Statements{
+					Commands: []string{`COMMENT ON ROLE "{{name}}" IS '{{subject}}';`},
+				},
+			},
+		}
+		if _, err := db.UpdateUser(ctx, updateReq); err != nil {
+			t.Fatalf("no error expected, got: %s", err)
+		}
+		require.Equal(t, "CN=rotated", getRoleComment(t, connURL, createResp.Username))
+	})
+}
+
+func getRoleComment(t testing.TB, connURL, username string) string {
+	t.Helper()
+	db, err := sql.Open("pgx", connURL)
+	if err != nil {
+		t.Fatalf("Failed to open connection: %s", err)
+	}
+	defer db.Close()
+
+	var comment string
+	err = db.QueryRow("SELECT shobj_description(oid, 'pg_authid') FROM pg_roles WHERE rolname = $1", username).Scan(&comment)
+	if err != nil {
+		t.Fatalf("Failed to read role comment: %s", err)
+	}
+	return comment
+}
+
 func TestUpdateUser_Expiration(t *testing.T) {
 	type testCase struct {
 		initialExpiration  time.Time
@@ -48,6 +48,11 @@ func TestConversionsHaveAllFields(t *testing.T) {
 			UsernameConfig: UsernameMetadata{
 				DisplayName: "dispName",
 				RoleName:    "roleName",
+				EntityID:    "entityID",
+				EntityMetadata: map[string]string{
+					"team": "audit",
+				},
+				MountAccessor: "database_1234",
 			},
 			Statements: Statements{
 				Commands: []string{
@@ -62,6 +67,7 @@ func TestConversionsHaveAllFields(t *testing.T) {
 			CredentialType: CredentialTypeRSAPrivateKey,
 			PublicKey:      []byte("-----BEGIN PUBLIC KEY-----"),
 			Password:       "password",
+			Subject:        "CN=subject",
 			Expiration:     time.Now(),
 		}
 
@@ -104,6 +110,14 @@ func TestConversionsHaveAllFields(t *testing.T) {
 					},
 				},
 			},
+			Subject: &ChangeSubject{
+				NewSubject: "CN=subject",
+				Statements: Statements{
+					Commands: []string{
+						"statement",
+					},
+				},
+			},
 			Expiration: &ChangeExpiration{
 				NewExpiration: time.Now(),
 				Statements: Statements{
@@ -112,6 +126,7 @@ func TestConversionsHaveAllFields(t *testing.T) {
 					},
 				},
 			},
+			SelfManagedPassword: "currentpassword",
 		}
 
 		protoReq, err := updateUserReqToProto(req)
@@ -182,6 +197,14 @@ func TestConversionsHaveAllFields(t *testing.T) {
 					},
 				},
 			},
+			Subject: &proto.ChangeSubject{
+				NewSubject: "CN=subject",
+				Statements: &proto.Statements{
+					Commands: []string{
+						"statement",
+					},
+				},
+			},
 			Expiration: &proto.ChangeExpiration{
 				NewExpiration: timestamppb.Now(),
 				Statements: &proto.Statements{
@@ -190,6 +213,7 @@ func TestConversionsHaveAllFields(t *testing.T) {
 					},
 				},
 			},
+			SelfManagedPassword: "currentpassword",
 		}
 
 		protoReq, err := getUpdateUserRequest(req)
@@ -87,6 +87,17 @@ func (ir InitializeResponse) SetSupportedCredentialTypes(credTypes []CredentialT
 	ir.Config[SupportedCredentialTypesKey] = sct
 }
 
+// SelfManagedPasswordSupportedKey is used to get and set whether database
+// plugins support the SelfManagedPassword of UpdateUserRequest.
+const SelfManagedPasswordSupportedKey = "self_managed_password_supported"
+
+// SetSelfManagedPasswordSupported marks the database plugin as supporting
+// self-managed passwords. Vault refuses to create self-managed static roles
+// for database plugins that don't.
+func (ir InitializeResponse) SetSelfManagedPasswordSupported() {
+	ir.Config[SelfManagedPasswordSupportedKey] = true
+}
+
 // ///////////////////////////////////////////////////////
 // NewUser()
 // ///////////////////////////////////////////////////////
@@ -120,6 +131,11 @@ type NewUserRequest struct {
 	// The value is set when the credential type is CredentialTypeRSAPrivateKey.
 	PublicKey []byte
 
+	// Subject is the distinguished name of the client certificate credential
+	// of the user, in RFC 2253 format.
+	// The value is set when the credential type is CredentialTypeClientCertificate.
+	Subject string
+
 	// Expiration of the user. Not all database plugins will support this.
 	Expiration time.Time
 }
@@ -128,6 +144,18 @@ type NewUserRequest struct {
 type UsernameMetadata struct {
 	DisplayName string
 	RoleName    string
+
+	// EntityID is the ID of the identity entity requesting the credentials.
+	// Empty if the request is not associated with an entity.
+	EntityID string
+
+	// EntityMetadata is the metadata of the identity entity requesting the
+	// credentials.
+	EntityMetadata map[string]string
+
+	// MountAccessor is the accessor of the mount the credentials are
+	// requested from.
+	MountAccessor string
 }
 
 // NewUserResponse returns any information Vault needs to know after creating a new user.
@@ -143,6 +171,7 @@ type CredentialType int
 const (
 	CredentialTypePassword CredentialType = iota
 	CredentialTypeRSAPrivateKey
+	CredentialTypeClientCertificate
 )
 
 func (k CredentialType) String() string {
@@ -151,6 +180,8 @@ func (k CredentialType) String() string {
 		return "password"
 	case CredentialTypeRSAPrivateKey:
 		return "rsa_private_key"
+	case CredentialTypeClientCertificate:
+		return "client_certificate"
 	default:
 		return "unknown"
 	}
@@ -179,9 +210,21 @@ type UpdateUserRequest struct {
 	// If nil, no change is requested.
 	PublicKey *ChangePublicKey
 
+	// Subject indicates the new client certificate subject to change to.
+	// The value is set when the credential type is CredentialTypeClientCertificate.
+	// If nil, no change is requested.
+	Subject *ChangeSubject
+
 	// Expiration indicates the new expiration date to change to.
 	// If nil, no change is requested.
 	Expiration *ChangeExpiration
+
+	// SelfManagedPassword is the current password of a user which manages
+	// its own credentials. If set, the database must authenticate as the
+	// user with this password, rather than with the credentials of the
+	// connection, to change the password of the user.
+	// The value is only set along with Password.
+	SelfManagedPassword string
 }
 
 // ChangePublicKey of a given user
@@ -195,6 +238,17 @@ type ChangePublicKey struct {
 	Statements Statements
 }
 
+// ChangeSubject of a given user
+type ChangeSubject struct {
+	// NewSubject is the distinguished name of the new client certificate
+	// credential of the user, in RFC 2253 format.
+	NewSubject string
+
+	// Statements is an ordered list of commands to run within the database
+	// when changing the user's client certificate credential.
+	Statements Statements
+}
+
 // ChangePassword of a given user
 type ChangePassword struct {
 	// NewPassword for the user
@@ -90,6 +90,10 @@ func newUserReqToProto(req NewUserRequest) (*proto.NewUserRequest, error) {
 		if len(req.PublicKey) == 0 {
 			return nil, fmt.Errorf("missing public key credential")
 		}
+	case CredentialTypeClientCertificate:
+		if req.Subject == "" {
+			return nil, fmt.Errorf("missing client certificate credential")
+		}
 	default:
 		return nil, fmt.Errorf("unknown credential type")
 	}
@@ -101,12 +105,16 @@ func newUserReqToProto(req NewUserRequest) (*proto.NewUserRequest, error) {
 
 	rpcReq := &proto.NewUserRequest{
 		UsernameConfig: &proto.UsernameConfig{
-			DisplayName: req.UsernameConfig.DisplayName,
-			RoleName:    req.UsernameConfig.RoleName,
+			DisplayName:    req.UsernameConfig.DisplayName,
+			RoleName:       req.UsernameConfig.RoleName,
+			EntityId:       req.UsernameConfig.EntityID,
+			EntityMetadata: req.UsernameConfig.EntityMetadata,
+			MountAccessor:  req.UsernameConfig.MountAccessor,
 		},
 		CredentialType: int32(req.CredentialType),
 		Password:       req.Password,
 		PublicKey:      req.PublicKey,
+		Subject:        req.Subject,
 		Expiration:     expiration,
 		Statements: &proto.Statements{
 			Commands: req.Statements.Commands,
@@ -150,10 +158,15 @@ func updateUserReqToProto(req UpdateUserRequest) (*proto.UpdateUserRequest, erro
 
 	if (req.Password == nil || req.Password.NewPassword == "") &&
 		(req.PublicKey == nil || len(req.PublicKey.NewPublicKey) == 0) &&
+		(req.Subject == nil || req.Subject.NewSubject == "") &&
 		(req.Expiration == nil || req.Expiration.NewExpiration.IsZero()) {
 		return nil, fmt.Errorf("missing changes")
 	}
 
+	if req.SelfManagedPassword != "" && (req.Password == nil || req.Password.NewPassword == "") {
+		return nil, fmt.Errorf("self-managed password requires a new password")
+	}
+
 	expiration, err := expirationToProto(req.Expiration)
 	if err != nil {
 		return nil, fmt.Errorf("unable to parse new expiration date: %w", err)
@@ -179,12 +192,24 @@ func updateUserReqToProto(req UpdateUserRequest) (*proto.UpdateUserRequest, erro
 		}
 	}
 
+	var subject *proto.ChangeSubject
+	if req.Subject != nil && req.Subject.NewSubject != "" {
+		subject = &proto.ChangeSubject{
+			NewSubject: req.Subject.NewSubject,
+			Statements: &proto.Statements{
+				Commands: req.Subject.Statements.Commands,
+			},
+		}
+	}
+
 	rpcReq := &proto.UpdateUserRequest{
-		Username:       req.Username,
-		CredentialType: int32(req.CredentialType),
-		Password:       password,
-		PublicKey:      publicKey,
-		Expiration:     expiration,
+		Username:            req.Username,
+		CredentialType:      int32(req.CredentialType),
+		Password:            password,
+		PublicKey:           publicKey,
+		Subject:             subject,
+		Expiration:          expiration,
+		SelfManagedPassword: req.SelfManagedPassword,
 	}
 	return rpcReq, nil
 }
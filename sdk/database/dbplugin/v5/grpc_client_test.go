@@ -259,6 +259,18 @@ func TestGRPCClient_UpdateUser(t *testing.T) {
 			doneCtx:   runningCtx,
 			assertErr: assertErrNotNil,
 		},
+		"self-managed password without new password": {
+			client: fakeClient{},
+			req: UpdateUserRequest{
+				Username: "user",
+				Expiration: &ChangeExpiration{
+					NewExpiration: time.Now(),
+				},
+				SelfManagedPassword: "currentpassword",
+			},
+			doneCtx:   runningCtx,
+			assertErr: assertErrNotNil,
+		},
 		"database error": {
 			client: fakeClient{
 				updateUserErr: errors.New("update user error"),
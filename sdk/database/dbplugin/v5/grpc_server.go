@@ -156,12 +156,16 @@ func (g *gRPCServer) NewUser(ctx context.Context, req *proto.NewUserRequest) (*p
 
 	dbReq := NewUserRequest{
 		UsernameConfig: UsernameMetadata{
-			DisplayName: req.GetUsernameConfig().GetDisplayName(),
-			RoleName:    req.GetUsernameConfig().GetRoleName(),
+			DisplayName:    req.GetUsernameConfig().GetDisplayName(),
+			RoleName:       req.GetUsernameConfig().GetRoleName(),
+			EntityID:       req.GetUsernameConfig().GetEntityId(),
+			EntityMetadata: req.GetUsernameConfig().GetEntityMetadata(),
+			MountAccessor:  req.GetUsernameConfig().GetMountAccessor(),
 		},
 		CredentialType:     CredentialType(req.GetCredentialType()),
 		Password:           req.GetPassword(),
 		PublicKey:          req.GetPublicKey(),
+		Subject:            req.GetSubject(),
 		Expiration:         expiration,
 		Statements:         getStatementsFromProto(req.GetStatements()),
 		RollbackStatements: getStatementsFromProto(req.GetRollbackStatements()),
@@ -217,6 +221,14 @@ func getUpdateUserRequest(req *proto.UpdateUserRequest) (UpdateUserRequest, erro
 		}
 	}
 
+	var subject *ChangeSubject
+	if req.GetSubject() != nil && req.GetSubject().GetNewSubject() != "" {
+		subject = &ChangeSubject{
+			NewSubject: req.GetSubject().GetNewSubject(),
+			Statements: getStatementsFromProto(req.GetSubject().GetStatements()),
+		}
+	}
+
 	var expiration *ChangeExpiration
 	if req.GetExpiration() != nil && req.GetExpiration().GetNewExpiration() != nil {
 		newExpiration, err := ptypes.Timestamp(req.GetExpiration().GetNewExpiration())
@@ -231,11 +243,13 @@ func getUpdateUserRequest(req *proto.UpdateUserRequest) (UpdateUserRequest, erro
 	}
 
 	dbReq := UpdateUserRequest{
-		Username:       req.GetUsername(),
-		CredentialType: CredentialType(req.GetCredentialType()),
-		Password:       password,
-		PublicKey:      publicKey,
-		Expiration:     expiration,
+		Username:            req.GetUsername(),
+		CredentialType:      CredentialType(req.GetCredentialType()),
+		Password:            password,
+		PublicKey:           publicKey,
+		Subject:             subject,
+		Expiration:          expiration,
+		SelfManagedPassword: req.GetSelfManagedPassword(),
 	}
 
 	if !hasChange(dbReq) {
@@ -252,6 +266,9 @@ func hasChange(dbReq UpdateUserRequest) bool {
 	if dbReq.PublicKey != nil && len(dbReq.PublicKey.NewPublicKey) > 0 {
 		return true
 	}
+	if dbReq.Subject != nil && dbReq.Subject.NewSubject != "" {
+		return true
+	}
 	if dbReq.Expiration != nil && !dbReq.Expiration.NewExpiration.IsZero() {
 		return true
 	}
@@ -31,6 +31,7 @@ type SQLConnectionProducer struct {
 	Type                  string
 	RawConfig             map[string]interface{}
 	maxConnectionLifetime time.Duration
+	rawConnectionURL      string
 	Initialized           bool
 	db                    *sql.DB
 	sync.Mutex
@@ -66,24 +67,8 @@ func (c *SQLConnectionProducer) Init(ctx context.Context, conf map[string]interf
 		return nil, fmt.Errorf("username and/or password cannot contain the template variables")
 	}
 
-	// Don't escape special characters for MySQL password
-	// Also don't escape special characters for the username and password if
-	// the disable_escaping parameter is set to true
-	username := c.Username
-	password := c.Password
-	if !c.DisableEscaping {
-		username = url.PathEscape(c.Username)
-	}
-	if (c.Type != "mysql") && !c.DisableEscaping {
-		password = url.PathEscape(c.Password)
-	}
-
-	// QueryHelper doesn't do any SQL escaping, but if it starts to do so
-	// then maybe we won't be able to use it to do URL substitution any more.
-	c.ConnectionURL = dbutil.QueryHelper(c.ConnectionURL, map[string]string{
-		"username": username,
-		"password": password,
-	})
+	c.rawConnectionURL = c.ConnectionURL
+	c.ConnectionURL = c.renderConnectionURL(c.Username, c.Password)
 
 	if c.MaxOpenConnections == 0 {
 		c.MaxOpenConnections = 4
@@ -136,15 +121,84 @@ func (c *SQLConnectionProducer) Connection(ctx context.Context) (interface{}, er
 		c.db.Close()
 	}
 
+	// Otherwise, attempt to make connection
+	var err error
+	c.db, err = c.open(c.ConnectionURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.db, nil
+}
+
+// StaticConnection returns a new connection to the database which
+// authenticates as the given user, rather than with the credentials of the
+// connection configuration. It is used to change the password of users which
+// manage their own credentials. The caller is responsible for closing the
+// returned connection.
+func (c *SQLConnectionProducer) StaticConnection(ctx context.Context, username, password string) (*sql.DB, error) {
+	if !c.Initialized {
+		return nil, ErrNotInitialized
+	}
+
+	conn, err := c.staticConnectionURL(username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := c.open(conn)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("unable to connect as %q: %w", username, err)
+	}
+
+	return db, nil
+}
+
+// staticConnectionURL renders the connection URL with the given username and
+// password in place of the credentials of the connection configuration.
+func (c *SQLConnectionProducer) staticConnectionURL(username, password string) (string, error) {
+	if !strings.Contains(c.rawConnectionURL, "{{username}}") ||
+		!strings.Contains(c.rawConnectionURL, "{{password}}") {
+		return "", fmt.Errorf("connection_url must contain the {{username}} and {{password}} templates to connect as a user")
+	}
+
+	return c.renderConnectionURL(username, password), nil
+}
+
+// renderConnectionURL substitutes the username and password templates of the
+// configured connection URL with the given username and password.
+func (c *SQLConnectionProducer) renderConnectionURL(username, password string) string {
+	// Don't escape special characters for MySQL password
+	// Also don't escape special characters for the username and password if
+	// the disable_escaping parameter is set to true
+	if !c.DisableEscaping {
+		username = url.PathEscape(username)
+	}
+	if (c.Type != "mysql") && !c.DisableEscaping {
+		password = url.PathEscape(password)
+	}
+
+	// QueryHelper doesn't do any SQL escaping, but if it starts to do so
+	// then maybe we won't be able to use it to do URL substitution any more.
+	return dbutil.QueryHelper(c.rawConnectionURL, map[string]string{
+		"username": username,
+		"password": password,
+	})
+}
+
+// open opens a new connection pool to the database with the given
+// connection string.
+func (c *SQLConnectionProducer) open(conn string) (*sql.DB, error) {
 	// For mssql backend, switch to sqlserver instead
 	dbType := c.Type
 	if c.Type == "mssql" {
 		dbType = "sqlserver"
 	}
 
-	// Otherwise, attempt to make connection
-	conn := c.ConnectionURL
-
 	// PostgreSQL specific settings
 	if strings.HasPrefix(conn, "postgres://") || strings.HasPrefix(conn, "postgresql://") {
 		// Ensure timezone is set to UTC for all the connections
@@ -160,19 +214,18 @@ func (c *SQLConnectionProducer) Connection(ctx context.Context) (interface{}, er
 		}
 	}
 
-	var err error
-	c.db, err = sql.Open(dbType, conn)
+	db, err := sql.Open(dbType, conn)
 	if err != nil {
 		return nil, err
 	}
 
 	// Set some connection pool settings. We don't need much of this,
 	// since the request rate shouldn't be high.
-	c.db.SetMaxOpenConns(c.MaxOpenConnections)
-	c.db.SetMaxIdleConns(c.MaxIdleConnections)
-	c.db.SetConnMaxLifetime(c.maxConnectionLifetime)
+	db.SetMaxOpenConns(c.MaxOpenConnections)
+	db.SetMaxIdleConns(c.MaxIdleConnections)
+	db.SetConnMaxLifetime(c.maxConnectionLifetime)
 
-	return c.db, nil
+	return db, nil
 }
 
 func (c *SQLConnectionProducer) SecretValues() map[string]interface{} {
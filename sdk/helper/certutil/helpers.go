@@ -469,6 +469,23 @@ func AddPolicyIdentifiers(data *CreationBundle, certTemplate *x509.Certificate)
 	}
 }
 
+// AddCustomExtensions adds the custom, non-critical extensions requested in
+// the CreationBundle to the certificate
+func AddCustomExtensions(data *CreationBundle, certTemplate *x509.Certificate) {
+	certTemplate.ExtraExtensions = append(certTemplate.ExtraExtensions, data.Params.CustomExtensions...)
+}
+
+// HasExtension returns whether an extension with the given OID is present
+func HasExtension(extensions []pkix.Extension, oid asn1.ObjectIdentifier) bool {
+	for _, extension := range extensions {
+		if extension.Id.Equal(oid) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // AddExtKeyUsageOids adds custom extended key usage OIDs to certificate
 func AddExtKeyUsageOids(data *CreationBundle, certTemplate *x509.Certificate) {
 	for _, oidstr := range data.Params.ExtKeyUsageOIDs {
@@ -592,6 +609,81 @@ func marshalSANs(dnsNames, emailAddresses []string, ipAddresses []net.IP, uris [
 	return rawValues
 }
 
+var (
+	oidExtensionDeltaCRLIndicator = asn1.ObjectIdentifier{2, 5, 29, 27}
+	oidExtensionFreshestCRL       = asn1.ObjectIdentifier{2, 5, 29, 46}
+)
+
+// Note: these mirror the (private) Go types used to marshal the
+// CRLDistributionPoints extension, which has the same syntax as FreshestCRL.
+type distributionPointName struct {
+	FullName     []asn1.RawValue  `asn1:"optional,tag:0"`
+	RelativeName pkix.RDNSequence `asn1:"optional,tag:1"`
+}
+
+type distributionPoint struct {
+	DistributionPoint distributionPointName `asn1:"optional,tag:0"`
+	Reason            asn1.BitString        `asn1:"optional,tag:1"`
+	CRLIssuer         []asn1.RawValue       `asn1:"optional,tag:2"`
+}
+
+// CreateDeltaCRLIndicatorExt creates the critical Delta CRL Indicator
+// extension (RFC 5280 Section 5.2.4), referencing the CRL number of the
+// complete CRL this delta CRL updates.
+func CreateDeltaCRLIndicatorExt(completeCRLNumber int64) (pkix.Extension, error) {
+	value, err := asn1.Marshal(big.NewInt(completeCRLNumber))
+	if err != nil {
+		return pkix.Extension{}, fmt.Errorf("unable to marshal complete CRL number (%v): %v", completeCRLNumber, err)
+	}
+
+	return pkix.Extension{
+		Id:       oidExtensionDeltaCRLIndicator,
+		Critical: true,
+		Value:    value,
+	}, nil
+}
+
+// CreateFreshestCRLExt creates the Freshest CRL extension (RFC 5280 Section
+// 4.2.1.15 and 5.2.6), pointing relying parties at the given delta CRL URLs.
+func CreateFreshestCRLExt(urls []string) (pkix.Extension, error) {
+	var points []distributionPoint
+	for _, url := range urls {
+		points = append(points, distributionPoint{
+			DistributionPoint: distributionPointName{
+				FullName: []asn1.RawValue{
+					{Tag: nameTypeURI, Class: 2, Bytes: []byte(url)},
+				},
+			},
+		})
+	}
+
+	value, err := asn1.Marshal(points)
+	if err != nil {
+		return pkix.Extension{}, fmt.Errorf("unable to marshal delta CRL distribution points: %v", err)
+	}
+
+	return pkix.Extension{
+		Id:    oidExtensionFreshestCRL,
+		Value: value,
+	}, nil
+}
+
+// AddFreshestCRL adds the Freshest CRL extension to the certificate when
+// delta CRL distribution points have been configured.
+func AddFreshestCRL(data *CreationBundle, certTemplate *x509.Certificate) error {
+	if data.Params.URLs == nil || len(data.Params.URLs.DeltaCRLDistributionPoints) == 0 {
+		return nil
+	}
+
+	ext, err := CreateFreshestCRLExt(data.Params.URLs.DeltaCRLDistributionPoints)
+	if err != nil {
+		return err
+	}
+
+	certTemplate.ExtraExtensions = append(certTemplate.ExtraExtensions, ext)
+	return nil
+}
+
 func StringToOid(in string) (asn1.ObjectIdentifier, error) {
 	split := strings.Split(in, ".")
 	ret := make(asn1.ObjectIdentifier, 0, len(split))
@@ -812,6 +904,8 @@ func createCertificate(data *CreationBundle, randReader io.Reader, privateKeyGen
 
 	AddPolicyIdentifiers(data, certTemplate)
 
+	AddCustomExtensions(data, certTemplate)
+
 	AddKeyUsages(data, certTemplate)
 
 	AddExtKeyUsageOids(data, certTemplate)
@@ -819,6 +913,9 @@ func createCertificate(data *CreationBundle, randReader io.Reader, privateKeyGen
 	certTemplate.IssuingCertificateURL = data.Params.URLs.IssuingCertificates
 	certTemplate.CRLDistributionPoints = data.Params.URLs.CRLDistributionPoints
 	certTemplate.OCSPServer = data.Params.URLs.OCSPServers
+	if err := AddFreshestCRL(data, certTemplate); err != nil {
+		return nil, errutil.InternalError{Err: err.Error()}
+	}
 
 	var certBytes []byte
 	if data.SigningBundle != nil {
@@ -1116,9 +1213,18 @@ func signCertificate(data *CreationBundle, randReader io.Reader) (*ParsedCertBun
 		certTemplate.URIs = data.CSR.URIs
 
 		for _, name := range data.CSR.Extensions {
-			if !name.Id.Equal(oidExtensionBasicConstraints) {
-				certTemplate.ExtraExtensions = append(certTemplate.ExtraExtensions, name)
+			if name.Id.Equal(oidExtensionBasicConstraints) {
+				continue
+			}
+			// Explicitly requested policies and extensions take precedence
+			// over those in the CSR, as duplicates aren't permitted.
+			if name.Id.Equal(policyInformationOid) && len(data.Params.PolicyIdentifiers) > 0 {
+				continue
+			}
+			if HasExtension(data.Params.CustomExtensions, name.Id) {
+				continue
 			}
+			certTemplate.ExtraExtensions = append(certTemplate.ExtraExtensions, name)
 		}
 
 	} else {
@@ -1134,6 +1240,8 @@ func signCertificate(data *CreationBundle, randReader io.Reader) (*ParsedCertBun
 
 	AddPolicyIdentifiers(data, certTemplate)
 
+	AddCustomExtensions(data, certTemplate)
+
 	AddKeyUsages(data, certTemplate)
 
 	AddExtKeyUsageOids(data, certTemplate)
@@ -1143,6 +1251,9 @@ func signCertificate(data *CreationBundle, randReader io.Reader) (*ParsedCertBun
 	certTemplate.IssuingCertificateURL = data.Params.URLs.IssuingCertificates
 	certTemplate.CRLDistributionPoints = data.Params.URLs.CRLDistributionPoints
 	certTemplate.OCSPServer = data.SigningBundle.URLs.OCSPServers
+	if err := AddFreshestCRL(data, certTemplate); err != nil {
+		return nil, errutil.InternalError{Err: err.Error()}
+	}
 
 	if data.Params.IsCA {
 		certTemplate.BasicConstraintsValid = true
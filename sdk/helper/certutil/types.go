@@ -682,6 +682,9 @@ type URLEntries struct {
 	IssuingCertificates   []string `json:"issuing_certificates" structs:"issuing_certificates" mapstructure:"issuing_certificates"`
 	CRLDistributionPoints []string `json:"crl_distribution_points" structs:"crl_distribution_points" mapstructure:"crl_distribution_points"`
 	OCSPServers           []string `json:"ocsp_servers" structs:"ocsp_servers" mapstructure:"ocsp_servers"`
+	// DeltaCRLDistributionPoints is encoded into the Freshest CRL extension
+	// of issued certificates and complete CRLs.
+	DeltaCRLDistributionPoints []string `json:"delta_crl_distribution_points" structs:"delta_crl_distribution_points" mapstructure:"delta_crl_distribution_points"`
 }
 
 type NotAfterBehavior int
@@ -780,6 +783,7 @@ type CreationParameters struct {
 	ExtKeyUsage                   CertExtKeyUsage
 	ExtKeyUsageOIDs               []string
 	PolicyIdentifiers             []string
+	CustomExtensions              []pkix.Extension
 	BasicConstraintsValidForNonCA bool
 	SignatureBits                 int
 	ForceAppendCaChain            bool
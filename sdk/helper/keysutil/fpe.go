@@ -0,0 +1,379 @@
+package keysutil
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+)
+
+const (
+	// fpeMinDomainSize is the smallest number of distinct values an input
+	// may take, as required by NIST SP 800-38G.
+	fpeMinDomainSize = 1000000
+
+	// fpeMaxRadix is the largest alphabet supported by FF1 and FF3-1.
+	fpeMaxRadix = 1 << 16
+
+	// ff1Rounds and ff3Rounds are the number of Feistel rounds of each mode.
+	ff1Rounds = 10
+	ff3Rounds = 8
+
+	// FF31TweakSize is the size in bytes of the tweak used by FF3-1.
+	FF31TweakSize = 7
+)
+
+// FPEEncrypt encrypts value, a string of characters taken from alphabet, with
+// the given key version using the key's format-preserving mode. The result
+// has the same length as value and is also made up of characters from
+// alphabet. Since the result does not record the key version, the same
+// version has to be given to FPEDecrypt.
+func (p *Policy) FPEEncrypt(ver int, tweak []byte, alphabet, value string) (string, error) {
+	return p.fpeTransform(KeyUsageEncode, ver, tweak, alphabet, value)
+}
+
+// FPEDecrypt reverses FPEEncrypt.
+func (p *Policy) FPEDecrypt(ver int, tweak []byte, alphabet, value string) (string, error) {
+	return p.fpeTransform(KeyUsageDecode, ver, tweak, alphabet, value)
+}
+
+func (p *Policy) fpeTransform(usage KeyUsage, ver int, tweak []byte, alphabet, value string) (string, error) {
+	if !p.Type.FPESupported() {
+		return "", errutil.UserError{Err: fmt.Sprintf("format-preserving encryption not supported for key type %v", p.Type)}
+	}
+
+	switch {
+	case ver == 0:
+		ver = p.LatestVersion
+	case ver < 0:
+		return "", errutil.UserError{Err: "requested key version is negative"}
+	case ver > p.LatestVersion:
+		return "", errutil.UserError{Err: "requested key version is higher than the latest key version"}
+	}
+	if usage == KeyUsageEncode && ver < p.MinEncryptionVersion {
+		return "", errutil.UserError{Err: "requested version for encoding is less than the minimum encryption key version"}
+	}
+	if usage == KeyUsageDecode && p.MinDecryptionVersion > 0 && ver < p.MinDecryptionVersion {
+		return "", errutil.UserError{Err: ErrTooOld}
+	}
+
+	if err := p.CheckUsage(usage, ver, nil); err != nil {
+		return "", err
+	}
+
+	symbols := []rune(alphabet)
+	indices, err := fpeAlphabetIndices(symbols)
+	if err != nil {
+		return "", errutil.UserError{Err: err.Error()}
+	}
+
+	runes := []rune(value)
+	numerals := make([]int, len(runes))
+	for i, r := range runes {
+		idx, ok := indices[r]
+		if !ok {
+			return "", errutil.UserError{Err: fmt.Sprintf("character %q of the value is not in the alphabet", r)}
+		}
+		numerals[i] = idx
+	}
+
+	keyEntry, err := p.safeGetKeyEntry(ver)
+	if err != nil {
+		return "", err
+	}
+
+	var result []int
+	switch p.Type {
+	case KeyType_AES256_FF1:
+		result, err = ff1(keyEntry.Key, tweak, len(symbols), numerals, usage == KeyUsageEncode)
+	case KeyType_AES256_FF3_1:
+		if tweak == nil {
+			tweak = make([]byte, FF31TweakSize)
+		}
+		result, err = ff31(keyEntry.Key, tweak, len(symbols), numerals, usage == KeyUsageEncode)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	out := make([]rune, len(result))
+	for i, n := range result {
+		out[i] = symbols[n]
+	}
+	return string(out), nil
+}
+
+func fpeAlphabetIndices(symbols []rune) (map[rune]int, error) {
+	if len(symbols) < 2 || len(symbols) > fpeMaxRadix {
+		return nil, fmt.Errorf("alphabet must contain between 2 and %d characters", fpeMaxRadix)
+	}
+
+	indices := make(map[rune]int, len(symbols))
+	for i, r := range symbols {
+		if _, ok := indices[r]; ok {
+			return nil, fmt.Errorf("alphabet contains the character %q more than once", r)
+		}
+		indices[r] = i
+	}
+	return indices, nil
+}
+
+// fpeCheckDomain verifies that an input of n numerals in the given radix has
+// enough possible values to be encrypted safely.
+func fpeCheckDomain(radix, n int) error {
+	if n < 2 {
+		return errutil.UserError{Err: "value must be at least 2 characters long"}
+	}
+
+	domain := new(big.Int).Exp(big.NewInt(int64(radix)), big.NewInt(int64(n)), nil)
+	if domain.Cmp(big.NewInt(fpeMinDomainSize)) < 0 {
+		return errutil.UserError{Err: fmt.Sprintf("value is too short for an alphabet of %d characters: at least %d possible values are required", radix, fpeMinDomainSize)}
+	}
+	return nil
+}
+
+// ff1 implements the FF1 mode of NIST SP 800-38G, encrypting or decrypting
+// the numeral string x.
+func ff1(key, tweak []byte, radix int, x []int, encrypt bool) ([]int, error) {
+	n := len(x)
+	if err := fpeCheckDomain(radix, n); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errutil.InternalError{Err: err.Error()}
+	}
+
+	u := n / 2
+	v := n - u
+	bigRadix := big.NewInt(int64(radix))
+
+	// b is the number of bytes needed to hold a half of the input, d the
+	// number of pseudorandom bytes generated per round
+	maxV := new(big.Int).Exp(bigRadix, big.NewInt(int64(v)), nil)
+	maxV.Sub(maxV, big.NewInt(1))
+	b := (maxV.BitLen() + 7) / 8
+	d := 4*((b+3)/4) + 4
+
+	p := make([]byte, aes.BlockSize)
+	p[0], p[1], p[2] = 1, 2, 1
+	p[3], p[4], p[5] = byte(radix>>16), byte(radix>>8), byte(radix)
+	p[6] = 10
+	p[7] = byte(u)
+	binary.BigEndian.PutUint32(p[8:], uint32(n))
+	binary.BigEndian.PutUint32(p[12:], uint32(len(tweak)))
+
+	padding := ((-len(tweak)-b-1)%aes.BlockSize + aes.BlockSize) % aes.BlockSize
+	q := make([]byte, len(tweak)+padding+1+b)
+	copy(q, tweak)
+
+	modU := new(big.Int).Exp(bigRadix, big.NewInt(int64(u)), nil)
+	modV := new(big.Int).Exp(bigRadix, big.NewInt(int64(v)), nil)
+
+	a := fpeNum(x[:u], radix)
+	bb := fpeNum(x[u:], radix)
+
+	round := func(i int, num *big.Int) *big.Int {
+		q[len(tweak)+padding] = byte(i)
+		for j := len(tweak) + padding + 1; j < len(q); j++ {
+			q[j] = 0
+		}
+		num.FillBytes(q[len(q)-b:])
+
+		r := ff1PRF(block, p, q)
+		s := make([]byte, 0, d+aes.BlockSize)
+		s = append(s, r...)
+		for j := 1; len(s) < d; j++ {
+			var xj [aes.BlockSize]byte
+			binary.BigEndian.PutUint64(xj[8:], uint64(j))
+			xorBytes(xj[:], xj[:], r)
+			block.Encrypt(xj[:], xj[:])
+			s = append(s, xj[:]...)
+		}
+		return new(big.Int).SetBytes(s[:d])
+	}
+
+	if encrypt {
+		for i := 0; i < ff1Rounds; i++ {
+			m := modV
+			if i%2 == 0 {
+				m = modU
+			}
+			c := round(i, bb)
+			c.Add(c, a).Mod(c, m)
+			a, bb = bb, c
+		}
+	} else {
+		for i := ff1Rounds - 1; i >= 0; i-- {
+			m := modV
+			if i%2 == 0 {
+				m = modU
+			}
+			c := round(i, a)
+			c.Sub(bb, c).Mod(c, m)
+			bb, a = a, c
+		}
+	}
+
+	return append(fpeStr(a, radix, u), fpeStr(bb, radix, v)...), nil
+}
+
+// ff1PRF computes the AES-CBC-MAC of p || q, where both are a multiple of the
+// block size.
+func ff1PRF(block cipher.Block, p, q []byte) []byte {
+	y := make([]byte, aes.BlockSize)
+	block.Encrypt(y, p)
+	for i := 0; i < len(q); i += aes.BlockSize {
+		xorBytes(y, y, q[i:i+aes.BlockSize])
+		block.Encrypt(y, y)
+	}
+	return y
+}
+
+// ff31 implements the FF3-1 mode of NIST SP 800-38G Revision 1, which is FF3
+// with its 64-bit tweak derived from a 56-bit one.
+func ff31(key, tweak []byte, radix int, x []int, encrypt bool) ([]int, error) {
+	if len(tweak) != FF31TweakSize {
+		return nil, errutil.UserError{Err: fmt.Sprintf("tweak must be %d bytes long for key type aes256-ff3-1", FF31TweakSize)}
+	}
+
+	expanded := []byte{
+		tweak[0], tweak[1], tweak[2], tweak[3] & 0xf0,
+		tweak[4], tweak[5], tweak[6], tweak[3] << 4,
+	}
+	return ff3(key, expanded, radix, x, encrypt)
+}
+
+// ff3 implements the FF3 mode of NIST SP 800-38G with a 64-bit tweak. It is
+// only used by ff31.
+func ff3(key, tweak []byte, radix int, x []int, encrypt bool) ([]int, error) {
+	n := len(x)
+	if err := fpeCheckDomain(radix, n); err != nil {
+		return nil, err
+	}
+	if maxLen := ff3MaxLength(radix); n > maxLen {
+		return nil, errutil.UserError{Err: fmt.Sprintf("value must be at most %d characters long for an alphabet of %d characters", maxLen, radix)}
+	}
+
+	reversedKey := make([]byte, len(key))
+	for i := range key {
+		reversedKey[i] = key[len(key)-1-i]
+	}
+	block, err := aes.NewCipher(reversedKey)
+	if err != nil {
+		return nil, errutil.InternalError{Err: err.Error()}
+	}
+
+	u := (n + 1) / 2
+	v := n - u
+	bigRadix := big.NewInt(int64(radix))
+	modU := new(big.Int).Exp(bigRadix, big.NewInt(int64(u)), nil)
+	modV := new(big.Int).Exp(bigRadix, big.NewInt(int64(v)), nil)
+
+	tl, tr := tweak[:4], tweak[4:]
+
+	a := fpeNum(fpeRev(x[:u]), radix)
+	bb := fpeNum(fpeRev(x[u:]), radix)
+
+	round := func(i int, num *big.Int) *big.Int {
+		w := tl
+		if i%2 == 0 {
+			w = tr
+		}
+
+		var pb [aes.BlockSize]byte
+		copy(pb[:4], w)
+		pb[3] ^= byte(i)
+		num.FillBytes(pb[4:])
+
+		// S = REVB(CIPH_REVB(K)(REVB(P)))
+		fpeRevBytes(pb[:])
+		block.Encrypt(pb[:], pb[:])
+		fpeRevBytes(pb[:])
+		return new(big.Int).SetBytes(pb[:])
+	}
+
+	if encrypt {
+		for i := 0; i < ff3Rounds; i++ {
+			m := modV
+			if i%2 == 0 {
+				m = modU
+			}
+			c := round(i, bb)
+			c.Add(c, a).Mod(c, m)
+			a, bb = bb, c
+		}
+	} else {
+		for i := ff3Rounds - 1; i >= 0; i-- {
+			m := modV
+			if i%2 == 0 {
+				m = modU
+			}
+			c := round(i, a)
+			c.Sub(bb, c).Mod(c, m)
+			bb, a = a, c
+		}
+	}
+
+	return append(fpeRev(fpeStr(a, radix, u)), fpeRev(fpeStr(bb, radix, v))...), nil
+}
+
+// ff3MaxLength returns 2 * floor(log_radix(2^96)), the longest input FF3-1
+// accepts for the radix.
+func ff3MaxLength(radix int) int {
+	limit := new(big.Int).Lsh(big.NewInt(1), 96)
+	bigRadix := big.NewInt(int64(radix))
+	power := big.NewInt(1)
+	k := 0
+	for {
+		power.Mul(power, bigRadix)
+		if power.Cmp(limit) > 0 {
+			return 2 * k
+		}
+		k++
+	}
+}
+
+// fpeNum returns the number represented by the numeral string x, most
+// significant numeral first.
+func fpeNum(x []int, radix int) *big.Int {
+	bigRadix := big.NewInt(int64(radix))
+	num := new(big.Int)
+	for _, numeral := range x {
+		num.Mul(num, bigRadix)
+		num.Add(num, big.NewInt(int64(numeral)))
+	}
+	return num
+}
+
+// fpeStr returns the representation of num as a string of m numerals, most
+// significant numeral first.
+func fpeStr(num *big.Int, radix, m int) []int {
+	bigRadix := big.NewInt(int64(radix))
+	rest := new(big.Int).Set(num)
+	numeral := new(big.Int)
+	x := make([]int, m)
+	for i := m - 1; i >= 0; i-- {
+		rest.QuoRem(rest, bigRadix, numeral)
+		x[i] = int(numeral.Int64())
+	}
+	return x
+}
+
+func fpeRev(x []int) []int {
+	rev := make([]int, len(x))
+	for i := range x {
+		rev[i] = x[len(x)-1-i]
+	}
+	return rev
+}
+
+func fpeRevBytes(b []byte) {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
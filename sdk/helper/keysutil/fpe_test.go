@@ -0,0 +1,149 @@
+package keysutil
+
+import (
+	"context"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func fpeNumerals(t *testing.T, alphabet, value string) []int {
+	t.Helper()
+	x := make([]int, len(value))
+	for i, r := range value {
+		idx := strings.IndexRune(alphabet, r)
+		if idx < 0 {
+			t.Fatalf("character %q not in alphabet", r)
+		}
+		x[i] = idx
+	}
+	return x
+}
+
+func fpeString(alphabet string, x []int) string {
+	var sb strings.Builder
+	for _, n := range x {
+		sb.WriteByte(alphabet[n])
+	}
+	return sb.String()
+}
+
+func Test_FPEVectors(t *testing.T) {
+	// Samples from the NIST FF1 and FF3 examples for SP 800-38G.
+	const base36 = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+	tests := []struct {
+		name       string
+		mode       func(key, tweak []byte, radix int, x []int, encrypt bool) ([]int, error)
+		key        string
+		tweak      string
+		alphabet   string
+		plaintext  string
+		ciphertext string
+	}{
+		{"ff1 aes-128", ff1, "2b7e151628aed2a6abf7158809cf4f3c", "", base36[:10], "0123456789", "2433477484"},
+		{"ff1 aes-128 tweak", ff1, "2b7e151628aed2a6abf7158809cf4f3c", "39383736353433323130", base36[:10], "0123456789", "6124200773"},
+		{"ff1 aes-128 radix 36", ff1, "2b7e151628aed2a6abf7158809cf4f3c", "3737373770717273373737", base36, "0123456789abcdefghi", "a9tv40mll9kdu509eum"},
+		{"ff1 aes-256", ff1, "2b7e151628aed2a6abf7158809cf4f3cef4359d8d580aa4f7f036d6f04fc6a94", "", base36[:10], "0123456789", "6657667009"},
+		{"ff1 aes-256 tweak", ff1, "2b7e151628aed2a6abf7158809cf4f3cef4359d8d580aa4f7f036d6f04fc6a94", "39383736353433323130", base36[:10], "0123456789", "1001623463"},
+		{"ff1 aes-256 radix 36", ff1, "2b7e151628aed2a6abf7158809cf4f3cef4359d8d580aa4f7f036d6f04fc6a94", "3737373770717273373737", base36, "0123456789abcdefghi", "xs8a0azh2avyalyzuwd"},
+		{"ff3 aes-128", ff3, "ef4359d8d580aa4f7f036d6f04fc6a94", "d8e7920afa330a73", base36[:10], "890121234567890000", "750918814058654607"},
+		{"ff3 aes-128 tweak", ff3, "ef4359d8d580aa4f7f036d6f04fc6a94", "9a768a92f60e12d8", base36[:10], "890121234567890000", "018989839189395384"},
+		{"ff3 aes-256", ff3, "ef4359d8d580aa4f7f036d6f04fc6a942b7e151628aed2a6abf7158809cf4f3c", "d8e7920afa330a73", base36[:10], "890121234567890000", "922011205562777495"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			key := mustDecodeHex(t, test.key)
+			tweak := mustDecodeHex(t, test.tweak)
+			radix := len(test.alphabet)
+
+			ct, err := test.mode(key, tweak, radix, fpeNumerals(t, test.alphabet, test.plaintext), true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := fpeString(test.alphabet, ct); got != test.ciphertext {
+				t.Fatalf("bad ciphertext: expected %s, got %s", test.ciphertext, got)
+			}
+
+			pt, err := test.mode(key, tweak, radix, ct, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := fpeString(test.alphabet, pt); got != test.plaintext {
+				t.Fatalf("bad plaintext: expected %s, got %s", test.plaintext, got)
+			}
+		})
+	}
+}
+
+func Test_FPEPolicy(t *testing.T) {
+	ctx := context.Background()
+	storage := &logical.InmemStorage{}
+
+	for _, keyType := range []KeyType{KeyType_AES256_FF1, KeyType_AES256_FF3_1} {
+		p := &Policy{
+			Name: "fpe-" + keyType.String(),
+			Type: keyType,
+		}
+		if err := p.Rotate(ctx, storage, rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+
+		const alphabet = "0123456789"
+		encoded, err := p.FPEEncrypt(0, nil, alphabet, "4111111111111111")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(encoded) != 16 || strings.Trim(encoded, alphabet) != "" {
+			t.Fatalf("%v: encoded value %q does not preserve the format", keyType, encoded)
+		}
+		if encoded == "4111111111111111" {
+			t.Fatalf("%v: value was not encrypted", keyType)
+		}
+
+		decoded, err := p.FPEDecrypt(1, nil, alphabet, encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded != "4111111111111111" {
+			t.Fatalf("%v: bad decoded value %q", keyType, decoded)
+		}
+
+		// Unicode alphabets are supported
+		encoded, err = p.FPEEncrypt(0, nil, "αβγδεζηθικ", "αβγδεζηθ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err = p.FPEDecrypt(0, nil, "αβγδεζηθικ", encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded != "αβγδεζηθ" {
+			t.Fatalf("%v: bad decoded value %q", keyType, decoded)
+		}
+
+		if _, err := p.FPEEncrypt(0, nil, alphabet, "12345"); err == nil {
+			t.Fatalf("%v: expected error for a value with too small a domain", keyType)
+		}
+		if _, err := p.FPEEncrypt(0, nil, alphabet, "12345a"); err == nil {
+			t.Fatalf("%v: expected error for a character outside the alphabet", keyType)
+		}
+		if _, err := p.FPEEncrypt(0, nil, "0123456780", "123456"); err == nil {
+			t.Fatalf("%v: expected error for an alphabet with duplicates", keyType)
+		}
+	}
+
+	p := &Policy{Name: "ff3-1", Type: KeyType_AES256_FF3_1}
+	if err := p.Rotate(ctx, storage, rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.FPEEncrypt(0, []byte("tooshort"), "0123456789", "1234567890"); err == nil {
+		t.Fatal("expected error for a tweak of the wrong length")
+	}
+	if _, err := p.FPEEncrypt(0, nil, "0123456789", strings.Repeat("1", 57)); err == nil {
+		t.Fatal("expected error for a value longer than FF3-1 allows")
+	}
+}
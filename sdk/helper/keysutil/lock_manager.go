@@ -45,6 +45,9 @@ type PolicyRequest struct {
 	// Whether to allow export
 	Exportable bool
 
+	// Whether to allow export wrapped under a caller-supplied public key
+	AllowWrappedExport bool
+
 	// Whether to upsert
 	Upsert bool
 
@@ -368,7 +371,11 @@ func (lm *LockManager) GetPolicy(ctx context.Context, req PolicyRequest, rand io
 				return nil, false, fmt.Errorf("convergent encryption not supported for keys of type %v", req.KeyType)
 			}
 
-		case KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096:
+		case KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096,
+			KeyType_ML_DSA_44, KeyType_ML_DSA_65, KeyType_ML_DSA_87, KeyType_ED25519_ML_DSA_65,
+			KeyType_ML_KEM_768, KeyType_ML_KEM_1024,
+			KeyType_AES128_CMAC, KeyType_AES256_CMAC, KeyType_KMAC128, KeyType_KMAC256,
+			KeyType_AES256_FF1, KeyType_AES256_FF3_1:
 			if req.Derived || req.Convergent {
 				cleanup()
 				return nil, false, fmt.Errorf("key derivation and convergent encryption not supported for keys of type %v", req.KeyType)
@@ -385,6 +392,7 @@ func (lm *LockManager) GetPolicy(ctx context.Context, req PolicyRequest, rand io
 			Type:                 req.KeyType,
 			Derived:              req.Derived,
 			Exportable:           req.Exportable,
+			AllowWrappedExport:   req.AllowWrappedExport,
 			AllowPlaintextBackup: req.AllowPlaintextBackup,
 			AutoRotatePeriod:     req.AutoRotatePeriod,
 		}
@@ -479,6 +487,7 @@ func (lm *LockManager) ImportPolicy(ctx context.Context, req PolicyRequest, key
 			Type:                     req.KeyType,
 			Derived:                  req.Derived,
 			Exportable:               req.Exportable,
+			AllowWrappedExport:       req.AllowWrappedExport,
 			AllowPlaintextBackup:     req.AllowPlaintextBackup,
 			AutoRotatePeriod:         req.AutoRotatePeriod,
 			AllowImportedKeyRotation: req.AllowImportedKeyRotation,
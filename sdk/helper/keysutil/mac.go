@@ -0,0 +1,147 @@
+package keysutil
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	// cmacBlockSize is the AES block size, and thus the length of an untruncated
+	// AES-CMAC tag.
+	cmacBlockSize = aes.BlockSize
+
+	// minMACLength is the shortest MAC that may be requested; SP 800-38B
+	// recommends against CMAC tags shorter than 64 bits.
+	minMACLength = 8
+
+	// maxKMACLength is the longest KMAC output that may be requested.
+	maxKMACLength = 64
+)
+
+// aesCMAC computes the AES-CMAC of message as specified in NIST SP 800-38B
+// and RFC 4493.
+func aesCMAC(key, message []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	k1, k2 := cmacSubkeys(block)
+
+	// The final block is XORed with K1 when complete, or padded with 10*
+	// and XORed with K2 otherwise. The empty message is a single incomplete
+	// block.
+	n := (len(message) + cmacBlockSize - 1) / cmacBlockSize
+	complete := n > 0 && len(message)%cmacBlockSize == 0
+	if n == 0 {
+		n = 1
+	}
+
+	last := make([]byte, cmacBlockSize)
+	lastStart := (n - 1) * cmacBlockSize
+	if complete {
+		xorBytes(last, message[lastStart:], k1)
+	} else {
+		copy(last, message[lastStart:])
+		last[len(message)-lastStart] = 0x80
+		xorBytes(last, last, k2)
+	}
+
+	x := make([]byte, cmacBlockSize)
+	for i := 0; i < n-1; i++ {
+		xorBytes(x, x, message[i*cmacBlockSize:(i+1)*cmacBlockSize])
+		block.Encrypt(x, x)
+	}
+	xorBytes(x, x, last)
+	block.Encrypt(x, x)
+
+	return x, nil
+}
+
+func cmacSubkeys(block cipher.Block) (k1, k2 []byte) {
+	l := make([]byte, cmacBlockSize)
+	block.Encrypt(l, l)
+
+	k1 = cmacDouble(l)
+	k2 = cmacDouble(k1)
+	return k1, k2
+}
+
+// cmacDouble multiplies the input by x in GF(2^128).
+func cmacDouble(in []byte) []byte {
+	out := make([]byte, len(in))
+	var carry byte
+	for i := len(in) - 1; i >= 0; i-- {
+		out[i] = in[i]<<1 | carry
+		carry = in[i] >> 7
+	}
+	// Constant-time conditional reduction by R_128 = 0x87.
+	out[len(out)-1] ^= byte(subtle.ConstantTimeByteEq(carry, 1)) * 0x87
+	return out
+}
+
+func xorBytes(dst, a, b []byte) {
+	for i := range dst {
+		dst[i] = a[i] ^ b[i]
+	}
+}
+
+// kmac computes KMAC128 or KMAC256 (selected by securityBits) of message with
+// an output of length bytes, as specified in NIST SP 800-185.
+func kmac(securityBits int, key, message []byte, length int) []byte {
+	var h sha3.ShakeHash
+	var rate int
+	if securityBits == 256 {
+		h = sha3.NewCShake256([]byte("KMAC"), nil)
+		rate = 136
+	} else {
+		h = sha3.NewCShake128([]byte("KMAC"), nil)
+		rate = 168
+	}
+
+	h.Write(kmacBytepad(kmacEncodeString(key), rate))
+	h.Write(message)
+	h.Write(kmacRightEncode(uint64(length) * 8))
+
+	out := make([]byte, length)
+	h.Read(out)
+	return out
+}
+
+func kmacLeftEncode(x uint64) []byte {
+	var buf [9]byte
+	binary.BigEndian.PutUint64(buf[1:], x)
+	i := 1
+	for i < 8 && buf[i] == 0 {
+		i++
+	}
+	buf[i-1] = byte(9 - i)
+	return buf[i-1:]
+}
+
+func kmacRightEncode(x uint64) []byte {
+	var buf [9]byte
+	binary.BigEndian.PutUint64(buf[:8], x)
+	i := 0
+	for i < 7 && buf[i] == 0 {
+		i++
+	}
+	buf[8] = byte(8 - i)
+	return buf[i:]
+}
+
+func kmacEncodeString(s []byte) []byte {
+	return append(kmacLeftEncode(uint64(len(s))*8), s...)
+}
+
+func kmacBytepad(x []byte, w int) []byte {
+	out := append(kmacLeftEncode(uint64(w)), x...)
+	if pad := len(out) % w; pad != 0 {
+		out = append(out, make([]byte, w-pad)...)
+	}
+	return out
+}
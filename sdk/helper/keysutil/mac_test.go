@@ -0,0 +1,120 @@
+package keysutil
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+)
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func Test_AESCMAC(t *testing.T) {
+	// Test vectors from RFC 4493, section 4.
+	key := mustDecodeHex(t, "2b7e151628aed2a6abf7158809cf4f3c")
+	message := mustDecodeHex(t, "6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710")
+
+	tests := []struct {
+		length   int
+		expected string
+	}{
+		{0, "bb1d6929e95937287fa37d129b756746"},
+		{16, "070a16b46b4d4144f79bdd9dd04a287c"},
+		{40, "dfa66747de9ae63030ca32611497c827"},
+		{64, "51f0bebf7e3b9d92fc49741779363cfe"},
+	}
+
+	for _, test := range tests {
+		mac, err := aesCMAC(key, message[:test.length])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(mac, mustDecodeHex(t, test.expected)) {
+			t.Fatalf("bad CMAC for %d byte message: expected %s, got %x", test.length, test.expected, mac)
+		}
+	}
+}
+
+func Test_KMAC(t *testing.T) {
+	// Samples from the NIST SP 800-185 KMAC examples, without a
+	// customization string.
+	key := mustDecodeHex(t, "404142434445464748494a4b4c4d4e4f505152535455565758595a5b5c5d5e5f")
+	message := mustDecodeHex(t, "00010203")
+
+	mac := kmac(128, key, message, 32)
+	expected := mustDecodeHex(t, "e5780b0d3ea6f7d3a429c5706aa43a00fadbd7d49628839e3187243f456ee14e")
+	if !bytes.Equal(mac, expected) {
+		t.Fatalf("bad KMAC128: expected %x, got %x", expected, mac)
+	}
+
+	message = make([]byte, 200)
+	for i := range message {
+		message[i] = byte(i)
+	}
+	mac = kmac(256, key, message, 64)
+	expected = mustDecodeHex(t, "75358cf39e41494e949707927cee0af20a3ff553904c86b08f21cc414bcfd691589d27cf5e15369cbbff8b9a4c2eb17800855d0235ff635da82533ec6b759b69")
+	if !bytes.Equal(mac, expected) {
+		t.Fatalf("bad KMAC256: expected %x, got %x", expected, mac)
+	}
+}
+
+func Test_PolicyCMAC(t *testing.T) {
+	for _, keyType := range []KeyType{KeyType_AES128_CMAC, KeyType_AES256_CMAC, KeyType_KMAC128, KeyType_KMAC256} {
+		p := NewPolicy(PolicyConfig{
+			Name: "test",
+			Type: keyType,
+		})
+		if err := p.RotateInMemory(rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+
+		mac, err := p.CMAC(1, []byte("input"), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedLength := map[KeyType]int{
+			KeyType_AES128_CMAC: 16,
+			KeyType_AES256_CMAC: 16,
+			KeyType_KMAC128:     32,
+			KeyType_KMAC256:     64,
+		}[keyType]
+		if len(mac) != expectedLength {
+			t.Fatalf("%s: expected %d byte MAC, got %d", keyType, expectedLength, len(mac))
+		}
+
+		truncated, err := p.CMAC(1, []byte("input"), 8)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if keyType == KeyType_AES128_CMAC || keyType == KeyType_AES256_CMAC {
+			if !bytes.Equal(truncated, mac[:8]) {
+				t.Fatalf("%s: expected truncated MAC to be a prefix of the full MAC", keyType)
+			}
+		}
+
+		if _, err := p.CMAC(1, []byte("input"), 4); err == nil {
+			t.Fatalf("%s: expected error for short MAC length", keyType)
+		}
+		if keyType.EncryptionSupported() || keyType.SigningSupported() {
+			t.Fatalf("%s: expected MAC-only key type", keyType)
+		}
+	}
+
+	p := NewPolicy(PolicyConfig{
+		Name: "test",
+		Type: KeyType_AES256_GCM96,
+	})
+	if err := p.RotateInMemory(rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.CMAC(1, []byte("input"), 0); err == nil {
+		t.Fatal("expected error computing CMAC with an encryption key")
+	}
+}
@@ -59,6 +59,18 @@ const (
 	KeyType_ECDSA_P521
 	KeyType_AES128_GCM96
 	KeyType_RSA3072
+	KeyType_ML_DSA_44
+	KeyType_ML_DSA_65
+	KeyType_ML_DSA_87
+	KeyType_ED25519_ML_DSA_65
+	KeyType_ML_KEM_768
+	KeyType_ML_KEM_1024
+	KeyType_AES128_CMAC
+	KeyType_AES256_CMAC
+	KeyType_KMAC128
+	KeyType_KMAC256
+	KeyType_AES256_FF1
+	KeyType_AES256_FF3_1
 )
 
 const (
@@ -93,7 +105,7 @@ type KeyType int
 
 func (kt KeyType) EncryptionSupported() bool {
 	switch kt {
-	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305, KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096:
+	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305, KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096, KeyType_ML_KEM_768, KeyType_ML_KEM_1024:
 		return true
 	}
 	return false
@@ -101,7 +113,7 @@ func (kt KeyType) EncryptionSupported() bool {
 
 func (kt KeyType) DecryptionSupported() bool {
 	switch kt {
-	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305, KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096:
+	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305, KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096, KeyType_ML_KEM_768, KeyType_ML_KEM_1024:
 		return true
 	}
 	return false
@@ -109,7 +121,8 @@ func (kt KeyType) DecryptionSupported() bool {
 
 func (kt KeyType) SigningSupported() bool {
 	switch kt {
-	case KeyType_ECDSA_P256, KeyType_ECDSA_P384, KeyType_ECDSA_P521, KeyType_ED25519, KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096:
+	case KeyType_ECDSA_P256, KeyType_ECDSA_P384, KeyType_ECDSA_P521, KeyType_ED25519, KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096,
+		KeyType_ML_DSA_44, KeyType_ML_DSA_65, KeyType_ML_DSA_87, KeyType_ED25519_ML_DSA_65:
 		return true
 	}
 	return false
@@ -123,6 +136,16 @@ func (kt KeyType) HashSignatureInput() bool {
 	return false
 }
 
+// AssociatedDataSupported reports whether encryption with the key type can
+// authenticate caller-supplied associated data.
+func (kt KeyType) AssociatedDataSupported() bool {
+	switch kt {
+	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305:
+		return true
+	}
+	return false
+}
+
 func (kt KeyType) DerivationSupported() bool {
 	switch kt {
 	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305, KeyType_ED25519:
@@ -131,6 +154,26 @@ func (kt KeyType) DerivationSupported() bool {
 	return false
 }
 
+// CMACSupported reports whether the key type is a MAC-only key type, usable
+// with CMAC but no other cryptographic operation.
+func (kt KeyType) CMACSupported() bool {
+	switch kt {
+	case KeyType_AES128_CMAC, KeyType_AES256_CMAC, KeyType_KMAC128, KeyType_KMAC256:
+		return true
+	}
+	return false
+}
+
+// FPESupported reports whether the key type is a format-preserving encryption
+// key type, usable with FF1 or FF3-1 but no other cryptographic operation.
+func (kt KeyType) FPESupported() bool {
+	switch kt {
+	case KeyType_AES256_FF1, KeyType_AES256_FF3_1:
+		return true
+	}
+	return false
+}
+
 func (kt KeyType) String() string {
 	switch kt {
 	case KeyType_AES128_GCM96:
@@ -153,6 +196,30 @@ func (kt KeyType) String() string {
 		return "rsa-3072"
 	case KeyType_RSA4096:
 		return "rsa-4096"
+	case KeyType_ML_DSA_44:
+		return "ml-dsa-44"
+	case KeyType_ML_DSA_65:
+		return "ml-dsa-65"
+	case KeyType_ML_DSA_87:
+		return "ml-dsa-87"
+	case KeyType_ED25519_ML_DSA_65:
+		return "ed25519-ml-dsa-65"
+	case KeyType_ML_KEM_768:
+		return "ml-kem-768"
+	case KeyType_ML_KEM_1024:
+		return "ml-kem-1024"
+	case KeyType_AES128_CMAC:
+		return "aes128-cmac"
+	case KeyType_AES256_CMAC:
+		return "aes256-cmac"
+	case KeyType_KMAC128:
+		return "kmac128"
+	case KeyType_KMAC256:
+		return "kmac256"
+	case KeyType_AES256_FF1:
+		return "aes256-ff1"
+	case KeyType_AES256_FF3_1:
+		return "aes256-ff3-1"
 	}
 
 	return "[unknown]"
@@ -180,6 +247,9 @@ type KeyEntry struct {
 
 	RSAKey *rsa.PrivateKey `json:"rsa_key"`
 
+	// The ML-DSA seed of hybrid keys; the classical half is stored in Key
+	HybridMLDSAKey []byte `json:"hybrid_ml_dsa_key"`
+
 	// The public key in an appropriate format for the type of key
 	FormattedPublicKey string `json:"public_key"`
 
@@ -325,6 +395,15 @@ type Policy struct {
 	// Whether the key is exportable
 	Exportable bool `json:"exportable"`
 
+	// Whether the key may be exported wrapped under a caller-supplied public
+	// key, even when it is not exportable in plaintext
+	AllowWrappedExport bool `json:"allow_wrapped_export"`
+
+	// UsagePolicy restricts the operations the key may be used for, and the
+	// key versions and derivation contexts allowed for each. If empty, the key
+	// may be used for any operation its type supports.
+	UsagePolicy map[KeyUsage]KeyUsageRule `json:"usage_policy,omitempty"`
+
 	// The minimum version of the key allowed to be used for decryption
 	MinDecryptionVersion int `json:"min_decryption_version"`
 
@@ -819,16 +898,59 @@ func (p *Policy) convergentVersion(ver int) int {
 }
 
 func (p *Policy) Encrypt(ver int, context, nonce []byte, value string) (string, error) {
+	return p.EncryptWithAssociatedData(ver, context, nonce, value, nil)
+}
+
+// EncryptWithAssociatedData encrypts the value like Encrypt, additionally
+// authenticating the associated data, which must then be supplied again to
+// decrypt the ciphertext. Associated data is only supported for AEAD key
+// types.
+func (p *Policy) EncryptWithAssociatedData(ver int, context, nonce []byte, value string, associatedData []byte) (string, error) {
+	return p.EncryptForUsage(KeyUsageEncrypt, ver, context, nonce, value, associatedData)
+}
+
+// EncryptForUsage encrypts the value like EncryptWithAssociatedData, checking
+// the key's usage policy for the given operation rather than encrypt.
+func (p *Policy) EncryptForUsage(usage KeyUsage, ver int, context, nonce []byte, value string, associatedData []byte) (string, error) {
+	return p.encryptForUsage(usage, ver, context, nonce, value, associatedData, false)
+}
+
+// ReencryptForUsage encrypts a plaintext obtained by decrypting one of the
+// policy's own ciphertexts, as rewrap does. Unlike EncryptForUsage it accepts
+// the plaintext prefix reserved for stream headers, as only stream headers
+// can have been encrypted with it, so that headers can be moved to newer key
+// versions.
+func (p *Policy) ReencryptForUsage(usage KeyUsage, ver int, context, nonce []byte, value string, associatedData []byte) (string, error) {
+	return p.encryptForUsage(usage, ver, context, nonce, value, associatedData, true)
+}
+
+// encryptForUsage implements EncryptForUsage. Only stream headers may be
+// encrypted with the plaintext prefix reserved for them.
+func (p *Policy) encryptForUsage(usage KeyUsage, ver int, context, nonce []byte, value string, associatedData []byte, streamHeader bool) (string, error) {
 	if !p.Type.EncryptionSupported() {
 		return "", errutil.UserError{Err: fmt.Sprintf("message encryption not supported for key type %v", p.Type)}
 	}
 
+	if len(associatedData) > 0 && !p.Type.AssociatedDataSupported() {
+		return "", errutil.UserError{Err: fmt.Sprintf("associated data not supported for key type %v", p.Type)}
+	}
+
+	// Convergent nonces are derived from the plaintext alone, so the same
+	// plaintext under different associated data would reuse a nonce.
+	if len(associatedData) > 0 && p.ConvergentEncryption {
+		return "", errutil.UserError{Err: "associated data not supported with convergent encryption"}
+	}
+
 	// Decode the plaintext value
 	plaintext, err := base64.StdEncoding.DecodeString(value)
 	if err != nil {
 		return "", errutil.UserError{Err: err.Error()}
 	}
 
+	if !streamHeader && bytes.HasPrefix(plaintext, streamHeaderPrefix) {
+		return "", errutil.UserError{Err: "plaintext begins with a prefix reserved for stream headers"}
+	}
+
 	switch {
 	case ver == 0:
 		ver = p.LatestVersion
@@ -840,6 +962,10 @@ func (p *Policy) Encrypt(ver int, context, nonce []byte, value string) (string,
 		return "", errutil.UserError{Err: "requested version for encryption is less than the minimum encryption key version"}
 	}
 
+	if err := p.CheckUsage(usage, ver, context); err != nil {
+		return "", err
+	}
+
 	var ciphertext []byte
 
 	switch p.Type {
@@ -881,9 +1007,10 @@ func (p *Policy) Encrypt(ver int, context, nonce []byte, value string) (string,
 
 		ciphertext, err = p.SymmetricEncryptRaw(ver, encKey, plaintext,
 			SymmetricOpts{
-				Convergent: p.ConvergentEncryption,
-				HMACKey:    hmacKey,
-				Nonce:      nonce,
+				Convergent:     p.ConvergentEncryption,
+				HMACKey:        hmacKey,
+				Nonce:          nonce,
+				AdditionalData: associatedData,
 			})
 
 		if err != nil {
@@ -899,6 +1026,15 @@ func (p *Policy) Encrypt(ver int, context, nonce []byte, value string) (string,
 		if err != nil {
 			return "", errutil.InternalError{Err: fmt.Sprintf("failed to RSA encrypt the plaintext: %v", err)}
 		}
+	case KeyType_ML_KEM_768, KeyType_ML_KEM_1024:
+		keyEntry, err := p.safeGetKeyEntry(ver)
+		if err != nil {
+			return "", err
+		}
+		ciphertext, err = mlKEMEncrypt(p.Type, keyEntry.Key, plaintext)
+		if err != nil {
+			return "", err
+		}
 
 	default:
 		return "", errutil.InternalError{Err: fmt.Sprintf("unsupported key type %v", p.Type)}
@@ -914,10 +1050,32 @@ func (p *Policy) Encrypt(ver int, context, nonce []byte, value string) (string,
 }
 
 func (p *Policy) Decrypt(context, nonce []byte, value string) (string, error) {
+	return p.DecryptWithAssociatedData(context, nonce, value, nil)
+}
+
+// DecryptWithAssociatedData decrypts the value like Decrypt, failing unless
+// the associated data matches that supplied at encryption.
+func (p *Policy) DecryptWithAssociatedData(context, nonce []byte, value string, associatedData []byte) (string, error) {
+	return p.DecryptForUsage(KeyUsageDecrypt, context, nonce, value, associatedData)
+}
+
+// DecryptForUsage decrypts the value like DecryptWithAssociatedData, checking
+// the key's usage policy for the given operation rather than decrypt.
+func (p *Policy) DecryptForUsage(usage KeyUsage, context, nonce []byte, value string, associatedData []byte) (string, error) {
 	if !p.Type.DecryptionSupported() {
 		return "", errutil.UserError{Err: fmt.Sprintf("message decryption not supported for key type %v", p.Type)}
 	}
 
+	if len(associatedData) > 0 && !p.Type.AssociatedDataSupported() {
+		return "", errutil.UserError{Err: fmt.Sprintf("associated data not supported for key type %v", p.Type)}
+	}
+
+	// Convergent nonces are derived from the plaintext alone, so the same
+	// plaintext under different associated data would reuse a nonce.
+	if len(associatedData) > 0 && p.ConvergentEncryption {
+		return "", errutil.UserError{Err: "associated data not supported with convergent encryption"}
+	}
+
 	tplParts, err := p.getTemplateParts()
 	if err != nil {
 		return "", err
@@ -952,6 +1110,10 @@ func (p *Policy) Decrypt(context, nonce []byte, value string) (string, error) {
 		return "", errutil.UserError{Err: ErrTooOld}
 	}
 
+	if err := p.CheckUsage(usage, ver, context); err != nil {
+		return "", err
+	}
+
 	convergentVersion := p.convergentVersion(ver)
 	if convergentVersion == 1 && (nonce == nil || len(nonce) == 0) {
 		return "", errutil.UserError{Err: "invalid convergent nonce supplied"}
@@ -985,6 +1147,7 @@ func (p *Policy) Decrypt(context, nonce []byte, value string) (string, error) {
 			SymmetricOpts{
 				Convergent:        p.ConvergentEncryption,
 				ConvergentVersion: p.ConvergentVersion,
+				AdditionalData:    associatedData,
 			})
 		if err != nil {
 			return "", err
@@ -999,6 +1162,15 @@ func (p *Policy) Decrypt(context, nonce []byte, value string) (string, error) {
 		if err != nil {
 			return "", errutil.InternalError{Err: fmt.Sprintf("failed to RSA decrypt the ciphertext: %v", err)}
 		}
+	case KeyType_ML_KEM_768, KeyType_ML_KEM_1024:
+		keyEntry, err := p.safeGetKeyEntry(ver)
+		if err != nil {
+			return "", err
+		}
+		plain, err = mlKEMDecrypt(p.Type, keyEntry.Key, decoded)
+		if err != nil {
+			return "", err
+		}
 
 	default:
 		return "", errutil.InternalError{Err: fmt.Sprintf("unsupported key type %v", p.Type)}
@@ -1008,12 +1180,19 @@ func (p *Policy) Decrypt(context, nonce []byte, value string) (string, error) {
 }
 
 func (p *Policy) HMACKey(version int) ([]byte, error) {
+	if p.Type.CMACSupported() {
+		return nil, errutil.UserError{Err: fmt.Sprintf("HMAC not supported for key type %v", p.Type)}
+	}
+
 	switch {
 	case version < 0:
 		return nil, fmt.Errorf("key version does not exist (cannot be negative)")
 	case version > p.LatestVersion:
 		return nil, fmt.Errorf("key version does not exist; latest key version is %d", p.LatestVersion)
 	}
+	if err := p.CheckUsage(KeyUsageHMAC, version, nil); err != nil {
+		return nil, err
+	}
 	keyEntry, err := p.safeGetKeyEntry(version)
 	if err != nil {
 		return nil, err
@@ -1025,6 +1204,75 @@ func (p *Policy) HMACKey(version int) ([]byte, error) {
 	return keyEntry.HMACKey, nil
 }
 
+// CMACKey returns the key used for CMAC operations with MAC-only key types.
+func (p *Policy) CMACKey(version int) ([]byte, error) {
+	if !p.Type.CMACSupported() {
+		return nil, errutil.UserError{Err: fmt.Sprintf("CMAC not supported for key type %v", p.Type)}
+	}
+
+	switch {
+	case version < 0:
+		return nil, fmt.Errorf("key version does not exist (cannot be negative)")
+	case version > p.LatestVersion:
+		return nil, fmt.Errorf("key version does not exist; latest key version is %d", p.LatestVersion)
+	}
+	if err := p.CheckUsage(KeyUsageCMAC, version, nil); err != nil {
+		return nil, err
+	}
+	keyEntry, err := p.safeGetKeyEntry(version)
+	if err != nil {
+		return nil, err
+	}
+	if keyEntry.Key == nil {
+		return nil, fmt.Errorf("no CMAC key exists for that key version")
+	}
+
+	return keyEntry.Key, nil
+}
+
+// CMAC computes the keyed MAC of the input with the given key version: AES-CMAC
+// for the aes128-cmac and aes256-cmac key types, KMAC for kmac128 and kmac256.
+// A macLength of zero selects the full-length MAC for the key type: 16 bytes
+// for AES-CMAC, 32 bytes for KMAC128 and 64 bytes for KMAC256.
+func (p *Policy) CMAC(version int, input []byte, macLength int) ([]byte, error) {
+	key, err := p.CMACKey(version)
+	if err != nil {
+		return nil, err
+	}
+
+	switch p.Type {
+	case KeyType_AES128_CMAC, KeyType_AES256_CMAC:
+		if macLength == 0 {
+			macLength = cmacBlockSize
+		}
+		if macLength < minMACLength || macLength > cmacBlockSize {
+			return nil, errutil.UserError{Err: fmt.Sprintf("MAC length must be between %d and %d bytes for key type %v", minMACLength, cmacBlockSize, p.Type)}
+		}
+
+		mac, err := aesCMAC(key, input)
+		if err != nil {
+			return nil, errutil.InternalError{Err: err.Error()}
+		}
+		return mac[:macLength], nil
+
+	case KeyType_KMAC128, KeyType_KMAC256:
+		securityBits := 128
+		if p.Type == KeyType_KMAC256 {
+			securityBits = 256
+		}
+		if macLength == 0 {
+			macLength = securityBits / 4
+		}
+		if macLength < minMACLength || macLength > maxKMACLength {
+			return nil, errutil.UserError{Err: fmt.Sprintf("MAC length must be between %d and %d bytes for key type %v", minMACLength, maxKMACLength, p.Type)}
+		}
+
+		return kmac(securityBits, key, input, macLength), nil
+	}
+
+	return nil, errutil.InternalError{Err: fmt.Sprintf("unsupported key type %v", p.Type)}
+}
+
 func (p *Policy) Sign(ver int, context, input []byte, hashAlgorithm HashType, sigAlgorithm string, marshaling MarshalingType) (*SigningResult, error) {
 	if !p.Type.SigningSupported() {
 		return nil, fmt.Errorf("message signing not supported for key type %v", p.Type)
@@ -1041,6 +1289,10 @@ func (p *Policy) Sign(ver int, context, input []byte, hashAlgorithm HashType, si
 		return nil, errutil.UserError{Err: "requested version for signing is less than the minimum encryption key version"}
 	}
 
+	if err := p.CheckUsage(KeyUsageSign, ver, context); err != nil {
+		return nil, err
+	}
+
 	var sig []byte
 	var pubKey []byte
 	var err error
@@ -1182,6 +1434,25 @@ func (p *Policy) Sign(ver int, context, input []byte, hashAlgorithm HashType, si
 			return nil, errutil.InternalError{Err: fmt.Sprintf("unsupported rsa signature algorithm %s", sigAlgorithm)}
 		}
 
+	case KeyType_ML_DSA_44, KeyType_ML_DSA_65, KeyType_ML_DSA_87:
+		// Like ed25519, ML-DSA performs its own hashing of the message
+		sig, err = mlDSASign(p.Type, keyParams.Key, input)
+		if err != nil {
+			return nil, err
+		}
+
+	case KeyType_ED25519_ML_DSA_65:
+		// The hybrid signature is the ed25519 signature followed by the
+		// ML-DSA signature over the same input; both must verify.
+		classicalSig := ed25519.Sign(ed25519.PrivateKey(keyParams.Key), input)
+
+		pqSig, err := hybridMLDSASign(p.Type, keyParams.HybridMLDSAKey, input)
+		if err != nil {
+			return nil, err
+		}
+
+		sig = append(classicalSig, pqSig...)
+
 	default:
 		return nil, fmt.Errorf("unsupported key type %v", p.Type)
 	}
@@ -1235,6 +1506,10 @@ func (p *Policy) VerifySignature(context, input []byte, hashAlgorithm HashType,
 		return false, errutil.UserError{Err: ErrTooOld}
 	}
 
+	if err := p.CheckUsage(KeyUsageVerify, ver, context); err != nil {
+		return false, err
+	}
+
 	var sigBytes []byte
 	switch marshaling {
 	case MarshalingTypeASN1:
@@ -1357,6 +1632,31 @@ func (p *Policy) VerifySignature(context, input []byte, hashAlgorithm HashType,
 
 		return err == nil, nil
 
+	case KeyType_ML_DSA_44, KeyType_ML_DSA_65, KeyType_ML_DSA_87:
+		keyEntry, err := p.safeGetKeyEntry(ver)
+		if err != nil {
+			return false, err
+		}
+
+		return mlDSAVerify(p.Type, keyEntry.Key, input, sigBytes)
+
+	case KeyType_ED25519_ML_DSA_65:
+		keyEntry, err := p.safeGetKeyEntry(ver)
+		if err != nil {
+			return false, err
+		}
+
+		if len(sigBytes) < ed25519.SignatureSize {
+			return false, nil
+		}
+
+		classicalKey := ed25519.PrivateKey(keyEntry.Key)
+		if !ed25519.Verify(classicalKey.Public().(ed25519.PublicKey), input, sigBytes[:ed25519.SignatureSize]) {
+			return false, nil
+		}
+
+		return hybridMLDSAVerify(p.Type, keyEntry.HybridMLDSAKey, input, sigBytes[ed25519.SignatureSize:])
+
 	default:
 		return false, errutil.InternalError{Err: fmt.Sprintf("unsupported key type %v", p.Type)}
 	}
@@ -1375,12 +1675,13 @@ func (p *Policy) Import(ctx context.Context, storage logical.Storage, key []byte
 	}
 	entry.HMACKey = hmacKey
 
-	if (p.Type == KeyType_AES128_GCM96 && len(key) != 16) ||
-		((p.Type == KeyType_AES256_GCM96 || p.Type == KeyType_ChaCha20_Poly1305) && len(key) != 32) {
+	if ((p.Type == KeyType_AES128_GCM96 || p.Type == KeyType_AES128_CMAC) && len(key) != 16) ||
+		((p.Type == KeyType_AES256_GCM96 || p.Type == KeyType_ChaCha20_Poly1305 || p.Type == KeyType_AES256_CMAC || p.Type.FPESupported()) && len(key) != 32) ||
+		((p.Type == KeyType_KMAC128 || p.Type == KeyType_KMAC256) && len(key) < 16) {
 		return fmt.Errorf("invalid key size %d bytes for key type %s", len(key), p.Type)
 	}
 
-	if p.Type == KeyType_AES128_GCM96 || p.Type == KeyType_AES256_GCM96 || p.Type == KeyType_ChaCha20_Poly1305 {
+	if p.Type == KeyType_AES128_GCM96 || p.Type == KeyType_AES256_GCM96 || p.Type == KeyType_ChaCha20_Poly1305 || p.Type.CMACSupported() || p.Type.FPESupported() {
 		entry.Key = key
 	} else {
 		parsedPrivateKey, err := x509.ParsePKCS8PrivateKey(key)
@@ -1532,10 +1833,12 @@ func (p *Policy) RotateInMemory(randReader io.Reader) (retErr error) {
 	entry.HMACKey = hmacKey
 
 	switch p.Type {
-	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305:
+	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305,
+		KeyType_AES128_CMAC, KeyType_AES256_CMAC, KeyType_KMAC128, KeyType_KMAC256,
+		KeyType_AES256_FF1, KeyType_AES256_FF3_1:
 		// Default to 256 bit key
 		numBytes := 32
-		if p.Type == KeyType_AES128_GCM96 {
+		if p.Type == KeyType_AES128_GCM96 || p.Type == KeyType_AES128_CMAC {
 			numBytes = 16
 		}
 		newKey, err := uuid.GenerateRandomBytesWithReader(numBytes, randReader)
@@ -1597,6 +1900,35 @@ func (p *Policy) RotateInMemory(randReader io.Reader) (retErr error) {
 		if err != nil {
 			return err
 		}
+
+	case KeyType_ML_DSA_44, KeyType_ML_DSA_65, KeyType_ML_DSA_87:
+		seed, pub, err := generateMLDSASeed(p.Type, randReader)
+		if err != nil {
+			return err
+		}
+		entry.Key = seed
+		entry.FormattedPublicKey = base64.StdEncoding.EncodeToString(pub)
+
+	case KeyType_ED25519_ML_DSA_65:
+		classicalPub, classicalPri, err := ed25519.GenerateKey(randReader)
+		if err != nil {
+			return err
+		}
+		seed, pub, err := generateMLDSASeed(p.Type, randReader)
+		if err != nil {
+			return err
+		}
+		entry.Key = classicalPri
+		entry.HybridMLDSAKey = seed
+		entry.FormattedPublicKey = base64.StdEncoding.EncodeToString(append(classicalPub, pub...))
+
+	case KeyType_ML_KEM_768, KeyType_ML_KEM_1024:
+		seed, pub, err := generateMLKEMSeed(p.Type, randReader)
+		if err != nil {
+			return err
+		}
+		entry.Key = seed
+		entry.FormattedPublicKey = base64.StdEncoding.EncodeToString(pub)
 	}
 
 	if p.ConvergentEncryption {
@@ -0,0 +1,189 @@
+//go:build go1.27
+
+package keysutil
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/mldsa"
+	"crypto/mlkem"
+	"crypto/rand"
+	"fmt"
+	"io"
+
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+)
+
+// hybridMLDSAContext is the ML-DSA context string used for the post-quantum
+// half of hybrid signatures, so that it cannot be stripped off and presented
+// as a standalone ML-DSA signature.
+const hybridMLDSAContext = "vault-transit-ed25519-ml-dsa-65"
+
+func mlDSAParameters(kt KeyType) (mldsa.Parameters, error) {
+	switch kt {
+	case KeyType_ML_DSA_44:
+		return mldsa.MLDSA44(), nil
+	case KeyType_ML_DSA_65, KeyType_ED25519_ML_DSA_65:
+		return mldsa.MLDSA65(), nil
+	case KeyType_ML_DSA_87:
+		return mldsa.MLDSA87(), nil
+	}
+
+	return mldsa.Parameters{}, fmt.Errorf("unsupported key type %v for ML-DSA", kt)
+}
+
+func mlDSAPrivateKey(kt KeyType, seed []byte) (*mldsa.PrivateKey, error) {
+	params, err := mlDSAParameters(kt)
+	if err != nil {
+		return nil, err
+	}
+
+	return mldsa.NewPrivateKey(params, seed)
+}
+
+func mlDSASign(kt KeyType, seed, input []byte) ([]byte, error) {
+	return mlDSASignWithOptions(kt, seed, input, crypto.Hash(0))
+}
+
+func hybridMLDSASign(kt KeyType, seed, input []byte) ([]byte, error) {
+	return mlDSASignWithOptions(kt, seed, input, &mldsa.Options{Context: hybridMLDSAContext})
+}
+
+func mlDSASignWithOptions(kt KeyType, seed, input []byte, opts crypto.SignerOpts) ([]byte, error) {
+	key, err := mlDSAPrivateKey(kt, seed)
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("error loading ML-DSA key: %v", err)}
+	}
+
+	return key.Sign(rand.Reader, input, opts)
+}
+
+func mlDSAVerify(kt KeyType, seed, input, sig []byte) (bool, error) {
+	return mlDSAVerifyWithOptions(kt, seed, input, sig, nil)
+}
+
+func hybridMLDSAVerify(kt KeyType, seed, input, sig []byte) (bool, error) {
+	return mlDSAVerifyWithOptions(kt, seed, input, sig, &mldsa.Options{Context: hybridMLDSAContext})
+}
+
+func mlDSAVerifyWithOptions(kt KeyType, seed, input, sig []byte, opts *mldsa.Options) (bool, error) {
+	key, err := mlDSAPrivateKey(kt, seed)
+	if err != nil {
+		return false, errutil.InternalError{Err: fmt.Sprintf("error loading ML-DSA key: %v", err)}
+	}
+
+	return mldsa.Verify(key.PublicKey(), input, sig, opts) == nil, nil
+}
+
+func generateMLDSASeed(kt KeyType, randReader io.Reader) (seed []byte, publicKey []byte, err error) {
+	seed, err = uuid.GenerateRandomBytesWithReader(mldsa.PrivateKeySize, randReader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key, err := mlDSAPrivateKey(kt, seed)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return seed, key.PublicKey().Bytes(), nil
+}
+
+func mlKEMDecapsulator(kt KeyType, seed []byte) (crypto.Decapsulator, int, error) {
+	switch kt {
+	case KeyType_ML_KEM_768:
+		key, err := mlkem.NewDecapsulationKey768(seed)
+		return key, mlkem.CiphertextSize768, err
+	case KeyType_ML_KEM_1024:
+		key, err := mlkem.NewDecapsulationKey1024(seed)
+		return key, mlkem.CiphertextSize1024, err
+	}
+
+	return nil, 0, fmt.Errorf("unsupported key type %v for ML-KEM", kt)
+}
+
+func generateMLKEMSeed(kt KeyType, randReader io.Reader) (seed []byte, publicKey []byte, err error) {
+	seed, err = uuid.GenerateRandomBytesWithReader(mlkem.SeedSize, randReader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key, _, err := mlKEMDecapsulator(kt, seed)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return seed, key.Encapsulator().Bytes(), nil
+}
+
+// mlKEMEncrypt encapsulates a fresh shared key to the ML-KEM key and uses it
+// to encrypt the plaintext with AES-256-GCM. The result is the KEM
+// ciphertext, followed by the GCM nonce and sealed plaintext.
+func mlKEMEncrypt(kt KeyType, seed, plaintext []byte) ([]byte, error) {
+	key, _, err := mlKEMDecapsulator(kt, seed)
+	if err != nil {
+		return nil, errutil.InternalError{Err: err.Error()}
+	}
+
+	sharedKey, kemCiphertext := key.Encapsulator().Encapsulate()
+	gcm, err := mlKEMAEAD(sharedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := uuid.GenerateRandomBytes(gcm.NonceSize())
+	if err != nil {
+		return nil, errutil.InternalError{Err: err.Error()}
+	}
+
+	ciphertext := append(kemCiphertext, nonce...)
+	return gcm.Seal(ciphertext, nonce, plaintext, nil), nil
+}
+
+func mlKEMDecrypt(kt KeyType, seed, ciphertext []byte) ([]byte, error) {
+	key, kemCiphertextSize, err := mlKEMDecapsulator(kt, seed)
+	if err != nil {
+		return nil, errutil.InternalError{Err: err.Error()}
+	}
+
+	if len(ciphertext) < kemCiphertextSize {
+		return nil, errutil.UserError{Err: "invalid ciphertext length"}
+	}
+
+	sharedKey, err := key.Decapsulate(ciphertext[:kemCiphertextSize])
+	if err != nil {
+		return nil, errutil.UserError{Err: err.Error()}
+	}
+
+	gcm, err := mlKEMAEAD(sharedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext = ciphertext[kemCiphertextSize:]
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errutil.UserError{Err: "invalid ciphertext length"}
+	}
+
+	plain, err := gcm.Open(nil, ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, errutil.UserError{Err: err.Error()}
+	}
+	return plain, nil
+}
+
+func mlKEMAEAD(sharedKey []byte) (cipher.AEAD, error) {
+	aesCipher, err := aes.NewCipher(sharedKey)
+	if err != nil {
+		return nil, errutil.InternalError{Err: err.Error()}
+	}
+
+	gcm, err := cipher.NewGCM(aesCipher)
+	if err != nil {
+		return nil, errutil.InternalError{Err: err.Error()}
+	}
+
+	return gcm, nil
+}
@@ -0,0 +1,46 @@
+//go:build !go1.27
+
+package keysutil
+
+import (
+	"errors"
+	"io"
+
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+)
+
+// The post-quantum key types rely on crypto/mldsa and crypto/mlkem, first
+// shipped with Go 1.27; builds with older toolchains refuse to use them.
+var errPostQuantumUnsupported = errors.New("post-quantum key types require a build with Go 1.27 or later")
+
+func mlDSASign(kt KeyType, seed, input []byte) ([]byte, error) {
+	return nil, errutil.InternalError{Err: errPostQuantumUnsupported.Error()}
+}
+
+func hybridMLDSASign(kt KeyType, seed, input []byte) ([]byte, error) {
+	return nil, errutil.InternalError{Err: errPostQuantumUnsupported.Error()}
+}
+
+func mlDSAVerify(kt KeyType, seed, input, sig []byte) (bool, error) {
+	return false, errutil.InternalError{Err: errPostQuantumUnsupported.Error()}
+}
+
+func hybridMLDSAVerify(kt KeyType, seed, input, sig []byte) (bool, error) {
+	return false, errutil.InternalError{Err: errPostQuantumUnsupported.Error()}
+}
+
+func generateMLDSASeed(kt KeyType, randReader io.Reader) (seed []byte, publicKey []byte, err error) {
+	return nil, nil, errPostQuantumUnsupported
+}
+
+func generateMLKEMSeed(kt KeyType, randReader io.Reader) (seed []byte, publicKey []byte, err error) {
+	return nil, nil, errPostQuantumUnsupported
+}
+
+func mlKEMEncrypt(kt KeyType, seed, plaintext []byte) ([]byte, error) {
+	return nil, errutil.InternalError{Err: errPostQuantumUnsupported.Error()}
+}
+
+func mlKEMDecrypt(kt KeyType, seed, ciphertext []byte) ([]byte, error) {
+	return nil, errutil.InternalError{Err: errPostQuantumUnsupported.Error()}
+}
@@ -0,0 +1,116 @@
+package keysutil
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+)
+
+// streamKeySize is the size of the AES-256 key protecting the chunks of a
+// stream.
+const streamKeySize = 32
+
+// streamHeaderPrefix precedes the stream key in the plaintext of a stream
+// header. Encrypt refuses plaintexts beginning with it, so that no other
+// ciphertext, such as a data key, can be passed off as a stream header.
+var streamHeaderPrefix = []byte("vault-transit-stream-header:v1:")
+
+// NewStreamHeader generates a fresh stream key and returns it encrypted under
+// the given version of the policy's key. The header is passed back with every
+// chunk of the stream, so that no per-stream state needs to be kept by Vault.
+func (p *Policy) NewStreamHeader(ver int, context []byte) (string, error) {
+	if !p.Type.EncryptionSupported() {
+		return "", errutil.UserError{Err: fmt.Sprintf("message encryption not supported for key type %v", p.Type)}
+	}
+
+	streamKey, err := uuid.GenerateRandomBytes(streamKeySize)
+	if err != nil {
+		return "", errutil.InternalError{Err: err.Error()}
+	}
+
+	plaintext := append(append([]byte{}, streamHeaderPrefix...), streamKey...)
+	return p.encryptForUsage(KeyUsageEncrypt, ver, context, nil, base64.StdEncoding.EncodeToString(plaintext), nil, true)
+}
+
+// EncryptStreamChunk encrypts one chunk of a stream. Every chunk is sealed
+// with the stream key under a fresh random nonce, authenticating its index and
+// whether it is the final chunk, so that chunks cannot be reordered, dropped
+// or the stream truncated without detection. The result is the nonce
+// followed by the sealed chunk.
+func (p *Policy) EncryptStreamChunk(context []byte, header string, index uint32, final bool, plaintext []byte) ([]byte, error) {
+	aead, err := p.streamAEAD(KeyUsageEncrypt, context, header)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := uuid.GenerateRandomBytes(aead.NonceSize())
+	if err != nil {
+		return nil, errutil.InternalError{Err: err.Error()}
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, streamChunkAAD(index, final)), nil
+}
+
+// DecryptStreamChunk decrypts one chunk of a stream produced by
+// EncryptStreamChunk. The index and final flag must match the values the
+// chunk was encrypted with.
+func (p *Policy) DecryptStreamChunk(context []byte, header string, index uint32, final bool, ciphertext []byte) ([]byte, error) {
+	aead, err := p.streamAEAD(KeyUsageDecrypt, context, header)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < aead.NonceSize()+aead.Overhead() {
+		return nil, errutil.UserError{Err: "invalid stream chunk length"}
+	}
+
+	plaintext, err := aead.Open(nil, ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():], streamChunkAAD(index, final))
+	if err != nil {
+		return nil, errutil.UserError{Err: "unable to decrypt stream chunk: chunk, index or final flag do not match the stream"}
+	}
+	return plaintext, nil
+}
+
+// streamAEAD recovers the stream key from the header. The key's usage policy is
+// checked for the operation being performed on the stream, so that chunks can
+// be encrypted with keys that may not be used for decryption; as only stream
+// headers decrypt to a stream key, this doesn't expose other ciphertexts.
+func (p *Policy) streamAEAD(usage KeyUsage, context []byte, header string) (cipher.AEAD, error) {
+	encoded, err := p.DecryptForUsage(usage, context, nil, header, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(plaintext) != len(streamHeaderPrefix)+streamKeySize || !bytes.HasPrefix(plaintext, streamHeaderPrefix) {
+		return nil, errutil.UserError{Err: "invalid stream header"}
+	}
+	streamKey := plaintext[len(streamHeaderPrefix):]
+
+	aesCipher, err := aes.NewCipher(streamKey)
+	if err != nil {
+		return nil, errutil.InternalError{Err: err.Error()}
+	}
+
+	gcm, err := cipher.NewGCM(aesCipher)
+	if err != nil {
+		return nil, errutil.InternalError{Err: err.Error()}
+	}
+
+	return gcm, nil
+}
+
+func streamChunkAAD(index uint32, final bool) []byte {
+	aad := make([]byte, 5)
+	binary.BigEndian.PutUint32(aad, index)
+	if final {
+		aad[4] = 1
+	}
+	return aad
+}
@@ -0,0 +1,117 @@
+package keysutil
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+)
+
+// KeyUsage names an operation governed by a key's usage policy.
+type KeyUsage string
+
+const (
+	KeyUsageEncrypt KeyUsage = "encrypt"
+	KeyUsageDecrypt KeyUsage = "decrypt"
+	KeyUsageRewrap  KeyUsage = "rewrap"
+	KeyUsageDatakey KeyUsage = "datakey"
+	KeyUsageSign    KeyUsage = "sign"
+	KeyUsageVerify  KeyUsage = "verify"
+	KeyUsageHMAC    KeyUsage = "hmac"
+	KeyUsageCMAC    KeyUsage = "cmac"
+	KeyUsageEncode  KeyUsage = "encode"
+	KeyUsageDecode  KeyUsage = "decode"
+)
+
+// KeyUsageRule restricts the use of a key for a single operation.
+type KeyUsageRule struct {
+	// MinVersion and MaxVersion bound the key versions that may be used for
+	// the operation. Zero leaves the bound unset.
+	MinVersion int `json:"min_version,omitempty" mapstructure:"min_version"`
+	MaxVersion int `json:"max_version,omitempty" mapstructure:"max_version"`
+
+	// ContextPrefixes, if set, requires the key derivation context of the
+	// operation to start with one of the prefixes.
+	ContextPrefixes []string `json:"context_prefixes,omitempty" mapstructure:"context_prefixes"`
+}
+
+// supportsUsage returns whether the key type can be used for the operation at
+// all, regardless of any usage policy.
+func (kt KeyType) supportsUsage(usage KeyUsage) (supported bool, known bool) {
+	switch usage {
+	case KeyUsageEncrypt, KeyUsageDatakey:
+		return kt.EncryptionSupported(), true
+	case KeyUsageDecrypt, KeyUsageRewrap:
+		return kt.DecryptionSupported(), true
+	case KeyUsageSign, KeyUsageVerify:
+		return kt.SigningSupported(), true
+	case KeyUsageHMAC:
+		return !kt.CMACSupported(), true
+	case KeyUsageCMAC:
+		return kt.CMACSupported(), true
+	case KeyUsageEncode, KeyUsageDecode:
+		return kt.FPESupported(), true
+	}
+
+	return false, false
+}
+
+// ValidateUsagePolicy checks that the usage policy only names operations the
+// key supports, with consistent version bounds, and only restricts the context
+// of derived keys.
+func (p *Policy) ValidateUsagePolicy(usagePolicy map[KeyUsage]KeyUsageRule) error {
+	for usage, rule := range usagePolicy {
+		supported, known := p.Type.supportsUsage(usage)
+		switch {
+		case !known:
+			return fmt.Errorf("unknown operation %q in usage policy", usage)
+		case !supported:
+			return fmt.Errorf("operation %q is not supported for key type %v", usage, p.Type)
+		case rule.MinVersion < 0 || rule.MaxVersion < 0:
+			return fmt.Errorf("key versions for operation %q cannot be negative", usage)
+		case rule.MaxVersion > 0 && rule.MinVersion > rule.MaxVersion:
+			return fmt.Errorf("min_version for operation %q is greater than max_version", usage)
+		}
+
+		if len(rule.ContextPrefixes) > 0 {
+			if !p.Derived {
+				return fmt.Errorf("context prefixes for operation %q require key derivation to be enabled", usage)
+			}
+			if usage == KeyUsageHMAC || usage == KeyUsageCMAC || usage == KeyUsageEncode || usage == KeyUsageDecode {
+				return fmt.Errorf("operation %q does not use a context", usage)
+			}
+		}
+	}
+
+	return nil
+}
+
+// CheckUsage returns an error unless the key's usage policy allows the
+// operation with the given key version and derivation context. Keys without a
+// usage policy may be used for any operation their type supports; otherwise
+// only the operations listed in the policy are allowed.
+func (p *Policy) CheckUsage(usage KeyUsage, ver int, context []byte) error {
+	if len(p.UsagePolicy) == 0 {
+		return nil
+	}
+
+	rule, ok := p.UsagePolicy[usage]
+	if !ok {
+		return errutil.UserError{Err: fmt.Sprintf("key usage policy does not allow the %s operation", usage)}
+	}
+
+	if (rule.MinVersion > 0 && ver < rule.MinVersion) || (rule.MaxVersion > 0 && ver > rule.MaxVersion) {
+		return errutil.UserError{Err: fmt.Sprintf("key usage policy does not allow the %s operation with key version %d", usage, ver)}
+	}
+
+	if len(rule.ContextPrefixes) == 0 {
+		return nil
+	}
+	for _, prefix := range rule.ContextPrefixes {
+		if bytes.HasPrefix(context, []byte(prefix)) {
+			return nil
+		}
+	}
+
+	return errutil.UserError{Err: fmt.Sprintf("key usage policy does not allow the %s operation with the given context", usage)}
+}
@@ -364,6 +364,7 @@ const (
 	DeleteOperation                   = "delete"
 	ListOperation                     = "list"
 	HelpOperation                     = "help"
+	HeaderOperation                   = "header"
 	AliasLookaheadOperation           = "alias-lookahead"
 
 	// The operations below are called globally, the path is less relevant.
@@ -416,7 +416,7 @@ CHECK:
 	var grantingPolicies []logical.PolicyInfo
 	operationAllowed := false
 	switch op {
-	case logical.ReadOperation:
+	case logical.ReadOperation, logical.HeaderOperation:
 		operationAllowed = capabilities&ReadCapabilityInt > 0
 		grantingPolicies = permissions.GrantingPoliciesMap[ReadCapabilityInt]
 	case logical.ListOperation: